@@ -0,0 +1,67 @@
+package config
+
+import "fmt"
+
+// MutationOp is a kind of a runtime config mutation.
+type MutationOp uint8
+
+const (
+	// OpRollback stands for a key served from a config snapshot (see
+	// Repository.Rollback).
+	OpRollback MutationOp = iota
+	// OpClearRollback stands for a rolled back key served by the providers
+	// again (see Repository.ClearRollback).
+	OpClearRollback
+	// OpReload stands for a refresh of all providers triggered at runtime,
+	// e.g. by the admin handler. The key is empty: the whole config is
+	// subject to change.
+	OpReload
+)
+
+// String satisfies Stringer interface
+func (op MutationOp) String() string {
+	switch op {
+	case OpRollback:
+		return "rollback"
+	case OpClearRollback:
+		return "clear_rollback"
+	case OpReload:
+		return "reload"
+	}
+	return "unknown"
+}
+
+// Authorizer decides whether the actor is allowed to mutate the key at
+// runtime. A non-nil error denies the mutation, e.g.:
+//
+//	func(actor string, key config.Key, op config.MutationOp) error {
+//		if actor != "ops" && key.String() != "log.level" {
+//			return errors.New("only the log level might be changed")
+//		}
+//		return nil
+//	}
+type Authorizer func(actor string, key Key, op MutationOp) error
+
+// authorize checks every key against the repository authorizer. Returns an
+// error wrapping ErrForbidden if any of them is denied.
+func (repo *Repository) authorize(actor string, keys []Key, op MutationOp) error {
+	auth := repo.options.Authorizer
+	if auth == nil {
+		return nil
+	}
+	for _, key := range keys {
+		if err := auth(actor, key, op); err != nil {
+			repo.Logger().Warnf("Denied config %s of key %q by %q: %s", op, key, actor, err)
+			return fmt.Errorf("%w: %s of key %q by %q: %s", ErrForbidden, op, key, actor, err)
+		}
+	}
+	return nil
+}
+
+// logMutation attributes the applied runtime mutation to the actor.
+func (repo *Repository) logMutation(actor string, keys []Key, op MutationOp) {
+	logger := repo.Logger()
+	for _, key := range keys {
+		logger.Infof("Config %s of key %q by %q", op, key, actor)
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAuthorizer(t *testing.T) {
+	var calls []string
+	logger := &testLogger{}
+	repo := NewRepositoryWithOptions(&RepositoryOptions{
+		HistorySize: 5,
+		Authorizer: func(actor string, key Key, op MutationOp) error {
+			calls = append(calls, actor+" "+op.String()+" "+key.String())
+			if actor != "ops" && key.String() != "log.level" {
+				return errors.New("only the log level might be changed")
+			}
+			return nil
+		},
+	})
+	repo.SetLogger(logger)
+	prov := &reloadTestProv{registry: map[string]Value{"log.level": "info", "port": 8080}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if err := prov.reload(repo, map[string]Value{"log.level": "debug", "port": 8081}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+
+	// The rollback touches the port, which is only allowed to ops
+	err := repo.RollbackAs("dev", 1)
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("Unexpected rollback error: got: %v, want it to wrap: %v", err, ErrForbidden)
+	}
+	if got := MustInt(repo, "port"); got != 8081 {
+		t.Fatalf("Unexpected value after a denied rollback: got: %d, want: %d", got, 8081)
+	}
+	if err := repo.RollbackAs("ops", 1); err != nil {
+		t.Fatalf("Unexpected rollback error: %s", err)
+	}
+	if got := MustInt(repo, "port"); got != 8080 {
+		t.Fatalf("Unexpected value after rollback: got: %d, want: %d", got, 8080)
+	}
+	if err := repo.ClearRollbackAs("ops"); err != nil {
+		t.Fatalf("Unexpected error clearing the rollback: %s", err)
+	}
+
+	wantCalls := []string{
+		"dev rollback log.level",
+		"dev rollback port",
+		"ops rollback log.level",
+		"ops rollback port",
+		"ops clear_rollback log.level",
+		"ops clear_rollback port",
+	}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Fatalf("Unexpected authorizer calls: got: %#v, want: %#v", calls, wantCalls)
+	}
+	wantMsg := `INFO: Config rollback of key "port" by "ops"`
+	found := false
+	for _, msg := range logger.messages {
+		found = found || msg == wantMsg
+	}
+	if !found {
+		t.Fatalf("Expected message %q to be logged, got: %#v", wantMsg, logger.messages)
+	}
+}
+
+func TestAdminHandlerAuthorizer(t *testing.T) {
+	repo := NewRepositoryWithOptions(&RepositoryOptions{
+		Authorizer: func(actor string, key Key, op MutationOp) error {
+			if op == OpReload && actor != "ops" {
+				return errors.New("reloads are restricted")
+			}
+			return nil
+		},
+	})
+	prov := &signalTestProv{name: "remote", refreshes: make(chan struct{}, 1)}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	handler := AdminHandlerWithOptions(repo, &AdminOptions{
+		Actor: func(r *http.Request) string { return r.Header.Get("X-User") },
+	})
+
+	tests := []struct {
+		user       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"dev", http.StatusForbidden, "reloads are restricted"},
+		{"ops", http.StatusOK, "ok"},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.user, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/reload", nil)
+			r.Header.Set("X-User", testCase.user)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != testCase.wantStatus || !strings.Contains(w.Body.String(), testCase.wantBody) {
+				t.Fatalf("Unexpected response: got: %d %q, want: %d %q", w.Code, w.Body.String(), testCase.wantStatus, testCase.wantBody)
+			}
+		})
+	}
+}
+
+func TestMutationOpString(t *testing.T) {
+	for op, want := range map[MutationOp]string{
+		OpRollback:      "rollback",
+		OpClearRollback: "clear_rollback",
+		OpReload:        "reload",
+		MutationOp(100): "unknown",
+	} {
+		if got := op.String(); got != want {
+			t.Fatalf("Unexpected op string: got: %q, want: %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ValueSource describes a value served by a provider for a key.
+type ValueSource struct {
+	Provider string `json:"provider"`
+	Weight   int    `json:"weight"`
+	// Value is the raw value. It is set to RedactedValue for secret keys.
+	Value Value `json:"value"`
+	// Location is the value position in the config file (if known), see
+	// ValueMeta.
+	Location string `json:"location,omitempty"`
+}
+
+// Sources returns the raw values served for the key by every provider, the
+// effective one first. Secret values are redacted. Returns false if the key
+// is not registered.
+// This method is thread safe.
+func (repo *Repository) Sources(key Key) ([]ValueSource, bool) {
+	key = repo.aliasTarget(repo.foldKey(key))
+	repo.viewMx.RLock()
+	defer repo.viewMx.RUnlock()
+	n := repo.root.find(key)
+	if n == nil || len(key) == 0 {
+		return nil, false
+	}
+	providers, _ := n.view()
+	res := make([]ValueSource, 0, len(providers))
+	for _, prov := range providers {
+		if kv, ok := prov.Get(n.provKey(prov, key)); ok {
+			res = append(res, ValueSource{
+				Provider: prov.Name(),
+				Weight:   prov.Weight(),
+				Value:    repo.displayValue(key, kv.Value),
+				Location: repo.provenanceOf(prov, n.provKey(prov, key)).Location(),
+			})
+		}
+	}
+	return res, true
+}
+
+// Provenance returns the origin of the effective key value: the provider
+// serving it and, if the provider reports it (see ProvenanceReporter), the
+// load time and the config file position. The key is looked up through the
+// middleware chain (see Use): the provenance of a value a middleware serves on
+// its own is empty. Returns false if the key is not served.
+// This method is thread safe.
+func (repo *Repository) Provenance(key Key) (*ValueMeta, bool) {
+	var meta *ValueMeta
+	// The innermost function records the provenance, the raw value is passed
+	// to the middlewares as is
+	get := repo.chainOver(func(key Key) (Value, bool) {
+		v, m, ok := repo.rawProvenance(key)
+		meta = m
+		return v, ok
+	})
+	if _, ok := get(key); !ok {
+		return nil, false
+	}
+	if meta == nil {
+		return &ValueMeta{}, true
+	}
+	return meta, true
+}
+
+// rawProvenance returns the raw effective key value along with its origin.
+func (repo *Repository) rawProvenance(key Key) (Value, *ValueMeta, bool) {
+	key = repo.aliasTarget(repo.foldKey(key))
+	repo.viewMx.RLock()
+	defer repo.viewMx.RUnlock()
+	n := repo.root.find(key)
+	if n == nil || len(key) == 0 {
+		return nil, nil, false
+	}
+	if providers, _ := n.view(); len(providers) == 0 {
+		return nil, nil, false
+	}
+	v, top, ok := n.rawValue(repo, key, key)
+	if !ok {
+		return nil, nil, false
+	}
+	return v, repo.provenanceOf(top, n.provKey(top, key)), true
+}
+
+// provenanceOf returns the origin of the value the provider serves for the
+// key. It is reduced to the provider name unless the provider implements
+// ProvenanceReporter.
+func (repo *Repository) provenanceOf(prov Provider, key Key) *ValueMeta {
+	if pr, ok := prov.(ProvenanceReporter); ok {
+		if meta, ok := pr.Provenance(key); ok {
+			return meta
+		}
+	}
+	return &ValueMeta{Provider: prov.Name()}
+}
+
+// redactedDump is a version of Dump with the secret values redacted.
+func (repo *Repository) redactedDump() map[string]Value {
+	res := repo.Dump()
+	for k, v := range res {
+		res[k] = repo.displayValue(repo.NewKey(k), v)
+	}
+	return res
+}
+
+// AdminHandler returns an http.Handler exposing the repository state for
+// debugging purposes:
+//
+//	GET  /config        the effective config dump, secret values are redacted
+//	GET  /config/{key}  the key value along with the values served by every
+//	                    provider
+//	POST /reload        refreshes all refreshable providers (see RefreshAll)
+//	GET  /providers     the provider status (see ProviderStatus)
+//
+// The paths are relative: the handler is expected to be mounted using
+// http.StripPrefix, e.g.:
+//
+//	mux.Handle("/debug/config/", http.StripPrefix("/debug/config", config.AdminHandler(repo)))
+//
+// The handler performs no authentication: it must not be exposed publicly.
+// The reload is subject to the repository authorizer (see
+// RepositoryOptions.Authorizer): a denied reload responds with 403
+// Forbidden.
+func AdminHandler(repo *Repository) http.Handler {
+	return AdminHandlerWithOptions(repo, &AdminOptions{})
+}
+
+// AdminOptions is a set of AdminHandler settings.
+type AdminOptions struct {
+	// Actor identifies the author of a mutating request, e.g. by an
+	// authenticated user header set by a proxy. The request remote address
+	// is used if not set.
+	Actor func(r *http.Request) string
+}
+
+// AdminHandlerWithOptions is AdminHandler configured with the options.
+func AdminHandlerWithOptions(repo *Repository, options *AdminOptions) http.Handler {
+	return &adminHandler{repo: repo, options: options}
+}
+
+type adminHandler struct {
+	repo    *Repository
+	options *AdminOptions
+}
+
+func (ah *adminHandler) actor(r *http.Request) string {
+	if ah.options.Actor != nil {
+		return ah.options.Actor(r)
+	}
+	return r.RemoteAddr
+}
+
+func (ah *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case path == "config":
+		if allowMethod(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, ah.repo.redactedDump())
+		}
+	case strings.HasPrefix(path, "config/"):
+		if allowMethod(w, r, http.MethodGet) {
+			ah.serveKey(w, strings.TrimPrefix(path, "config/"))
+		}
+	case path == "reload":
+		if allowMethod(w, r, http.MethodPost) {
+			ah.reload(w, r)
+		}
+	case path == "providers":
+		if allowMethod(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, ah.repo.ProviderStatus())
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (ah *adminHandler) reload(w http.ResponseWriter, r *http.Request) {
+	actor := ah.actor(r)
+	if err := ah.repo.authorize(actor, []Key{{}}, OpReload); err != nil {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := ah.repo.RefreshAll(r.Context()); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	ah.repo.logMutation(actor, []Key{{}}, OpReload)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (ah *adminHandler) serveKey(w http.ResponseWriter, str string) {
+	key := ah.repo.NewKey(str)
+	// An admin read neither counts as a key use nor panics on a mapper
+	// failure
+	v, ok, err := tryLookup(ah.repo.getChain(), key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": ErrKeyNotFound.Error()})
+		return
+	}
+	sources, _ := ah.repo.Sources(key)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"key":     str,
+		"value":   ah.repo.displayValue(key, v),
+		"sources": sources,
+	})
+}
+
+// allowMethod responds with 405 Method Not Allowed unless the request method
+// matches.
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAdminHandler(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"db": map[string]Schema{
+			"host":     ToStr,
+			"password": Secret(ToStr),
+		},
+	})
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"db.host": "localhost",
+	})
+	prov := &signalTestProv{name: "remote", refreshes: make(chan struct{}, 1)}
+	prov.registry = map[string]Value{
+		"db.host":     "db.local",
+		"db.password": "s3cr3t",
+	}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	handler := http.StripPrefix("/debug/config", AdminHandler(repo))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		want       interface{}
+	}{
+		{
+			"config dump",
+			http.MethodGet,
+			"/debug/config/config",
+			http.StatusOK,
+			map[string]interface{}{
+				"db.host":     "db.local",
+				"db.password": RedactedValue,
+			},
+		},
+		{
+			"key provenance",
+			http.MethodGet,
+			"/debug/config/config/db.host",
+			http.StatusOK,
+			map[string]interface{}{
+				"key":   "db.host",
+				"value": "db.local",
+				"sources": []interface{}{
+					map[string]interface{}{"provider": "remote", "weight": float64(DefaultWeight), "value": "db.local"},
+					map[string]interface{}{"provider": "default", "weight": float64(0), "value": "localhost"},
+				},
+			},
+		},
+		{
+			"secret key provenance",
+			http.MethodGet,
+			"/debug/config/config/db.password",
+			http.StatusOK,
+			map[string]interface{}{
+				"key":   "db.password",
+				"value": RedactedValue,
+				"sources": []interface{}{
+					map[string]interface{}{"provider": "remote", "weight": float64(DefaultWeight), "value": RedactedValue},
+				},
+			},
+		},
+		{
+			"parent key provenance",
+			http.MethodGet,
+			"/debug/config/config/db",
+			http.StatusOK,
+			map[string]interface{}{
+				"key": "db",
+				"value": map[string]interface{}{
+					"host":     "db.local",
+					"password": RedactedValue,
+				},
+				"sources": []interface{}{},
+			},
+		},
+		{
+			"unknown key",
+			http.MethodGet,
+			"/debug/config/config/db.port",
+			http.StatusNotFound,
+			map[string]interface{}{"error": ErrKeyNotFound.Error()},
+		},
+		{
+			"reload",
+			http.MethodPost,
+			"/debug/config/reload",
+			http.StatusOK,
+			map[string]interface{}{"status": "ok"},
+		},
+		{
+			"reload method",
+			http.MethodGet,
+			"/debug/config/reload",
+			http.StatusMethodNotAllowed,
+			map[string]interface{}{"error": "method not allowed"},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(testCase.method, testCase.path, nil))
+			if rec.Code != testCase.wantStatus {
+				t.Fatalf("Unexpected status: got: %d, want: %d", rec.Code, testCase.wantStatus)
+			}
+			var got interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to decode the response: %s", err)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected response: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+
+	if len(prov.refreshes) != 1 {
+		t.Fatalf("Expected the provider to be refreshed")
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config/providers", nil))
+	var statuses []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("Failed to decode the response: %s", err)
+	}
+	if len(statuses) != 2 || statuses[0]["name"] != "default" || statuses[1]["state"] != "ready" {
+		t.Fatalf("Unexpected provider status: got: %#v", statuses)
+	}
+}
+
+func TestAdminHandlerMapperFailure(t *testing.T) {
+	repo := NewRepositoryWithOptions(&RepositoryOptions{TrackUnused: true})
+	repo.DefineSchema(map[string]Schema{
+		"db": map[string]Schema{"port": ToInt},
+	})
+	repo.RegisterKey(NewKey("db.port"), NewTestProv("abc", DefaultWeight))
+	handler := http.StripPrefix("/debug/config", AdminHandler(repo))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config/config/db.port", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Unexpected status: got: %d, want: %d", rec.Code, http.StatusInternalServerError)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to decode the response: %s", err)
+	}
+	if !strings.Contains(got["error"], "Failed to map the value") {
+		t.Fatalf("Unexpected error: got: %#v, want it to report the mapper failure", got)
+	}
+	if unused := repo.UnusedKeys(); len(unused) != 1 || unused[0].String() != "db.port" {
+		t.Fatalf("Unexpected unused keys: got: %v, want: [db.port]", unused)
+	}
+}
+
+func TestDisplayValue(t *testing.T) {
+	type creds struct {
+		User     string
+		Password string
+	}
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"db": map[string]Schema{
+			"user":     ToStr,
+			"password": Secret(ToStr),
+		},
+		"replicas": map[string]Schema{
+			"*": map[string]Schema{
+				"token": Secret(ToStr),
+			},
+		},
+	})
+
+	tests := []struct {
+		name string
+		key  string
+		v    Value
+		want Value
+	}{
+		{
+			"nested map",
+			"db",
+			map[string]Value{"user": "admin", "password": "s3cr3t"},
+			map[string]Value{"user": "admin", "password": RedactedValue},
+		},
+		{
+			"root map",
+			"",
+			map[string]Value{"db": map[string]Value{"password": "s3cr3t"}},
+			map[string]Value{"db": map[string]Value{"password": RedactedValue}},
+		},
+		{
+			"list",
+			"replicas",
+			[]Value{map[string]interface{}{"host": "r1", "token": "t0k3n"}},
+			[]Value{map[string]interface{}{"host": "r1", "token": RedactedValue}},
+		},
+		{
+			"struct",
+			"db",
+			creds{User: "admin", Password: "s3cr3t"},
+			RedactedValue,
+		},
+		{
+			"no secrets",
+			"db.user",
+			"admin",
+			"admin",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := repo.displayValue(NewKey(testCase.key), testCase.v)
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Redefined in tests
+var timeNow = time.Now
+
+// AuditRecord describes the reads of a config key by a caller. See
+// RepositoryOptions.Audit.
+type AuditRecord struct {
+	Key Key
+	// Caller is the label provided to Repository.Caller. It is empty for
+	// unlabeled reads.
+	Caller    string
+	FirstRead time.Time
+	LastRead  time.Time
+	Reads     int
+}
+
+// Caller returns a view of the repository labeling all reads with the caller
+// label, e.g. the name of the reading subsystem. The label shows up in the
+// audit log (see RepositoryOptions.Audit).
+func (repo *Repository) Caller(label string) *ScopedRepo {
+	return &ScopedRepo{
+		repo:   repo,
+		caller: label,
+	}
+}
+
+// Caller returns a copy of the view labeling all reads with the caller label.
+// See Repository.Caller.
+func (sr *ScopedRepo) Caller(label string) *ScopedRepo {
+	return &ScopedRepo{
+		repo:   sr.repo,
+		prefix: sr.prefix,
+		caller: label,
+	}
+}
+
+// audit records a read of the key by the caller. The key is marked as read
+// (see UnusedKeys), the audit record is only kept in the audit mode. Is a
+// no-op unless the audit mode or the unused key tracking is enabled.
+func (repo *Repository) audit(caller string, key Key) {
+	if len(key) == 0 || !repo.tracksReads() {
+		return
+	}
+	key = repo.aliasTarget(repo.foldKey(key))
+	// Old keys of renamed ones serve the value too
+	for _, k := range append(repo.aliasesOf(key), key) {
+		if _, ok := repo.reads.Load(k.String()); !ok {
+			repo.reads.Store(k.String(), k)
+		}
+	}
+	if !repo.options.Audit {
+		return
+	}
+	now := timeNow()
+	id := key.String() + "\x00" + caller
+	repo.auditMx.Lock()
+	defer repo.auditMx.Unlock()
+	rec, ok := repo.audits[id]
+	if !ok {
+		rec = &AuditRecord{Key: key, Caller: caller, FirstRead: now}
+		repo.audits[id] = rec
+	}
+	rec.LastRead = now
+	rec.Reads++
+}
+
+// AuditLog returns the key reads recorded in the audit mode (see
+// RepositoryOptions.Audit), sorted by key and caller. Returns an empty list
+// if the audit mode is disabled.
+// This method is thread safe.
+func (repo *Repository) AuditLog() []AuditRecord {
+	repo.auditMx.Lock()
+	res := make([]AuditRecord, 0, len(repo.audits))
+	for _, rec := range repo.audits {
+		res = append(res, *rec)
+	}
+	repo.auditMx.Unlock()
+	sort.Slice(res, func(a, b int) bool {
+		if ka, kb := res[a].Key.String(), res[b].Key.String(); ka != kb {
+			return ka < kb
+		}
+		return res[a].Caller < res[b].Caller
+	})
+	return res
+}
+
+// tracksReads returns true if the key reads are recorded.
+func (repo *Repository) tracksReads() bool {
+	return repo.options.Audit || repo.options.TrackUnused
+}
+
+// UnusedKeys returns a sorted list of keys registered by providers but never
+// retrieved. A lookup of a key covers all of its sub-keys. Combined with
+// UnknownKeys, it helps to detect stale configuration, e.g. in a CI check
+// running once the application is started. Returns an empty list unless the
+// reads are tracked (see RepositoryOptions.TrackUnused).
+// This method is thread safe.
+func (repo *Repository) UnusedKeys() []Key {
+	if !repo.tracksReads() {
+		return []Key{}
+	}
+	read := make([]Key, 0)
+	repo.reads.Range(func(_, k interface{}) bool {
+		read = append(read, k.(Key))
+		return true
+	})
+	repo.mx.Lock()
+	keys := repo.root.keys(nil)
+	repo.mx.Unlock()
+	res := make([]Key, 0)
+keys:
+	for _, key := range keys {
+		for _, r := range read {
+			if key.HasPrefix(r) {
+				continue keys
+			}
+		}
+		res = append(res, key)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].String() < res[b].String()
+	})
+	return res
+}
+
+// UnknownKeys returns a sorted list of keys registered by providers but not
+// covered by the schema. These are rejected in strict mode (see
+// RepositoryOptions.Strict).
+// This method is thread safe.
+func (repo *Repository) UnknownKeys() []Key {
+	return repo.unknownKeys()
+}
+
+// reportUnread logs the keys registered by providers but never read. Is a
+// no-op unless the audit mode is enabled.
+func (repo *Repository) reportUnread() {
+	if !repo.options.Audit {
+		return
+	}
+	unread := repo.UnusedKeys()
+	if len(unread) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(unread))
+	for _, key := range unread {
+		keys = append(keys, key.String())
+	}
+	repo.Logger().Warnf("Config keys registered but never read: %s", strings.Join(keys, ", "))
+}
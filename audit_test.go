@@ -0,0 +1,166 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func(orig func() time.Time) { timeNow = orig }(timeNow)
+	timeNow = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	repo := NewRepositoryWithOptions(&RepositoryOptions{Audit: true})
+	prov := &reloadTestProv{registry: map[string]Value{
+		"server.host": "localhost",
+		"server.port": 8080,
+		"db.user":     "admin",
+		"db.password": "secret",
+	}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	repo.Get(NewKey("server"))
+	repo.Caller("http").Scope("server").Get(NewKey("port"))
+	repo.Caller("http").Get(NewKey("server.port"))
+	repo.Caller("db").Get(NewKey("db.user"))
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	want := []AuditRecord{
+		{
+			Key:       NewKey("db.user"),
+			Caller:    "db",
+			FirstRead: t0.Add(4 * time.Second),
+			LastRead:  t0.Add(4 * time.Second),
+			Reads:     1,
+		},
+		{
+			Key:       NewKey("server"),
+			Caller:    "",
+			FirstRead: t0.Add(1 * time.Second),
+			LastRead:  t0.Add(1 * time.Second),
+			Reads:     1,
+		},
+		{
+			Key:       NewKey("server.port"),
+			Caller:    "http",
+			FirstRead: t0.Add(2 * time.Second),
+			LastRead:  t0.Add(3 * time.Second),
+			Reads:     2,
+		},
+	}
+	if got := repo.AuditLog(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected audit log: got: %#v, want: %#v", got, want)
+	}
+
+	logger := &testLogger{}
+	repo.SetLogger(logger)
+	if err := repo.TearDown(); err != nil {
+		t.Fatalf("Failed to tear down the repository: %s", err)
+	}
+	wantMsg := `WARN: Config keys registered but never read: db.password`
+	found := false
+	for _, msg := range logger.messages {
+		if strings.HasPrefix(msg, "WARN:") {
+			if msg != wantMsg {
+				t.Fatalf("Unexpected warning: got: %q, want: %q", msg, wantMsg)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Expected the unread keys to be reported on tear down")
+	}
+}
+
+func TestAuditDisabled(t *testing.T) {
+	repo := NewRepository()
+	repo.RegisterKey(NewKey("foo"), NewTestProv(42, DefaultWeight))
+	repo.Caller("test").Get(NewKey("foo"))
+	if got := repo.AuditLog(); len(got) != 0 {
+		t.Fatalf("Unexpected audit log: got: %#v, want an empty log", got)
+	}
+}
+
+func TestUnusedKeys(t *testing.T) {
+	repo := NewRepositoryWithOptions(&RepositoryOptions{TrackUnused: true})
+	repo.Alias("db.pass", "db.password")
+	prov := &reloadTestProv{registry: map[string]Value{
+		"server.host": "localhost",
+		"server.port": 8080,
+		"db.user":     "admin",
+		"db.pass":     "secret",
+		"legacy":      true,
+	}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	tests := []struct {
+		name string
+		read string
+		want []string
+	}{
+		{"nothing read", "", []string{"db.pass", "db.user", "legacy", "server.host", "server.port"}},
+		{"leaf read", "db.user", []string{"db.pass", "legacy", "server.host", "server.port"}},
+		{"subtree read", "server", []string{"db.pass", "legacy"}},
+		{"missing key read", "db.host", []string{"db.pass", "legacy"}},
+		{"renamed key read", "db.password", []string{"legacy"}},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if testCase.read != "" {
+				repo.Get(NewKey(testCase.read))
+			}
+			got := make([]string, 0)
+			for _, key := range repo.UnusedKeys() {
+				got = append(got, key.String())
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected unused keys: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"server": map[string]Schema{
+			"port": ToInt,
+		},
+	})
+	repo.RegisterKey(NewKey("server.port"), NewTestProv(8080, DefaultWeight))
+	repo.RegisterKey(NewKey("server.prot"), NewTestProv(8080, DefaultWeight))
+	repo.RegisterKey(NewKey("debug"), NewTestProv(true, DefaultWeight))
+
+	got := make([]string, 0)
+	for _, key := range repo.UnknownKeys() {
+		got = append(got, key.String())
+	}
+	want := []string{"debug", "server.prot"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected unknown keys: got: %#v, want: %#v", got, want)
+	}
+}
+
+func TestUnusedKeysNotTracked(t *testing.T) {
+	repo := NewRepository()
+	repo.RegisterKey(NewKey("foo"), NewTestProv(42, DefaultWeight))
+	repo.Get(NewKey("foo"))
+	if _, ok := repo.reads.Load("foo"); ok {
+		t.Fatalf("Unexpected read recorded with the tracking disabled")
+	}
+	if got := repo.UnusedKeys(); len(got) != 0 {
+		t.Fatalf("Unexpected unused keys: got: %#v, want an empty list", got)
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// StrVar is a string config value binding. It is safe for concurrent use.
+type StrVar struct {
+	v atomic.Value
+}
+
+// Load returns the bound value.
+func (sv *StrVar) Load() string {
+	if v, ok := sv.v.Load().(string); ok {
+		return v
+	}
+	return ""
+}
+
+// IntVar is an int config value binding. It is safe for concurrent use.
+type IntVar struct {
+	v int64
+}
+
+// Load returns the bound value.
+func (iv *IntVar) Load() int {
+	return int(atomic.LoadInt64(&iv.v))
+}
+
+// BoolVar is a bool config value binding. It is safe for concurrent use.
+type BoolVar struct {
+	v int32
+}
+
+// Load returns the bound value.
+func (bv *BoolVar) Load() bool {
+	return atomic.LoadInt32(&bv.v) == 1
+}
+
+// DurationVar is a time.Duration config value binding. It is safe for
+// concurrent use.
+type DurationVar struct {
+	v int64
+}
+
+// Load returns the bound value.
+func (dv *DurationVar) Load() time.Duration {
+	return time.Duration(atomic.LoadInt64(&dv.v))
+}
+
+// BindStr binds the key to the variable. See Bind.
+func (repo *Repository) BindStr(key string, sv *StrVar) error {
+	return repo.Bind(key, func() error {
+		v, ok, err := LookupStr(repo, key)
+		if ok && err == nil {
+			sv.v.Store(v)
+		}
+		return err
+	})
+}
+
+// BindInt binds the key to the variable. See Bind.
+func (repo *Repository) BindInt(key string, iv *IntVar) error {
+	return repo.Bind(key, func() error {
+		v, ok, err := LookupInt(repo, key)
+		if ok && err == nil {
+			atomic.StoreInt64(&iv.v, int64(v))
+		}
+		return err
+	})
+}
+
+// BindBool binds the key to the variable. See Bind.
+func (repo *Repository) BindBool(key string, bv *BoolVar) error {
+	return repo.Bind(key, func() error {
+		v, ok, err := LookupBool(repo, key)
+		if ok && err == nil {
+			var b int32
+			if v {
+				b = 1
+			}
+			atomic.StoreInt32(&bv.v, b)
+		}
+		return err
+	})
+}
+
+// BindDuration binds the key to the variable. The value might be a
+// time.Duration or a string like "1m30s". See Bind.
+func (repo *Repository) BindDuration(key string, dv *DurationVar) error {
+	return repo.Bind(key, func() error {
+		v, ok, err := Lookup(repo, key)
+		if !ok || err != nil {
+			return err
+		}
+		d, ok := toDuration(v)
+		if !ok {
+			return typeMismatch(key, v, "time.Duration")
+		}
+		atomic.StoreInt64(&dv.v, int64(d))
+		return nil
+	})
+}
+
+// BindVar binds the key to the variable storing the value as is. See Bind.
+func (repo *Repository) BindVar(key string, av *atomic.Value) error {
+	return repo.Bind(key, func() error {
+		v, ok, err := Lookup(repo, key)
+		if ok && err == nil && v != nil {
+			av.Store(v)
+		}
+		return err
+	})
+}
+
+// Bind is the generic binding mechanism: the update function is expected to
+// look up the key value and store it in a variable. It is called right away,
+// once the repository is set up and on every reload changing the key value
+// (or any of its sub-keys). If the update function fails on a reload, the
+// error is logged and the variable keeps the previous value.
+// Returns the error of the initial update.
+func (repo *Repository) Bind(key string, update func() error) error {
+	k := repo.NewKey(key)
+	repo.mx.Lock()
+	repo.bindings = append(repo.bindings, update)
+	repo.mx.Unlock()
+	repo.Subscribe(func(event *ChangeEvent) {
+		for _, change := range event.Changes {
+			if change.Key.HasPrefix(k) || k.HasPrefix(change.Key) {
+				if err := update(); err != nil {
+					repo.Logger().Errorf("Failed to update config binding for key %q: %s", key, err)
+				}
+				return
+			}
+		}
+	})
+	return update()
+}
+
+// applyBindings refreshes all bound variables.
+func (repo *Repository) applyBindings() error {
+	repo.mx.Lock()
+	bindings := make([]func() error, len(repo.bindings))
+	copy(bindings, repo.bindings)
+	repo.mx.Unlock()
+	for _, update := range bindings {
+		if err := update(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
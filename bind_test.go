@@ -0,0 +1,74 @@
+package config
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBind(t *testing.T) {
+	repo := NewRepository()
+	prov := &reloadTestProv{registry: map[string]Value{
+		"server.host":    "localhost",
+		"server.port":    "8080",
+		"server.debug":   "true",
+		"server.timeout": "5s",
+	}}
+	repo.RegisterProvider(prov)
+
+	var host StrVar
+	var port IntVar
+	var debug BoolVar
+	var timeout DurationVar
+	var server atomic.Value
+	binds := []func() error{
+		func() error { return repo.BindStr("server.host", &host) },
+		func() error { return repo.BindInt("server.port", &port) },
+		func() error { return repo.BindBool("server.debug", &debug) },
+		func() error { return repo.BindDuration("server.timeout", &timeout) },
+		func() error { return repo.BindVar("server", &server) },
+	}
+	for _, bind := range binds {
+		if err := bind(); err != nil {
+			t.Fatalf("Unexpected bind error: %s", err)
+		}
+	}
+	if port.Load() != 0 {
+		t.Fatalf("Unexpected value before set up: got: %d, want: 0", port.Load())
+	}
+
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if host.Load() != "localhost" || port.Load() != 8080 || !debug.Load() || timeout.Load() != 5*time.Second {
+		t.Fatalf("Unexpected bound values: got: %q, %d, %t, %s", host.Load(), port.Load(), debug.Load(), timeout.Load())
+	}
+	if srv, ok := server.Load().(map[string]Value); !ok || srv["port"] != "8080" {
+		t.Fatalf("Unexpected bound composite value: got: %#v", server.Load())
+	}
+
+	if err := prov.reload(repo, map[string]Value{
+		"server.host":    "localhost",
+		"server.port":    "9090",
+		"server.debug":   "true",
+		"server.timeout": "5s",
+	}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if port.Load() != 9090 {
+		t.Fatalf("Unexpected value after reload: got: %d, want: %d", port.Load(), 9090)
+	}
+
+	// A malformed value keeps the previous one
+	if err := prov.reload(repo, map[string]Value{
+		"server.host":    "localhost",
+		"server.port":    "abc",
+		"server.debug":   "true",
+		"server.timeout": "5s",
+	}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if port.Load() != 9090 {
+		t.Fatalf("Unexpected value after a malformed reload: got: %d, want: %d", port.Load(), 9090)
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	boolStrings   = makeBoolStrings(DefaultTrueStrings, DefaultFalseStrings)
+	boolStringsMx sync.RWMutex
+)
+
+// The default string spellings of boolean values (see SetBoolStrings).
+var (
+	DefaultTrueStrings  = []string{"true", "y", "1"}
+	DefaultFalseStrings = []string{"false", "n", "0"}
+)
+
+// The YAML 1.1 boolean spellings. ToBool and the env provider do not accept
+// them by default: a string value like the `no` country code would silently
+// become false. They might be opted in with SetBoolStrings. The value
+// normalization (see RepositoryOptions.Normalize) always accepts them.
+var (
+	ExtendedTrueStrings  = []string{"yes", "on"}
+	ExtendedFalseStrings = []string{"no", "off"}
+)
+
+// SetBoolStrings replaces the string spellings of boolean values accepted
+// by ToBool (StrToBool), the env provider value parsing (see
+// EnvProviderOptions.ParseValues) and the value normalization (see
+// RepositoryOptions.Normalize, in addition to the YAML 1.1 spellings), e.g.
+// to accept the YAML 1.1 spellings everywhere:
+//
+//	config.SetBoolStrings(
+//		append(config.DefaultTrueStrings, config.ExtendedTrueStrings...),
+//		append(config.DefaultFalseStrings, config.ExtendedFalseStrings...),
+//	)
+//
+// Spellings are matched case-insensitively. Numeric and single letter
+// spellings (`1`, `y`) only apply to ToBool: they are too ambiguous to type
+// an untyped value, so the env provider and the normalization keep them as
+// numbers and strings. Returns an error if a spelling is both true and false.
+// This function is thread safe.
+func SetBoolStrings(trueStrings, falseStrings []string) error {
+	for _, t := range trueStrings {
+		for _, f := range falseStrings {
+			if strings.EqualFold(t, f) {
+				return fmt.Errorf("Boolean string %q is both true and false", t)
+			}
+		}
+	}
+	forms := makeBoolStrings(trueStrings, falseStrings)
+	boolStringsMx.Lock()
+	defer boolStringsMx.Unlock()
+	boolStrings = forms
+	return nil
+}
+
+func makeBoolStrings(trueStrings, falseStrings []string) map[string]bool {
+	res := make(map[string]bool, len(trueStrings)+len(falseStrings))
+	for _, s := range trueStrings {
+		res[strings.ToLower(s)] = true
+	}
+	for _, s := range falseStrings {
+		res[strings.ToLower(s)] = false
+	}
+	return res
+}
+
+// parseBoolString returns the boolean value of the string spelling. The
+// boolean flag is false if the string is not a known spelling.
+func parseBoolString(s string) (bool, bool) {
+	boolStringsMx.RLock()
+	defer boolStringsMx.RUnlock()
+	v, ok := boolStrings[strings.ToLower(s)]
+	return v, ok
+}
+
+// parseBoolWord is parseBoolString ignoring numeric and single letter
+// spellings.
+func parseBoolWord(s string) (bool, bool) {
+	if len([]rune(s)) < 2 {
+		return false, false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false, false
+	}
+	return parseBoolString(s)
+}
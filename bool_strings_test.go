@@ -0,0 +1,113 @@
+package config
+
+import (
+	"testing"
+)
+
+func withBoolStrings(t *testing.T, trueStrings, falseStrings []string) {
+	boolStringsMx.RLock()
+	prev := boolStrings
+	boolStringsMx.RUnlock()
+	t.Cleanup(func() {
+		boolStringsMx.Lock()
+		boolStrings = prev
+		boolStringsMx.Unlock()
+	})
+	if err := SetBoolStrings(trueStrings, falseStrings); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func TestStrToBoolConverter(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   bool
+		wantOk bool
+	}{
+		{"true", true, true},
+		{"True", true, true},
+		{"y", true, true},
+		{"1", true, true},
+		{"false", false, true},
+		{"n", false, true},
+		{"0", false, true},
+		{"Yes", false, false},
+		{"off", false, false},
+		{"enabled", false, false},
+		{"", false, false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.input, func(t *testing.T) {
+			kv, ok := StrToBool.Convert(&KeyValue{Value: testCase.input})
+			if ok != testCase.wantOk {
+				t.Fatalf("Unexpected conversion flag: got: %t, want: %t", ok, testCase.wantOk)
+			}
+			if ok && kv.Value != testCase.want {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", kv.Value, testCase.want)
+			}
+		})
+	}
+}
+
+func TestDefaultBoolStrings(t *testing.T) {
+	// The YAML 1.1 spellings are kept as strings unless opted in, the
+	// normalization always accepts them
+	ep := &EnvProvider{options: &EnvProviderOptions{ParseValues: true}}
+	for s, want := range map[string]bool{"yes": true, "No": false, "on": true, "OFF": false} {
+		if _, ok := ToBool.Convert(&KeyValue{Value: s}); ok {
+			t.Fatalf("Unexpected ToBool conversion of %q", s)
+		}
+		if got := ep.parseValue(s); got != s {
+			t.Fatalf("Unexpected env value: got: %#v, want: %#v", got, s)
+		}
+		if got := normalizeValue(s); got != want {
+			t.Fatalf("Unexpected normalized value: got: %#v, want: %#v", got, want)
+		}
+	}
+}
+
+func TestSetBoolStrings(t *testing.T) {
+	withBoolStrings(t,
+		append(append(DefaultTrueStrings, ExtendedTrueStrings...), "enabled", "ja"),
+		append(append(DefaultFalseStrings, ExtendedFalseStrings...), "disabled", "nein"),
+	)
+
+	tests := []struct {
+		input     string
+		wantConv  Value
+		wantParse Value
+	}{
+		{"Enabled", true, true},
+		{"nein", false, false},
+		{"on", true, true},
+		{"No", false, false},
+		{"y", true, "y"},
+		{"1", true, 1},
+		{"maybe", nil, "maybe"},
+	}
+	ep := &EnvProvider{options: &EnvProviderOptions{ParseValues: true}}
+	for _, testCase := range tests {
+		t.Run(testCase.input, func(t *testing.T) {
+			var got Value
+			if kv, ok := ToBool.Convert(&KeyValue{Value: testCase.input}); ok {
+				got = kv.Value
+			}
+			if got != testCase.wantConv {
+				t.Fatalf("Unexpected ToBool value: got: %#v, want: %#v", got, testCase.wantConv)
+			}
+			if got := ep.parseValue(testCase.input); got != testCase.wantParse {
+				t.Fatalf("Unexpected env value: got: %#v, want: %#v", got, testCase.wantParse)
+			}
+			if _, isBool := testCase.wantParse.(bool); isBool {
+				if got := normalizeValue(testCase.input); got != testCase.wantParse {
+					t.Fatalf("Unexpected normalized value: got: %#v, want: %#v", got, testCase.wantParse)
+				}
+			}
+		})
+	}
+
+	if err := SetBoolStrings([]string{"on"}, []string{"ON"}); err == nil {
+		t.Fatalf("Expected an error for a conflicting spelling")
+	}
+}
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// Bootstrap manifest keys.
+const (
+	// ManifestSourcesKey is the manifest key of the provider sections.
+	ManifestSourcesKey = "sources"
+	// ManifestPluginPathKey is the manifest key of the plugin folder location.
+	ManifestPluginPathKey = "plugins.path"
+)
+
+func init() {
+	RegisterProviderFactory("default", newDefaultProviderFromSettings)
+	RegisterProviderFactory("yaml", newYamlProviderFromSettings)
+	RegisterProviderFactory("env", newEnvProviderFromSettings)
+	RegisterProviderFactory("cli", newCliProviderFromSettings)
+}
+
+// Bootstrap builds a repository out of a sources manifest and sets it up. The
+// manifest is a YAML (or JSON) file declaring the providers to enable, their
+// weights and options, e.g.:
+//
+//	plugins:
+//	  path: /usr/lib/myapp/plugins
+//	sources:
+//	  defaults:
+//	    type: default
+//	    weight: 10
+//	    values:
+//	      server:
+//	        port: 8080
+//	  file:
+//	    type: yaml
+//	    weight: 20
+//	    path: /etc/app/config.yaml
+//	    watch: true
+//	  env:
+//	    weight: 30
+//	    prefix: APP_
+//	  consul:
+//	    weight: 50
+//	    address: consul.local:8500
+//
+// Every section under `sources` is a provider section (see PluginLoader): the
+// provider is created by the factory registered under the section type,
+// which defaults to the section name. The built-in factories are:
+//   - default: `values` is the tree of the default values;
+//   - yaml: `path` is the config file location (`path_key` is looked up if
+//     not set), `watch`, `watch_interval`, `template`, `name` and
+//     `path_key` are the YamlProviderOptions, `sha256` is the expected
+//     config file checksum;
+//   - env: `prefix`, `bindings`, `allowlist`, `from_schema`, `parse_values`
+//     and `list_separator` are the EnvProviderOptions;
+//   - cli: `positional`, `from_schema` and `flags` are the
+//     CliProviderOptions.
+//
+// Go plugins found in the plugin folder are opened before the providers are
+// created, so they can register their factories.
+func Bootstrap(manifestPath string) (*Repository, error) {
+	return BootstrapContext(context.Background(), manifestPath)
+}
+
+// BootstrapContext is equivalent to Bootstrap. The context is passed to
+// SetUpContext.
+func BootstrapContext(ctx context.Context, manifestPath string) (*Repository, error) {
+	repo, err := BootstrapBuild(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.SetUpContext(ctx); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// BootstrapBuild returns a new Repository with the providers declared in the
+// manifest registered (see Bootstrap). The repository is not set up.
+func BootstrapBuild(manifestPath string) (*Repository, error) {
+	return BootstrapBuildFS(OSFS, manifestPath)
+}
+
+// BootstrapBuildFS is a version of BootstrapBuild reading the manifest from
+// the filesystem, e.g. an embed.FS. The yaml sources read their config files
+// from the same filesystem unless the factory sets one.
+func BootstrapBuildFS(fsys fs.FS, manifestPath string) (*Repository, error) {
+	manifest, err := readManifest(fsys, manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	defer manifest.TearDown()
+
+	repo := NewRepository()
+	pluginDir, _, err := LookupStr(manifest, ManifestPluginPathKey)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config manifest %q: %s", manifestPath, err)
+	}
+	if err := openPluginDir(repo.Logger(), pluginDir); err != nil {
+		return nil, err
+	}
+	sources, ok := manifest.GetTree(manifest.NewKey(ManifestSourcesKey))
+	if !ok {
+		return nil, fmt.Errorf("Config manifest %q declares no sources", manifestPath)
+	}
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prov, err := loadProvider(repo, manifest.Scope(ManifestSourcesKey+"."+name), name)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read config manifest %q: %s", manifestPath, err)
+		}
+		if yp, ok := prov.(*YamlProvider); ok && yp.options.FS == nil {
+			yp.options.FS = fsys
+		}
+		if prov != nil {
+			repo.RegisterProvider(prov)
+		}
+	}
+	return repo, nil
+}
+
+// readManifest parses the manifest file into a separate repository.
+func readManifest(fsys fs.FS, path string) (*Repository, error) {
+	data, err := readFS(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := parseYaml(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse config manifest %q: %s", path, err)
+	}
+	manifest := NewRepository()
+	if _, err := NewMapProvider(manifest, 0, "manifest", flatten(raw)); err != nil {
+		return nil, err
+	}
+	if err := manifest.SetUp(); err != nil {
+		return nil, fmt.Errorf("Failed to read config manifest %q: %s", path, err)
+	}
+	return manifest, nil
+}
+
+func newDefaultProviderFromSettings(repo *Repository, weight int, settings Getter) (Provider, error) {
+	values := make(map[string]Value)
+	if v, ok, err := Lookup(settings, "values"); err != nil {
+		return nil, err
+	} else if ok {
+		tree, ok := toValueMap(v)
+		if !ok {
+			return nil, typeMismatch("values", v, "map[string]Value")
+		}
+		flattenTree("", tree, values)
+	}
+	return NewDefaultProviderWithDefaults(repo, weight, values)
+}
+
+// flattenTree stores the tree leaves into out under the dotted keys.
+func flattenTree(prefix string, tree map[string]Value, out map[string]Value) {
+	for k, v := range tree {
+		key := QuoteFragment(k)
+		if len(prefix) > 0 {
+			key = prefix + KeySepCh + key
+		}
+		if sub, ok := toValueMap(v); ok {
+			flattenTree(key, sub, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+func newYamlProviderFromSettings(repo *Repository, weight int, settings Getter) (Provider, error) {
+	path, _, err := LookupStr(settings, "path")
+	if err != nil {
+		return nil, err
+	}
+	options := &YamlProviderOptions{}
+	if options.Watch, _, err = LookupBool(settings, "watch"); err != nil {
+		return nil, err
+	}
+	if options.WatchInterval, err = lookupDuration(settings, "watch_interval"); err != nil {
+		return nil, err
+	}
+	if options.Template, _, err = LookupBool(settings, "template"); err != nil {
+		return nil, err
+	}
+	if options.Name, _, err = LookupStr(settings, "name"); err != nil {
+		return nil, err
+	}
+	if options.PathKey, _, err = LookupStr(settings, "path_key"); err != nil {
+		return nil, err
+	}
+	if checksum, ok, err := LookupStr(settings, "sha256"); err != nil {
+		return nil, err
+	} else if ok {
+		if options.Verifier, err = NewChecksumVerifier(checksum); err != nil {
+			return nil, err
+		}
+	}
+	return NewYamlProviderFromSource(repo, weight, options, path)
+}
+
+func newEnvProviderFromSettings(repo *Repository, weight int, settings Getter) (Provider, error) {
+	options := &EnvProviderOptions{}
+	var err error
+	if options.Prefix, _, err = LookupStr(settings, "prefix"); err != nil {
+		return nil, err
+	}
+	if options.Bindings, _, err = LookupStrMap(settings, "bindings"); err != nil {
+		return nil, err
+	}
+	if options.Allowlist, _, err = LookupStrArr(settings, "allowlist"); err != nil {
+		return nil, err
+	}
+	if options.FromSchema, _, err = LookupBool(settings, "from_schema"); err != nil {
+		return nil, err
+	}
+	if options.ParseValues, _, err = LookupBool(settings, "parse_values"); err != nil {
+		return nil, err
+	}
+	if options.ListSeparator, _, err = LookupStr(settings, "list_separator"); err != nil {
+		return nil, err
+	}
+	return NewEnvProviderWithOptions(repo, weight, options)
+}
+
+func newCliProviderFromSettings(repo *Repository, weight int, settings Getter) (Provider, error) {
+	options := &CliProviderOptions{}
+	var err error
+	if options.Positional, _, err = LookupStrArr(settings, "positional"); err != nil {
+		return nil, err
+	}
+	if options.FromSchema, _, err = LookupBool(settings, "from_schema"); err != nil {
+		return nil, err
+	}
+	if options.Flags, _, err = LookupStrMap(settings, "flags"); err != nil {
+		return nil, err
+	}
+	return NewCliProviderWithOptions(repo, weight, options)
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const testManifest = `
+sources:
+  defaults:
+    type: default
+    weight: 10
+    values:
+      server:
+        port: 8080
+        debug: false
+  file:
+    type: yaml
+    weight: 20
+    path: config.yaml
+  env:
+    weight: 30
+    prefix: APP_
+  extra:
+    type: test-map
+    weight: 50
+    name: extra
+    value: extra value
+  disabled:
+    type: test-map
+    enabled: false
+    weight: 60
+    name: disabled
+    value: disabled value
+`
+
+func TestBootstrap(t *testing.T) {
+	withProviderFactory(t, "test-map", testMapProviderFactory)
+	oldEnvVars := envVars
+	defer func() { envVars = oldEnvVars }()
+	envVars = func() []string {
+		return []string{"APP_SERVER_PORT=9090", "OTHER_SERVER_HOST=example.com"}
+	}
+	fsys := fstest.MapFS{
+		"manifest.yaml":  {Data: []byte(testManifest)},
+		"manifest.json":  {Data: []byte(`{"sources": {"defaults": {"type": "default", "weight": 10, "values": {"server": {"host": "json"}}}}}`)},
+		"config.yaml":    {Data: []byte("server:\n  host: localhost\n  port: 8081\n")},
+		"no-source.yaml": {Data: []byte("plugins:\n  path: ''\n")},
+		"malformed.yaml": {Data: []byte("sources: [")},
+		"unknown.yaml":   {Data: []byte("sources:\n  vault:\n    weight: 10\n")},
+	}
+	bootstrap := func(path string) (*Repository, error) {
+		repo, err := BootstrapBuildFS(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+		return repo, repo.SetUp()
+	}
+
+	repo, err := bootstrap("manifest.yaml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer repo.TearDown()
+
+	tests := []struct {
+		key  string
+		want Value
+	}{
+		{"server.port", "9090"},
+		{"server.host", "localhost"},
+		{"server.debug", false},
+		{"extra.value", "extra value"},
+	}
+	for _, testCase := range tests {
+		if got, ok := repo.Get(NewKey(testCase.key)); !ok || got != testCase.want {
+			t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", testCase.key, got, testCase.want)
+		}
+	}
+	if _, ok := repo.providers["disabled"]; ok {
+		t.Fatalf("Unexpected disabled provider registration")
+	}
+
+	repo, err = bootstrap("manifest.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, _, _ := LookupStr(repo, "server.host"); got != "json" {
+		t.Fatalf("Unexpected value for key %q: got: %q, want: %q", "server.host", got, "json")
+	}
+
+	errTests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"missing.yaml", "file does not exist"},
+		{"malformed.yaml", "Failed to parse config manifest"},
+		{"no-source.yaml", "declares no sources"},
+		{"unknown.yaml", `No config provider factory registered for type "vault"`},
+	}
+	for _, testCase := range errTests {
+		t.Run(testCase.path, func(t *testing.T) {
+			_, err := bootstrap(testCase.path)
+			if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+				t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"context"
+	"io/fs"
+)
+
+// Standard provider weights used by New. Command-line flags override env
+// variables, env variables override the config file, the config file
+// overrides the embedded config, which overrides defaults.
+const (
+	DefaultProviderWeight  = 10
+	EmbeddedProviderWeight = 15
+	YamlProviderWeight     = 20
+	EnvProviderWeight      = 30
+	CliProviderWeight      = 40
+)
+
+// The command-line flag and the env var supplying the config file location
+// (see WithConfigPath).
+const (
+	ConfigPathFlag   = "config"
+	ConfigPathEnvVar = "CONFIG_CONFIG_PATH"
+)
+
+// Option is a Builder setting. See New.
+type Option func(*Builder)
+
+// Builder assembles a Repository with the standard providers.
+type Builder struct {
+	options     RepositoryOptions
+	schemas     []Schema
+	defaults    map[string]Value
+	embedFS     fs.FS
+	embedPath   string
+	env         bool
+	envPrefix   string
+	yaml        bool
+	yamlSource  string
+	yamlOptions *YamlProviderOptions
+	flags       bool
+	// configPath makes the yaml provider read the file location from
+	// CfgPathKey supplied by flags, env or defaults (see WithConfigPath)
+	configPath        bool
+	configPathDefault string
+	providers         []func(*Repository) error
+}
+
+// NewBuilder returns a new Builder configured with the options.
+func NewBuilder(opts ...Option) *Builder {
+	return (&Builder{}).With(opts...)
+}
+
+// With applies the options to the builder.
+func (b *Builder) With(opts ...Option) *Builder {
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Build returns a new Repository with the schema defined and the providers
+// registered. The repository is not set up.
+func (b *Builder) Build() (*Repository, error) {
+	options := b.options
+	repo := NewRepositoryWithOptions(&options)
+	for _, schema := range b.schemas {
+		if err := repo.DefineSchema(schema); err != nil {
+			return nil, err
+		}
+	}
+	defaults := b.defaults
+	if b.configPath && len(b.configPathDefault) > 0 {
+		defaults = make(map[string]Value, len(b.defaults)+1)
+		for k, v := range b.defaults {
+			defaults[k] = v
+		}
+		defaults[CfgPathKey] = b.configPathDefault
+	}
+	if defaults != nil {
+		if _, err := NewDefaultProviderWithDefaults(repo, DefaultProviderWeight, defaults); err != nil {
+			return nil, err
+		}
+	}
+	if b.embedFS != nil {
+		if _, err := NewEmbeddedProvider(repo, EmbeddedProviderWeight, b.embedFS, b.embedPath); err != nil {
+			return nil, err
+		}
+	}
+	if b.yaml {
+		if _, err := NewYamlProviderFromSource(repo, YamlProviderWeight, b.yamlOptions, b.yamlSource); err != nil {
+			return nil, err
+		}
+	}
+	if b.env || b.configPath {
+		options := &EnvProviderOptions{Prefix: b.envPrefix}
+		if b.configPath {
+			options.Bindings = map[string]string{ConfigPathEnvVar: CfgPathKey}
+			if !b.env {
+				// Only the config path is loaded
+				options.Allowlist = []string{}
+			}
+		}
+		if _, err := NewEnvProviderWithOptions(repo, EnvProviderWeight, options); err != nil {
+			return nil, err
+		}
+	}
+	if b.configPath {
+		if _, err := NewCliProviderWithOptions(repo, CliProviderWeight, &CliProviderOptions{
+			Flags: map[string]string{ConfigPathFlag: CfgPathKey},
+		}); err != nil {
+			return nil, err
+		}
+	} else if b.flags {
+		if _, err := NewCliProvider(repo, CliProviderWeight); err != nil {
+			return nil, err
+		}
+	}
+	for _, register := range b.providers {
+		if err := register(repo); err != nil {
+			return nil, err
+		}
+	}
+	return repo, nil
+}
+
+// New is a one-call alternative to the step-by-step repository wiring: it
+// builds a Repository with the standard providers configured by the options
+// and sets it up.
+//
+// Example:
+//
+//	cfg, err := config.New(
+//		config.WithSchema(schema),
+//		config.WithDefaults(map[string]config.Value{"server.port": 8080}),
+//		config.WithYamlFile("/etc/app/config.yaml"),
+//		config.WithEnvPrefix("APP_"),
+//		config.WithFlags(),
+//	)
+func New(opts ...Option) (*Repository, error) {
+	return NewContext(context.Background(), opts...)
+}
+
+// NewContext is equivalent to New. The context is passed to SetUpContext.
+func NewContext(ctx context.Context, opts ...Option) (*Repository, error) {
+	repo, err := NewBuilder(opts...).Build()
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.SetUpContext(ctx); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// WithRepositoryOptions sets the repository options.
+func WithRepositoryOptions(options RepositoryOptions) Option {
+	return func(b *Builder) {
+		b.options = options
+	}
+}
+
+// WithSchema defines the schema in the repository. Might be used multiple
+// times with non-overlapping schemas.
+func WithSchema(schema Schema) Option {
+	return func(b *Builder) {
+		b.schemas = append(b.schemas, schema)
+	}
+}
+
+// WithDefaults registers a DefaultProvider serving the values. Might be used
+// multiple times: the values are merged.
+func WithDefaults(defaults map[string]Value) Option {
+	return func(b *Builder) {
+		if b.defaults == nil {
+			b.defaults = make(map[string]Value, len(defaults))
+		}
+		for k, v := range defaults {
+			b.defaults[k] = v
+		}
+	}
+}
+
+// WithEmbeddedDefaults registers an embedded config provider serving the
+// file from the filesystem, e.g. an embed.FS (see NewEmbeddedProvider). The
+// embedded values override WithDefaults and are overridden by the config
+// file.
+func WithEmbeddedDefaults(fsys fs.FS, path string) Option {
+	return func(b *Builder) {
+		b.embedFS = fsys
+		b.embedPath = path
+	}
+}
+
+// WithEnvPrefix registers an EnvProvider serving env variables with the
+// prefix.
+func WithEnvPrefix(prefix string) Option {
+	return func(b *Builder) {
+		b.env = true
+		b.envPrefix = prefix
+	}
+}
+
+// WithYamlFile registers a YamlProvider serving the config file.
+func WithYamlFile(path string) Option {
+	return WithYamlFileOptions(path, nil)
+}
+
+// WithYamlFileOptions registers a YamlProvider serving the config file with
+// the provider options.
+func WithYamlFileOptions(path string, options *YamlProviderOptions) Option {
+	return func(b *Builder) {
+		b.yaml = true
+		b.yamlSource = path
+		b.yamlOptions = options
+		b.configPath = false
+	}
+}
+
+// WithConfigPath registers a YamlProvider serving the config file located by
+// the `--config` flag, the CONFIG_CONFIG_PATH env var or the default path (if
+// not empty), in this order. The location is served under CfgPathKey: the
+// flag and the env var are set up before the config file is read. The
+// command line is parsed by a CliProvider accepting `-o` and `--config`
+// flags (WithFlags is implied). The env var is loaded regardless of
+// WithEnvPrefix. The yaml provider options might be nil.
+//
+// Example:
+//
+//	cfg, err := config.New(
+//		config.WithConfigPath("/etc/app/config.yaml", nil),
+//		config.WithEnvPrefix("APP_"),
+//	)
+func WithConfigPath(defaultPath string, options *YamlProviderOptions) Option {
+	return func(b *Builder) {
+		b.yaml = true
+		b.yamlSource = ""
+		b.yamlOptions = options
+		b.configPath = true
+		b.configPathDefault = defaultPath
+	}
+}
+
+// WithFlags registers a CliProvider serving command-line flags.
+func WithFlags() Option {
+	return func(b *Builder) {
+		b.flags = true
+	}
+}
+
+// WithProvider registers a custom provider. The register function is
+// expected to call a provider constructor, e.g.:
+//
+//	config.WithProvider(func(repo *config.Repository) error {
+//		_, err := redis.NewProvider(repo, 50, client, nil)
+//		return err
+//	})
+func WithProvider(register func(*Repository) error) Option {
+	return func(b *Builder) {
+		b.providers = append(b.providers, register)
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNew(t *testing.T) {
+	oldEnvVars := envVars
+	defer func() { envVars = oldEnvVars }()
+	envVars = func() []string {
+		return []string{"APP_SERVER_PORT=9090", "OTHER_SERVER_HOST=example.com"}
+	}
+	fsys := fstest.MapFS{
+		"config.yaml": {Data: []byte("server:\n  host: localhost\n  port: 8081\n")},
+	}
+
+	repo, err := New(
+		WithSchema(map[string]Schema{
+			"server": map[string]Schema{
+				"port": ToInt,
+			},
+		}),
+		WithDefaults(map[string]Value{"server.port": 8080, "server.debug": false}),
+		WithYamlFileOptions("config.yaml", &YamlProviderOptions{FS: fsys}),
+		WithEnvPrefix("APP_"),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer repo.TearDown()
+
+	tests := []struct {
+		key  string
+		want Value
+	}{
+		{"server.port", 9090},
+		{"server.host", "localhost"},
+		{"server.debug", false},
+	}
+	for _, testCase := range tests {
+		if got, ok := repo.Get(NewKey(testCase.key)); !ok || got != testCase.want {
+			t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", testCase.key, got, testCase.want)
+		}
+	}
+}
+
+func TestBuilderBuild(t *testing.T) {
+	registered := false
+	repo, err := NewBuilder(WithRepositoryOptions(RepositoryOptions{StrictTypes: true})).
+		With(WithProvider(func(repo *Repository) error {
+			registered = true
+			_, err := NewMapProvider(repo, DefaultWeight, "custom", map[string]Value{"foo": "bar"})
+			return err
+		})).
+		Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !registered || !repo.strictTypes() {
+		t.Fatalf("Unexpected builder result: provider registered: %t, strict types: %t", registered, repo.strictTypes())
+	}
+	if _, ok := repo.Get(NewKey("foo")); ok {
+		t.Fatalf("Expected the repository not to be set up")
+	}
+}
+
+func TestWithConfigPath(t *testing.T) {
+	oldEnvVars, oldArgs := envVars, os.Args
+	defer func() { envVars, os.Args = oldEnvVars, oldArgs }()
+	fsys := fstest.MapFS{}
+	for _, path := range []string{"flag.yaml", "env.yaml", "default.yaml"} {
+		fsys[path] = &fstest.MapFile{Data: []byte("source: " + path + "\n")}
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		env         []string
+		defaultPath string
+		want        string
+		wantErr     bool
+	}{
+		{"flag", []string{"--config", "flag.yaml"}, []string{"CONFIG_CONFIG_PATH=env.yaml"}, "default.yaml", "flag.yaml", false},
+		{"env", nil, []string{"CONFIG_CONFIG_PATH=env.yaml"}, "default.yaml", "env.yaml", false},
+		{"default", []string{"-o", "foo=bar"}, nil, "default.yaml", "default.yaml", false},
+		{"no path", nil, []string{"CONFIG_OTHER=value"}, "", "", true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			os.Args = append([]string{"app"}, testCase.args...)
+			envVars = func() []string { return testCase.env }
+			repo, err := New(WithConfigPath(testCase.defaultPath, &YamlProviderOptions{FS: fsys}))
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer repo.TearDown()
+			if got, _ := repo.Get(NewKey("source")); got != testCase.want {
+				t.Fatalf("Unexpected config file: got: %#v, want: %#v", got, testCase.want)
+			}
+			// Only the config path env var is loaded
+			if _, ok := repo.Get(NewKey("other")); ok {
+				t.Fatalf("Unexpected env var served")
+			}
+		})
+	}
+}
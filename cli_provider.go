@@ -3,9 +3,14 @@ package config
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strings"
 )
 
+// CommandKey is the key the CLI provider serves the subcommand name under.
+const CommandKey = "command"
+
 // Redefined in tests
 var regFlags = func(cp *CliProvider) {
 	if !flag.Parsed() {
@@ -20,6 +25,35 @@ type CliProvider struct {
 	weight   int
 	registry map[string]Value
 	ready    chan struct{}
+	options  *CliProviderOptions
+	// scope is the key prefix of the options following a subcommand
+	scope Key
+}
+
+// CliProviderOptions is a set of CliProvider settings. If the options are
+// provided, the provider parses the command line by means of its own flag
+// set instead of the global one.
+type CliProviderOptions struct {
+	// Args is the command line without the program name. os.Args[1:] is
+	// used if nil.
+	Args []string
+	// Positional maps positional arguments to keys in order, e.g.
+	// {"input.path", "output.path"}.
+	Positional []string
+	// Commands maps subcommand names to the keys of their positional
+	// arguments. If set, the first positional argument is expected to be a
+	// subcommand: options following it are scoped under the subcommand name
+	// (`app serve -o port=8080` sets `serve.port`), so are the subcommand
+	// positional arguments. The subcommand name is served under CommandKey.
+	Commands map[string][]string
+	// FromSchema registers a flag for every key defined in the schema (see
+	// FlagName), e.g. `--server-port 8080` sets `server.port`. Schema flags
+	// are accepted before and after a subcommand and are not scoped.
+	FromSchema bool
+	// Flags maps extra flag names to config keys, e.g. {"config": CfgPathKey}
+	// makes `--config app.yaml` set `config.path`. Like schema flags, these
+	// are accepted before and after a subcommand and are not scoped.
+	Flags map[string]string
 }
 
 var _ Provider = (*CliProvider)(nil)
@@ -27,10 +61,17 @@ var _ flag.Value = (*CliProvider)(nil)
 
 // NewCliProvider returns a new instance of CliProvider.
 func NewCliProvider(repo *Repository, weight int) (*CliProvider, error) {
+	return NewCliProviderWithOptions(repo, weight, nil)
+}
+
+// NewCliProviderWithOptions returns a new instance of CliProvider configured
+// with the options.
+func NewCliProviderWithOptions(repo *Repository, weight int, options *CliProviderOptions) (*CliProvider, error) {
 	prov := &CliProvider{
 		weight:   weight,
 		registry: make(map[string]Value),
 		ready:    make(chan struct{}),
+		options:  options,
 	}
 	repo.RegisterProvider(prov)
 
@@ -54,9 +95,9 @@ func (cp *CliProvider) Set(val string) error {
 	if chunks := strings.Split(val, "="); len(chunks) > 2 {
 		return fmt.Errorf("Possibly malformed flag (way too many `=`): %q", val)
 	} else if len(chunks) == 2 {
-		cp.registry[chunks[0]] = chunks[1]
+		cp.registry[cp.scope.Join(NewKey(chunks[0])).String()] = chunks[1]
 	} else {
-		cp.registry[val] = true
+		cp.registry[cp.scope.Join(NewKey(val)).String()] = true
 	}
 	return nil
 }
@@ -68,7 +109,11 @@ func (cp *CliProvider) Set(val string) error {
 // * -o: extra options, ex: -o system.maxproc=4 -o pipeline.tcp_rcv.connect=udp
 func (cp *CliProvider) SetUp(repo *Repository) error {
 	defer close(cp.ready)
-	regFlags(cp)
+	if cp.options == nil {
+		regFlags(cp)
+	} else if err := cp.parse(repo); err != nil {
+		return err
+	}
 	for k := range cp.registry {
 		if err := repo.RegisterKey(NewKey(k), cp); err != nil {
 			return err
@@ -77,6 +122,69 @@ func (cp *CliProvider) SetUp(repo *Repository) error {
 	return nil
 }
 
+// parse parses the command line provided in the options.
+func (cp *CliProvider) parse(repo *Repository) error {
+	args := cp.options.Args
+	if args == nil {
+		args = os.Args[1:]
+	}
+	defineFlags := func(*flag.FlagSet) {}
+	if cp.options.FromSchema && repo != nil {
+		var err error
+		if defineFlags, err = cp.schemaFlags(repo); err != nil {
+			return err
+		}
+	}
+	positional, err := cp.parseFlags(args, defineFlags)
+	if err != nil {
+		return err
+	}
+	keys := cp.options.Positional
+	if len(cp.options.Commands) > 0 && len(positional) > 0 {
+		cmd := positional[0]
+		cmdKeys, ok := cp.options.Commands[cmd]
+		if !ok {
+			return fmt.Errorf("Unknown command %q", cmd)
+		}
+		cp.registry[CommandKey] = cmd
+		cp.scope = NewKey(cmd)
+		if positional, err = cp.parseFlags(positional[1:], defineFlags); err != nil {
+			return err
+		}
+		keys = make([]string, 0, len(cmdKeys))
+		for _, k := range cmdKeys {
+			keys = append(keys, cp.scope.Join(NewKey(k)).String())
+		}
+	}
+	if len(positional) > len(keys) {
+		return fmt.Errorf("Unexpected positional argument %q", positional[len(keys)])
+	}
+	for ix, arg := range positional {
+		cp.registry[keys[ix]] = arg
+	}
+	return nil
+}
+
+// parseFlags parses the flags and returns the remaining positional
+// arguments.
+func (cp *CliProvider) parseFlags(args []string, defineFlags func(*flag.FlagSet)) ([]string, error) {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(cp, "o", "Extra options")
+	for name, key := range cp.options.Flags {
+		key := key
+		fs.Func(name, "Sets "+key, func(v string) error {
+			cp.registry[key] = v
+			return nil
+		})
+	}
+	defineFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("Failed to parse command line: %s", err)
+	}
+	return fs.Args(), nil
+}
+
 // TearDown is a no-op operation for CliProvider
 func (cp *CliProvider) TearDown(*Repository) error { return nil }
 
@@ -123,3 +123,90 @@ func TestCliProviderSet(t *testing.T) {
 		})
 	}
 }
+
+func TestCliProviderArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      *CliProviderOptions
+		wantRegistry map[string]Value
+		wantErr      bool
+	}{
+		{
+			"options only",
+			&CliProviderOptions{Args: []string{"-o", "foo=bar", "-o", "debug"}},
+			map[string]Value{"foo": "bar", "debug": true},
+			false,
+		},
+		{
+			"positional arguments",
+			&CliProviderOptions{
+				Args:       []string{"-o", "foo=bar", "in.txt", "out.txt"},
+				Positional: []string{"input.path", "output.path"},
+			},
+			map[string]Value{"foo": "bar", "input.path": "in.txt", "output.path": "out.txt"},
+			false,
+		},
+		{
+			"too many positional arguments",
+			&CliProviderOptions{
+				Args:       []string{"in.txt", "out.txt"},
+				Positional: []string{"input.path"},
+			},
+			nil,
+			true,
+		},
+		{
+			"subcommand scoping",
+			&CliProviderOptions{
+				Args: []string{"-o", "debug", "serve", "-o", "port=8080"},
+				Commands: map[string][]string{
+					"serve":   {},
+					"migrate": {"target"},
+				},
+			},
+			map[string]Value{"debug": true, "command": "serve", "serve.port": "8080"},
+			false,
+		},
+		{
+			"subcommand positional arguments",
+			&CliProviderOptions{
+				Args: []string{"migrate", "-o", "timeout=1m", "42"},
+				Commands: map[string][]string{
+					"serve":   {},
+					"migrate": {"target"},
+				},
+			},
+			map[string]Value{"command": "migrate", "migrate.timeout": "1m", "migrate.target": "42"},
+			false,
+		},
+		{
+			"unknown subcommand",
+			&CliProviderOptions{
+				Args:     []string{"deploy"},
+				Commands: map[string][]string{"serve": {}},
+			},
+			nil,
+			true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			prov, err := NewCliProviderWithOptions(repo, 0, testCase.options)
+			if err != nil {
+				t.Fatalf("Failed to initialize a new cli provider: %s", err)
+			}
+			err = prov.SetUp(repo)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected set up error: %v, want error: %t", err, testCase.wantErr)
+			}
+			if testCase.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(prov.registry, testCase.wantRegistry) {
+				t.Fatalf("Unexpected state for CliProvider.registry: got: %#v, want: %#v", prov.registry, testCase.wantRegistry)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/osdrv/config"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// schemaTypes maps type names used in schema files to converters.
+var schemaTypes = map[string]config.Converter{
+	"int":    config.ToInt,
+	"string": config.ToStr,
+	"str":    config.ToStr,
+	"bool":   config.ToBool,
+	"any":    config.Identity,
+}
+
+// loadSchema reads a yaml schema file. A schema file mimics the config
+// structure, leafs are type names, e.g.:
+//
+//	server:
+//	  port: int
+//	  host: string
+func loadSchema(path string) (config.Schema, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return buildSchema(nil, raw)
+}
+
+func buildSchema(pref []string, raw map[string]interface{}) (config.Schema, error) {
+	res := make(map[string]config.Schema, len(raw))
+	for k, v := range raw {
+		path := append(append([]string{}, pref...), k)
+		switch tv := v.(type) {
+		case string:
+			conv, ok := schemaTypes[tv]
+			if !ok {
+				return nil, fmt.Errorf("unknown type %q for key %q", tv, strings.Join(path, config.KeySepCh))
+			}
+			res[k] = conv
+		case map[string]interface{}:
+			sub, err := buildSchema(path, tv)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = sub
+		default:
+			return nil, fmt.Errorf("unexpected schema definition for key %q: %#v", strings.Join(path, config.KeySepCh), v)
+		}
+	}
+	return res, nil
+}
+
+func validate(repo *config.Repository, schemaPath string, stdout, stderr io.Writer) int {
+	schema, err := loadSchema(schemaPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load schema: %s\n", err)
+		return 1
+	}
+	strict := config.NewRepositoryWithOptions(&config.RepositoryOptions{Strict: true})
+	if err := strict.DefineSchema(schema); err != nil {
+		fmt.Fprintf(stderr, "failed to define schema: %s\n", err)
+		return 1
+	}
+	if err := repo.SetUp(); err != nil {
+		fmt.Fprintf(stderr, "failed to set up config repository: %s\n", err)
+		return 1
+	}
+	// The effective values are copied into a strict repository: this way
+	// both unknown keys and conversion failures are reported.
+	values := repo.Dump()
+	if _, err := config.NewMapProvider(strict, 0, "effective", values); err != nil {
+		fmt.Fprintf(stderr, "failed to initialize map provider: %s\n", err)
+		return 1
+	}
+	errs := make([]string, 0)
+	if err := strict.SetUp(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	for _, k := range sortedKeys(values) {
+		if err := tryGet(strict, k); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(stderr, err)
+		}
+		return 1
+	}
+	fmt.Fprintln(stdout, "config is valid")
+	return 0
+}
+
+func tryGet(repo *config.Repository, key string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	repo.Get(config.NewKey(key))
+	return nil
+}
+
+func dump(repo *config.Repository, format string, w io.Writer) error {
+	values := repo.Dump()
+	if format == "json" {
+		return writeJSON(w, values)
+	}
+	for _, k := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s = %v\n", k, values[k])
+	}
+	return nil
+}
+
+func explain(repo *config.Repository, format string, w io.Writer) error {
+	flat := make(map[string]config.Value)
+	flattenExplain(nil, repo.Explain(), flat)
+	if format == "json" {
+		return writeJSON(w, flat)
+	}
+	for _, k := range sortedKeys(flat) {
+		fmt.Fprintf(w, "%s\n", k)
+		if d, ok := repo.Description(config.NewKey(k)); ok {
+			fmt.Fprintf(w, "  # %s\n", d.Text)
+		}
+		for ix, descr := range flat[k].([]map[string]interface{}) {
+			mark := " "
+			if ix == 0 {
+				mark = "*"
+			}
+			fmt.Fprintf(w, "  %s %s (weight: %v): %v", mark,
+				descr["provider_name"], descr["provider_weight"], descr["value"])
+			if loc, ok := descr["location"]; ok {
+				fmt.Fprintf(w, " at %s", loc)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+// diff prints the changes turning the config file a into b. Updates changing
+// the value type are marked with `!`.
+func diff(a, b, format string, w io.Writer) error {
+	changes, err := config.DiffSources(
+		config.Source{config.WithYamlFile(a)},
+		config.Source{config.WithYamlFile(b)},
+	)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		res := make([]map[string]interface{}, 0, len(changes))
+		for _, change := range changes {
+			res = append(res, map[string]interface{}{
+				"key":          change.Key.String(),
+				"type":         change.Type().String(),
+				"old":          change.Old,
+				"new":          change.New,
+				"type_changed": change.TypeChanged(),
+			})
+		}
+		return writeJSON(w, res)
+	}
+	for _, change := range changes {
+		switch change.Type() {
+		case config.ChangeAdded:
+			fmt.Fprintf(w, "+ %s = %v\n", change.Key, change.New)
+		case config.ChangeDeleted:
+			fmt.Fprintf(w, "- %s = %v\n", change.Key, change.Old)
+		default:
+			mark := "~"
+			if change.TypeChanged() {
+				mark = "!"
+			}
+			fmt.Fprintf(w, "%s %s = %v (%T) -> %v (%T)\n", mark, change.Key,
+				change.Old, change.Old, change.New, change.New)
+		}
+	}
+	return nil
+}
+
+func flattenExplain(pref []string, in map[string]interface{}, out map[string]config.Value) {
+	if v, ok := in["__value__"]; ok {
+		out[config.Key(pref).String()] = v
+		return
+	}
+	for k, v := range in {
+		if sub, ok := v.(map[string]interface{}); ok {
+			flattenExplain(append(append([]string{}, pref...), k), sub, out)
+		}
+	}
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func sortedKeys(m map[string]config.Value) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
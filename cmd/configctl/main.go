@@ -0,0 +1,134 @@
+// Command configctl loads a set of config sources, prints the merged
+// effective config, explains the provenance of every key, validates the
+// config against a schema file and compares config files.
+//
+// Usage:
+//
+//	configctl [flags] dump|explain|validate
+//	configctl [-format text|json] diff <a.yaml> <b.yaml>
+//
+// Example:
+//
+//	configctl -file config.yaml -env-prefix APP_ -o server.port=8080 dump
+//	configctl -file config.yaml -schema schema.yaml validate
+//	configctl diff staging.yaml production.yaml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/osdrv/config"
+)
+
+const usage = `Usage: configctl [flags] dump|explain|validate
+       configctl [-format text|json] diff <a.yaml> <b.yaml>
+
+Commands:
+  dump      print the merged effective config
+  explain   print every key along with the providers serving it
+  validate  validate the config against the schema file (requires -schema)
+  diff      print the key-level differences of 2 config files
+
+Flags:
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("configctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	file := fs.String("file", "", "yaml config file path")
+	envPrefix := fs.String("env-prefix", "CONFIG_", "environment variables prefix, empty string disables env provider")
+	schemaPath := fs.String("schema", "", "schema file path")
+	format := fs.String("format", "text", "output format: text or json")
+
+	repo := config.NewRepository()
+	cli, err := config.NewCliProvider(repo, 30)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to initialize cli provider: %s\n", err)
+		return 1
+	}
+	fs.Var(cli, "o", "extra options, ex: -o server.port=8080")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 3 && fs.Arg(0) == "diff" {
+		if err := diff(fs.Arg(1), fs.Arg(2), *format, stdout); err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return 1
+		}
+		return 0
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	if _, err := config.NewDefaultProvider(repo, 0); err != nil {
+		fmt.Fprintf(stderr, "failed to initialize default provider: %s\n", err)
+		return 1
+	}
+	if len(*envPrefix) > 0 {
+		if _, err := config.NewEnvProviderWithPrefix(repo, 20, *envPrefix); err != nil {
+			fmt.Fprintf(stderr, "failed to initialize env provider: %s\n", err)
+			return 1
+		}
+	}
+	if len(*file) > 0 {
+		if _, err := config.NewYamlProviderFromSource(repo, 10, &config.YamlProviderOptions{}, *file); err != nil {
+			fmt.Fprintf(stderr, "failed to initialize yaml provider: %s\n", err)
+			return 1
+		}
+	}
+
+	cmd := fs.Arg(0)
+	if cmd == "validate" {
+		if len(*schemaPath) == 0 {
+			fmt.Fprintln(stderr, "validate command requires -schema flag")
+			return 2
+		}
+		return validate(repo, *schemaPath, stdout, stderr)
+	}
+
+	if len(*schemaPath) > 0 {
+		schema, err := loadSchema(*schemaPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to load schema: %s\n", err)
+			return 1
+		}
+		if err := repo.DefineSchema(schema); err != nil {
+			fmt.Fprintf(stderr, "failed to define schema: %s\n", err)
+			return 1
+		}
+	}
+	if err := repo.SetUp(); err != nil {
+		fmt.Fprintf(stderr, "failed to set up config repository: %s\n", err)
+		return 1
+	}
+
+	switch cmd {
+	case "dump":
+		err = dump(repo, *format, stdout)
+	case "explain":
+		err = explain(repo, *format, stdout)
+	default:
+		fmt.Fprintf(stderr, "unknown command: %q\n", cmd)
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file %q: %s", path, err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configctl")
+	if err != nil {
+		t.Fatalf("Failed to create a temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfgPath := writeFile(t, dir, "config.yaml", "server:\n  port: 8080\n  host: localhost\n")
+	schemaPath := writeFile(t, dir, "schema.yaml", "server:\n  port: int\n  host: string\n")
+	badSchemaPath := writeFile(t, dir, "bad_schema.yaml", "server:\n  prot: int\n  host: string\n")
+	otherCfgPath := writeFile(t, dir, "other.yaml", "server:\n  port: \"8080\"\n  debug: true\n")
+
+	os.Setenv("CONFIGCTL_TEST_SERVER_HOST", "example.com")
+	defer os.Unsetenv("CONFIGCTL_TEST_SERVER_HOST")
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+	}{
+		{
+			"dump",
+			[]string{"-file", cfgPath, "-env-prefix", "CONFIGCTL_TEST_", "-o", "server.port=9090", "dump"},
+			0,
+			"server.host = example.com\nserver.port = 9090\n",
+		},
+		{
+			"explain",
+			[]string{"-file", cfgPath, "-env-prefix", "CONFIGCTL_TEST_", "explain"},
+			0,
+			"server.host\n  * env (weight: 20): example.com\n    yaml (weight: 10): localhost at " + cfgPath + ":3:9\n" +
+				"server.port\n  * yaml (weight: 10): 8080 at " + cfgPath + ":2:9\n",
+		},
+		{
+			"valid config",
+			[]string{"-file", cfgPath, "-env-prefix", "", "-o", "server.port=9090", "-schema", schemaPath, "validate"},
+			0,
+			"config is valid\n",
+		},
+		{
+			"invalid value",
+			[]string{"-file", cfgPath, "-env-prefix", "", "-o", "server.port=abc", "-schema", schemaPath, "validate"},
+			1,
+			"",
+		},
+		{
+			"unknown key",
+			[]string{"-file", cfgPath, "-env-prefix", "", "-schema", badSchemaPath, "validate"},
+			1,
+			"",
+		},
+		{
+			"diff",
+			[]string{"diff", cfgPath, otherCfgPath},
+			0,
+			"+ server.debug = true\n- server.host = localhost\n! server.port = 8080 (int) -> 8080 (string)\n",
+		},
+		{
+			"diff missing file",
+			[]string{"diff", cfgPath, filepath.Join(dir, "missing.yaml")},
+			1,
+			"",
+		},
+		{
+			"unknown command",
+			[]string{"-env-prefix", "", "foo"},
+			2,
+			"",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(testCase.args, &stdout, &stderr)
+			if code != testCase.wantCode {
+				t.Fatalf("Unexpected exit code: got: %d, want: %d, stderr: %s", code, testCase.wantCode, stderr.String())
+			}
+			if got := stdout.String(); got != testCase.wantStdout {
+				t.Fatalf("Unexpected output: got: %q, want: %q", got, testCase.wantStdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+// Collection values come in different shapes depending on the provider: YAML
+// produces []interface{} and map[string]interface{} (or
+// map[interface{}]interface{}), composite lookups produce map[string]Value.
+// The helpers below convert any of these shapes to a typed collection
+// element-by-element. In strict mode, only the exact type is accepted (see
+// RepositoryOptions.StrictTypes).
+
+// toValues returns the elements of a slice or an array value.
+func toValues(v Value) ([]Value, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	res := make([]Value, rv.Len())
+	for i := range res {
+		res[i] = rv.Index(i).Interface()
+	}
+	return res, true
+}
+
+// toValueMap returns a map value with the keys converted to strings.
+func toValueMap(v Value) (map[string]Value, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return nil, false
+	}
+	res := make(map[string]Value, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		k, ok := ToStr.Convert(&KeyValue{Value: iter.Key().Interface()})
+		if !ok {
+			return nil, false
+		}
+		res[k.Value.(string)] = iter.Value().Interface()
+	}
+	return res, true
+}
+
+func convert(conv Converter, v Value) (Value, bool) {
+	kv, ok := conv.Convert(&KeyValue{Value: v})
+	if !ok {
+		return nil, false
+	}
+	return kv.Value, true
+}
+
+func toFloat(v Value) (float64, bool) {
+	if f, err := toFloatBits(v, 64); err == nil {
+		return f, true
+	}
+	if i, ok := convert(IntOrIntPtr, v); ok {
+		return float64(i.(int)), true
+	}
+	return 0, false
+}
+
+func toDuration(v Value) (time.Duration, bool) {
+	switch d := v.(type) {
+	case time.Duration:
+		return d, true
+	case string:
+		if res, err := time.ParseDuration(d); err == nil {
+			return res, true
+		}
+	}
+	return 0, false
+}
+
+func toStrArr(v Value, strict bool) ([]string, bool) {
+	if arr, ok := v.([]string); ok || strict {
+		return arr, ok
+	}
+	elems, ok := toValues(v)
+	if !ok {
+		return nil, false
+	}
+	res := make([]string, 0, len(elems))
+	for _, e := range elems {
+		s, ok := convert(ToStr, e)
+		if !ok {
+			return nil, false
+		}
+		res = append(res, s.(string))
+	}
+	return res, true
+}
+
+func toIntArr(v Value, strict bool) ([]int, bool) {
+	if arr, ok := v.([]int); ok || strict {
+		return arr, ok
+	}
+	elems, ok := toValues(v)
+	if !ok {
+		return nil, false
+	}
+	res := make([]int, 0, len(elems))
+	for _, e := range elems {
+		i, ok := convert(ToInt, e)
+		if !ok {
+			return nil, false
+		}
+		res = append(res, i.(int))
+	}
+	return res, true
+}
+
+func toFloatArr(v Value, strict bool) ([]float64, bool) {
+	if arr, ok := v.([]float64); ok || strict {
+		return arr, ok
+	}
+	elems, ok := toValues(v)
+	if !ok {
+		return nil, false
+	}
+	res := make([]float64, 0, len(elems))
+	for _, e := range elems {
+		f, ok := toFloat(e)
+		if !ok {
+			return nil, false
+		}
+		res = append(res, f)
+	}
+	return res, true
+}
+
+func toDurationArr(v Value, strict bool) ([]time.Duration, bool) {
+	if arr, ok := v.([]time.Duration); ok || strict {
+		return arr, ok
+	}
+	elems, ok := toValues(v)
+	if !ok {
+		return nil, false
+	}
+	res := make([]time.Duration, 0, len(elems))
+	for _, e := range elems {
+		d, ok := toDuration(e)
+		if !ok {
+			return nil, false
+		}
+		res = append(res, d)
+	}
+	return res, true
+}
+
+func toStrMap(v Value, strict bool) (map[string]string, bool) {
+	if m, ok := v.(map[string]string); ok || strict {
+		return m, ok
+	}
+	vmap, ok := toValueMap(v)
+	if !ok {
+		return nil, false
+	}
+	res := make(map[string]string, len(vmap))
+	for k, e := range vmap {
+		s, ok := convert(ToStr, e)
+		if !ok {
+			return nil, false
+		}
+		res[k] = s.(string)
+	}
+	return res, true
+}
+
+func toIntMap(v Value, strict bool) (map[string]int, bool) {
+	if m, ok := v.(map[string]int); ok || strict {
+		return m, ok
+	}
+	vmap, ok := toValueMap(v)
+	if !ok {
+		return nil, false
+	}
+	res := make(map[string]int, len(vmap))
+	for k, e := range vmap {
+		i, ok := convert(ToInt, e)
+		if !ok {
+			return nil, false
+		}
+		res[k] = i.(int)
+	}
+	return res, true
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCollectionGetters(t *testing.T) {
+	tests := []struct {
+		name string
+		val  Value
+		get  func(repo Getter) interface{}
+		want interface{}
+	}{
+		{
+			"MustStrArr from a generic list",
+			[]interface{}{"foo", 42},
+			func(repo Getter) interface{} { return MustStrArr(repo, "key") },
+			[]string{"foo", "42"},
+		},
+		{
+			"MustIntArr from a generic list",
+			[]interface{}{1, "2"},
+			func(repo Getter) interface{} { return MustIntArr(repo, "key") },
+			[]int{1, 2},
+		},
+		{
+			"MustFloatArr from a generic list",
+			[]interface{}{1, 2.5, "1e3"},
+			func(repo Getter) interface{} { return MustFloatArr(repo, "key") },
+			[]float64{1, 2.5, 1000},
+		},
+		{
+			"MustDurationArr from a generic list",
+			[]interface{}{"1s", "250ms"},
+			func(repo Getter) interface{} { return MustDurationArr(repo, "key") },
+			[]time.Duration{time.Second, 250 * time.Millisecond},
+		},
+		{
+			"MustStrMap from a generic map",
+			map[string]interface{}{"foo": "bar", "baz": 1},
+			func(repo Getter) interface{} { return MustStrMap(repo, "key") },
+			map[string]string{"foo": "bar", "baz": "1"},
+		},
+		{
+			"MustStrMap from a map with interface keys",
+			map[interface{}]interface{}{"foo": "bar", 8080: "http"},
+			func(repo Getter) interface{} { return MustStrMap(repo, "key") },
+			map[string]string{"foo": "bar", "8080": "http"},
+		},
+		{
+			"MustIntMap from a composite value",
+			map[string]Value{"foo": 1, "bar": "2"},
+			func(repo Getter) interface{} { return MustIntMap(repo, "key") },
+			map[string]int{"foo": 1, "bar": 2},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			repo.RegisterKey(NewKey("key"), NewTestProv(testCase.val, DefaultWeight))
+			if got := testCase.get(repo); !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestCollectionGetterMismatch(t *testing.T) {
+	repo := NewRepository()
+	repo.RegisterKey(NewKey("key"), NewTestProv([]interface{}{"1s", "forever"}, DefaultWeight))
+	if _, ok, err := LookupDurationArr(repo, "key"); !ok || err == nil {
+		t.Fatalf("Unexpected lookup result: got: %t, %v, want: true, an error", ok, err)
+	}
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 )
 
 const (
@@ -13,24 +16,178 @@ const (
 // Key type represents a key used in key-value relationships. A key is a
 // composite structure: itconsists of fragments. Say, a key `foo.bar.baz`
 // consists of 3 fragments: []string{"foo", "bar", "baz"} (split by `KeySepCh`).
+// Fragments containing the separator are double-quoted: a key
+// `limits."api.example.com".rps` consists of 3 fragments:
+// []string{"limits", "api.example.com", "rps"}.
 type Key []string
 
-// String satisfies Stringer interface
+// String satisfies Stringer interface. Fragments containing the separator
+// are quoted (see `QuoteFragment`).
 func (key Key) String() string {
-	return strings.Join(key, KeySepCh)
+	return joinKey(key, KeySepCh)
 }
 
 func (key Key) Equals(k2 Key) bool {
 	return reflect.DeepEqual(key, k2)
 }
 
+// Len returns the number of key fragments
+func (key Key) Len() int {
+	return len(key)
+}
+
+// Parent returns the key without the last fragment. Returns nil for a single
+// fragment key.
+func (key Key) Parent() Key {
+	if len(key) <= 1 {
+		return Key(nil)
+	}
+	return append(Key{}, key[:len(key)-1]...)
+}
+
+// Child returns a new key with the fragments appended. The original key is
+// not modified.
+func (key Key) Child(fragments ...string) Key {
+	res := make(Key, 0, len(key)+len(fragments))
+	res = append(res, key...)
+	return append(res, fragments...)
+}
+
+// Join returns a new key consisting of the key fragments followed by the
+// other key fragments.
+func (key Key) Join(other Key) Key {
+	return key.Child(other...)
+}
+
+// HasPrefix returns true if the key starts with all the prefix fragments. A
+// key is a prefix of itself.
+func (key Key) HasPrefix(prefix Key) bool {
+	if len(prefix) > len(key) {
+		return false
+	}
+	for i, k := range prefix {
+		if key[i] != k {
+			return false
+		}
+	}
+	return true
+}
+
 // NewKey is a default constructor used for a new key instantiation.
-// Automatically splits the input string into key fragments.
+// Automatically splits the input string into key fragments. Double-quoted
+// fragments are taken as is: `"api.example.com"` is a single fragment. A
+// quote and a backslash inside a quoted fragment are escaped with a
+// backslash. A malformed quoted fragment is taken literally.
 func NewKey(str string) Key {
+	return NewKeyWithSeparator(str, KeySepCh)
+}
+
+// NewKeyWithSeparator is a version of NewKey splitting the input string by
+// the custom separator, e.g. `:` in Redis keys or `/` in paths.
+func NewKeyWithSeparator(str string, sep string) Key {
 	if len(str) == 0 {
 		return Key(nil)
 	}
-	return Key(strings.Split(str, KeySepCh))
+	key, err := splitKey(str, sep)
+	if err != nil {
+		return Key(strings.Split(str, sep))
+	}
+	return key
+}
+
+// ParseKey is the validating version of NewKey. Returns an error if the key
+// contains empty fragments, e.g.: `foo..bar` or `foo.`, or malformed quoted
+// fragments.
+func ParseKey(str string) (Key, error) {
+	if len(str) == 0 {
+		return Key(nil), nil
+	}
+	key, err := splitKey(str, KeySepCh)
+	if err != nil {
+		return nil, fmt.Errorf("Malformed config key %q: %s", str, err)
+	}
+	for _, k := range key {
+		if len(k) == 0 {
+			return nil, fmt.Errorf("Malformed config key %q: empty key fragment", str)
+		}
+	}
+	return key, nil
+}
+
+// QuoteFragment returns the key fragment quoted if it contains the
+// separator, so it is not split by NewKey. Returns the fragment as is
+// otherwise.
+func QuoteFragment(fragment string) string {
+	return quoteFragment(fragment, KeySepCh)
+}
+
+func quoteFragment(fragment string, sep string) string {
+	if !strings.Contains(fragment, sep) && !strings.HasPrefix(fragment, `"`) {
+		return fragment
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(fragment) + `"`
+}
+
+func joinKey(key Key, sep string) string {
+	parts := make([]string, len(key))
+	for i, k := range key {
+		parts[i] = quoteFragment(k, sep)
+	}
+	return strings.Join(parts, sep)
+}
+
+// splitKey splits the string into key fragments honoring quoted fragments.
+func splitKey(str string, sep string) (Key, error) {
+	if !strings.Contains(str, `"`) {
+		return Key(strings.Split(str, sep)), nil
+	}
+	res := make(Key, 0)
+	for {
+		if !strings.HasPrefix(str, `"`) {
+			ix := strings.Index(str, sep)
+			if ix == -1 {
+				return append(res, str), nil
+			}
+			res = append(res, str[:ix])
+			str = str[ix+len(sep):]
+			continue
+		}
+		var b strings.Builder
+		closed := false
+		i := 1
+		for i < len(str) {
+			c := str[i]
+			if c == '\\' && i+1 < len(str) {
+				b.WriteByte(str[i+1])
+				i += 2
+				continue
+			}
+			i++
+			if c == '"' {
+				closed = true
+				break
+			}
+			b.WriteByte(c)
+		}
+		if !closed {
+			return nil, fmt.Errorf("unterminated quoted fragment")
+		}
+		res = append(res, b.String())
+		str = str[i:]
+		if len(str) == 0 {
+			return res, nil
+		}
+		if !strings.HasPrefix(str, sep) {
+			return nil, fmt.Errorf("unexpected %q after a quoted fragment", str)
+		}
+		str = str[len(sep):]
+	}
+}
+
+// prefixKey converts a key prefix into a key. A trailing separator is
+// optional: `foo.bar.` is equivalent to `foo.bar`.
+func prefixKey(prefix string) Key {
+	return NewKey(strings.TrimSuffix(prefix, KeySepCh))
 }
 
 // Value represents a value in key-value relationships.
@@ -42,5 +199,113 @@ type KeyValue struct {
 	Value Value
 }
 
+// ValueMeta is the value provenance: where and when the value was loaded
+// from (see Repository.Provenance).
+type ValueMeta struct {
+	// Provider is the name of the provider serving the value. It is empty if
+	// a middleware serves the value (see Repository.Use).
+	Provider string
+	// LoadedAt is the time the value was loaded at. It is zero if unknown.
+	LoadedAt time.Time
+	// File is the config file the value is defined in (if any). Line and
+	// Column point at the value within the file, they are zero if unknown.
+	File   string
+	Line   int
+	Column int
+}
+
+// ProvenanceReporter is an optional provider capability: a provider
+// implementing it reports the origin of the values it serves. The provenance
+// is only looked up on request, e.g. by Repository.Provenance or in mapper
+// errors, never on Get.
+type ProvenanceReporter interface {
+	Provenance(key Key) (*ValueMeta, bool)
+}
+
+// Location returns the value position in the `file:line:column` format.
+// Returns an empty string if the file is unknown.
+func (m *ValueMeta) Location() string {
+	if m == nil {
+		return ""
+	}
+	return formatLocation(m.File, m.Line, m.Column)
+}
+
+// formatLocation returns the `file:line:column` position. Zero line and
+// column are omitted. Returns an empty string if the file is empty.
+func formatLocation(file string, line, column int) string {
+	switch {
+	case len(file) == 0:
+		return ""
+	case line == 0:
+		return file
+	case column == 0:
+		return fmt.Sprintf("%s:%d", file, line)
+	}
+	return fmt.Sprintf("%s:%d:%d", file, line, column)
+}
+
 // Params is a simple string-Value map, used to pass flattened parameters.
 type Params map[string]Value
+
+// nearestKeys returns up to `limit` candidates closest to the key in terms of
+// the edit distance. Only the candidates with the smallest distance are
+// returned. Candidates that are too far from the original key are not
+// considered a match.
+func nearestKeys(key Key, candidates []string, limit int) []string {
+	needle := key.String()
+	maxDist := len(needle)/3 + 1
+	type match struct {
+		key  string
+		dist int
+	}
+	matches := make([]match, 0)
+	for _, cand := range candidates {
+		if d := levenshtein(needle, cand); d <= maxDist {
+			matches = append(matches, match{cand, d})
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		return matches[a].dist < matches[b].dist
+	})
+	res := make([]string, 0, limit)
+	for ix := 0; ix < len(matches) && ix < limit; ix++ {
+		if matches[ix].dist > matches[0].dist {
+			break
+		}
+		res = append(res, matches[ix].key)
+	}
+	return res
+}
+
+// levenshtein computes the edit distance between 2 strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyOperations(t *testing.T) {
+	key := NewKey("foo.bar.baz")
+
+	if got := key.Len(); got != 3 {
+		t.Fatalf("Unexpected key length: got: %d, want: %d", got, 3)
+	}
+	if got, want := key.Parent(), NewKey("foo.bar"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected parent key: got: %#v, want: %#v", got, want)
+	}
+	if got := NewKey("foo").Parent(); got != nil {
+		t.Fatalf("Unexpected parent key: got: %#v, want: nil", got)
+	}
+	if got, want := key.Child("moo", "boo"), NewKey("foo.bar.baz.moo.boo"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected child key: got: %#v, want: %#v", got, want)
+	}
+	if got, want := NewKey("foo").Join(NewKey("bar.baz")), key; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected joined key: got: %#v, want: %#v", got, want)
+	}
+
+	// Child keys must not share the underlying array
+	parent := make(Key, 1, 4)
+	parent[0] = "foo"
+	a, b := parent.Child("a"), parent.Child("b")
+	if a.String() != "foo.a" || b.String() != "foo.b" {
+		t.Fatalf("Unexpected child keys: %q, %q", a, b)
+	}
+}
+
+func TestKeyHasPrefix(t *testing.T) {
+	tests := []struct {
+		key    string
+		prefix string
+		want   bool
+	}{
+		{"foo.bar", "foo", true},
+		{"foo.bar", "foo.bar", true},
+		{"foo.bar", "", true},
+		{"foo.bar", "fo", false},
+		{"foo", "foo.bar", false},
+		{"foo.bar", "bar", false},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.key+"/"+testCase.prefix, func(t *testing.T) {
+			if got := NewKey(testCase.key).HasPrefix(NewKey(testCase.prefix)); got != testCase.want {
+				t.Fatalf("Unexpected HasPrefix result: got: %t, want: %t", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Key
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"foo", Key{"foo"}, false},
+		{"foo.bar", Key{"foo", "bar"}, false},
+		{"foo..bar", nil, true},
+		{".foo", nil, true},
+		{"foo.", nil, true},
+		{`limits."api.example.com".rps`, Key{"limits", "api.example.com", "rps"}, false},
+		{`"a.b"`, Key{"a.b"}, false},
+		{`foo."say \"hi\"".bar`, Key{"foo", `say "hi"`, "bar"}, false},
+		{`foo."a.b`, nil, true},
+		{`foo."a"b`, nil, true},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.input, func(t *testing.T) {
+			got, err := ParseKey(testCase.input)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected key: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestKeyQuoting(t *testing.T) {
+	tests := []struct {
+		key  Key
+		want string
+	}{
+		{Key{"foo", "bar"}, "foo.bar"},
+		{Key{"limits", "api.example.com", "rps"}, `limits."api.example.com".rps`},
+		{Key{"foo", `"quoted"`}, `foo."\"quoted\""`},
+		{Key{`back\slash.dot`}, `"back\\slash.dot"`},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.want, func(t *testing.T) {
+			if got := testCase.key.String(); got != testCase.want {
+				t.Fatalf("Unexpected key string: got: %s, want: %s", got, testCase.want)
+			}
+			if got := NewKey(testCase.key.String()); !reflect.DeepEqual(got, testCase.key) {
+				t.Fatalf("Unexpected round trip key: got: %#v, want: %#v", got, testCase.key)
+			}
+		})
+	}
+
+	// A malformed quoted fragment is taken literally
+	if got, want := NewKey(`foo."bar`), (Key{"foo", `"bar`}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected key: got: %#v, want: %#v", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+// Package configtest provides helpers for testing code that relies on config
+// repositories: building a repository from a literal map, asserting on the
+// resolved values, a fake provider pushing changes on demand and golden file
+// comparisons for the repository dump.
+package configtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/osdrv/config"
+)
+
+const (
+	// UpdateGoldenEnv is the environment variable name which turns golden
+	// file comparisons into golden file updates if set to a non-empty value.
+	UpdateGoldenEnv = "CONFIGTEST_UPDATE"
+)
+
+// NewRepository builds a repository served by a single map provider and sets
+// it up. The schema is optional: nil means no schema. The test fails
+// immediately if the repository could not be set up.
+func NewRepository(t testing.TB, values map[string]config.Value, schema config.Schema) *config.Repository {
+	t.Helper()
+	repo := config.NewRepository()
+	if schema != nil {
+		if err := repo.DefineSchema(schema); err != nil {
+			t.Fatalf("Failed to define the config schema: %s", err)
+		}
+	}
+	if _, err := config.NewMapProvider(repo, 0, "configtest", values); err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the config repository: %s", err)
+	}
+	return repo
+}
+
+// AssertValue fails the test if the key lookup returns no value or the value
+// is not deeply equal to the expected one.
+func AssertValue(t testing.TB, repo *config.Repository, key string, want config.Value) {
+	t.Helper()
+	got, ok := repo.Get(config.NewKey(key))
+	if !ok {
+		t.Fatalf("Expected lookup for key %q to find a value, none returned", key)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", key, got, want)
+	}
+}
+
+// AssertMissing fails the test if the key lookup returns a value.
+func AssertMissing(t testing.TB, repo *config.Repository, key string) {
+	t.Helper()
+	if got, ok := repo.Get(config.NewKey(key)); ok {
+		t.Fatalf("Expected lookup for key %q to return no value, got: %#v", key, got)
+	}
+}
+
+// AssertGolden compares the JSON-encoded repository dump with the contents of
+// the golden file. If UpdateGoldenEnv environment variable is set, the golden
+// file is overwritten with the actual dump instead.
+func AssertGolden(t testing.TB, repo *config.Repository, path string) {
+	t.Helper()
+	got, err := json.MarshalIndent(repo.Dump(), "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to encode the config dump: %s", err)
+	}
+	got = append(got, '\n')
+	if len(os.Getenv(UpdateGoldenEnv)) > 0 {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("Failed to update the golden file %q: %s", path, err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the golden file %q: %s", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Config dump does not match the golden file %q:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package configtest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/osdrv/config"
+)
+
+func TestNewRepository(t *testing.T) {
+	repo := NewRepository(t, map[string]config.Value{
+		"server.port": "8080",
+		"server.host": "localhost",
+	}, map[string]config.Schema{
+		"server": map[string]config.Schema{
+			"port": config.ToInt,
+		},
+	})
+	AssertValue(t, repo, "server.port", 8080)
+	AssertValue(t, repo, "server.host", "localhost")
+	AssertMissing(t, repo, "server.addr")
+	AssertGolden(t, repo, "testdata/dump.golden.json")
+}
+
+func TestWatchProvider(t *testing.T) {
+	repo := config.NewRepository()
+	if _, err := config.NewMapProvider(repo, 10, "base", map[string]config.Value{
+		"foo": 1,
+	}); err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	prov := NewWatchProvider(repo, 20, "watch", map[string]config.Value{})
+	changes := make([]string, 0)
+	prov.OnChange(func(key config.Key) {
+		changes = append(changes, key.String())
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the config repository: %s", err)
+	}
+
+	AssertValue(t, repo, "foo", 1)
+	if err := prov.Push("foo", 2); err != nil {
+		t.Fatalf("Failed to push a value: %s", err)
+	}
+	AssertValue(t, repo, "foo", 2)
+	if err := prov.Push("bar", 3); err != nil {
+		t.Fatalf("Failed to push a value: %s", err)
+	}
+	AssertValue(t, repo, "bar", 3)
+	prov.Delete("foo")
+	AssertValue(t, repo, "foo", 1)
+
+	if want := []string{"foo", "bar", "foo"}; !reflect.DeepEqual(changes, want) {
+		t.Fatalf("Unexpected change notifications: got: %#v, want: %#v", changes, want)
+	}
+}
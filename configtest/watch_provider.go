@@ -0,0 +1,131 @@
+package configtest
+
+import (
+	"sync"
+
+	"github.com/osdrv/config"
+)
+
+// WatchProvider is a fake provider imitating a watching config source: the
+// values can be changed at any moment by calling Push and Delete. Every
+// change is applied as a reload (see `config.Repository.ApplyReload`), so
+// repository subscribers are notified as well. Change listeners are notified
+// synchronously.
+type WatchProvider struct {
+	name      string
+	weight    int
+	repo      *config.Repository
+	registry  map[string]config.Value
+	listeners []func(config.Key)
+	mx        sync.RWMutex
+}
+
+var _ config.Provider = (*WatchProvider)(nil)
+
+// NewWatchProvider is the constructor for WatchProvider. The registry is the
+// initial set of flattened values.
+func NewWatchProvider(repo *config.Repository, weight int, name string, registry map[string]config.Value) *WatchProvider {
+	values := make(map[string]config.Value, len(registry))
+	for k, v := range registry {
+		values[k] = v
+	}
+	prov := &WatchProvider{
+		name:      name,
+		weight:    weight,
+		repo:      repo,
+		registry:  values,
+		listeners: make([]func(config.Key), 0),
+	}
+	repo.RegisterProvider(prov)
+	return prov
+}
+
+// Name returns the provider name provided to the constructor
+func (wp *WatchProvider) Name() string { return wp.name }
+
+// Depends returns the list of provider dependencies: none
+func (wp *WatchProvider) Depends() []string { return []string{} }
+
+// Weight returns the provider weight
+func (wp *WatchProvider) Weight() int { return wp.weight }
+
+// SetUp registers all keys from the registry in the repo
+func (wp *WatchProvider) SetUp(repo *config.Repository) error {
+	wp.mx.RLock()
+	defer wp.mx.RUnlock()
+	for k := range wp.registry {
+		if err := repo.RegisterKey(config.NewKey(k), wp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TearDown is a no-op operation for WatchProvider
+func (wp *WatchProvider) TearDown(*config.Repository) error { return nil }
+
+// Get returns the current value for the key
+func (wp *WatchProvider) Get(key config.Key) (*config.KeyValue, bool) {
+	wp.mx.RLock()
+	defer wp.mx.RUnlock()
+	if v, ok := wp.registry[key.String()]; ok {
+		return &config.KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
+
+// OnChange registers a listener called on every Push and Delete.
+func (wp *WatchProvider) OnChange(listener func(config.Key)) {
+	wp.mx.Lock()
+	defer wp.mx.Unlock()
+	wp.listeners = append(wp.listeners, listener)
+}
+
+// Push sets the value for the key and notifies the listeners. A new key is
+// registered in the repository. Returns an error if the value is rejected by
+// the schema validation: in this case the previous value is restored.
+func (wp *WatchProvider) Push(key string, value config.Value) error {
+	wp.mx.RLock()
+	prev, known := wp.registry[key]
+	wp.mx.RUnlock()
+	if err := wp.repo.ApplyReload(wp, func() error {
+		wp.mx.Lock()
+		wp.registry[key] = value
+		wp.mx.Unlock()
+		return wp.repo.RegisterKey(config.NewKey(key), wp)
+	}, func() {
+		wp.mx.Lock()
+		defer wp.mx.Unlock()
+		if known {
+			wp.registry[key] = prev
+		} else {
+			delete(wp.registry, key)
+		}
+	}); err != nil {
+		return err
+	}
+	wp.notify(config.NewKey(key))
+	return nil
+}
+
+// Delete removes the value for the key and notifies the listeners. The key
+// registration is preserved: lookups fall through to other providers.
+func (wp *WatchProvider) Delete(key string) {
+	wp.repo.ApplyReload(wp, func() error {
+		wp.mx.Lock()
+		delete(wp.registry, key)
+		wp.mx.Unlock()
+		return nil
+	}, nil)
+	wp.notify(config.NewKey(key))
+}
+
+func (wp *WatchProvider) notify(key config.Key) {
+	wp.mx.RLock()
+	listeners := make([]func(config.Key), len(wp.listeners))
+	copy(listeners, wp.listeners)
+	wp.mx.RUnlock()
+	for _, listener := range listeners {
+		listener(key)
+	}
+}
@@ -71,16 +71,14 @@ type StrToBoolConverter struct{}
 
 var _ Converter = (*StrToBoolConverter)(nil)
 
-// Convert returns true, true for strings "true", "1", "y".
-// For strings "false", "0" and "n" returns false, true.
+// Convert returns true, true for the true spellings, e.g. "true", "1", "y",
+// and false, true for the false spellings, e.g. "false", "0" and "n" (see
+// SetBoolStrings).
 // Returns false, false otherwise treating the case as non-successful conversion.
 func (*StrToBoolConverter) Convert(kv *KeyValue) (*KeyValue, bool) {
 	if sv, ok := kv.Value.(string); ok {
-		switch sv {
-		case "true", "1", "y":
-			return &KeyValue{Key: kv.Key, Value: true}, true
-		case "false", "0", "n":
-			return &KeyValue{Key: kv.Key, Value: false}, true
+		if v, ok := parseBoolString(sv); ok {
+			return &KeyValue{Key: kv.Key, Value: v}, true
 		}
 	}
 	return nil, false
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var (
+	typeConverters   = make(map[reflect.Type]Converter)
+	typeConvertersMx sync.RWMutex
+)
+
+// RegisterConverter registers the converter for the type, e.g. for an
+// application-defined enum or ID type. A type might be used as a schema
+// definition directly: the registered converter is used to map the values.
+// The converter is expected to return values of the type exactly.
+// Registering a converter for an already registered type replaces it.
+// This function is thread safe.
+//
+// Example:
+//
+//	config.RegisterConverter(reflect.TypeOf(LogLevel("")), LogLevelConverter)
+//	repo.DefineSchema(map[string]config.Schema{
+//		"log.level": reflect.TypeOf(LogLevel("")),
+//	})
+func RegisterConverter(t reflect.Type, conv Converter) {
+	typeConvertersMx.Lock()
+	defer typeConvertersMx.Unlock()
+	typeConverters[t] = conv
+}
+
+// ConverterFor returns the converter registered for the type. The standard
+// converters are registered for int, string and bool (ToInt, ToStr and
+// ToBool), the sized integers (ToInt8 to ToUint64), the floats (ToFloat32
+// and ToFloat64), time.Duration (ToDuration), ByteSize, RetryPolicy and
+// RateLimit.
+// Returns false if no converter is registered.
+// This function is thread safe.
+func ConverterFor(t reflect.Type) (Converter, bool) {
+	typeConvertersMx.RLock()
+	conv, ok := typeConverters[t]
+	typeConvertersMx.RUnlock()
+	if ok {
+		return conv, true
+	}
+	if it, ok := intKindTypes[t.Kind()]; ok && it == t && t.Kind() != reflect.Int {
+		return intConverterFor(t.Kind()), true
+	}
+	switch t {
+	case reflect.TypeOf(0):
+		return ToInt, true
+	case reflect.TypeOf(""):
+		return ToStr, true
+	case reflect.TypeOf(false):
+		return ToBool, true
+	case reflect.TypeOf(float32(0)):
+		return ToFloat32, true
+	case reflect.TypeOf(float64(0)):
+		return ToFloat64, true
+	case reflect.TypeOf(time.Duration(0)):
+		return ToDuration, true
+	case reflect.TypeOf(ByteSize(0)):
+		return ToByteSize, true
+	case reflect.TypeOf(RetryPolicy{}):
+		return ToRetryPolicy, true
+	case reflect.TypeOf(RateLimit{}):
+		return ToRateLimit, true
+	}
+	return nil, false
+}
+
+// TypeMapper maps values to the type using the converter registered for it
+// (see RegisterConverter). A reflect.Type schema definition is turned into a
+// TypeMapper.
+type TypeMapper struct {
+	t reflect.Type
+}
+
+var _ Mapper = (*TypeMapper)(nil)
+
+// NewTypeMapper is the constructor for TypeMapper.
+func NewTypeMapper(t reflect.Type) *TypeMapper {
+	return &TypeMapper{t: t}
+}
+
+// Map converts the value using the converter registered for the type. The
+// converter is looked up on every call: converters might be registered after
+// the schema is defined. Returns an error if no converter is registered, if
+// the conversion fails or if the converted value is not of the type.
+func (tm *TypeMapper) Map(kv *KeyValue) (*KeyValue, error) {
+	conv, ok := ConverterFor(tm.t)
+	if !ok {
+		return nil, fmt.Errorf("No converter registered for type %s (key %q)", tm.t, kv.Key)
+	}
+	mkv, err := NewConvMapper(conv).Map(kv)
+	if err != nil {
+		return nil, err
+	}
+	if got := reflect.TypeOf(mkv.Value); got != tm.t {
+		return nil, fmt.Errorf("Converter for type %s returned %v value for key %q", tm.t, got, kv.Key)
+	}
+	return mkv, nil
+}
+
+// JSONSchema describes the values accepted by the converter registered for
+// the type.
+func (tm *TypeMapper) JSONSchema() map[string]interface{} {
+	if conv, ok := ConverterFor(tm.t); ok {
+		return describeConverter(conv)
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testLogLevel string
+
+type testLogLevelConverter struct{}
+
+func (testLogLevelConverter) Convert(kv *KeyValue) (*KeyValue, bool) {
+	if s, ok := kv.Value.(string); ok {
+		switch s {
+		case "debug", "info", "error":
+			return &KeyValue{Key: kv.Key, Value: testLogLevel(s)}, true
+		}
+	}
+	return nil, false
+}
+
+type testUnregistered string
+
+type testBrokenID int
+
+func TestTypeMapper(t *testing.T) {
+	RegisterConverter(reflect.TypeOf(testLogLevel("")), testLogLevelConverter{})
+	// The converter returns values of a wrong type
+	RegisterConverter(reflect.TypeOf(testBrokenID(0)), ToInt)
+
+	tests := []struct {
+		name    string
+		typ     reflect.Type
+		value   Value
+		want    Value
+		wantErr bool
+	}{
+		{"registered type", reflect.TypeOf(testLogLevel("")), "info", testLogLevel("info"), false},
+		{"invalid value", reflect.TypeOf(testLogLevel("")), "verbose", nil, true},
+		{"unregistered type", reflect.TypeOf(testUnregistered("")), "foo", nil, true},
+		{"wrong converted type", reflect.TypeOf(testBrokenID(0)), 42, nil, true},
+		{"int", reflect.TypeOf(0), "8080", 8080, false},
+		{"string", reflect.TypeOf(""), 42, "42", false},
+		{"bool", reflect.TypeOf(false), "true", true, false},
+		{"duration", reflect.TypeOf(time.Second), "1m30s", 90 * time.Second, false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			mkv, err := NewTypeMapper(testCase.typ).Map(&KeyValue{Key: NewKey("foo"), Value: testCase.value})
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(mkv.Value, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", mkv.Value, testCase.want)
+			}
+		})
+	}
+}
+
+func TestTypeSchema(t *testing.T) {
+	RegisterConverter(reflect.TypeOf(testLogLevel("")), testLogLevelConverter{})
+
+	repo := NewRepository()
+	if err := repo.DefineSchema(map[string]Schema{
+		"log.level": reflect.TypeOf(testLogLevel("")),
+		"timeout":   Describe(reflect.TypeOf(time.Second), "Request timeout"),
+	}); err != nil {
+		t.Fatalf("Failed to define the schema: %s", err)
+	}
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"log.level": "debug",
+		"timeout":   "5s",
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if got, _ := repo.Get(NewKey("log.level")); got != testLogLevel("debug") {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testLogLevel("debug"))
+	}
+	if got, _ := repo.Get(NewKey("timeout")); got != 5*time.Second {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", got, 5*time.Second)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import "sync"
+
+var (
+	defaultRepo   *Repository
+	defaultRepoMx sync.Mutex
+)
+
+// Default returns the package-level default repository. It is created on the
+// first call. Small programs might use the default repository instead of
+// threading a *Repository through every function: Must* and Lookup*
+// functions fall back to it if the getter is nil.
+//
+// Example:
+//
+//	config.NewEnvProviderWithPrefix(config.Default(), 10, "APP_")
+//	if err := config.Default().SetUp(); err != nil {
+//		// ...
+//	}
+//	port := config.MustInt(nil, "server.port")
+//
+// This function is thread safe.
+func Default() *Repository {
+	defaultRepoMx.Lock()
+	defer defaultRepoMx.Unlock()
+	if defaultRepo == nil {
+		defaultRepo = NewRepository()
+	}
+	return defaultRepo
+}
+
+// SetDefault replaces the package-level default repository, e.g. with a
+// repository created by New.
+// This function is thread safe.
+func SetDefault(repo *Repository) {
+	defaultRepoMx.Lock()
+	defer defaultRepoMx.Unlock()
+	defaultRepo = repo
+}
+
+// Get looks up the key in the default repository. See Default.
+func Get(key string) (Value, bool) {
+	repo := Default()
+	return repo.Get(repo.NewKey(key))
+}
+
+// orDefault returns the getter itself or the default repository if the
+// getter is nil.
+func orDefault(repo Getter) Getter {
+	if repo == nil {
+		return Default()
+	}
+	return repo
+}
@@ -1,5 +1,13 @@
 package config
 
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
 // DefaultProvider represents a set of default values.
 // Prefer keeping defaults over providing default values local to other
 // providers as it guarantees presence of the default values indiffirent to
@@ -8,6 +16,8 @@ type DefaultProvider struct {
 	weight   int
 	registry map[string]Value
 	ready    chan struct{}
+	mx       sync.Mutex
+	isSetUp  bool
 }
 
 var _ Provider = (*DefaultProvider)(nil)
@@ -30,6 +40,121 @@ func NewDefaultProviderWithDefaults(repo *Repository, weight int, registry map[s
 	return prov, nil
 }
 
+// NewDefaultProviderWithRegistry is an alias for
+// NewDefaultProviderWithDefaults.
+//
+// Deprecated: use NewDefaultProviderWithDefaults.
+func NewDefaultProviderWithRegistry(repo *Repository, weight int, registry map[string]Value) (*DefaultProvider, error) {
+	return NewDefaultProviderWithDefaults(repo, weight, registry)
+}
+
+// SetDefault adds a default value to the registry, so modules can register
+// their defaults incrementally, e.g. from init():
+//
+//	defaults.SetDefault("billing.port", 8080)
+//
+// The value replaces the previous default of the key, if any. Returns an
+// error if the provider is already set up.
+// This method is thread safe.
+func (dp *DefaultProvider) SetDefault(key string, value Value) error {
+	dp.mx.Lock()
+	defer dp.mx.Unlock()
+	if dp.isSetUp {
+		return fmt.Errorf("Failed to set default value for key %q: the default provider is already set up", key)
+	}
+	if dp.registry == nil {
+		dp.registry = make(map[string]Value)
+	}
+	dp.registry[key] = value
+	return nil
+}
+
+// NewDefaultProviderFromStruct is an alternative constructor for
+// DefaultProvider. The registry is built out of a struct (or a pointer to a
+// struct) value, so the defaults live next to the typed config definition:
+//
+//	type Config struct {
+//		Server struct {
+//			Port    int
+//			Timeout time.Duration `config:"request_timeout"`
+//		}
+//		Debug bool `config:"-"`
+//	}
+//
+//	config.NewDefaultProviderFromStruct(repo, 10, &Config{...})
+//
+// Fields are mapped to keys by the `config` tag or by the snake-cased field
+// name (RequestTimeout becomes request_timeout), the same way sections are
+// decoded (see RegisterSection). Nested structs are flattened, nil pointers,
+// slices and maps, unexported fields and fields tagged with "-" are skipped.
+// Structs having a registered converter (see ConverterFor) are leaf values.
+func NewDefaultProviderFromStruct(repo *Repository, weight int, defaults interface{}) (*DefaultProvider, error) {
+	rv := reflect.ValueOf(defaults)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Failed to load config defaults: a struct value expected, got: %T", defaults)
+	}
+	registry := make(map[string]Value)
+	flattenStruct("", rv, registry)
+	return NewDefaultProviderWithDefaults(repo, weight, registry)
+}
+
+// flattenStruct stores the struct fields into out under the dotted keys.
+func flattenStruct(prefix string, rv reflect.Value, out map[string]Value) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Tag.Get("config")
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			name = snakeCase(field.Name)
+		}
+		key := QuoteFragment(name)
+		if len(prefix) > 0 {
+			key = prefix + KeySepCh + key
+		}
+		fv := rv.Field(i)
+		switch fv.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			if fv.IsNil() {
+				continue
+			}
+		}
+		if sv := reflect.Indirect(fv); sv.Kind() == reflect.Struct {
+			if _, ok := ConverterFor(sv.Type()); !ok {
+				flattenStruct(key, sv, out)
+				continue
+			}
+		}
+		out[key] = fv.Interface()
+	}
+}
+
+// snakeCase converts a Go identifier into a snake-cased key fragment, e.g.
+// RequestTimeout becomes request_timeout and HTTPPort becomes http_port.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 // Name returns provider name: default
 func (dp *DefaultProvider) Name() string { return "default" }
 
@@ -39,13 +164,20 @@ func (dp *DefaultProvider) Depends() []string { return []string{} }
 // Weight returns the provider weight
 func (dp *DefaultProvider) Weight() int { return dp.weight }
 
-// SetUp registers all keys from the registry in the repo
+// SetUp registers all keys from the registry in the repo. Described values
+// (see Describe) register their descriptions in the repo as well.
 func (dp *DefaultProvider) SetUp(repo *Repository) error {
 	defer close(dp.ready)
-	for k := range dp.registry {
+	dp.mx.Lock()
+	defer dp.mx.Unlock()
+	dp.isSetUp = true
+	for k, v := range dp.registry {
 		if err := repo.RegisterKey(NewKey(k), dp); err != nil {
 			return err
 		}
+		if d, ok := v.(*Description); ok {
+			repo.addDescription(NewKey(k), d)
+		}
 	}
 	return nil
 }
@@ -57,7 +189,7 @@ func (dp *DefaultProvider) TearDown(*Repository) error { return nil }
 func (dp *DefaultProvider) Get(key Key) (*KeyValue, bool) {
 	<-dp.ready
 	if val, ok := dp.registry[key.String()]; ok {
-		return &KeyValue{Key: key, Value: val}, ok
+		return &KeyValue{Key: key, Value: unwrapDescription(val)}, ok
 	}
 	return nil, false
 }
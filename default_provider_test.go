@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultProviderSetUp(t *testing.T) {
@@ -62,3 +63,94 @@ func TestDefaultProviderSetUp(t *testing.T) {
 		})
 	}
 }
+
+func TestNewDefaultProviderFromStruct(t *testing.T) {
+	type tlsConfig struct {
+		CertFile string
+	}
+	type serverConfig struct {
+		Port           int
+		RequestTimeout time.Duration
+		TLS            *tlsConfig
+		Tags           []string
+	}
+	type testConfig struct {
+		Server   serverConfig
+		LogLevel string `config:"level"`
+		Debug    bool   `config:"-"`
+		HTTPPort int
+		internal string
+	}
+
+	defaults := &testConfig{
+		Server: serverConfig{
+			Port:           8080,
+			RequestTimeout: 5 * time.Second,
+			TLS:            &tlsConfig{CertFile: "cert.pem"},
+		},
+		LogLevel: "info",
+		Debug:    true,
+		internal: "internal",
+	}
+	repo := NewRepository()
+	if _, err := NewDefaultProviderFromStruct(repo, 0, defaults); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	want := map[string]Value{
+		"server.port":            8080,
+		"server.request_timeout": 5 * time.Second,
+		"server.tls.cert_file":   "cert.pem",
+		"level":                  "info",
+		"http_port":              0,
+	}
+	if got := repo.Dump(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected defaults: got: %#v, want: %#v", got, want)
+	}
+
+	if _, err := NewDefaultProviderFromStruct(NewRepository(), 0, 42); err == nil {
+		t.Fatalf("Expected an error for a non-struct value")
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"Port":           "port",
+		"RequestTimeout": "request_timeout",
+		"HTTPPort":       "http_port",
+		"UserID":         "user_id",
+	} {
+		if got := snakeCase(name); got != want {
+			t.Fatalf("Unexpected snake case of %q: got: %q, want: %q", name, got, want)
+		}
+	}
+}
+
+func TestDefaultProviderSetDefault(t *testing.T) {
+	repo := NewRepository()
+	prov, err := NewDefaultProviderWithRegistry(repo, 0, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for key, v := range map[string]Value{"server.port": 8080, "server.host": "localhost"} {
+		if err := prov.SetDefault(key, v); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	if err := prov.SetDefault("server.port", 9090); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	want := map[string]Value{"server.port": 9090, "server.host": "localhost"}
+	if got := repo.Dump(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected defaults: got: %#v, want: %#v", got, want)
+	}
+	if err := prov.SetDefault("server.debug", true); err == nil {
+		t.Fatalf("Expected an error once the provider is set up")
+	}
+}
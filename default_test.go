@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestDefaultRepository(t *testing.T) {
+	defer SetDefault(nil)
+
+	SetDefault(nil)
+	if Default() != Default() {
+		t.Fatalf("Expected Default to return the same repository")
+	}
+
+	repo := NewRepository()
+	repo.RegisterKey(NewKey("server.port"), NewTestProv("8080", DefaultWeight))
+	SetDefault(repo)
+
+	if v, ok := Get("server.port"); !ok || v != "8080" {
+		t.Fatalf("Unexpected Get result: got: %#v, %t, want: %#v, true", v, ok, "8080")
+	}
+	if v := MustInt(nil, "server.port"); v != 8080 {
+		t.Fatalf("Unexpected MustInt result: got: %d, want: %d", v, 8080)
+	}
+	if v, ok, err := LookupStr(nil, "server.port"); !ok || err != nil || v != "8080" {
+		t.Fatalf("Unexpected LookupStr result: got: %#v, %t, %v", v, ok, err)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import "reflect"
+
+// Description is a wrapper attaching human-readable metadata to a key. It
+// might wrap a schema definition (a Mapper, a Converter or a nested schema
+// map) or a default value in DefaultProvider registry. Descriptions are
+// surfaced by Explain, the JSON Schema export and the docs generator.
+type Description struct {
+	// Subject is the described schema definition or a default value.
+	Subject  interface{}
+	Text     string
+	Examples []string
+	// Secret marks the key value as sensitive: it is redacted in error
+	// messages.
+	Secret bool
+	// Required makes the repository set up fail if no provider serves the
+	// key. Wildcard keys are not checked.
+	Required bool
+}
+
+var _ Mapper = (*Description)(nil)
+var _ JSONSchemaDescriber = (*Description)(nil)
+
+// Describe wraps a schema definition or a default value with a description
+// and a list of examples.
+//
+// Example:
+//
+//	schema := map[string]Schema{
+//		"port": Describe(ToInt, "HTTP listen port", "8080"),
+//	}
+func Describe(subject interface{}, text string, examples ...string) *Description {
+	return &Description{
+		Subject:  subject,
+		Text:     text,
+		Examples: examples,
+	}
+}
+
+// Secret wraps a schema definition and marks the key value as sensitive. Raw
+// values of secret keys never show up in mapping error messages.
+//
+// Example:
+//
+//	schema := map[string]Schema{
+//		"password": Secret(ToStr),
+//	}
+func Secret(subject interface{}) *Description {
+	return &Description{
+		Subject: subject,
+		Secret:  true,
+	}
+}
+
+// Map delegates the mapping to the described schema definition. If the
+// subject is neither a Mapper nor a Converter, the key-value pair is returned
+// as is.
+func (d *Description) Map(kv *KeyValue) (*KeyValue, error) {
+	switch s := d.Subject.(type) {
+	case Mapper:
+		return s.Map(kv)
+	case Converter:
+		return NewConvMapper(s).Map(kv)
+	case reflect.Type:
+		return NewTypeMapper(s).Map(kv)
+	}
+	return kv, nil
+}
+
+// JSONSchema describes the subject and annotates it with the description and
+// examples.
+func (d *Description) JSONSchema() map[string]interface{} {
+	res, err := describeSchema(NewKey(""), d.Subject)
+	if err != nil {
+		res = map[string]interface{}{}
+	}
+	d.annotate(res)
+	return res
+}
+
+func (d *Description) annotate(res map[string]interface{}) {
+	if len(d.Text) > 0 {
+		res["description"] = d.Text
+	}
+	if len(d.Examples) > 0 {
+		examples := make([]interface{}, 0, len(d.Examples))
+		for _, ex := range d.Examples {
+			examples = append(examples, ex)
+		}
+		res["examples"] = examples
+	}
+}
+
+// unwrapDescription returns the described value if the argument is a
+// Description. Returns the argument itself otherwise.
+func unwrapDescription(v interface{}) interface{} {
+	if d, ok := v.(*Description); ok {
+		return d.Subject
+	}
+	return v
+}
+
+// collectDescriptions walks the schema and inserts all descriptions in the
+// trie.
+func collectDescriptions(key Key, schema Schema, descriptions *MapperNode) {
+	if d, ok := schema.(*Defaulted); ok {
+		schema = d.Subject
+	}
+	if d, ok := schema.(*Description); ok {
+		if len(key) > 0 {
+			descriptions.Insert(key, d)
+		}
+		schema = d.Subject
+	}
+	if smap, ok := schema.(map[string]Schema); ok {
+		for subKey, subSchema := range smap {
+			if subKey == "__self__" {
+				collectDescriptions(key, subSchema, descriptions)
+				continue
+			}
+			collectDescriptions(key.Join(NewKey(subKey)), subSchema, descriptions)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	schema := Schema(map[string]Schema{
+		"server": Describe(map[string]Schema{
+			"port": Describe(ToInt, "HTTP listen port", "8080"),
+		}, "HTTP server settings"),
+		"plugins": map[string]Schema{
+			"*": map[string]Schema{
+				"enabled": Describe(ToBool, "Enables the plugin"),
+			},
+		},
+	})
+
+	repo := NewRepository()
+	if err := repo.DefineSchema(schema); err != nil {
+		t.Fatalf("Failed to define schema: %s", err)
+	}
+	defaults, err := NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"server.port":         "8080",
+		"plugins.foo.enabled": "true",
+		"debug":               Describe(false, "Enables debug output"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new default provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	// Described schema definitions keep mapping the values
+	for k, want := range map[string]Value{
+		"server.port":         8080,
+		"plugins.foo.enabled": true,
+		"debug":               false,
+	} {
+		if got, ok := repo.Get(NewKey(k)); !ok || !reflect.DeepEqual(got, want) {
+			t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", k, got, want)
+		}
+	}
+
+	for k, want := range map[string]string{
+		"server":              "HTTP server settings",
+		"server.port":         "HTTP listen port",
+		"plugins.foo.enabled": "Enables the plugin",
+		"debug":               "Enables debug output",
+	} {
+		d, ok := repo.Description(NewKey(k))
+		if !ok || d.Text != want {
+			t.Fatalf("Unexpected description for key %q: got: %#v, want: %q", k, d, want)
+		}
+	}
+
+	explained := repo.Explain()
+	port := explained["server"].(map[string]interface{})["port"].(map[string]interface{})
+	if port["__description__"] != "HTTP listen port" || !reflect.DeepEqual(port["__examples__"], []string{"8080"}) {
+		t.Fatalf("Unexpected explanation for key server.port: %#v", port)
+	}
+
+	var buf bytes.Buffer
+	if err := GenerateDocs(&buf, schema, defaults, nil); err != nil {
+		t.Fatalf("Unexpected docs generation error: %s", err)
+	}
+	for _, want := range []string{
+		"| `server.port` | integer | `8080` | HTTP listen port |",
+		"| `debug` | any | `false` | Enables debug output |",
+		"| `plugins.*.enabled` | boolean |  | Enables the plugin |",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("Expected docs to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	data, err := SchemaToJSONSchema(schema)
+	if err != nil {
+		t.Fatalf("Unexpected JSON schema export error: %s", err)
+	}
+	for _, want := range []string{
+		`"description": "HTTP server settings"`,
+		`"description": "HTTP listen port"`,
+		`"examples": [`,
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Fatalf("Expected JSON schema to contain %q, got:\n%s", want, data)
+		}
+	}
+}
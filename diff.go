@@ -0,0 +1,39 @@
+package config
+
+import "fmt"
+
+// Source is a configuration to load: the Builder options assembling its
+// providers, e.g. `config.Source{config.WithYamlFile("prod.yaml")}`.
+type Source []Option
+
+// DiffSources loads both configurations and returns the key-level
+// differences of the effective values sorted by key: a change turns the
+// value served by a into the one served by b. Keys absent from a are
+// reported as added, keys absent from b as deleted (see Change.Type).
+func DiffSources(a, b Source) ([]Change, error) {
+	before, err := loadSource(a)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the first config source: %s", err)
+	}
+	after, err := loadSource(b)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the second config source: %s", err)
+	}
+	return diffSnapshots(before, after), nil
+}
+
+// loadSource returns the effective values of the source.
+func loadSource(src Source) (map[string]Value, error) {
+	repo, err := New(src...)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.TearDown()
+	return repo.Dump(), nil
+}
+
+// TypeChanged returns true if an updated value changed its type, e.g. a
+// port defined as a string instead of an int.
+func (c Change) TypeChanged() bool {
+	return c.Type() == ChangeUpdated && fmt.Sprintf("%T", c.Old) != fmt.Sprintf("%T", c.New)
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffSources(t *testing.T) {
+	staging := Source{WithDefaults(map[string]Value{
+		"server.port":  8080,
+		"server.host":  "staging.local",
+		"server.debug": true,
+	})}
+	production := Source{WithDefaults(map[string]Value{
+		"server.port":    "8080",
+		"server.host":    "prod.local",
+		"server.workers": 8,
+	})}
+
+	changes, err := DiffSources(staging, production)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := []Change{
+		{Key: NewKey("server.debug"), Old: true},
+		{Key: NewKey("server.host"), Old: "staging.local", New: "prod.local"},
+		{Key: NewKey("server.port"), Old: 8080, New: "8080"},
+		{Key: NewKey("server.workers"), New: 8},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("Unexpected changes: got: %#v, want: %#v", changes, want)
+	}
+	gotTypeChanged := make([]bool, 0, len(changes))
+	for _, change := range changes {
+		gotTypeChanged = append(gotTypeChanged, change.TypeChanged())
+	}
+	if wantTypeChanged := []bool{false, false, true, false}; !reflect.DeepEqual(gotTypeChanged, wantTypeChanged) {
+		t.Fatalf("Unexpected type changes: got: %v, want: %v", gotTypeChanged, wantTypeChanged)
+	}
+
+	if changes, err := DiffSources(staging, staging); err != nil || len(changes) != 0 {
+		t.Fatalf("Unexpected result: got: %#v, %v, want no changes", changes, err)
+	}
+
+	_, err = DiffSources(staging, Source{WithYamlFileOptions("missing.yaml", &YamlProviderOptions{FS: OSFS})})
+	wantErr := "Failed to load the second config source"
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, wantErr)
+	}
+}
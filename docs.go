@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DocsOptions is a set of documentation generator settings.
+type DocsOptions struct {
+	// EnvPrefix is the environment variable prefix used by EnvProvider. If
+	// empty, the env var column is omitted.
+	EnvPrefix string
+}
+
+// docsRow is a single documented key.
+type docsRow struct {
+	key, typ, def, env, descr string
+}
+
+// GenerateDocs walks the schema and the default provider registry and writes
+// a markdown table describing every key: its type, the default value, the
+// corresponding environment variable name and the description. Defaults
+// might be nil. Descriptions are taken from the JSON Schema fragments of
+// converters and mappers (see JSONSchemaDescriber) or from described default
+// values (see Describe).
+func GenerateDocs(w io.Writer, schema Schema, defaults *DefaultProvider, options *DocsOptions) error {
+	if options == nil {
+		options = &DocsOptions{}
+	}
+	rows := make(map[string]*docsRow)
+	if err := collectDocs(NewKey(""), schema, rows); err != nil {
+		return err
+	}
+	if defaults != nil {
+		for k, v := range defaults.registry {
+			row, ok := rows[k]
+			if !ok {
+				row = &docsRow{key: k, typ: "any"}
+				rows[k] = row
+			}
+			row.def = fmt.Sprintf("`%v`", unwrapDescription(v))
+			if d, ok := v.(*Description); ok && len(row.descr) == 0 {
+				row.descr = d.Text
+			}
+		}
+	}
+	keys := make([]string, 0, len(rows))
+	for k := range rows {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	withEnv := len(options.EnvPrefix) > 0
+	if withEnv {
+		fmt.Fprintln(w, "| Key | Type | Default | Env | Description |")
+		fmt.Fprintln(w, "|-----|------|---------|-----|-------------|")
+	} else {
+		fmt.Fprintln(w, "| Key | Type | Default | Description |")
+		fmt.Fprintln(w, "|-----|------|---------|-------------|")
+	}
+	for _, k := range keys {
+		row := rows[k]
+		cols := []string{"`" + row.key + "`", row.typ, row.def}
+		if withEnv {
+			cols = append(cols, "`"+EnvVarName(options.EnvPrefix, NewKey(row.key))+"`")
+		}
+		cols = append(cols, row.descr)
+		for ix, col := range cols {
+			cols[ix] = strings.Replace(col, "|", "\\|", -1)
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cols, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectDocs(key Key, schema Schema, rows map[string]*docsRow) error {
+	if d, ok := schema.(*Description); ok {
+		if _, ok := d.Subject.(map[string]Schema); ok {
+			return collectDocs(key, d.Subject, rows)
+		}
+	}
+	if smap, ok := schema.(map[string]Schema); ok {
+		for subKey, subSchema := range smap {
+			if subKey == "__self__" {
+				continue
+			}
+			if err := collectDocs(key.Join(NewKey(subKey)), subSchema, rows); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	descr, err := describeSchema(key, schema)
+	if err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return nil
+	}
+	row := &docsRow{key: key.String(), typ: docsType(descr)}
+	if d, ok := descr["description"].(string); ok {
+		row.descr = d
+	}
+	if def, ok := descr["default"]; ok {
+		row.def = fmt.Sprintf("`%v`", def)
+	}
+	rows[row.key] = row
+	return nil
+}
+
+// docsType returns a human-readable type name of a JSON Schema fragment. For
+// unions, the first variant is the one that counts.
+func docsType(descr map[string]interface{}) string {
+	if typ, ok := descr["type"].(string); ok {
+		return typ
+	}
+	if anyOf, ok := descr["anyOf"].([]interface{}); ok && len(anyOf) > 0 {
+		if variant, ok := anyOf[0].(map[string]interface{}); ok {
+			return docsType(variant)
+		}
+	}
+	return "any"
+}
+
+// EnvVarName returns the environment variable name EnvProvider maps to the
+// key. This is the reverse of EnvProvider key canonisation: key fragments are
+// upper-cased and joined with an underscore, underscores are doubled.
+// Wildcard fragments are rendered as `<NAME>`.
+func EnvVarName(prefix string, key Key) string {
+	chunks := make([]string, 0, len(key))
+	for _, k := range key {
+		if k == "*" {
+			chunks = append(chunks, "<NAME>")
+			continue
+		}
+		chunks = append(chunks, strings.ToUpper(strings.Replace(k, "_", "__", -1)))
+	}
+	return prefix + strings.Join(chunks, "_")
+}
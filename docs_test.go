@@ -0,0 +1,67 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	schema := Schema(map[string]Schema{
+		"server": map[string]Schema{
+			"port":      ToInt,
+			"read_host": ToStr,
+		},
+		"plugins": map[string]Schema{
+			"*": map[string]Schema{
+				"enabled": ToBool,
+			},
+		},
+	})
+	repo := NewRepository()
+	defaults, err := NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"server.port": 8080,
+		"debug":       false,
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new default provider: %s", err)
+	}
+
+	tests := []struct {
+		name    string
+		options *DocsOptions
+		want    string
+	}{
+		{
+			"No env prefix",
+			nil,
+			"| Key | Type | Default | Description |\n" +
+				"|-----|------|---------|-------------|\n" +
+				"| `debug` | any | `false` |  |\n" +
+				"| `plugins.*.enabled` | boolean |  |  |\n" +
+				"| `server.port` | integer | `8080` |  |\n" +
+				"| `server.read_host` | string |  |  |\n",
+		},
+		{
+			"With env prefix",
+			&DocsOptions{EnvPrefix: "APP_"},
+			"| Key | Type | Default | Env | Description |\n" +
+				"|-----|------|---------|-----|-------------|\n" +
+				"| `debug` | any | `false` | `APP_DEBUG` |  |\n" +
+				"| `plugins.*.enabled` | boolean |  | `APP_PLUGINS_<NAME>_ENABLED` |  |\n" +
+				"| `server.port` | integer | `8080` | `APP_SERVER_PORT` |  |\n" +
+				"| `server.read_host` | string |  | `APP_SERVER_READ__HOST` |  |\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := GenerateDocs(&buf, schema, defaults, testCase.options); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if got := buf.String(); got != testCase.want {
+				t.Fatalf("Unexpected docs:\ngot:\n%s\nwant:\n%s", got, testCase.want)
+			}
+		})
+	}
+}
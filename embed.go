@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// EmbeddedProviderName is the name of the provider serving the embedded
+// default config (see NewEmbeddedProvider).
+const EmbeddedProviderName = "embedded"
+
+// NewEmbeddedProvider returns a new YamlProvider serving the default config
+// file shipped within the binary, e.g.:
+//
+//	//go:embed defaults.yaml
+//	var defaultsFS embed.FS
+//
+//	config.NewEmbeddedProvider(cfg, config.EmbeddedProviderWeight, defaultsFS, "defaults.yaml")
+//
+// The file is expected to exist at once. The provider is named
+// EmbeddedProviderName and is expected to be weighted lower than the on-disk
+// config, so the latter overrides the built-in defaults.
+func NewEmbeddedProvider(repo *Repository, weight int, fsys fs.FS, path string) (*YamlProvider, error) {
+	if _, err := fs.Stat(fsys, path); err != nil {
+		return nil, fmt.Errorf("Failed to read embedded config %q: %s", path, err)
+	}
+	return NewYamlProviderFromSource(repo, weight, &YamlProviderOptions{
+		Name: EmbeddedProviderName,
+		FS:   fsys,
+	}, path)
+}
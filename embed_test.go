@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedProvider(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults.yaml": &fstest.MapFile{Data: []byte("server:\n  host: localhost\n  port: 8080\n  debug: true\n")},
+		"config.yaml":   &fstest.MapFile{Data: []byte("server:\n  port: 8081\n")},
+	}
+
+	repo, err := New(
+		WithDefaults(map[string]Value{"server.debug": false, "server.timeout": "1s"}),
+		WithEmbeddedDefaults(fsys, "defaults.yaml"),
+		WithYamlFileOptions("config.yaml", &YamlProviderOptions{FS: fsys}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer repo.TearDown()
+
+	tests := []struct {
+		key  string
+		want Value
+	}{
+		{"server.port", 8081},
+		{"server.host", "localhost"},
+		{"server.debug", true},
+		{"server.timeout", "1s"},
+	}
+	for _, testCase := range tests {
+		if got, ok := repo.Get(NewKey(testCase.key)); !ok || got != testCase.want {
+			t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", testCase.key, got, testCase.want)
+		}
+	}
+	if _, ok := repo.providers[EmbeddedProviderName]; !ok {
+		t.Fatalf("Expected provider %q to be registered", EmbeddedProviderName)
+	}
+
+	_, err = NewEmbeddedProvider(NewRepository(), 0, fsys, "missing.yaml")
+	wantErr := `Failed to read embedded config "missing.yaml"`
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, wantErr)
+	}
+}
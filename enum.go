@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnumConverter converts string values into a typed enum. Values outside of
+// the allowed set are rejected.
+type EnumConverter[T ~string] struct {
+	allowed []T
+}
+
+// Enum returns a converter accepting the allowed values only and converting
+// them to the enum type. Used as a schema definition, it fails the mapping
+// with an error listing the allowed values.
+//
+// Example:
+//
+//	type LogLevel string
+//
+//	repo.DefineSchema(map[string]config.Schema{
+//		"log.level": config.Enum[LogLevel]("debug", "info", "error"),
+//	})
+func Enum[T ~string](allowed ...T) Converter {
+	return &EnumConverter[T]{allowed: allowed}
+}
+
+var _ Converter = (*EnumConverter[string])(nil)
+var _ Mapper = (*EnumConverter[string])(nil)
+
+// Convert returns the value converted to the enum type and true if the value
+// is a string (or a value of the enum type) in the allowed set.
+func (ec *EnumConverter[T]) Convert(kv *KeyValue) (*KeyValue, bool) {
+	var v T
+	switch tv := kv.Value.(type) {
+	case T:
+		v = tv
+	case string:
+		v = T(tv)
+	default:
+		return nil, false
+	}
+	for _, a := range ec.allowed {
+		if a == v {
+			return &KeyValue{Key: kv.Key, Value: v}, true
+		}
+	}
+	return nil, false
+}
+
+// Map converts the value. Returns an error listing the allowed values if
+// the value is not in the allowed set.
+func (ec *EnumConverter[T]) Map(kv *KeyValue) (*KeyValue, error) {
+	if mkv, ok := ec.Convert(kv); ok {
+		return mkv, nil
+	}
+	// The raw value is not a part of the message: it might be a secret
+	return nil, fmt.Errorf("Unexpected value for key %q: allowed values are: %s", kv.Key, ec.allowedList())
+}
+
+func (ec *EnumConverter[T]) allowedList() string {
+	names := make([]string, 0, len(ec.allowed))
+	for _, a := range ec.allowed {
+		names = append(names, string(a))
+	}
+	return strings.Join(names, ", ")
+}
+
+// JSONSchema describes the accepted values: one of the allowed strings
+func (ec *EnumConverter[T]) JSONSchema() map[string]interface{} {
+	enum := make([]interface{}, 0, len(ec.allowed))
+	for _, a := range ec.allowed {
+		enum = append(enum, string(a))
+	}
+	return map[string]interface{}{"type": "string", "enum": enum}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testColor string
+
+func TestEnum(t *testing.T) {
+	conv := Enum[testColor]("red", "green")
+	tests := []struct {
+		name   string
+		value  Value
+		want   Value
+		wantOk bool
+	}{
+		{"allowed string", "red", testColor("red"), true},
+		{"allowed typed value", testColor("green"), testColor("green"), true},
+		{"unknown value", "blue", nil, false},
+		{"case mismatch", "Red", nil, false},
+		{"non-string value", 42, nil, false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			kv, ok := conv.Convert(&KeyValue{Key: NewKey("color"), Value: testCase.value})
+			if ok != testCase.wantOk {
+				t.Fatalf("Unexpected conversion result: got: %t, want: %t", ok, testCase.wantOk)
+			}
+			if ok && !reflect.DeepEqual(kv.Value, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", kv.Value, testCase.want)
+			}
+		})
+	}
+}
+
+func TestEnumSchema(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"color": Enum[testColor]("red", "green"),
+	})
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{"color": "blue"})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	_, _, err := Lookup(repo, "color")
+	var cerr *ConversionError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("Unexpected error: got: %v, want: a conversion error", err)
+	}
+	if want := "allowed values are: red, green"; !strings.Contains(err.Error(), want) {
+		t.Fatalf("Unexpected error: got: %q, want it to contain: %q", err, want)
+	}
+
+	want := map[string]interface{}{"type": "string", "enum": []interface{}{"red", "green"}}
+	if got := describeConverter(Enum[testColor]("red", "green")); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected JSON schema: got: %#v, want: %#v", got, want)
+	}
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Redefined in tests
@@ -10,10 +15,71 @@ var envVars = func() []string {
 	return os.Environ()
 }
 
-func canonise(key string) string {
-	k := strings.Replace(key, "_", ".", -1)
-	k = strings.Replace(k, "..", "_", -1)
-	return strings.ToLower(k)
+// EnvKeyRules defines the env var name to config key transformation (see
+// CanonicalizeEnvKey).
+type EnvKeyRules struct {
+	// Prefix is stripped from the env var name. Names lacking the prefix are
+	// rejected.
+	Prefix string
+	// Separator is the env var name fragment separator. Defaults to `_`.
+	Separator string
+	// KeySeparator is the config key fragment separator. Defaults to
+	// KeySepCh.
+	KeySeparator string
+	// PreserveCase keeps the env var name case. By default, keys are
+	// lowercased.
+	PreserveCase bool
+}
+
+// CanonicalizeEnvKey converts the env var name to a config key, e.g.
+// `CONFIG_SERVER_PORT` with prefix `CONFIG_` to `server.port`. The name is
+// scanned left to right: a doubled separator stands for a literal one, a
+// single separator splits key fragments. E.g. `DB__NAME_MAX___LEN` is
+// converted to `db_name.max_.len`. This is the inverse of EnvVarName for keys
+// with fragments not starting or ending with an underscore.
+// Returns an error if the name lacks the prefix or produces an empty key
+// fragment, e.g. `CONFIG__PORT` or `CONFIG_PORT_`. Rules might be nil.
+func CanonicalizeEnvKey(name string, rules *EnvKeyRules) (string, error) {
+	if rules == nil {
+		rules = &EnvKeyRules{}
+	}
+	sep, keySep := rules.Separator, rules.KeySeparator
+	if len(sep) == 0 {
+		sep = "_"
+	}
+	if len(keySep) == 0 {
+		keySep = KeySepCh
+	}
+	if !strings.HasPrefix(name, rules.Prefix) {
+		return "", fmt.Errorf("Malformed env var name %q: missing prefix %q", name, rules.Prefix)
+	}
+	rest := name[len(rules.Prefix):]
+	key := make(Key, 0)
+	var frag strings.Builder
+	for len(rest) > 0 {
+		switch {
+		case strings.HasPrefix(rest, sep+sep):
+			frag.WriteString(sep)
+			rest = rest[2*len(sep):]
+		case strings.HasPrefix(rest, sep):
+			key = key.Child(strings.Split(frag.String(), keySep)...)
+			frag.Reset()
+			rest = rest[len(sep):]
+		default:
+			frag.WriteByte(rest[0])
+			rest = rest[1:]
+		}
+	}
+	key = key.Child(strings.Split(frag.String(), keySep)...)
+	for i, k := range key {
+		if len(k) == 0 {
+			return "", fmt.Errorf("Malformed env var name %q: empty key fragment", name)
+		}
+		if !rules.PreserveCase {
+			key[i] = strings.ToLower(k)
+		}
+	}
+	return joinKey(key, keySep), nil
 }
 
 // EnvProvider reads special FLOW_ preffixed environment variables.
@@ -21,32 +87,120 @@ func canonise(key string) string {
 // * Underscores are being transformed to dots in key part (before the first =).
 // * There must be exactly 1 `=` sign.
 // * Double underscores are converted to singulars and preserved with no dot-conversion.
+// * Names producing empty key fragments are skipped.
+// See CanonicalizeEnvKey for the details.
 type EnvProvider struct {
 	weight   int
 	registry map[string]Value
 	ready    chan struct{}
 
 	prefix string
+	// bindings maps env var names to config keys
+	bindings map[string]string
+	// allowlist restricts the set of loaded prefixed env vars if not nil
+	allowlist map[string]bool
+	options   *EnvProviderOptions
+	repo      *Repository
+	mx        sync.RWMutex
 }
 
+// EnvProviderOptions is a set of EnvProvider settings.
+type EnvProviderOptions struct {
+	// Prefix is the env var name prefix. Prefixed env vars are canonised to
+	// config keys automatically (see CanonicalizeEnvKey). DefaultEnvPrefix is
+	// used if not set.
+	Prefix string
+	// Bindings maps env var names to config keys explicitly, e.g.
+	// {"DATABASE_URL": "db.url"}. Bound env vars do not need to be prefixed
+	// and are loaded regardless of the allowlist.
+	Bindings map[string]string
+	// Allowlist restricts the automatically loaded prefixed env vars to the
+	// listed names (including the prefix) if not nil. An empty non-nil list
+	// means only the bound env vars are loaded.
+	Allowlist []string
+	// FromSchema binds an env var to every key defined in the schema (see
+	// EnvVarName), e.g. `APP_SERVER_PORT` to `server.port`. Explicit
+	// bindings take precedence.
+	FromSchema bool
+	// ParseValues enables typed value parsing: instead of raw strings, the
+	// provider serves bools (`true`, `false`, see SetBoolStrings), ints,
+	// floats, durations (`1m30s`) and lists of these (see ListSeparator),
+	// just like a yaml config file would.
+	ParseValues bool
+	// ListSeparator is the list element separator used if ParseValues is
+	// set. DefaultEnvListSeparator is used if not set.
+	ListSeparator string
+}
+
+// DefaultEnvListSeparator is the default list element separator used by
+// EnvProvider if value parsing is enabled.
+const DefaultEnvListSeparator = ","
+
+// DefaultEnvPrefix is the default env var name prefix.
+const DefaultEnvPrefix = "CONFIG_"
+
 var _ Provider = (*EnvProvider)(nil)
+var _ Refresher = (*EnvProvider)(nil)
 
 func NewEnvProvider(repo *Repository, weight int) (*EnvProvider, error) {
-	return NewEnvProviderWithPrefix(repo, weight, "CONFIG_")
+	return NewEnvProviderWithPrefix(repo, weight, DefaultEnvPrefix)
 }
 
 // NewEnvProvider returns a new instance of EnvProvider.
 func NewEnvProviderWithPrefix(repo *Repository, weight int, prefix string) (*EnvProvider, error) {
+	return NewEnvProviderWithOptions(repo, weight, &EnvProviderOptions{Prefix: prefix})
+}
+
+// NewEnvProviderWithOptions returns a new instance of EnvProvider configured
+// with the options.
+func NewEnvProviderWithOptions(repo *Repository, weight int, options *EnvProviderOptions) (*EnvProvider, error) {
+	if options == nil {
+		options = &EnvProviderOptions{}
+	}
+	prefix := options.Prefix
+	if len(prefix) == 0 {
+		prefix = DefaultEnvPrefix
+	}
 	prov := &EnvProvider{
-		weight: weight,
-		ready:  make(chan struct{}),
-		prefix: prefix,
+		weight:   weight,
+		ready:    make(chan struct{}),
+		prefix:   prefix,
+		bindings: make(map[string]string),
+		options:  options,
+	}
+	for name, key := range options.Bindings {
+		prov.BindEnv(name, key)
+	}
+	if options.Allowlist != nil {
+		prov.Allow(options.Allowlist...)
 	}
 	repo.RegisterProvider(prov)
 
 	return prov, nil
 }
 
+// BindEnv binds the env var to the config key explicitly: the variable value
+// is served under the key regardless of the prefix and the allowlist. Must be
+// called before the provider is set up.
+func (ep *EnvProvider) BindEnv(name, key string) *EnvProvider {
+	ep.bindings[name] = key
+	return ep
+}
+
+// Allow restricts the automatically loaded prefixed env vars to the listed
+// names (including the prefix). Might be called multiple times. Calling it
+// with no names restricts the provider to the bound env vars. Must be called
+// before the provider is set up.
+func (ep *EnvProvider) Allow(names ...string) *EnvProvider {
+	if ep.allowlist == nil {
+		ep.allowlist = make(map[string]bool)
+	}
+	for _, name := range names {
+		ep.allowlist[name] = true
+	}
+	return ep
+}
+
 // Name returns provider name: env
 func (ep *EnvProvider) Name() string { return "env" }
 
@@ -58,43 +212,157 @@ func (ep *EnvProvider) Weight() int { return ep.weight }
 
 // SetUp takes the list of env vars and canonizes them before registration in
 // repo. Env vars are expected to be in form FLOW_<K>=<v>. FLOW_ preffix
-// would be cleared out.
+// would be cleared out. Explicitly bound env vars (see BindEnv) are
+// registered under the bound keys and take precedence over the canonised
+// ones.
 func (ep *EnvProvider) SetUp(repo *Repository) error {
 	defer close(ep.ready)
+	if ep.options.FromSchema && repo != nil {
+		for _, key := range repo.schemaKeys() {
+			name := EnvVarName(ep.prefix, key)
+			if _, ok := ep.bindings[name]; !ok {
+				ep.bindings[name] = key.String()
+			}
+		}
+	}
+	registry := ep.load(repo)
+	if err := ep.register(repo, registry); err != nil {
+		return err
+	}
+	ep.mx.Lock()
+	ep.registry = registry
+	ep.repo = repo
+	ep.mx.Unlock()
+
+	return nil
+}
+
+// Refresh re-reads the environment, e.g. if the process environment has been
+// updated after the start up. The new key set is applied atomically (see
+// Repository.ApplyReload): subscribers are notified on the changes.
+func (ep *EnvProvider) Refresh(context.Context) error {
+	ep.mx.RLock()
+	repo, prevRegistry := ep.repo, ep.registry
+	ep.mx.RUnlock()
+	if repo == nil {
+		return fmt.Errorf("Config provider %q is not set up", ep.Name())
+	}
+	registry := ep.load(repo)
+	return repo.ApplyReload(ep, func() error {
+		ep.mx.Lock()
+		ep.registry = registry
+		ep.mx.Unlock()
+		return ep.register(repo, registry)
+	}, func() {
+		ep.mx.Lock()
+		ep.registry = prevRegistry
+		ep.mx.Unlock()
+	})
+}
+
+// load reads the env vars and returns the provider registry. Malformed env
+// var names are skipped with a warning.
+func (ep *EnvProvider) load(repo *Repository) map[string]Value {
 	registry := make(map[string]Value)
-	var k string
+	bound := make(map[string]Value)
+	var name string
 	var v interface{}
 
 	for _, kv := range envVars() {
-		if !strings.HasPrefix(kv, ep.prefix) {
-			continue
-		}
-		kv = kv[len(ep.prefix):]
 		if ix := strings.Index(kv, "="); ix != -1 {
-			k, v = kv[:ix], kv[ix+1:]
+			name, v = kv[:ix], ep.parseValue(kv[ix+1:])
 		} else {
-			k, v = kv, true
+			name, v = kv, true
 		}
-		k = canonise(k)
-		registry[k] = v
-		if repo != nil {
-			if err := repo.RegisterKey(NewKey(k), ep); err != nil {
-				return err
+		if key, ok := ep.bindings[name]; ok {
+			bound[NewKey(key).String()] = v
+			continue
+		}
+		if !strings.HasPrefix(name, ep.prefix) {
+			continue
+		}
+		if ep.allowlist != nil && !ep.allowlist[name] {
+			continue
+		}
+		key, err := CanonicalizeEnvKey(name, &EnvKeyRules{Prefix: ep.prefix})
+		if err != nil {
+			if repo != nil {
+				repo.Logger().Warnf("Skipped env var: %s", err)
 			}
+			continue
 		}
+		registry[key] = v
 	}
+	for k, v := range bound {
+		registry[k] = v
+	}
+	return registry
+}
 
-	ep.registry = registry
-
+func (ep *EnvProvider) register(repo *Repository, registry map[string]Value) error {
+	if repo == nil {
+		return nil
+	}
+	for k := range registry {
+		if err := repo.RegisterKey(NewKey(k), ep); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// parseValue returns the raw env var value or the typed one if value parsing
+// is enabled.
+func (ep *EnvProvider) parseValue(raw string) Value {
+	if !ep.options.ParseValues {
+		return raw
+	}
+	sep := ep.options.ListSeparator
+	if len(sep) == 0 {
+		sep = DefaultEnvListSeparator
+	}
+	if strings.Contains(raw, sep) {
+		chunks := strings.Split(raw, sep)
+		res := make([]interface{}, 0, len(chunks))
+		for _, chunk := range chunks {
+			res = append(res, parseScalar(strings.TrimSpace(chunk)))
+		}
+		return res
+	}
+	return parseScalar(raw)
+}
+
+// parseScalar converts the string to a bool, an int, a float64 or a
+// time.Duration if the string looks like one. Returns the string as is
+// otherwise.
+func parseScalar(s string) Value {
+	if v, ok := parseBoolWord(s); ok {
+		return v
+	}
+	// Keeps inf and nan spellings as strings
+	if len(s) == 0 || !strings.ContainsAny(s, "0123456789") {
+		return s
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d
+	}
+	return s
+}
+
 // TearDown is a no-op operation for CliProvider
 func (ep *EnvProvider) TearDown(_ *Repository) error { return nil }
 
 // Get is the primary method to fetch values from the provider registry.
 func (ep *EnvProvider) Get(key Key) (*KeyValue, bool) {
 	<-ep.ready
+	ep.mx.RLock()
+	defer ep.mx.RUnlock()
 	if val, ok := ep.registry[key.String()]; ok {
 		return &KeyValue{Key: key, Value: val}, ok
 	}
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"context"
+	"math/rand"
 	"reflect"
 	"sort"
 	"strings"
 	"testing"
+	"testing/quick"
+	"time"
 )
 
 func TestEnvProviderSetUp(t *testing.T) {
@@ -97,3 +101,240 @@ func TestEnvProviderSetUp(t *testing.T) {
 		})
 	}
 }
+
+func TestCanonicalizeEnvKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		rules   *EnvKeyRules
+		want    string
+		wantErr bool
+	}{
+		{"A simple key", "CONFIG_FOO", &EnvKeyRules{Prefix: "CONFIG_"}, "foo", false},
+		{"A nested key", "CONFIG_FOO_BAR_BAZ", &EnvKeyRules{Prefix: "CONFIG_"}, "foo.bar.baz", false},
+		{"An escaped underscore", "CONFIG_FOO__BAR", &EnvKeyRules{Prefix: "CONFIG_"}, "foo_bar", false},
+		{"An escaped underscore before a separator", "CONFIG_FOO___BAR", &EnvKeyRules{Prefix: "CONFIG_"}, "foo_.bar", false},
+		{"Escaped underscores", "CONFIG_FOO____BAR", &EnvKeyRules{Prefix: "CONFIG_"}, "foo__bar", false},
+		{"No rules", "FOO_BAR", nil, "foo.bar", false},
+		{"Preserved case", "APP_Foo_Bar", &EnvKeyRules{Prefix: "APP_", PreserveCase: true}, "Foo.Bar", false},
+		{"Custom separators", "APP-SERVER-HTTP--PORT", &EnvKeyRules{Prefix: "APP-", Separator: "-", KeySeparator: "/"}, "server/http-port", false},
+		{"A missing prefix", "FOO_BAR", &EnvKeyRules{Prefix: "CONFIG_"}, "", true},
+		{"An empty key", "CONFIG_", &EnvKeyRules{Prefix: "CONFIG_"}, "", true},
+		{"A leading separator", "CONFIG__FOO_BAR", &EnvKeyRules{Prefix: "CONFIG_"}, "", true},
+		{"A trailing separator", "CONFIG_FOO_", &EnvKeyRules{Prefix: "CONFIG_"}, "", true},
+		{"An empty fragment", "CONFIG_FOO.", &EnvKeyRules{Prefix: "CONFIG_"}, "", true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := CanonicalizeEnvKey(testCase.env, testCase.rules)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if got != testCase.want {
+				t.Fatalf("Unexpected key: got: %q, want: %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+// envTestKey is a config key with fragments made of lowercase letters,
+// digits and inner underscores: the keys EnvVarName and CanonicalizeEnvKey
+// convert losslessly.
+type envTestKey Key
+
+func (envTestKey) Generate(rnd *rand.Rand, size int) reflect.Value {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789_"
+	key := make(envTestKey, 1+rnd.Intn(4))
+	for i := range key {
+		frag := make([]byte, 1+rnd.Intn(size+1))
+		for j := range frag {
+			if j == 0 || j == len(frag)-1 {
+				frag[j] = alphabet[rnd.Intn(len(alphabet)-1)]
+			} else {
+				frag[j] = alphabet[rnd.Intn(len(alphabet))]
+			}
+		}
+		key[i] = string(frag)
+	}
+	return reflect.ValueOf(key)
+}
+
+func TestCanonicalizeEnvKeyRoundTrip(t *testing.T) {
+	rules := &EnvKeyRules{Prefix: "CONFIG_"}
+	roundTrip := func(key envTestKey) bool {
+		got, err := CanonicalizeEnvKey(EnvVarName(rules.Prefix, Key(key)), rules)
+		return err == nil && got == Key(key).String()
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Fatalf("Unexpected round trip result: %s", err)
+	}
+
+	// Every accepted name is converted to a key of non-empty fragments
+	wellFormed := func(name string) bool {
+		got, err := CanonicalizeEnvKey(name, nil)
+		if err != nil {
+			return true
+		}
+		for _, frag := range strings.Split(got, KeySepCh) {
+			if len(frag) == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(wellFormed, nil); err != nil {
+		t.Fatalf("Unexpected canonical key: %s", err)
+	}
+}
+
+func TestEnvProviderBindings(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      *EnvProviderOptions
+		wantRegistry map[string]Value
+	}{
+		{
+			"no restrictions",
+			&EnvProviderOptions{Prefix: "APP_"},
+			map[string]Value{"server.port": "8080", "debug": "true"},
+		},
+		{
+			"explicit binding",
+			&EnvProviderOptions{
+				Prefix:   "APP_",
+				Bindings: map[string]string{"DATABASE_URL": "db.url", "APP_DEBUG": "server.debug"},
+			},
+			map[string]Value{"server.port": "8080", "server.debug": "true", "db.url": "postgres://localhost"},
+		},
+		{
+			"allowlist",
+			&EnvProviderOptions{
+				Prefix:    "APP_",
+				Allowlist: []string{"APP_SERVER_PORT"},
+			},
+			map[string]Value{"server.port": "8080"},
+		},
+		{
+			"bound variables only",
+			&EnvProviderOptions{
+				Prefix:    "APP_",
+				Bindings:  map[string]string{"DATABASE_URL": "db.url"},
+				Allowlist: []string{},
+			},
+			map[string]Value{"db.url": "postgres://localhost"},
+		},
+	}
+
+	oldEnvVars := envVars
+	defer func() { envVars = oldEnvVars }()
+	envVars = func() []string {
+		return []string{"APP_SERVER_PORT=8080", "APP_DEBUG=true", "DATABASE_URL=postgres://localhost"}
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			prov, err := NewEnvProviderWithOptions(repo, 0, testCase.options)
+			if err != nil {
+				t.Fatalf("Failed to initialize a new env provider: %s", err)
+			}
+			if err := prov.SetUp(repo); err != nil {
+				t.Fatalf("Failed to set up env provider: %s", err)
+			}
+			if !reflect.DeepEqual(prov.registry, testCase.wantRegistry) {
+				t.Fatalf("Unexpected state for EnvProvider.registry: want: %#v, got: %#v", testCase.wantRegistry, prov.registry)
+			}
+			for k := range testCase.wantRegistry {
+				if _, ok := repo.Get(NewKey(k)); !ok {
+					t.Fatalf("Failed to find a registration for key %q", k)
+				}
+			}
+		})
+	}
+}
+
+func TestEnvProviderParseValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *EnvProviderOptions
+		raw     string
+		want    Value
+	}{
+		{"raw string", &EnvProviderOptions{}, "42", "42"},
+		{"int", &EnvProviderOptions{ParseValues: true}, "42", 42},
+		{"negative int", &EnvProviderOptions{ParseValues: true}, "-42", -42},
+		{"float", &EnvProviderOptions{ParseValues: true}, "0.5", 0.5},
+		{"bool", &EnvProviderOptions{ParseValues: true}, "TRUE", true},
+		{"duration", &EnvProviderOptions{ParseValues: true}, "1m30s", 90 * time.Second},
+		{"string", &EnvProviderOptions{ParseValues: true}, "localhost", "localhost"},
+		{"not a number", &EnvProviderOptions{ParseValues: true}, "NaN", "NaN"},
+		{"ip address", &EnvProviderOptions{ParseValues: true}, "127.0.0.1", "127.0.0.1"},
+		{"list", &EnvProviderOptions{ParseValues: true}, "a, 1,true", []interface{}{"a", 1, true}},
+		{"custom separator", &EnvProviderOptions{ParseValues: true, ListSeparator: ";"}, "a,b;c", []interface{}{"a,b", "c"}},
+	}
+
+	oldEnvVars := envVars
+	defer func() { envVars = oldEnvVars }()
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			envVars = func() []string { return []string{"CONFIG_FOO=" + testCase.raw} }
+			repo := NewRepository()
+			prov, err := NewEnvProviderWithOptions(repo, 0, testCase.options)
+			if err != nil {
+				t.Fatalf("Failed to initialize a new env provider: %s", err)
+			}
+			if err := prov.SetUp(repo); err != nil {
+				t.Fatalf("Failed to set up env provider: %s", err)
+			}
+			kv, ok := prov.Get(NewKey("foo"))
+			if !ok || !reflect.DeepEqual(kv.Value, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", kv, testCase.want)
+			}
+		})
+	}
+}
+
+func TestEnvProviderRefresh(t *testing.T) {
+	oldEnvVars := envVars
+	defer func() { envVars = oldEnvVars }()
+	env := []string{"CONFIG_FOO=1", "CONFIG_BAR=2"}
+	envVars = func() []string { return env }
+
+	repo := NewRepository()
+	if _, err := NewEnvProvider(repo, 0); err != nil {
+		t.Fatalf("Failed to initialize a new env provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	events := make([]*ChangeEvent, 0)
+	repo.Subscribe(func(event *ChangeEvent) {
+		events = append(events, event)
+	})
+
+	env = []string{"CONFIG_FOO=3", "CONFIG_BAZ=4"}
+	if err := repo.RefreshProvider(context.Background(), "env"); err != nil {
+		t.Fatalf("Unexpected refresh error: %s", err)
+	}
+	wantEvents := []*ChangeEvent{
+		{
+			ChangeSet: ChangeSet{
+				Provider: "env",
+				Changes: []Change{
+					{Key: NewKey("bar"), Old: "2"},
+					{Key: NewKey("baz"), New: "4"},
+					{Key: NewKey("foo"), Old: "1", New: "3"},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Fatalf("Unexpected change events: got: %#v, want: %#v", events, wantEvents)
+	}
+
+	if err := repo.RefreshProvider(context.Background(), "yaml"); err == nil {
+		t.Fatalf("Expected an error refreshing an unregistered provider")
+	}
+}
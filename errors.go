@@ -0,0 +1,118 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrKeyNotFound indicates that no provider serves the requested key.
+	ErrKeyNotFound = errors.New("Unregistered config key")
+	// ErrTypeMismatch indicates that the key value is not of the requested
+	// type.
+	ErrTypeMismatch = errors.New("Unexpected config value type")
+	// ErrIntegrity indicates that a config document failed the checksum or
+	// the signature verification (see Verifier).
+	ErrIntegrity = errors.New("Config integrity check failed")
+	// ErrForbidden indicates that the actor is not allowed to mutate the
+	// config at runtime (see RepositoryOptions.Authorizer).
+	ErrForbidden = errors.New("Config mutation is not allowed")
+)
+
+// ConversionError is returned if the schema mapper fails to map a value. It
+// carries the full key path, the raw value and the name of the provider the
+// value originates from.
+type ConversionError struct {
+	Key Key
+	// Value is the raw value. It is set to RedactedValue for secret keys (see
+	// Secret) and is nil for composite values.
+	Value Value
+	// Provider is the name of the provider the value originates from. It is
+	// empty for composite values.
+	Provider string
+	// Location is the value position in the config file, e.g.
+	// `config.yaml:3:9`, if the provider reports it (see ValueMeta).
+	Location string
+	// Err is the original mapper error.
+	Err error
+}
+
+var _ error = (*ConversionError)(nil)
+
+// Error satisfies error interface.
+func (e *ConversionError) Error() string {
+	if len(e.Provider) == 0 {
+		return fmt.Sprintf("Failed to map the value for key %q: %s", e.Key, e.Err)
+	}
+	value := RedactedValue
+	if e.Value != RedactedValue {
+		value = fmt.Sprintf("%#v", e.Value)
+	}
+	if len(e.Location) > 0 {
+		return fmt.Sprintf("Failed to map the value %s for key %q provided by %q at %s: %s",
+			value, e.Key, e.Provider, e.Location, e.Err)
+	}
+	return fmt.Sprintf("Failed to map the value %s for key %q provided by %q: %s",
+		value, e.Key, e.Provider, e.Err)
+}
+
+// Unwrap returns the original mapper error.
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned if the configuration fails the validation: a
+// provider reload is rejected (see ApplyReload) or the strict mode check
+// fails (see RepositoryOptions.Strict). errors.Is and errors.As match the
+// individual key errors as well.
+type ValidationError struct {
+	// Provider is the name of the provider whose reload has been rejected. It
+	// is empty for the repository-wide checks.
+	Provider string
+	// Errors is the list of individual key errors.
+	Errors []error
+}
+
+var _ error = (*ValidationError)(nil)
+
+// Error satisfies error interface.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	sort.Strings(msgs)
+	if len(e.Provider) == 0 {
+		return fmt.Sprintf("Unexpected config keys not defined in the schema: %s",
+			strings.Join(msgs, "; "))
+	}
+	return fmt.Sprintf("Rejected config provider %q reload: %s", e.Provider,
+		strings.Join(msgs, "; "))
+}
+
+// Is reports whether any of the individual key errors matches the target.
+func (e *ValidationError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first individual key error matching the target.
+func (e *ValidationError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeMismatch returns an error wrapping ErrTypeMismatch.
+func typeMismatch(key string, v Value, want string) error {
+	return fmt.Errorf("%w for key %q: got: %T, want: %s", ErrTypeMismatch, key, v, want)
+}
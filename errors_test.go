@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMustErrors(t *testing.T) {
+	repo := NewRepository()
+	repo.RegisterKey(NewKey("foo"), NewTestProv(42, DefaultWeight))
+
+	tests := []struct {
+		name    string
+		get     func()
+		wantErr error
+		wantMsg string
+	}{
+		{
+			"missing key",
+			func() { Must(repo, "bar") },
+			ErrKeyNotFound,
+			`Unregistered config key: "bar"`,
+		},
+		{
+			"type mismatch",
+			func() { MustStrArr(repo, "foo") },
+			ErrTypeMismatch,
+			`Unexpected config value type for key "foo": got: int, want: []string`,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				err, ok := r.(error)
+				if !ok {
+					t.Fatalf("Unexpected panic value: got: %#v, want an error", r)
+				}
+				if !errors.Is(err, testCase.wantErr) {
+					t.Fatalf("Unexpected error: got: %v, want: %v", err, testCase.wantErr)
+				}
+				if err.Error() != testCase.wantMsg {
+					t.Fatalf("Unexpected error message: got: %q, want: %q", err.Error(), testCase.wantMsg)
+				}
+			}()
+			testCase.get()
+		})
+	}
+}
+
+func TestConversionError(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{"port": ToInt})
+	repo.RegisterKey(NewKey("port"), NewTestProv("abc", DefaultWeight))
+
+	err := repo.tryGet(NewKey("port"))
+	var cerr *ConversionError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("Unexpected error type: got: %#v, want: *ConversionError", err)
+	}
+	if cerr.Key.String() != "port" || cerr.Value != "abc" || cerr.Provider != "test" {
+		t.Fatalf("Unexpected conversion error: got: %#v", cerr)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"server": map[string]Schema{
+			"port": ToInt,
+		},
+	})
+	prov := &reloadTestProv{registry: map[string]Value{"server.port": "8080"}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	err := prov.reload(repo, map[string]Value{"server.port": "abc"})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Unexpected error type: got: %#v, want: *ValidationError", err)
+	}
+	if verr.Provider != "reload" {
+		t.Fatalf("Unexpected provider: got: %q, want: %q", verr.Provider, "reload")
+	}
+	var cerr *ConversionError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("Expected the validation error to match a *ConversionError")
+	}
+	if cerr.Key.String() != "server.port" {
+		t.Fatalf("Unexpected conversion error key: got: %q, want: %q", cerr.Key, "server.port")
+	}
+}
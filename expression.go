@@ -0,0 +1,264 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ExpressionsName is the name of the provider serving the evaluated
+// expression values.
+const ExpressionsName = "expressions"
+
+// expressions is the provider serving the evaluated expression values. A
+// config value is an expression if it is a string wrapped in double curly
+// braces, e.g. `workers: "{{ .system.maxprocs * 2 }}"`. Expressions are
+// evaluated once all providers are set up and re-evaluated on every applied
+// reload. The provider has the highest possible weight: an evaluated value
+// replaces the expression it originates from.
+type expressions struct {
+	registry map[string]Value
+	mx       sync.RWMutex
+}
+
+var _ Provider = (*expressions)(nil)
+
+func (ex *expressions) Name() string               { return ExpressionsName }
+func (ex *expressions) Depends() []string          { return []string{} }
+func (ex *expressions) Weight() int                { return math.MaxInt32 }
+func (ex *expressions) SetUp(*Repository) error    { return nil }
+func (ex *expressions) TearDown(*Repository) error { return nil }
+
+func (ex *expressions) Get(key Key) (*KeyValue, bool) {
+	ex.mx.RLock()
+	defer ex.mx.RUnlock()
+	if v, ok := ex.registry[key.String()]; ok {
+		return &KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
+
+func (ex *expressions) set(key Key, v Value) {
+	ex.mx.Lock()
+	defer ex.mx.Unlock()
+	ex.registry[key.String()] = v
+}
+
+func (ex *expressions) swap(registry map[string]Value) map[string]Value {
+	ex.mx.Lock()
+	defer ex.mx.Unlock()
+	prev := ex.registry
+	ex.registry = registry
+	return prev
+}
+
+// exprSource returns the expression source if the value is an expression.
+func exprSource(v Value) (string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{{") || !strings.HasSuffix(s, "}}") || len(s) < 4 {
+		return "", false
+	}
+	return strings.TrimSpace(s[2 : len(s)-2]), true
+}
+
+// parsedExpr is an expression along with the keys it refers to.
+type parsedExpr struct {
+	node exprNode
+	refs []Key
+}
+
+// findExpressions returns the parsed expressions keyed by the config keys
+// holding them. The caller is expected to hold viewMx and to have the
+// previously evaluated values dropped.
+func (repo *Repository) findExpressions() (map[string]*parsedExpr, error) {
+	repo.mx.Lock()
+	keys := repo.root.keys(nil)
+	repo.mx.Unlock()
+	res := make(map[string]*parsedExpr)
+	for _, key := range keys {
+		v, _, ok := repo.root.find(key).rawValue(repo, key, key)
+		if !ok {
+			continue
+		}
+		src, ok := exprSource(v)
+		if !ok {
+			continue
+		}
+		node, refs, err := parseExpr(src)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse config expression for key %q: %s", key, err)
+		}
+		for i, ref := range refs {
+			refs[i] = repo.aliasTarget(repo.foldKey(ref))
+		}
+		res[key.String()] = &parsedExpr{node: node, refs: refs}
+	}
+	return res, nil
+}
+
+// orderExpressions returns the expression keys sorted so that every
+// expression goes after the expressions it refers to. Returns an error if
+// the expressions refer to each other in a cycle.
+func orderExpressions(exprs map[string]*parsedExpr) ([]string, error) {
+	keys := make([]string, 0, len(exprs))
+	for k := range exprs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(exprs))
+	order := make([]string, 0, len(exprs))
+	path := make([]string, 0)
+	var visit func(k string) error
+	visit = func(k string) error {
+		switch state[k] {
+		case visited:
+			return nil
+		case visiting:
+			for i, p := range path {
+				if p == k {
+					return fmt.Errorf("Config expressions refer to each other in a cycle: %s",
+						strings.Join(append(path[i:], k), " -> "))
+				}
+			}
+		}
+		state[k] = visiting
+		path = append(path, k)
+		for _, ref := range exprs[k].refs {
+			if _, ok := exprs[ref.String()]; ok {
+				if err := visit(ref.String()); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[k] = visited
+		order = append(order, k)
+		return nil
+	}
+	for _, k := range keys {
+		if err := visit(k); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// evalExpressions evaluates all expressions in the dependency order and
+// stores the values in the provider. The caller is expected to hold viewMx.
+func (repo *Repository) evalExpressions(ex *expressions) error {
+	exprs, err := repo.findExpressions()
+	if err != nil {
+		return err
+	}
+	order, err := orderExpressions(exprs)
+	if err != nil {
+		return err
+	}
+	for _, k := range order {
+		key := NewKey(k)
+		v, err := exprs[k].node.eval(func(ref Key) (Value, error) {
+			v, ok, err := repo.tryLookup(ref)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, ref)
+			}
+			return v, nil
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to evaluate config expression for key %q: %s", key, err)
+		}
+		ex.set(key, v)
+		if err := repo.RegisterKey(key, ex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tryLookup is a version of get turning mapper panics into errors. The
+// caller is expected to hold viewMx.
+func (repo *Repository) tryLookup(key Key) (v Value, ok bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rerr, isErr := r.(error); isErr {
+				err = rerr
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	v, ok = repo.get(key)
+	return v, ok, nil
+}
+
+// hasExpressions returns true if at least 1 effective value is an
+// expression.
+func (repo *Repository) hasExpressions() bool {
+	repo.mx.Lock()
+	keys := repo.root.keys(nil)
+	repo.mx.Unlock()
+	repo.viewMx.RLock()
+	defer repo.viewMx.RUnlock()
+	for _, key := range keys {
+		if v, _, ok := repo.root.find(key).rawValue(repo, key, key); ok {
+			if _, ok := exprSource(v); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evaluateExpressions (re-)evaluates the config expressions. The new values
+// are applied as a reload (see ApplyReload): if the evaluation fails, the
+// previous values are kept. Once expressions are found, they are
+// re-evaluated on every applied reload. Is a no-op unless the expressions are
+// enabled (see RepositoryOptions.Expressions).
+func (repo *Repository) evaluateExpressions() error {
+	if !repo.options.Expressions {
+		return nil
+	}
+	repo.mx.Lock()
+	ex := repo.exprs
+	repo.mx.Unlock()
+	if ex == nil {
+		if !repo.hasExpressions() {
+			return nil
+		}
+		ex = &expressions{registry: make(map[string]Value)}
+		repo.mx.Lock()
+		repo.exprs = ex
+		repo.mx.Unlock()
+		repo.Subscribe(func(event *ChangeEvent) {
+			if event.Provider == ExpressionsName || event.Err != nil {
+				return
+			}
+			// The error is logged by ApplyReload
+			repo.evaluateExpressions()
+		})
+	}
+	var prev map[string]Value
+	return repo.ApplyReload(ex, func() error {
+		// Previous values must not shadow the expressions
+		prev = ex.swap(make(map[string]Value))
+		if err := repo.evalExpressions(ex); err != nil {
+			ex.swap(prev)
+			return err
+		}
+		return nil
+	}, func() {
+		ex.swap(prev)
+	})
+}
@@ -0,0 +1,417 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The expression language is deliberately small: key references (`.a.b`, key
+// fragments are made of letters, digits and underscores), number, string and
+// boolean literals, arithmetic (`+ - * / %`, `+` concatenates strings),
+// comparisons (`== != < <= > >=`), logical operators (`&& || !`), the
+// conditional operator (`cond ? a : b`) and parentheses.
+
+// exprNode is a node of a parsed expression.
+type exprNode interface {
+	eval(lookup func(Key) (Value, error)) (Value, error)
+}
+
+type exprLiteral struct {
+	v Value
+}
+
+func (l *exprLiteral) eval(func(Key) (Value, error)) (Value, error) { return l.v, nil }
+
+type exprRef struct {
+	key Key
+}
+
+func (r *exprRef) eval(lookup func(Key) (Value, error)) (Value, error) { return lookup(r.key) }
+
+type exprUnary struct {
+	op string
+	x  exprNode
+}
+
+func (u *exprUnary) eval(lookup func(Key) (Value, error)) (Value, error) {
+	v, err := u.x.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	if u.op == "!" {
+		b, ok := convert(ToBool, v)
+		if !ok {
+			return nil, fmt.Errorf("Operator ! expects a boolean, got: %#v", v)
+		}
+		return !b.(bool), nil
+	}
+	return arith("-", 0, v)
+}
+
+type exprBinary struct {
+	op   string
+	x, y exprNode
+}
+
+func (b *exprBinary) eval(lookup func(Key) (Value, error)) (Value, error) {
+	x, err := b.x.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	// Logical operators short-circuit
+	if b.op == "&&" || b.op == "||" {
+		xb, ok := convert(ToBool, x)
+		if !ok {
+			return nil, fmt.Errorf("Operator %s expects booleans, got: %#v", b.op, x)
+		}
+		if xb.(bool) == (b.op == "||") {
+			return xb, nil
+		}
+		y, err := b.y.eval(lookup)
+		if err != nil {
+			return nil, err
+		}
+		yb, ok := convert(ToBool, y)
+		if !ok {
+			return nil, fmt.Errorf("Operator %s expects booleans, got: %#v", b.op, y)
+		}
+		return yb, nil
+	}
+	y, err := b.y.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	switch b.op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return compare(b.op, x, y)
+	}
+	return arith(b.op, x, y)
+}
+
+type exprCond struct {
+	cond, then, els exprNode
+}
+
+func (c *exprCond) eval(lookup func(Key) (Value, error)) (Value, error) {
+	v, err := c.cond.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := convert(ToBool, v)
+	if !ok {
+		return nil, fmt.Errorf("Condition expects a boolean, got: %#v", v)
+	}
+	if b.(bool) {
+		return c.then.eval(lookup)
+	}
+	return c.els.eval(lookup)
+}
+
+// toNumber converts the value to an int if possible, to a float64 otherwise.
+func toNumber(v Value) (Value, bool) {
+	switch n := v.(type) {
+	case bool:
+		return nil, false
+	case float64, float32:
+		f, _ := toFloat(n)
+		return f, true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(rv.Uint()), true
+	}
+	if i, ok := convert(ToInt, v); ok {
+		return i, true
+	}
+	if f, ok := toFloat(v); ok {
+		return f, true
+	}
+	return nil, false
+}
+
+func arith(op string, x, y Value) (Value, error) {
+	if op == "+" {
+		xs, xok := x.(string)
+		ys, yok := y.(string)
+		_, xnum := toNumber(x)
+		_, ynum := toNumber(y)
+		if (xok && !xnum) || (yok && !ynum) {
+			if !xok {
+				xs = fmt.Sprint(x)
+			}
+			if !yok {
+				ys = fmt.Sprint(y)
+			}
+			return xs + ys, nil
+		}
+	}
+	xn, xok := toNumber(x)
+	yn, yok := toNumber(y)
+	if !xok || !yok {
+		return nil, fmt.Errorf("Operator %s expects numbers, got: %#v and %#v", op, x, y)
+	}
+	xi, xint := xn.(int)
+	yi, yint := yn.(int)
+	if xint && yint {
+		switch op {
+		case "+":
+			return xi + yi, nil
+		case "-":
+			return xi - yi, nil
+		case "*":
+			return xi * yi, nil
+		case "/", "%":
+			if yi == 0 {
+				return nil, fmt.Errorf("Division by zero")
+			}
+			if op == "/" {
+				return xi / yi, nil
+			}
+			return xi % yi, nil
+		}
+	}
+	xf, _ := toFloat(xn)
+	yf, _ := toFloat(yn)
+	switch op {
+	case "+":
+		return xf + yf, nil
+	case "-":
+		return xf - yf, nil
+	case "*":
+		return xf * yf, nil
+	case "/":
+		if yf == 0 {
+			return nil, fmt.Errorf("Division by zero")
+		}
+		return xf / yf, nil
+	case "%":
+		if yf == 0 {
+			return nil, fmt.Errorf("Division by zero")
+		}
+		return math.Mod(xf, yf), nil
+	}
+	return nil, fmt.Errorf("Unknown operator %s", op)
+}
+
+func compare(op string, x, y Value) (Value, error) {
+	var c int
+	xn, xok := toNumber(x)
+	yn, yok := toNumber(y)
+	if xok && yok {
+		xf, _ := toFloat(xn)
+		yf, _ := toFloat(yn)
+		switch {
+		case xf < yf:
+			c = -1
+		case xf > yf:
+			c = 1
+		}
+	} else {
+		xs, ys := fmt.Sprint(x), fmt.Sprint(y)
+		if op != "==" && op != "!=" {
+			_, xstr := x.(string)
+			_, ystr := y.(string)
+			if !xstr || !ystr {
+				return nil, fmt.Errorf("Operator %s expects numbers or strings, got: %#v and %#v", op, x, y)
+			}
+		}
+		c = strings.Compare(xs, ys)
+	}
+	switch op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+// exprParser is a recursive descent parser of the expression language.
+type exprParser struct {
+	src  string
+	pos  int
+	refs []Key
+}
+
+// parseExpr parses the expression and returns the list of the referred keys.
+func parseExpr(src string) (exprNode, []Key, error) {
+	p := &exprParser{src: src}
+	n, err := p.parseCond()
+	if err != nil {
+		return nil, nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, nil, fmt.Errorf("Unexpected %q at position %d", p.src[p.pos:], p.pos)
+	}
+	return n, p.refs, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept consumes the first matching operator.
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	p.skipSpace()
+	for _, op := range ops {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			p.pos += len(op)
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) parseCond() (exprNode, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return cond, nil
+	}
+	then, err := p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept(":"); !ok {
+		return nil, fmt.Errorf("Expected \":\" at position %d", p.pos)
+	}
+	els, err := p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+	return &exprCond{cond: cond, then: then, els: els}, nil
+}
+
+// binaryOps lists the binary operators by precedence, the lowest first.
+// Longer operators go first: `<=` must not be consumed as `<`.
+var binaryOps = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<=", ">=", "<", ">"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) parseBinary(level int) (exprNode, error) {
+	if level == len(binaryOps) {
+		return p.parseUnary()
+	}
+	x, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(binaryOps[level]...)
+		if !ok {
+			return x, nil
+		}
+		y, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &exprBinary{op: op, x: x, y: y}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if op, ok := p.accept("-", "!"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &exprUnary{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	p.skipSpace()
+	if p.pos == len(p.src) {
+		return nil, fmt.Errorf("Unexpected end of expression")
+	}
+	start := p.pos
+	switch c := p.src[p.pos]; {
+	case c == '(':
+		p.pos++
+		n, err := p.parseCond()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("Expected \")\" at position %d", p.pos)
+		}
+		return n, nil
+	case c == '"':
+		for p.pos++; p.pos < len(p.src) && p.src[p.pos] != '"'; p.pos++ {
+			if p.src[p.pos] == '\\' {
+				p.pos++
+			}
+		}
+		if p.pos >= len(p.src) {
+			return nil, fmt.Errorf("Unterminated string at position %d", start)
+		}
+		p.pos++
+		s, err := strconv.Unquote(p.src[start:p.pos])
+		if err != nil {
+			return nil, fmt.Errorf("Malformed string at position %d: %s", start, err)
+		}
+		return &exprLiteral{v: s}, nil
+	case c == '.':
+		key := make(Key, 0)
+		for p.pos < len(p.src) && p.src[p.pos] == '.' {
+			p.pos++
+			fstart := p.pos
+			for p.pos < len(p.src) && isFragmentChar(p.src[p.pos]) {
+				p.pos++
+			}
+			if p.pos == fstart {
+				return nil, fmt.Errorf("Malformed key reference at position %d", start)
+			}
+			key = append(key, p.src[fstart:p.pos])
+		}
+		p.refs = append(p.refs, key)
+		return &exprRef{key: key}, nil
+	case c >= '0' && c <= '9':
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		lit := p.src[start:p.pos]
+		if i, err := strconv.Atoi(lit); err == nil {
+			return &exprLiteral{v: i}, nil
+		}
+		f, err := strconv.ParseFloat(lit, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Malformed number %q at position %d", lit, start)
+		}
+		return &exprLiteral{v: f}, nil
+	}
+	for _, lit := range []string{"true", "false"} {
+		if strings.HasPrefix(p.src[p.pos:], lit) {
+			p.pos += len(lit)
+			return &exprLiteral{v: lit == "true"}, nil
+		}
+	}
+	return nil, fmt.Errorf("Unexpected %q at position %d", p.src[p.pos:], p.pos)
+}
+
+func isFragmentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
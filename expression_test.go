@@ -0,0 +1,177 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvalExpr(t *testing.T) {
+	values := map[string]Value{
+		"system.maxprocs": 4,
+		"env":             "prod",
+		"port":            "8080",
+		"ratio":           0.5,
+		"debug":           false,
+	}
+	lookup := func(key Key) (Value, error) {
+		if v, ok := values[key.String()]; ok {
+			return v, nil
+		}
+		return nil, ErrKeyNotFound
+	}
+
+	tests := []struct {
+		src     string
+		want    Value
+		wantErr bool
+	}{
+		{".system.maxprocs * 2", 8, false},
+		{"1 + 2 * 3", 7, false},
+		{"(1 + 2) * 3", 9, false},
+		{"7 / 2", 3, false},
+		{"7 % 4", 3, false},
+		{"7.0 / 2", 3.5, false},
+		{".ratio * 10", 5.0, false},
+		{".port + 1", 8081, false},
+		{"-.system.maxprocs", -4, false},
+		{`"http://" + .env + ".example.com"`, "http://prod.example.com", false},
+		{`.env == "prod" ? 16 : 2`, 16, false},
+		{`.env != "prod" && .system.maxprocs > 2`, false, false},
+		{`!.debug || 1 / 0`, true, false},
+		{".system.maxprocs >= 4", true, false},
+		{`"a" < "b"`, true, false},
+		{"true ? 1 : 2 ? 3 : 4", 1, false},
+		{"1 / 0", nil, true},
+		{".missing + 1", nil, true},
+		{".env * 2", nil, true},
+		{"1 +", nil, true},
+		{"(1 + 2", nil, true},
+		{"1 ? 2", nil, true},
+		{`"unterminated`, nil, true},
+		{"1 2", nil, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.src, func(t *testing.T) {
+			node, _, err := parseExpr(testCase.src)
+			var got Value
+			if err == nil {
+				got, err = node.eval(lookup)
+			}
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestExpressions(t *testing.T) {
+	repo := NewRepositoryWithOptions(&RepositoryOptions{Expressions: true})
+	repo.DefineSchema(map[string]Schema{
+		"workers": ToInt,
+	})
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"system.maxprocs": 4,
+		"workers":         "{{ .pool.size * 2 }}",
+		"pool.size":       "{{ .system.maxprocs + 1 }}",
+	})
+	prov := &reloadTestProv{registry: map[string]Value{"system.maxprocs": 4}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if got := MustInt(repo, "workers"); got != 10 {
+		t.Fatalf("Unexpected workers value: got: %d, want: %d", got, 10)
+	}
+
+	events := make([]*ChangeEvent, 0)
+	repo.Subscribe(func(event *ChangeEvent) {
+		if event.Provider == ExpressionsName {
+			events = append(events, event)
+		}
+	})
+	if err := prov.reload(repo, map[string]Value{"system.maxprocs": 8}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if got := MustInt(repo, "workers"); got != 18 {
+		t.Fatalf("Unexpected workers value after reload: got: %d, want: %d", got, 18)
+	}
+	want := []Change{
+		{Key: NewKey("pool.size"), Old: 5, New: 9},
+		{Key: NewKey("workers"), Old: 10, New: 18},
+	}
+	if len(events) != 1 || !reflect.DeepEqual(events[0].Changes, want) {
+		t.Fatalf("Unexpected change events: got: %#v, want changes: %#v", events, want)
+	}
+
+	// A failed evaluation keeps the previous values
+	prov.reload(repo, map[string]Value{"system.maxprocs": "many"})
+	if got := MustInt(repo, "workers"); got != 18 {
+		t.Fatalf("Unexpected workers value after a failed evaluation: got: %d, want: %d", got, 18)
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]Value
+		wantErr string
+	}{
+		{
+			"cycle",
+			map[string]Value{
+				"a": "{{ .b + 1 }}",
+				"b": "{{ .c + 1 }}",
+				"c": "{{ .a + 1 }}",
+			},
+			"Config expressions refer to each other in a cycle: a -> b -> c -> a",
+		},
+		{
+			"self reference",
+			map[string]Value{"a": "{{ .a }}"},
+			"Config expressions refer to each other in a cycle: a -> a",
+		},
+		{
+			"syntax error",
+			map[string]Value{"a": "{{ 1 + }}"},
+			`Failed to parse config expression for key "a"`,
+		},
+		{
+			"unknown key",
+			map[string]Value{"a": "{{ .b }}"},
+			`Failed to evaluate config expression for key "a": Unregistered config key: "b"`,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepositoryWithOptions(&RepositoryOptions{Expressions: true})
+			NewDefaultProviderWithDefaults(repo, 10, testCase.values)
+			err := repo.SetUp()
+			if err == nil || !strings.HasPrefix(err.Error(), testCase.wantErr) {
+				t.Fatalf("Unexpected error: got: %v, want: %q", err, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpressionsDisabled(t *testing.T) {
+	repo := NewRepository()
+	NewDefaultProviderWithDefaults(repo, 10, map[string]Value{
+		"greeting": "{{ .name }}",
+		"broken":   "{{ 1 + }}",
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if got := MustStr(repo, "greeting"); got != "{{ .name }}" {
+		t.Fatalf("Unexpected value: got: %q, want: %q", got, "{{ .name }}")
+	}
+	if got := MustStr(repo, "broken"); got != "{{ 1 + }}" {
+		t.Fatalf("Unexpected value: got: %q, want: %q", got, "{{ 1 + }}")
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"expvar"
+	"fmt"
+)
+
+// PublishExpvar publishes the config values and the provider status via
+// expvar as `<prefix>.config` and `<prefix>.providers`, e.g. for inspection
+// at /debug/vars. Only the listed keys are published, all keys if none are
+// listed. Secret keys (see Secret) are never published, secret sub-keys of
+// composite values are redacted. Values are looked up on every expvar read.
+// Returns an error if the variables are already published.
+func PublishExpvar(repo *Repository, prefix string, keys ...string) error {
+	configName, providersName := prefix+".config", prefix+".providers"
+	for _, name := range []string{configName, providersName} {
+		if expvar.Get(name) != nil {
+			return fmt.Errorf("Failed to publish expvar %q: the name is already taken", name)
+		}
+	}
+	expvar.Publish(configName, expvar.Func(func() interface{} {
+		return repo.expvarValues(keys)
+	}))
+	expvar.Publish(providersName, expvar.Func(func() interface{} {
+		return repo.ProviderStatus()
+	}))
+	return nil
+}
+
+// expvarValues returns the non-secret values of the keys, all keys if none
+// are listed. Composite values are redacted (see displayValue).
+func (repo *Repository) expvarValues(keys []string) map[string]Value {
+	res := make(map[string]Value)
+	if len(keys) == 0 {
+		for k, v := range repo.Dump() {
+			if key := repo.NewKey(k); !repo.isSecret(key) {
+				res[k] = repo.displayValue(key, v)
+			}
+		}
+		return res
+	}
+	for _, k := range keys {
+		key := repo.NewKey(k)
+		if repo.isSecret(key) {
+			continue
+		}
+		if v, ok := repo.Get(key); ok {
+			res[k] = repo.displayValue(key, v)
+		}
+	}
+	return res
+}
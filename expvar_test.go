@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// expvarTestRun makes the expvar names unique across the test runs: expvars
+// can not be unpublished.
+var expvarTestRun int
+
+func TestPublishExpvar(t *testing.T) {
+	expvarTestRun++
+	all := fmt.Sprintf("test_all_%d", expvarTestRun)
+	selected := fmt.Sprintf("test_selected_%d", expvarTestRun)
+
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"db": map[string]Schema{
+			"host":     ToStr,
+			"port":     ToInt,
+			"password": Secret(ToStr),
+		},
+	})
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"db.host":     "localhost",
+		"db.port":     5432,
+		"db.password": "s3cr3t",
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	if err := PublishExpvar(repo, all); err != nil {
+		t.Fatalf("Failed to publish expvars: %s", err)
+	}
+	if err := PublishExpvar(repo, selected, "db.port", "db.password", "db.missing", "db"); err != nil {
+		t.Fatalf("Failed to publish expvars: %s", err)
+	}
+	if err := PublishExpvar(repo, all); err == nil {
+		t.Fatalf("Expected an error publishing the same expvars twice")
+	}
+
+	tests := []struct {
+		name string
+		want interface{}
+	}{
+		{all + ".config", map[string]interface{}{"db.host": "localhost", "db.port": float64(5432)}},
+		{selected + ".config", map[string]interface{}{
+			"db.port": float64(5432),
+			"db": map[string]interface{}{
+				"host":     "localhost",
+				"port":     float64(5432),
+				"password": RedactedValue,
+			},
+		}},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			var got interface{}
+			if err := json.Unmarshal([]byte(expvar.Get(testCase.name).String()), &got); err != nil {
+				t.Fatalf("Failed to decode the expvar: %s", err)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected expvar value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+
+	var statuses []map[string]interface{}
+	if err := json.Unmarshal([]byte(expvar.Get(all+".providers").String()), &statuses); err != nil {
+		t.Fatalf("Failed to decode the expvar: %s", err)
+	}
+	if len(statuses) != 1 || statuses[0]["name"] != "default" {
+		t.Fatalf("Unexpected provider status: got: %#v", statuses)
+	}
+}
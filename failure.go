@@ -0,0 +1,255 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// FailurePolicy defines the repository behavior if a provider fails to set
+// up (see RegisterProviderWithPolicy).
+type FailurePolicy uint8
+
+const (
+	// FailFast makes the repository set up fail. It is the default policy.
+	FailFast FailurePolicy = iota
+	// WarnAndContinue logs a warning and proceeds with the rest of the
+	// providers: the keys of the failed provider are served by the other
+	// providers (e.g. local defaults) if any.
+	WarnAndContinue
+	// ServeStaleCache serves the values the provider served on the last
+	// successful set up, loaded from the repository provider cache (see
+	// RepositoryOptions.ProviderCache). The set up fails if there are no
+	// cached values.
+	ServeStaleCache
+)
+
+// String satisfies Stringer interface
+func (fp FailurePolicy) String() string {
+	switch fp {
+	case FailFast:
+		return "fail_fast"
+	case WarnAndContinue:
+		return "warn_and_continue"
+	case ServeStaleCache:
+		return "serve_stale_cache"
+	}
+	return "unknown"
+}
+
+// ProviderCache persists the values served by providers with the
+// ServeStaleCache failure policy. Values are keyed by the provider name.
+type ProviderCache interface {
+	// Load returns the values stored for the provider. The boolean flag is
+	// false if there are none.
+	Load(provider string) (map[string]Value, bool, error)
+	// Store replaces the values stored for the provider.
+	Store(provider string, values map[string]Value) error
+}
+
+// FileProviderCache is a ProviderCache keeping the provider values in YAML
+// files: a file per provider in the cache folder.
+type FileProviderCache struct {
+	dir string
+}
+
+var _ ProviderCache = (*FileProviderCache)(nil)
+
+// NewFileProviderCache is the constructor for FileProviderCache. The folder
+// is expected to exist.
+func NewFileProviderCache(dir string) *FileProviderCache {
+	return &FileProviderCache{dir: dir}
+}
+
+func (fc *FileProviderCache) path(provider string) string {
+	return filepath.Join(fc.dir, provider+".yaml")
+}
+
+// Load reads the provider cache file. Returns false if the file does not
+// exist.
+func (fc *FileProviderCache) Load(provider string) (map[string]Value, bool, error) {
+	return ReadCacheFile(fc.path(provider))
+}
+
+// Store replaces the provider cache file atomically.
+func (fc *FileProviderCache) Store(provider string, values map[string]Value) error {
+	return WriteCacheFile(fc.path(provider), values)
+}
+
+// ReadCacheFile reads the values persisted by WriteCacheFile. Returns false
+// if the file does not exist.
+func ReadCacheFile(path string) (map[string]Value, bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to read config cache file %q: %s", path, err)
+	}
+	values := make(map[string]Value)
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, false, fmt.Errorf("Failed to parse config cache file %q: %s", path, err)
+	}
+	return values, true, nil
+}
+
+// WriteCacheFile persists the flattened provider values, e.g. the values
+// fetched by a remote provider, as a YAML document. The file is replaced
+// atomically and is only readable by the owner.
+func WriteCacheFile(path string, values map[string]Value) error {
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal config cache file %q: %s", path, err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// CacheFile is the file a remote provider persists the fetched values to
+// (see WriteCacheFile), so they can be served if the remote source is
+// unavailable on the next start, e.g.:
+//
+//	cache := config.CacheFile{Path: "/var/cache/app/redis.yaml", Source: "Redis"}
+//	registry, err := fetch(ctx)
+//	if err != nil {
+//		var ok bool
+//		if registry, ok = cache.Load(repo, err); !ok {
+//			return err
+//		}
+//	} else {
+//		cache.Store(repo, registry)
+//	}
+type CacheFile struct {
+	// Path is the cache file path. Caching is disabled if empty.
+	Path string
+	// Source describes the cached values in log messages, e.g. "Redis".
+	Source string
+}
+
+// Load reads the cached values if the source failed to serve them. fetchErr
+// is the source failure, it is logged along with the cache file path.
+// Returns false if caching is disabled, the file does not exist or can not be
+// read. Read errors are logged.
+func (cf CacheFile) Load(repo *Repository, fetchErr error) (map[string]Value, bool) {
+	if len(cf.Path) == 0 {
+		return nil, false
+	}
+	values, ok, err := ReadCacheFile(cf.Path)
+	if err != nil {
+		repo.Logger().Errorf("%s", err)
+		return nil, false
+	}
+	if ok {
+		repo.Logger().Warnf("Serving cached %s config values from %q: %s", cf.Source, cf.Path, fetchErr)
+	}
+	return values, ok
+}
+
+// Store persists the values to the cache file. Is a no-op if caching is
+// disabled. Failures are logged.
+func (cf CacheFile) Store(repo *Repository, values map[string]Value) {
+	if len(cf.Path) == 0 {
+		return
+	}
+	if err := WriteCacheFile(cf.Path, values); err != nil {
+		repo.Logger().Warnf("Failed to cache %s config values: %s", cf.Source, err)
+	}
+}
+
+// RegisterProviderWithPolicy registers the provider (see RegisterProvider)
+// with the failure policy applied if it fails to set up, e.g.:
+//
+//	consul, _ := NewConsulProvider(repo, 50)
+//	repo.RegisterProviderWithPolicy(consul, config.WarnAndContinue)
+//
+// The provider status reports the failure regardless of the policy.
+// This method is thread safe.
+func (repo *Repository) RegisterProviderWithPolicy(prov Provider, policy FailurePolicy) {
+	repo.mx.Lock()
+	repo.failurePolicies[prov.Name()] = policy
+	repo.mx.Unlock()
+	repo.RegisterProvider(prov)
+}
+
+func (repo *Repository) failurePolicy(prov Provider) FailurePolicy {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	return repo.failurePolicies[prov.Name()]
+}
+
+// handleSetUpFailure applies the provider failure policy. Returns nil if the
+// failure is tolerated.
+func (repo *Repository) handleSetUpFailure(prov Provider, err error) error {
+	logger := repo.Logger()
+	switch repo.failurePolicy(prov) {
+	case WarnAndContinue:
+		logger.Warnf("Continuing without config provider %q: %s", prov.Name(), err)
+		return nil
+	case ServeStaleCache:
+		cache := repo.options.ProviderCache
+		if cache == nil {
+			return fmt.Errorf("%s (no stale values: the provider cache is not configured)", err)
+		}
+		values, ok, cerr := cache.Load(prov.Name())
+		if cerr != nil {
+			return fmt.Errorf("%s (no stale values: %s)", err, cerr)
+		}
+		if !ok {
+			return fmt.Errorf("%s (no stale values cached)", err)
+		}
+		stale := &staleProvider{name: prov.Name(), weight: prov.Weight(), values: values}
+		for k := range values {
+			if rerr := repo.RegisterKey(NewKey(k), stale); rerr != nil {
+				return rerr
+			}
+		}
+		logger.Warnf("Serving %d stale cached values of config provider %q: %s", len(values), prov.Name(), err)
+		return nil
+	}
+	return err
+}
+
+// storeProviderCache persists the provider values if the provider is
+// registered with the ServeStaleCache policy. Failures are logged.
+func (repo *Repository) storeProviderCache(prov Provider) {
+	cache := repo.options.ProviderCache
+	if cache == nil || repo.failurePolicy(prov) != ServeStaleCache {
+		return
+	}
+	repo.mx.Lock()
+	keys := repo.root.providerKeys(nil, prov)
+	repo.mx.Unlock()
+	values := make(map[string]Value, len(keys))
+	for _, key := range keys {
+		if kv, ok := prov.Get(key); ok {
+			values[key.String()] = kv.Value
+		}
+	}
+	if err := cache.Store(prov.Name(), values); err != nil {
+		repo.Logger().Warnf("Failed to cache config provider %q values: %s", prov.Name(), err)
+	}
+}
+
+// staleProvider serves the cached values of a failed provider on its behalf.
+type staleProvider struct {
+	name   string
+	weight int
+	values map[string]Value
+}
+
+var _ Provider = (*staleProvider)(nil)
+
+func (sp *staleProvider) Name() string               { return sp.name }
+func (sp *staleProvider) Depends() []string          { return nil }
+func (sp *staleProvider) Weight() int                { return sp.weight }
+func (sp *staleProvider) SetUp(*Repository) error    { return nil }
+func (sp *staleProvider) TearDown(*Repository) error { return nil }
+
+func (sp *staleProvider) Get(key Key) (*KeyValue, bool) {
+	if v, ok := sp.values[key.String()]; ok {
+		return &KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
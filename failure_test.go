@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type flakyTestProv struct {
+	registry map[string]Value
+	err      error
+}
+
+func (fp *flakyTestProv) Name() string               { return "remote" }
+func (fp *flakyTestProv) Depends() []string          { return nil }
+func (fp *flakyTestProv) Weight() int                { return 20 }
+func (fp *flakyTestProv) TearDown(*Repository) error { return nil }
+
+func (fp *flakyTestProv) SetUp(repo *Repository) error {
+	if fp.err != nil {
+		return fp.err
+	}
+	for k := range fp.registry {
+		if err := repo.RegisterKey(NewKey(k), fp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fp *flakyTestProv) Get(key Key) (*KeyValue, bool) {
+	if v, ok := fp.registry[key.String()]; ok {
+		return &KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
+
+func TestFailurePolicy(t *testing.T) {
+	errUnreachable := errors.New("remote is unreachable")
+	cacheDir := t.TempDir()
+	// The remote values are cached on a successful set up
+	{
+		repo := NewRepositoryWithOptions(&RepositoryOptions{ProviderCache: NewFileProviderCache(cacheDir)})
+		repo.RegisterProviderWithPolicy(&flakyTestProv{registry: map[string]Value{
+			"server.port": 9090,
+			"server.tags": []Value{"a", "b"},
+		}}, ServeStaleCache)
+		if err := repo.SetUp(); err != nil {
+			t.Fatalf("Failed to set up the repository: %s", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		policy  FailurePolicy
+		cache   ProviderCache
+		want    map[string]Value
+		wantErr string
+	}{
+		{
+			name:    "fail fast",
+			policy:  FailFast,
+			wantErr: "remote is unreachable",
+		},
+		{
+			name:   "warn and continue",
+			policy: WarnAndContinue,
+			want:   map[string]Value{"server.port": 8080, "server.host": "localhost"},
+		},
+		{
+			name:   "serve stale cache",
+			policy: ServeStaleCache,
+			cache:  NewFileProviderCache(cacheDir),
+			want: map[string]Value{
+				"server.port": 9090,
+				"server.host": "localhost",
+				"server.tags": []interface{}{"a", "b"},
+			},
+		},
+		{
+			name:    "no cached values",
+			policy:  ServeStaleCache,
+			cache:   NewFileProviderCache(t.TempDir()),
+			wantErr: "remote is unreachable (no stale values cached)",
+		},
+		{
+			name:    "no cache",
+			policy:  ServeStaleCache,
+			wantErr: "the provider cache is not configured",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepositoryWithOptions(&RepositoryOptions{ProviderCache: testCase.cache})
+			NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+				"server.port": 8080,
+				"server.host": "localhost",
+			})
+			repo.RegisterProviderWithPolicy(&flakyTestProv{err: errUnreachable}, testCase.policy)
+			err := repo.SetUp()
+			if len(testCase.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			for key, want := range testCase.want {
+				if got, ok := repo.Get(NewKey(key)); !ok || !reflect.DeepEqual(got, want) {
+					t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", key, got, want)
+				}
+			}
+			for _, st := range repo.ProviderStatus() {
+				if st.Name == "remote" && (st.State != ProviderFailed || st.LastError != errUnreachable) {
+					t.Fatalf("Unexpected provider status: got: %#v, want a failure", st)
+				}
+			}
+		})
+	}
+}
+
+func TestFailurePolicyString(t *testing.T) {
+	for policy, want := range map[FailurePolicy]string{
+		FailFast:           "fail_fast",
+		WarnAndContinue:    "warn_and_continue",
+		ServeStaleCache:    "serve_stale_cache",
+		FailurePolicy(100): "unknown",
+	} {
+		if got := policy.String(); got != want {
+			t.Fatalf("Unexpected policy string: got: %q, want: %q", got, want)
+		}
+	}
+}
+
+func TestCacheFile(t *testing.T) {
+	repo := NewRepository()
+	logger := &testLogger{}
+	repo.SetLogger(logger)
+	fetchErr := errors.New("connection refused")
+
+	disabled := CacheFile{Source: "Redis"}
+	disabled.Store(repo, map[string]Value{"foo": "bar"})
+	if _, ok := disabled.Load(repo, fetchErr); ok {
+		t.Fatalf("Unexpected cached values with the caching disabled")
+	}
+
+	cache := CacheFile{Path: filepath.Join(t.TempDir(), "redis.yaml"), Source: "Redis"}
+	if _, ok := cache.Load(repo, fetchErr); ok {
+		t.Fatalf("Unexpected cached values before the cache file is written")
+	}
+	want := map[string]Value{"db.host": "localhost"}
+	cache.Store(repo, want)
+	got, ok := cache.Load(repo, fetchErr)
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected cached values: got: %#v, want: %#v", got, want)
+	}
+	wantMsg := fmt.Sprintf("WARN: Serving cached Redis config values from %q: connection refused", cache.Path)
+	if len(logger.messages) != 1 || logger.messages[0] != wantMsg {
+		t.Fatalf("Unexpected log messages: got: %#v, want: %#v", logger.messages, []string{wantMsg})
+	}
+}
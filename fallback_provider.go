@@ -0,0 +1,135 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FallbackProvider presents multiple providers as one: a key lookup queries
+// the providers in order and returns the first value found. Unlike the weight
+// based precedence, a provider failing to set up does not fail the repository
+// set up as long as at least 1 provider succeeded: the failed providers are
+// skipped. E.g. a remote config source falling back to a local snapshot:
+//
+//	remote, _ := grpcconfig.NewProvider(repo, 20, grpcconfig.NewClient(conn), nil)
+//	snapshot, _ := NewYamlProviderFromSource(repo, 20, nil, "snapshot.yaml")
+//	repo.RegisterProvider(config.NewFallbackProvider(remote, snapshot))
+//
+// The wrapped providers are managed by the fallback provider: they are
+// removed from the repository provider list once the fallback provider is
+// registered.
+type FallbackProvider struct {
+	providers []Provider
+	active    []Provider
+	mx        sync.RWMutex
+}
+
+var _ ProviderWrapper = (*FallbackProvider)(nil)
+var _ ContextSetUpper = (*FallbackProvider)(nil)
+
+// NewFallbackProvider is the constructor for FallbackProvider. The returned
+// provider must be registered in the repository explicitly.
+func NewFallbackProvider(providers ...Provider) *FallbackProvider {
+	return &FallbackProvider{
+		providers: providers,
+		active:    make([]Provider, 0, len(providers)),
+	}
+}
+
+// Name returns the provider name composed of the wrapped provider names,
+// e.g.: fallback(consul,yaml)
+func (fp *FallbackProvider) Name() string {
+	names := make([]string, 0, len(fp.providers))
+	for _, prov := range fp.providers {
+		names = append(names, prov.Name())
+	}
+	return "fallback(" + strings.Join(names, ",") + ")"
+}
+
+// Depends returns the dependencies of all wrapped providers
+func (fp *FallbackProvider) Depends() []string {
+	own := make(map[string]bool, len(fp.providers))
+	for _, prov := range fp.providers {
+		own[prov.Name()] = true
+	}
+	seen := make(map[string]bool)
+	deps := make([]string, 0)
+	for _, prov := range fp.providers {
+		for _, dep := range prov.Depends() {
+			if !own[dep] && !seen[dep] {
+				seen[dep] = true
+				deps = append(deps, dep)
+			}
+		}
+	}
+	return deps
+}
+
+// Weight returns the weight of the first wrapped provider
+func (fp *FallbackProvider) Weight() int {
+	if len(fp.providers) == 0 {
+		return 0
+	}
+	return fp.providers[0].Weight()
+}
+
+// Unwrap returns the wrapped providers
+func (fp *FallbackProvider) Unwrap() []Provider { return fp.providers }
+
+// WrapKey returns the key as is
+func (fp *FallbackProvider) WrapKey(key Key) Key { return key }
+
+// SetUp sets up the wrapped providers using a background context.
+func (fp *FallbackProvider) SetUp(repo *Repository) error {
+	return fp.SetUpContext(context.Background(), repo)
+}
+
+// SetUpContext sets up the wrapped providers in order. Returns an error if
+// all of them failed.
+func (fp *FallbackProvider) SetUpContext(ctx context.Context, repo *Repository) error {
+	active := make([]Provider, 0, len(fp.providers))
+	errs := make([]string, 0)
+	for _, prov := range fp.providers {
+		if err := repo.setUpProvider(ctx, prov); err != nil {
+			repo.Logger().Warnf("Config provider %q failed to set up, falling back: %s", prov.Name(), err)
+			errs = append(errs, fmt.Sprintf("%s: %s", prov.Name(), err))
+			continue
+		}
+		active = append(active, prov)
+	}
+	fp.mx.Lock()
+	fp.active = active
+	fp.mx.Unlock()
+	if len(active) == 0 {
+		return fmt.Errorf("All fallback config providers failed to set up: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// TearDown tears down the successfully set up providers. Returns the first
+// error.
+func (fp *FallbackProvider) TearDown(repo *Repository) error {
+	fp.mx.RLock()
+	defer fp.mx.RUnlock()
+	var res error
+	for _, prov := range fp.active {
+		if err := prov.TearDown(repo); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
+
+// Get returns the value served by the first provider having it
+func (fp *FallbackProvider) Get(key Key) (*KeyValue, bool) {
+	fp.mx.RLock()
+	defer fp.mx.RUnlock()
+	for _, prov := range fp.active {
+		if kv, ok := prov.Get(key); ok {
+			return &KeyValue{Key: key, Value: kv.Value}, true
+		}
+	}
+	return nil, false
+}
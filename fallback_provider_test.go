@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFallbackProvider(t *testing.T) {
+	repo := NewRepository()
+	down := &failingTestProv{TestProv: NewTestProv(1, 20), name: "remote", err: fmt.Errorf("connection refused")}
+	repo.RegisterProvider(down)
+	primary, _ := NewMapProvider(repo, 20, "primary", map[string]Value{
+		"foo": "primary foo",
+	})
+	snapshot, _ := NewMapProvider(repo, 20, "snapshot", map[string]Value{
+		"foo": "snapshot foo",
+		"bar": "snapshot bar",
+	})
+	fallback := NewFallbackProvider(down, primary, snapshot)
+	repo.RegisterProvider(fallback)
+
+	if got, want := fallback.Name(), "fallback(remote,primary,snapshot)"; got != want {
+		t.Fatalf("Unexpected name: got: %q, want: %q", got, want)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	statuses := repo.ProviderStatus()
+	if len(statuses) != 1 || statuses[0].Name != fallback.Name() || statuses[0].State != ProviderReady {
+		t.Fatalf("Unexpected provider statuses: %#v", statuses)
+	}
+
+	for k, want := range map[string]Value{"foo": "primary foo", "bar": "snapshot bar"} {
+		got, ok := repo.Get(NewKey(k))
+		if !ok || got != want {
+			t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", k, got, want)
+		}
+	}
+
+	// A reload applied by a wrapped provider is processed on behalf of the
+	// fallback provider
+	var event *ChangeEvent
+	repo.Subscribe(func(e *ChangeEvent) { event = e })
+	if err := repo.ApplyReload(primary, func() error {
+		delete(primary.registry, "foo")
+		return nil
+	}, nil); err != nil {
+		t.Fatalf("Failed to apply reload: %s", err)
+	}
+	wantChanges := []Change{{Key: NewKey("foo"), Old: "primary foo", New: "snapshot foo"}}
+	if event == nil || event.Provider != fallback.Name() || !reflect.DeepEqual(event.Changes, wantChanges) {
+		t.Fatalf("Unexpected change event: %#v", event)
+	}
+}
+
+func TestFallbackProviderAllFailed(t *testing.T) {
+	repo := NewRepository()
+	first := &failingTestProv{TestProv: NewTestProv(1, 10), name: "first", err: fmt.Errorf("timeout")}
+	second := &failingTestProv{TestProv: NewTestProv(1, 10), name: "second", err: fmt.Errorf("not found")}
+	repo.RegisterProvider(NewFallbackProvider(first, second))
+
+	err := repo.SetUp()
+	want := fmt.Errorf("All fallback config providers failed to set up: first: timeout; second: not found")
+	if !reflect.DeepEqual(err, want) {
+		t.Fatalf("Unexpected error: got: %v, want: %v", err, want)
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// FlagName returns the command-line flag name CliProvider registers for the
+// key if flags are generated from the schema (see
+// CliProviderOptions.FromSchema): key fragments are lower-cased and joined
+// with a dash, underscores are replaced with dashes. Example: `server.read_timeout`
+// is set by `--server-read-timeout`.
+func FlagName(key Key) string {
+	chunks := make([]string, 0, len(key))
+	for _, k := range key {
+		chunks = append(chunks, strings.ToLower(strings.Replace(k, "_", "-", -1)))
+	}
+	return strings.Join(chunks, "-")
+}
+
+// schemaKeys returns the list of leaf keys defined in the schema. Wildcard
+// definitions are skipped: they do not define specific keys.
+func (repo *Repository) schemaKeys() []Key {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	res := make([]Key, 0)
+keys:
+	for _, str := range repo.mappers.Keys() {
+		key := NewKey(str)
+		for _, k := range key {
+			if k == WildcardFragment || k == "**" {
+				continue keys
+			}
+		}
+		res = append(res, key)
+	}
+	return res
+}
+
+// schemaFlag is a command-line flag setting a schema key.
+type schemaFlag struct {
+	cp      *CliProvider
+	key     string
+	isBool  bool
+	current string
+}
+
+var _ flag.Value = (*schemaFlag)(nil)
+
+func (sf *schemaFlag) String() string { return sf.current }
+
+func (sf *schemaFlag) Set(val string) error {
+	sf.current = val
+	sf.cp.registry[sf.key] = val
+	return nil
+}
+
+// IsBoolFlag makes boolean keys settable by a bare flag, e.g. `--debug`.
+func (sf *schemaFlag) IsBoolFlag() bool { return sf.isBool }
+
+// schemaFlags returns a flag definition function registering a flag for every
+// schema key. Returns an error if 2 keys map to the same flag name.
+func (cp *CliProvider) schemaFlags(repo *Repository) (func(*flag.FlagSet), error) {
+	flags := make(map[string]*schemaFlag)
+	usages := make(map[string]string)
+	for _, key := range repo.schemaKeys() {
+		name := FlagName(key)
+		if prev, ok := flags[name]; ok {
+			return nil, fmt.Errorf("Config keys %q and %q map to the same flag %q",
+				prev.key, key.String(), name)
+		}
+		sf := &schemaFlag{cp: cp, key: key.String()}
+		if ptr := repo.mappers.Find(key); ptr != nil && ptr.Mpr != nil {
+			if descr, err := describeSchema(key, ptr.Mpr); err == nil {
+				sf.isBool = docsType(descr) == "boolean"
+			}
+		}
+		if d, ok := repo.Description(key); ok {
+			usages[name] = d.Text
+		} else {
+			usages[name] = fmt.Sprintf("Sets %q", key.String())
+		}
+		flags[name] = sf
+	}
+	return func(fs *flag.FlagSet) {
+		for name, sf := range flags {
+			fs.Var(sf, name, usages[name])
+		}
+	}, nil
+}
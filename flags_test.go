@@ -0,0 +1,71 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlagName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"port", "port"},
+		{"server.port", "server-port"},
+		{"server.read_timeout", "server-read-timeout"},
+		{"Server.Host", "server-host"},
+	}
+	for _, testCase := range tests {
+		if got := FlagName(NewKey(testCase.key)); got != testCase.want {
+			t.Fatalf("Unexpected flag name for key %q: got: %q, want: %q", testCase.key, got, testCase.want)
+		}
+	}
+}
+
+func TestFlagsFromSchema(t *testing.T) {
+	oldEnvVars := envVars
+	defer func() { envVars = oldEnvVars }()
+	envVars = func() []string {
+		return []string{"APP_SERVER_HOST=example.com", "APP_SERVER_READ__TIMEOUT=5s", "APP_UNKNOWN=1"}
+	}
+
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"server": map[string]Schema{
+			"host":         ToStr,
+			"port":         ToInt,
+			"debug":        ToBool,
+			"read_timeout": ToStr,
+			"*": map[string]Schema{
+				"enabled": ToBool,
+			},
+		},
+	})
+	cli, err := NewCliProviderWithOptions(repo, 20, &CliProviderOptions{
+		Args:       []string{"--server-port", "8080", "--server-debug"},
+		FromSchema: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new cli provider: %s", err)
+	}
+	env, err := NewEnvProviderWithOptions(repo, 10, &EnvProviderOptions{
+		Prefix:     "APP_",
+		Allowlist:  []string{},
+		FromSchema: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new env provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	wantCli := map[string]Value{"server.port": "8080", "server.debug": "true"}
+	if !reflect.DeepEqual(cli.registry, wantCli) {
+		t.Fatalf("Unexpected state for CliProvider.registry: got: %#v, want: %#v", cli.registry, wantCli)
+	}
+	wantEnv := map[string]Value{"server.host": "example.com", "server.read_timeout": "5s"}
+	if !reflect.DeepEqual(env.registry, wantEnv) {
+		t.Fatalf("Unexpected state for EnvProvider.registry: got: %#v, want: %#v", env.registry, wantEnv)
+	}
+}
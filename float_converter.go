@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FloatConverter converts floats, integers and numeric strings, including
+// the scientific notation (`1.5e-3`), into a float32 or a float64.
+type FloatConverter struct {
+	// Bits is the float size: 32 or 64.
+	Bits int
+	// Finite rejects NaN and infinite values, e.g. `.inf` in YAML or "NaN".
+	Finite bool
+}
+
+var _ Converter = (*FloatConverter)(nil)
+var _ Mapper = (*FloatConverter)(nil)
+
+// Initialized instances of FloatConverter accepting NaN and infinite values.
+// They are registered as the converters for the float32 and float64 types
+// (see ConverterFor).
+//
+// Example:
+//
+//	repo.DefineSchema(map[string]config.Schema{
+//		"sampling.ratio": config.ToFloat64,
+//		"sampling.limit": &config.FloatConverter{Bits: 64, Finite: true},
+//	})
+var (
+	ToFloat32 = &FloatConverter{Bits: 32}
+	ToFloat64 = &FloatConverter{Bits: 64}
+)
+
+// Convert returns the float value and true if the value is a number within
+// the float range.
+func (fc *FloatConverter) Convert(kv *KeyValue) (*KeyValue, bool) {
+	mkv, err := fc.Map(kv)
+	return mkv, err == nil
+}
+
+// Map converts the value. Returns an error if the value is not a number, if
+// it overflows the float size or if it is not finite and Finite is set.
+func (fc *FloatConverter) Map(kv *KeyValue) (*KeyValue, error) {
+	bits := fc.Bits
+	if bits != 32 {
+		bits = 64
+	}
+	f, err := toFloatBits(kv.Value, bits)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to convert %T value for key %q: %s", kv.Value, kv.Key, err)
+	}
+	if fc.Finite && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return nil, fmt.Errorf("Failed to convert %T value for key %q: want a finite number", kv.Value, kv.Key)
+	}
+	if bits == 32 {
+		return &KeyValue{Key: kv.Key, Value: float32(f)}, nil
+	}
+	return &KeyValue{Key: kv.Key, Value: f}, nil
+}
+
+// JSONSchema describes the accepted values: number
+func (fc *FloatConverter) JSONSchema() map[string]interface{} { return jsonType("number") }
+
+// toFloatBits returns the number as a float64 within the float size range.
+func toFloatBits(v Value, bits int) (float64, error) {
+	var f float64
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f = rv.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		f = float64(rv.Uint())
+	case reflect.String:
+		res, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), bits)
+		if err != nil {
+			if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+				return 0, fmt.Errorf("overflows float%d", bits)
+			}
+			return 0, fmt.Errorf("want float%d", bits)
+		}
+		return res, nil
+	default:
+		return 0, fmt.Errorf("want float%d", bits)
+	}
+	if bits == 32 && !math.IsInf(f, 0) && math.Abs(f) > math.MaxFloat32 {
+		return 0, fmt.Errorf("overflows float32")
+	}
+	return f, nil
+}
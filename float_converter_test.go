@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFloatConverter(t *testing.T) {
+	tests := []struct {
+		name    string
+		conv    *FloatConverter
+		value   Value
+		want    Value
+		wantErr string
+	}{
+		{"float64", ToFloat64, 0.25, 0.25, ""},
+		{"int", ToFloat64, 42, 42.0, ""},
+		{"uint", ToFloat64, uint8(7), 7.0, ""},
+		{"string", ToFloat64, " 3.5 ", 3.5, ""},
+		{"scientific notation", ToFloat64, "1.5e-3", 1.5e-3, ""},
+		{"float32", ToFloat32, "2.5E2", float32(250), ""},
+		{"float32 from float64", ToFloat32, 0.5, float32(0.5), ""},
+		{"float32 overflow", ToFloat32, 1e39, nil, "overflows float32"},
+		{"float32 string overflow", ToFloat32, "1e39", nil, "overflows float32"},
+		{"float64 string overflow", ToFloat64, "1e309", nil, "overflows float64"},
+		{"infinity", ToFloat64, "-Inf", math.Inf(-1), ""},
+		{"finite infinity", &FloatConverter{Bits: 64, Finite: true}, math.Inf(1), nil, "want a finite number"},
+		{"finite nan", &FloatConverter{Bits: 32, Finite: true}, "NaN", nil, "want a finite number"},
+		{"malformed", ToFloat64, "1,5", nil, "want float64"},
+		{"not a number", ToFloat32, true, nil, "want float32"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			kv, err := testCase.conv.Map(&KeyValue{Key: NewKey("f"), Value: testCase.value})
+			if len(testCase.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if kv.Value != testCase.want {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", kv.Value, testCase.want)
+			}
+		})
+	}
+}
+
+func TestFloatGetters(t *testing.T) {
+	repo := NewRepository()
+	NewMapProvider(repo, 0, "env", map[string]Value{
+		"sampling.ratio": "1e-2",
+		"sampling.limit": 100,
+		"sampling.name":  "all",
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if got := MustFloat64(repo, "sampling.ratio"); got != 0.01 {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", got, 0.01)
+	}
+	if got, ok, err := LookupFloat32(repo, "sampling.limit"); !ok || err != nil || got != 100 {
+		t.Fatalf("Unexpected lookup result: got: %#v, %t, %v, want: 100, true, nil", got, ok, err)
+	}
+	if _, _, err := LookupFloat64(repo, "sampling.name"); err == nil {
+		t.Fatalf("Expected a type mismatch error")
+	}
+
+	var target struct {
+		Ratio float32
+		Limit float64
+	}
+	if _, err := Decode(repo, "sampling", &target); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if target.Ratio != 0.01 || target.Limit != 100 {
+		t.Fatalf("Unexpected decoded value: got: %#v", target)
+	}
+}
+
+func TestFloatConverterSecret(t *testing.T) {
+	tests := []struct {
+		name  string
+		conv  *FloatConverter
+		value Value
+	}{
+		{"malformed", ToFloat64, "hunter2-secret"},
+		{"overflow", ToFloat32, "4.2e42"},
+		{"not finite", &FloatConverter{Bits: 64, Finite: true}, "+Inf"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			repo.DefineSchema(map[string]Schema{"db": map[string]Schema{"ratio": Secret(testCase.conv)}})
+			repo.RegisterKey(NewKey("db.ratio"), NewTestProv(testCase.value, DefaultWeight))
+			err := repo.tryGet(NewKey("db.ratio"))
+			if err == nil {
+				t.Fatalf("Expected a mapper error, got nil")
+			}
+			raw := fmt.Sprint(testCase.value)
+			if strings.Contains(err.Error(), raw) {
+				t.Fatalf("Unexpected secret value %q in the error: %s", raw, err)
+			}
+			var cerr *ConversionError
+			if !errors.As(err, &cerr) || strings.Contains(cerr.Err.Error(), raw) {
+				t.Fatalf("Unexpected wrapped error: got: %v, want it to redact %q", cerr, raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+// Package gcpsecrets provides a config provider serving secrets stored in
+// Google Secret Manager.
+//
+// The package does not depend on the Google Cloud SDK: the provider talks to
+// Secret Manager through the minimal Client interface, which is expected to be
+// satisfied by a thin adapter over
+// cloud.google.com/go/secretmanager/apiv1.Client.
+package gcpsecrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/osdrv/config"
+)
+
+const (
+	// LatestVersion is the Secret Manager alias for the most recent secret
+	// version.
+	LatestVersion = "latest"
+	// DefaultTimeout is the default timeout of a single secrets fetch.
+	DefaultTimeout = 10 * time.Second
+)
+
+// Client is the subset of the Secret Manager API used by the provider.
+type Client interface {
+	// ListSecrets returns the IDs of all secrets in the project, e.g.:
+	// "db_password" for "projects/my-project/secrets/db_password".
+	ListSecrets(ctx context.Context, project string) ([]string, error)
+	// AccessSecretVersion returns the payload of the secret version
+	// identified by the full resource name, e.g.:
+	// "projects/my-project/secrets/db_password/versions/latest".
+	AccessSecretVersion(ctx context.Context, name string) ([]byte, error)
+}
+
+// Options is a set of Provider settings.
+type Options struct {
+	// Project is the GCP project ID the secrets belong to.
+	Project string
+	// Prefix selects all secrets with IDs starting with the prefix. The
+	// prefix is stripped from config keys.
+	Prefix string
+	// Secrets is the explicit list of secret IDs to load. If both Prefix and
+	// Secrets are set, the explicit list is loaded along with the prefixed
+	// secrets.
+	Secrets []string
+	// Version is the version loaded for every secret. LatestVersion is used
+	// if not set.
+	Version string
+	// Versions pins specific secrets to fixed versions, e.g.:
+	// map[string]string{"db_password": "3"}. Keys are secret IDs.
+	Versions map[string]string
+	// RefreshInterval enables periodic secrets reloads. Secrets are only
+	// loaded once if not set.
+	RefreshInterval time.Duration
+	// RefreshPolicy defines the reload schedule. It takes precedence over
+	// RefreshInterval.
+	RefreshPolicy config.RefreshPolicy
+	// Timeout limits a single secrets fetch. DefaultTimeout is used if not
+	// set.
+	Timeout time.Duration
+	// CacheFile is the location of a local cache file. If set, the values
+	// are persisted to it on every successful fetch and are served from it
+	// if Secret Manager is unavailable at set up (see config.CacheFile).
+	// Beware the cache file keeps the secret values in plain text.
+	CacheFile string
+}
+
+// Provider serves secrets loaded from Google Secret Manager. Secret IDs are
+// converted to config keys following the env provider convention: an
+// underscore is interpreted as a key separator, a double underscore as a
+// singular underscore, e.g. secret `db_password` is served under the key
+// `db.password`.
+type Provider struct {
+	weight    int
+	client    Client
+	options   *Options
+	registry  map[string]config.Value
+	cache     config.CacheFile
+	repo      *config.Repository
+	refresher *config.RefreshScheduler
+	mx        sync.RWMutex
+}
+
+var _ config.Provider = (*Provider)(nil)
+var _ config.ContextSetUpper = (*Provider)(nil)
+
+// NewProvider is the constructor for Provider.
+func NewProvider(repo *config.Repository, weight int, client Client, options *Options) (*Provider, error) {
+	if client == nil {
+		return nil, fmt.Errorf("Secret Manager client is not set")
+	}
+	if options == nil || len(options.Project) == 0 {
+		return nil, fmt.Errorf("GCP project is not set")
+	}
+	prov := &Provider{
+		weight:   weight,
+		client:   client,
+		options:  options,
+		registry: make(map[string]config.Value),
+		cache:    config.CacheFile{Path: options.CacheFile, Source: "GCP secrets"},
+	}
+	repo.RegisterProvider(prov)
+	return prov, nil
+}
+
+// Name returns provider name: gcpsecrets
+func (p *Provider) Name() string { return "gcpsecrets" }
+
+// Depends returns the list of provider dependencies: none
+func (p *Provider) Depends() []string { return []string{} }
+
+// Weight returns the provider weight
+func (p *Provider) Weight() int { return p.weight }
+
+// SetUp loads the secrets using a background context.
+func (p *Provider) SetUp(repo *config.Repository) error {
+	return p.SetUpContext(context.Background(), repo)
+}
+
+// SetUpContext loads the secrets and registers the keys in the repo. Reloads
+// are scheduled according to RefreshPolicy or RefreshInterval (if set). If
+// Secret Manager is unavailable and CacheFile is set, the cached values are
+// served.
+func (p *Provider) SetUpContext(ctx context.Context, repo *config.Repository) error {
+	p.repo = repo
+	registry, err := p.fetch(ctx)
+	if err != nil {
+		var ok bool
+		if registry, ok = p.cache.Load(p.repo, err); !ok {
+			return err
+		}
+	} else {
+		p.cache.Store(p.repo, registry)
+	}
+	p.mx.Lock()
+	p.registry = registry
+	p.mx.Unlock()
+	for k := range registry {
+		if err := repo.RegisterKey(config.NewKey(k), p); err != nil {
+			return err
+		}
+	}
+	policy := p.options.RefreshPolicy
+	if policy == nil && p.options.RefreshInterval > 0 {
+		policy = config.TTL(p.options.RefreshInterval)
+	}
+	if policy != nil {
+		p.refresher = config.NewRefreshScheduler(repo, p, p.refresh)
+		p.refresher.SetPolicy("", policy)
+		p.refresher.Start()
+	}
+	return nil
+}
+
+// TearDown stops the scheduled reloads.
+func (p *Provider) TearDown(*config.Repository) error {
+	if p.refresher != nil {
+		p.refresher.Stop()
+	}
+	return nil
+}
+
+// Get returns the secret value for the key
+func (p *Provider) Get(key config.Key) (*config.KeyValue, bool) {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+	if v, ok := p.registry[key.String()]; ok {
+		return &config.KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
+
+// Reload re-fetches the secrets and applies the new key set atomically (see
+// `config.Repository.ApplyReload`). If the new values fail the schema
+// validation, the previous values are kept.
+func (p *Provider) Reload(ctx context.Context) error {
+	return p.refresh(ctx, nil)
+}
+
+// refresh re-fetches the values and applies the ones under the config key
+// prefix (see config.RefreshFunc). Fetched values are keyed the same way as
+// at set up, so the prefix is matched against the served keys.
+func (p *Provider) refresh(ctx context.Context, prefix config.Key) (err error) {
+	fresh, err := p.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	p.mx.RLock()
+	prevRegistry := p.registry
+	p.mx.RUnlock()
+	registry := config.ReplacePrefix(prevRegistry, fresh, prefix)
+	defer func() {
+		if err == nil {
+			p.cache.Store(p.repo, registry)
+		}
+	}()
+	return p.repo.ApplyReload(p, func() error {
+		p.mx.Lock()
+		p.registry = registry
+		p.mx.Unlock()
+		for k := range registry {
+			if err := p.repo.RegisterKey(config.NewKey(k), p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func() {
+		p.mx.Lock()
+		p.registry = prevRegistry
+		p.mx.Unlock()
+	})
+}
+
+func (p *Provider) fetch(ctx context.Context) (map[string]config.Value, error) {
+	timeout := p.options.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ctx, span := p.repo.StartProviderSpan(ctx, "config.gcpsecrets.Fetch", p)
+	defer span.End()
+
+	ids, err := p.secretIDs(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	registry := make(map[string]config.Value, len(ids))
+	for _, id := range ids {
+		name := fmt.Sprintf("projects/%s/secrets/%s/versions/%s",
+			p.options.Project, id, p.version(id))
+		data, err := p.client.AccessSecretVersion(ctx, name)
+		if err != nil {
+			err = fmt.Errorf("Failed to access secret %q: %s", name, err)
+			span.RecordError(err)
+			return nil, err
+		}
+		registry[p.keyOf(id)] = string(data)
+	}
+	return registry, nil
+}
+
+// secretIDs returns the explicitly listed secret IDs followed by the IDs
+// matching the prefix.
+func (p *Provider) secretIDs(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	ids := make([]string, 0, len(p.options.Secrets))
+	for _, id := range p.options.Secrets {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(p.options.Prefix) == 0 {
+		return ids, nil
+	}
+	all, err := p.client.ListSecrets(ctx, p.options.Project)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list secrets in project %q: %s", p.options.Project, err)
+	}
+	for _, id := range all {
+		if strings.HasPrefix(id, p.options.Prefix) && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (p *Provider) version(id string) string {
+	if v, ok := p.options.Versions[id]; ok {
+		return v
+	}
+	if len(p.options.Version) > 0 {
+		return p.options.Version
+	}
+	return LatestVersion
+}
+
+func (p *Provider) keyOf(id string) string {
+	if len(p.options.Prefix) > 0 {
+		id = strings.TrimPrefix(id, p.options.Prefix)
+	}
+	k := strings.Replace(id, "_", config.KeySepCh, -1)
+	k = strings.Replace(k, "..", "_", -1)
+	return strings.ToLower(k)
+}
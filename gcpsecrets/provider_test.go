@@ -0,0 +1,241 @@
+package gcpsecrets
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/osdrv/config"
+)
+
+type testClient struct {
+	// secrets maps secret IDs to version payloads
+	secrets  map[string]map[string]string
+	accessed []string
+	// err is returned by ListSecrets if set
+	err error
+	mx  sync.Mutex
+}
+
+var _ Client = (*testClient)(nil)
+
+func (c *testClient) ListSecrets(_ context.Context, project string) ([]string, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	ids := make([]string, 0, len(c.secrets))
+	for id := range c.secrets {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (c *testClient) AccessSecretVersion(_ context.Context, name string) ([]byte, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.accessed = append(c.accessed, name)
+	parts := strings.Split(name, "/")
+	if len(parts) != 6 {
+		return nil, fmt.Errorf("malformed secret version name: %q", name)
+	}
+	if versions, ok := c.secrets[parts[3]]; ok {
+		if payload, ok := versions[parts[5]]; ok {
+			return []byte(payload), nil
+		}
+	}
+	return nil, fmt.Errorf("not found: %q", name)
+}
+
+func (c *testClient) set(id, version, payload string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if _, ok := c.secrets[id]; !ok {
+		c.secrets[id] = make(map[string]string)
+	}
+	c.secrets[id][version] = payload
+}
+
+func newTestClient() *testClient {
+	return &testClient{
+		secrets: map[string]map[string]string{
+			"app_db_password": {"latest": "s3cr3t", "1": "old"},
+			"app_api__key":    {"latest": "key"},
+			"other_token":     {"latest": "token"},
+		},
+	}
+}
+
+func TestProviderSetUp(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *Options
+		want    map[string]config.Value
+		wantErr bool
+	}{
+		{
+			"By prefix",
+			&Options{Project: "p", Prefix: "app_"},
+			map[string]config.Value{
+				"db.password": "s3cr3t",
+				"api_key":     "key",
+			},
+			false,
+		},
+		{
+			"An explicit list",
+			&Options{Project: "p", Secrets: []string{"other_token"}},
+			map[string]config.Value{
+				"other.token": "token",
+			},
+			false,
+		},
+		{
+			"A pinned version",
+			&Options{
+				Project:  "p",
+				Prefix:   "app_",
+				Versions: map[string]string{"app_db_password": "1"},
+			},
+			map[string]config.Value{
+				"db.password": "old",
+				"api_key":     "key",
+			},
+			false,
+		},
+		{
+			"A missing secret",
+			&Options{Project: "p", Secrets: []string{"missing"}},
+			nil,
+			true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := config.NewRepository()
+			prov, err := NewProvider(repo, 10, newTestClient(), testCase.options)
+			if err != nil {
+				t.Fatalf("Failed to initialize a new provider: %s", err)
+			}
+			err = repo.SetUp()
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if testCase.wantErr {
+				return
+			}
+			defer repo.TearDown()
+			got := make(map[string]config.Value)
+			for k := range prov.registry {
+				v, ok := repo.Get(config.NewKey(k))
+				if !ok {
+					t.Fatalf("Expected lookup for key %q to find a value, none returned", k)
+				}
+				got[k] = v
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected values: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestProviderReload(t *testing.T) {
+	client := newTestClient()
+	repo := config.NewRepository()
+	prov, err := NewProvider(repo, 10, client, &Options{Project: "p", Prefix: "app_"})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	events := make([]*config.ChangeEvent, 0)
+	repo.Subscribe(func(e *config.ChangeEvent) {
+		events = append(events, e)
+	})
+
+	client.set("app_db_password", "latest", "n3w")
+	if err := prov.Reload(context.Background()); err != nil {
+		t.Fatalf("Failed to reload the provider: %s", err)
+	}
+	if v, _ := repo.Get(config.NewKey("db.password")); v != "n3w" {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", v, "n3w")
+	}
+	if len(events) != 1 || events[0].Provider != "gcpsecrets" {
+		t.Fatalf("Unexpected change events: %#v", events)
+	}
+}
+
+func TestProviderRefreshPrefix(t *testing.T) {
+	client := newTestClient()
+	repo := config.NewRepository()
+	prov, err := NewProvider(repo, 10, client, &Options{Project: "p", Prefix: "app_"})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	client.set("app_db_password", "latest", "n3w")
+	client.set("app_api__key", "latest", "n3w")
+	if err := prov.refresh(context.Background(), config.NewKey("db")); err != nil {
+		t.Fatalf("Failed to refresh the provider: %s", err)
+	}
+	want := map[string]config.Value{
+		"db.password": "n3w",
+		"api_key":     "key",
+	}
+	if !reflect.DeepEqual(prov.registry, want) {
+		t.Fatalf("Unexpected registry: got: %#v, want: %#v", prov.registry, want)
+	}
+}
+
+func TestProviderCacheFile(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "gcpsecrets.yaml")
+	client := newTestClient()
+	options := &Options{Project: "p", Prefix: "app_", CacheFile: cacheFile}
+	repo := config.NewRepository()
+	if _, err := NewProvider(repo, 10, client, options); err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	repo.TearDown()
+
+	client.err = fmt.Errorf("permission denied")
+	repo = config.NewRepository()
+	prov, err := NewProvider(repo, 10, client, options)
+	if err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+	want := map[string]config.Value{"db.password": "s3cr3t", "api_key": "key"}
+	if !reflect.DeepEqual(prov.registry, want) {
+		t.Fatalf("Unexpected registry: got: %#v, want: %#v", prov.registry, want)
+	}
+}
+
+func TestNewProviderErrors(t *testing.T) {
+	repo := config.NewRepository()
+	if _, err := NewProvider(repo, 10, nil, &Options{Project: "p"}); err == nil {
+		t.Fatalf("Expected an error for a nil client")
+	}
+	if _, err := NewProvider(repo, 10, newTestClient(), &Options{}); err == nil {
+		t.Fatalf("Expected an error for an empty project")
+	}
+}
@@ -1,79 +1,246 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
-func Must(repo *Repository, key string) Value {
-	v, ok := repo.Get(NewKey(key))
+// Getter is implemented by config value sources: Repository and ScopedRepo.
+type Getter interface {
+	Get(Key) (Value, bool)
+}
+
+var _ Getter = (*Repository)(nil)
+var _ Getter = (*ScopedRepo)(nil)
+
+// keyParser is implemented by getters with custom key parsing rules
+type keyParser interface {
+	NewKey(string) Key
+}
+
+// typeOptions is implemented by getters with configurable value coercion
+type typeOptions interface {
+	strictTypes() bool
+}
+
+func (repo *Repository) strictTypes() bool { return repo.options.StrictTypes }
+
+func (sr *ScopedRepo) strictTypes() bool { return sr.repo.strictTypes() }
+
+func isStrictTypes(repo Getter) bool {
+	to, ok := orDefault(repo).(typeOptions)
+	return ok && to.strictTypes()
+}
+
+// coerce converts the value using the converter unless the getter requires
+// strict types. The value is returned as is if the conversion fails.
+func coerce(repo Getter, key string, v Value, conv Converter) Value {
+	if isStrictTypes(repo) {
+		return v
+	}
+	if kv, ok := conv.Convert(&KeyValue{Key: parseKey(repo, key), Value: v}); ok {
+		return kv.Value
+	}
+	return v
+}
+
+// parseKey parses the key string according to the getter key parsing rules.
+func parseKey(repo Getter, key string) Key {
+	if kp, ok := orDefault(repo).(keyParser); ok {
+		return kp.NewKey(key)
+	}
+	return NewKey(key)
+}
+
+// Must looks up the key value. If the getter is nil, the default repository
+// is used (see Default). It panics with an error wrapping
+// ErrKeyNotFound if the key is not served by any provider. Typed Must*
+// functions panic with an error wrapping ErrTypeMismatch if the value is not of
+// the requested type.
+func Must(repo Getter, key string) Value {
+	v, ok := orDefault(repo).Get(parseKey(repo, key))
 	if !ok {
-		panic(fmt.Sprintf("Unregistered config key: %q", key))
+		panic(fmt.Errorf("%w: %q", ErrKeyNotFound, key))
 	}
 	return v
 }
 
-func MustStr(repo *Repository, key string) string {
-	return Must(repo, key).(string)
+func MustStr(repo Getter, key string) string {
+	v := coerce(repo, key, Must(repo, key), ToStr)
+	if tv, ok := v.(string); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "string"))
+}
+
+func MustInt(repo Getter, key string) int {
+	v := coerce(repo, key, Must(repo, key), ToInt)
+	if tv, ok := v.(int); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "int"))
+}
+
+func MustInt8(repo Getter, key string) int8 {
+	v := coerce(repo, key, Must(repo, key), ToInt8)
+	if tv, ok := v.(int8); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "int8"))
+}
+
+func MustInt16(repo Getter, key string) int16 {
+	v := coerce(repo, key, Must(repo, key), ToInt16)
+	if tv, ok := v.(int16); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "int16"))
+}
+
+func MustInt32(repo Getter, key string) int32 {
+	v := coerce(repo, key, Must(repo, key), ToInt32)
+	if tv, ok := v.(int32); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "int32"))
 }
 
-func MustInt(repo *Repository, key string) int {
-	return Must(repo, key).(int)
+func MustInt64(repo Getter, key string) int64 {
+	v := coerce(repo, key, Must(repo, key), ToInt64)
+	if tv, ok := v.(int64); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "int64"))
 }
 
-func MustInt8(repo *Repository, key string) int8 {
-	return Must(repo, key).(int8)
+func MustUint(repo Getter, key string) uint {
+	v := coerce(repo, key, Must(repo, key), ToUint)
+	if tv, ok := v.(uint); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "uint"))
 }
 
-func MustInt16(repo *Repository, key string) int16 {
-	return Must(repo, key).(int16)
+func MustUint8(repo Getter, key string) uint8 {
+	v := coerce(repo, key, Must(repo, key), ToUint8)
+	if tv, ok := v.(uint8); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "uint8"))
 }
 
-func MustInt32(repo *Repository, key string) int32 {
-	return Must(repo, key).(int32)
+func MustUint16(repo Getter, key string) uint16 {
+	v := coerce(repo, key, Must(repo, key), ToUint16)
+	if tv, ok := v.(uint16); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "uint16"))
 }
 
-func MustInt64(repo *Repository, key string) int64 {
-	return Must(repo, key).(int64)
+func MustUint32(repo Getter, key string) uint32 {
+	v := coerce(repo, key, Must(repo, key), ToUint32)
+	if tv, ok := v.(uint32); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "uint32"))
 }
 
-func MustUint(repo *Repository, key string) uint {
-	return Must(repo, key).(uint)
+func MustUint64(repo Getter, key string) uint64 {
+	v := coerce(repo, key, Must(repo, key), ToUint64)
+	if tv, ok := v.(uint64); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "uint64"))
 }
 
-func MustUint8(repo *Repository, key string) uint8 {
-	return Must(repo, key).(uint8)
+func MustUintptr(repo Getter, key string) uintptr {
+	v := Must(repo, key)
+	if tv, ok := v.(uintptr); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "uintptr"))
 }
 
-func MustUint16(repo *Repository, key string) uint16 {
-	return Must(repo, key).(uint16)
+func MustBool(repo Getter, key string) bool {
+	v := coerce(repo, key, Must(repo, key), ToBool)
+	if tv, ok := v.(bool); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "bool"))
 }
 
-func MustUint32(repo *Repository, key string) uint32 {
-	return Must(repo, key).(uint32)
+func MustFloat32(repo Getter, key string) float32 {
+	v := coerce(repo, key, Must(repo, key), ToFloat32)
+	if tv, ok := v.(float32); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "float32"))
 }
 
-func MustUint64(repo *Repository, key string) uint64 {
-	return Must(repo, key).(uint64)
+func MustFloat64(repo Getter, key string) float64 {
+	v := coerce(repo, key, Must(repo, key), ToFloat64)
+	if tv, ok := v.(float64); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "float64"))
 }
 
-func MustUintptr(repo *Repository, key string) uintptr {
-	return Must(repo, key).(uintptr)
+// MustStrArr returns the key value as []string. A generic list (e.g. produced by
+// YAML) is converted element-by-element to strings.
+func MustStrArr(repo Getter, key string) []string {
+	v := Must(repo, key)
+	if tv, ok := toStrArr(v, isStrictTypes(repo)); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "[]string"))
 }
 
-func MustBool(repo *Repository, key string) bool {
-	return Must(repo, key).(bool)
+// MustIntArr returns the key value as []int. A generic list (e.g. produced by
+// YAML) is converted element-by-element to ints.
+func MustIntArr(repo Getter, key string) []int {
+	v := Must(repo, key)
+	if tv, ok := toIntArr(v, isStrictTypes(repo)); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "[]int"))
 }
 
-func MustFloat32(repo *Repository, key string) float32 {
-	return Must(repo, key).(float32)
+// MustFloatArr returns the key value as []float64. A generic list (e.g. produced by
+// YAML) is converted element-by-element to floats.
+func MustFloatArr(repo Getter, key string) []float64 {
+	v := Must(repo, key)
+	if tv, ok := toFloatArr(v, isStrictTypes(repo)); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "[]float64"))
 }
 
-func MustFloat64(repo *Repository, key string) float64 {
-	return Must(repo, key).(float64)
+// MustDurationArr returns the key value as []time.Duration. A generic list (e.g. produced by
+// YAML) is converted element-by-element to durations.
+func MustDurationArr(repo Getter, key string) []time.Duration {
+	v := Must(repo, key)
+	if tv, ok := toDurationArr(v, isStrictTypes(repo)); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "[]time.Duration"))
 }
 
-func MustStrArr(repo *Repository, key string) []string {
-	return Must(repo, key).([]string)
+// MustStrMap returns the key value as map[string]string. A generic map (e.g. produced by
+// YAML) is converted element-by-element to strings.
+func MustStrMap(repo Getter, key string) map[string]string {
+	v := Must(repo, key)
+	if tv, ok := toStrMap(v, isStrictTypes(repo)); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "map[string]string"))
 }
 
-func MustIntArr(repo *Repository, key string) []int {
-	return Must(repo, key).([]int)
+// MustIntMap returns the key value as map[string]int. A generic map (e.g. produced by
+// YAML) is converted element-by-element to ints.
+func MustIntMap(repo Getter, key string) map[string]int {
+	v := Must(repo, key)
+	if tv, ok := toIntMap(v, isStrictTypes(repo)); ok {
+		return tv
+	}
+	panic(typeMismatch(key, v, "map[string]int"))
 }
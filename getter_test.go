@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMustCoercion(t *testing.T) {
+	tests := []struct {
+		name        string
+		strictTypes bool
+		val         Value
+		get         func(repo Getter) interface{}
+		want        interface{}
+		wantErr     error
+	}{
+		{
+			"int from a string",
+			false,
+			"8080",
+			func(repo Getter) interface{} { return MustInt(repo, "key") },
+			8080,
+			nil,
+		},
+		{
+			"bool from a string",
+			false,
+			"true",
+			func(repo Getter) interface{} { return MustBool(repo, "key") },
+			true,
+			nil,
+		},
+		{
+			"string from an int",
+			false,
+			42,
+			func(repo Getter) interface{} { return MustStr(repo, "key") },
+			"42",
+			nil,
+		},
+		{
+			"uint16 from an int",
+			false,
+			8080,
+			func(repo Getter) interface{} { return MustUint16(repo, "key") },
+			uint16(8080),
+			nil,
+		},
+		{
+			"int8 from a string",
+			false,
+			"-12",
+			func(repo Getter) interface{} { return MustInt8(repo, "key") },
+			int8(-12),
+			nil,
+		},
+		{
+			"uint8 overflow",
+			false,
+			300,
+			func(repo Getter) interface{} { return MustUint8(repo, "key") },
+			nil,
+			ErrTypeMismatch,
+		},
+		{
+			"uint64 from a negative int",
+			false,
+			-1,
+			func(repo Getter) interface{} { return MustUint64(repo, "key") },
+			nil,
+			ErrTypeMismatch,
+		},
+		{
+			"strict types int from a string",
+			true,
+			"8080",
+			func(repo Getter) interface{} { return MustInt(repo, "key") },
+			nil,
+			ErrTypeMismatch,
+		},
+		{
+			"strict types list from a generic list",
+			true,
+			[]interface{}{"foo"},
+			func(repo Getter) interface{} { return MustStrArr(repo, "key") },
+			nil,
+			ErrTypeMismatch,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepositoryWithOptions(&RepositoryOptions{StrictTypes: testCase.strictTypes})
+			repo.RegisterKey(NewKey("scope.key"), NewTestProv(testCase.val, DefaultWeight))
+			defer func() {
+				r := recover()
+				if testCase.wantErr == nil {
+					if r != nil {
+						t.Fatalf("Unexpected panic: %v", r)
+					}
+					return
+				}
+				if err, ok := r.(error); !ok || !errors.Is(err, testCase.wantErr) {
+					t.Fatalf("Unexpected panic value: got: %#v, want: %v", r, testCase.wantErr)
+				}
+			}()
+			if got := testCase.get(repo.Scope("scope")); !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package grpcconfig
+
+import (
+	"context"
+
+	"github.com/osdrv/config/grpcconfig/configpb"
+	"google.golang.org/grpc"
+)
+
+//go:generate protoc --go_out=. --go_opt=module=github.com/osdrv/config/grpcconfig --go-grpc_out=. --go-grpc_opt=module=github.com/osdrv/config/grpcconfig config.proto
+
+// grpcClient adapts the generated config service client to Client.
+type grpcClient struct {
+	client configpb.ConfigServiceClient
+}
+
+var _ Client = (*grpcClient)(nil)
+
+// NewClient returns a Client calling the config service over the connection.
+//
+// Example:
+//
+//	conn, err := grpc.Dial("config.local:9000", grpc.WithTransportCredentials(creds))
+//	if err != nil {
+//		return err
+//	}
+//	prov, err := grpcconfig.NewProvider(repo, 10, grpcconfig.NewClient(conn), &grpcconfig.Options{
+//		Prefix: "billing",
+//		Watch:  true,
+//	})
+func NewClient(conn grpc.ClientConnInterface) Client {
+	return &grpcClient{client: configpb.NewConfigServiceClient(conn)}
+}
+
+// ListValues calls the ListValues method.
+func (c *grpcClient) ListValues(ctx context.Context, prefix string) ([]KeyValue, int64, error) {
+	resp, err := c.client.ListValues(ctx, &configpb.ListValuesRequest{Prefix: prefix})
+	if err != nil {
+		return nil, 0, err
+	}
+	return fromProtoValues(resp.GetValues()), resp.GetRevision(), nil
+}
+
+// WatchValues calls the WatchValues method.
+func (c *grpcClient) WatchValues(ctx context.Context, prefix string, revision int64) (UpdateStream, error) {
+	stream, err := c.client.WatchValues(ctx, &configpb.WatchValuesRequest{Prefix: prefix, Revision: revision})
+	if err != nil {
+		return nil, err
+	}
+	return &grpcStream{stream: stream}, nil
+}
+
+// grpcStream adapts the generated update stream to UpdateStream.
+type grpcStream struct {
+	stream configpb.ConfigService_WatchValuesClient
+}
+
+var _ UpdateStream = (*grpcStream)(nil)
+
+// Recv blocks until the next update is received.
+func (s *grpcStream) Recv() (*Update, error) {
+	resp, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return &Update{
+		Updated:  fromProtoValues(resp.GetUpdated()),
+		Deleted:  resp.GetDeleted(),
+		Revision: resp.GetRevision(),
+	}, nil
+}
+
+func fromProtoValues(values []*configpb.KeyValue) []KeyValue {
+	res := make([]KeyValue, 0, len(values))
+	for _, kv := range values {
+		res = append(res, KeyValue{Key: kv.GetKey(), Value: kv.GetValue()})
+	}
+	return res
+}
@@ -0,0 +1,117 @@
+package grpcconfig
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/osdrv/config"
+	"github.com/osdrv/config/grpcconfig/configpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testServer struct {
+	configpb.UnimplementedConfigServiceServer
+	values   []*configpb.KeyValue
+	revision int64
+	updates  chan *configpb.WatchValuesResponse
+	watches  chan *configpb.WatchValuesRequest
+}
+
+func (s *testServer) ListValues(_ context.Context, req *configpb.ListValuesRequest) (*configpb.ListValuesResponse, error) {
+	return &configpb.ListValuesResponse{Values: s.values, Revision: s.revision}, nil
+}
+
+func (s *testServer) WatchValues(req *configpb.WatchValuesRequest, stream configpb.ConfigService_WatchValuesServer) error {
+	s.watches <- req
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case update := <-s.updates:
+			if err := stream.Send(update); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	server := &testServer{
+		values: []*configpb.KeyValue{
+			{Key: "db.host", Value: "localhost"},
+			{Key: "db.user", Value: "admin"},
+		},
+		revision: 7,
+		updates:  make(chan *configpb.WatchValuesResponse),
+		watches:  make(chan *configpb.WatchValuesRequest, 1),
+	}
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	configpb.RegisterConfigServiceServer(srv, server)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial the test server: %s", err)
+	}
+	defer conn.Close()
+
+	repo := config.NewRepository()
+	prov, err := NewProvider(repo, 10, NewClient(conn), &Options{Prefix: "db", Watch: true})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	if v, _ := repo.Get(config.NewKey("db.host")); v != "localhost" {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", v, "localhost")
+	}
+	select {
+	case req := <-server.watches:
+		if req.GetPrefix() != "db" || req.GetRevision() != 7 {
+			t.Fatalf("Unexpected watch request: got: prefix %q, revision %d, want: prefix %q, revision %d",
+				req.GetPrefix(), req.GetRevision(), "db", 7)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for a watch request")
+	}
+
+	events := make(chan *config.ChangeEvent, 1)
+	repo.Subscribe(func(e *config.ChangeEvent) {
+		events <- e
+	})
+	server.updates <- &configpb.WatchValuesResponse{
+		Updated:  []*configpb.KeyValue{{Key: "db.host", Value: "db.internal"}},
+		Deleted:  []string{"db.user"},
+		Revision: 8,
+	}
+	select {
+	case e := <-events:
+		want := []config.Change{
+			{Key: config.NewKey("db.host"), Old: "localhost", New: "db.internal"},
+			{Key: config.NewKey("db.user"), Old: "admin", New: nil},
+		}
+		if !reflect.DeepEqual(e.Changes, want) {
+			t.Fatalf("Unexpected changes: got: %#v, want: %#v", e.Changes, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for a change event")
+	}
+	if rev := prov.Revision(); rev != 8 {
+		t.Fatalf("Unexpected revision: got: %d, want: %d", rev, 8)
+	}
+}
@@ -0,0 +1,184 @@
+// The config service protocol consumed by grpcconfig.Provider.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: config.proto
+
+package configpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ConfigService_ListValues_FullMethodName  = "/osdrv.config.v1.ConfigService/ListValues"
+	ConfigService_WatchValues_FullMethodName = "/osdrv.config.v1.ConfigService/WatchValues"
+)
+
+// ConfigServiceClient is the client API for ConfigService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ConfigServiceClient interface {
+	// ListValues returns all values with keys starting with the prefix along
+	// with the current revision.
+	ListValues(ctx context.Context, in *ListValuesRequest, opts ...grpc.CallOption) (*ListValuesResponse, error)
+	// WatchValues streams all changes made after the revision to the values
+	// with keys starting with the prefix.
+	WatchValues(ctx context.Context, in *WatchValuesRequest, opts ...grpc.CallOption) (ConfigService_WatchValuesClient, error)
+}
+
+type configServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConfigServiceClient(cc grpc.ClientConnInterface) ConfigServiceClient {
+	return &configServiceClient{cc}
+}
+
+func (c *configServiceClient) ListValues(ctx context.Context, in *ListValuesRequest, opts ...grpc.CallOption) (*ListValuesResponse, error) {
+	out := new(ListValuesResponse)
+	err := c.cc.Invoke(ctx, ConfigService_ListValues_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configServiceClient) WatchValues(ctx context.Context, in *WatchValuesRequest, opts ...grpc.CallOption) (ConfigService_WatchValuesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ConfigService_ServiceDesc.Streams[0], ConfigService_WatchValues_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &configServiceWatchValuesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ConfigService_WatchValuesClient interface {
+	Recv() (*WatchValuesResponse, error)
+	grpc.ClientStream
+}
+
+type configServiceWatchValuesClient struct {
+	grpc.ClientStream
+}
+
+func (x *configServiceWatchValuesClient) Recv() (*WatchValuesResponse, error) {
+	m := new(WatchValuesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ConfigServiceServer is the server API for ConfigService service.
+// All implementations must embed UnimplementedConfigServiceServer
+// for forward compatibility
+type ConfigServiceServer interface {
+	// ListValues returns all values with keys starting with the prefix along
+	// with the current revision.
+	ListValues(context.Context, *ListValuesRequest) (*ListValuesResponse, error)
+	// WatchValues streams all changes made after the revision to the values
+	// with keys starting with the prefix.
+	WatchValues(*WatchValuesRequest, ConfigService_WatchValuesServer) error
+	mustEmbedUnimplementedConfigServiceServer()
+}
+
+// UnimplementedConfigServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedConfigServiceServer struct {
+}
+
+func (UnimplementedConfigServiceServer) ListValues(context.Context, *ListValuesRequest) (*ListValuesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListValues not implemented")
+}
+func (UnimplementedConfigServiceServer) WatchValues(*WatchValuesRequest, ConfigService_WatchValuesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchValues not implemented")
+}
+func (UnimplementedConfigServiceServer) mustEmbedUnimplementedConfigServiceServer() {}
+
+// UnsafeConfigServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConfigServiceServer will
+// result in compilation errors.
+type UnsafeConfigServiceServer interface {
+	mustEmbedUnimplementedConfigServiceServer()
+}
+
+func RegisterConfigServiceServer(s grpc.ServiceRegistrar, srv ConfigServiceServer) {
+	s.RegisterService(&ConfigService_ServiceDesc, srv)
+}
+
+func _ConfigService_ListValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListValuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigServiceServer).ListValues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConfigService_ListValues_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigServiceServer).ListValues(ctx, req.(*ListValuesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConfigService_WatchValues_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchValuesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConfigServiceServer).WatchValues(m, &configServiceWatchValuesServer{stream})
+}
+
+type ConfigService_WatchValuesServer interface {
+	Send(*WatchValuesResponse) error
+	grpc.ServerStream
+}
+
+type configServiceWatchValuesServer struct {
+	grpc.ServerStream
+}
+
+func (x *configServiceWatchValuesServer) Send(m *WatchValuesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ConfigService_ServiceDesc is the grpc.ServiceDesc for ConfigService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConfigService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "osdrv.config.v1.ConfigService",
+	HandlerType: (*ConfigServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListValues",
+			Handler:    _ConfigService_ListValues_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchValues",
+			Handler:       _ConfigService_WatchValues_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "config.proto",
+}
@@ -0,0 +1,269 @@
+// Package grpcconfig provides a config provider consuming a central config
+// service over gRPC.
+//
+// The service protocol is defined in config.proto, the generated code lives
+// in the configpb package. The provider talks to the service through the
+// minimal Client interface: NewClient adapts a gRPC connection to it.
+//
+// The package is a separate module so that gRPC is only pulled in by the
+// applications using it.
+package grpcconfig
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/osdrv/config"
+)
+
+const (
+	// DefaultRetryInterval is the default delay before re-establishing a
+	// broken watch stream.
+	DefaultRetryInterval = time.Second
+)
+
+// KeyValue mirrors the KeyValue protocol message.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Update mirrors the WatchValuesResponse protocol message.
+type Update struct {
+	Updated  []KeyValue
+	Deleted  []string
+	Revision int64
+}
+
+// UpdateStream is a stream of WatchValues updates.
+type UpdateStream interface {
+	// Recv blocks until the next update is received. Returns an error once
+	// the stream is broken or the watch context is cancelled.
+	Recv() (*Update, error)
+}
+
+// Client is the config service API used by the provider.
+type Client interface {
+	// ListValues calls the ListValues method.
+	ListValues(ctx context.Context, prefix string) (values []KeyValue, revision int64, err error)
+	// WatchValues calls the WatchValues method.
+	WatchValues(ctx context.Context, prefix string, revision int64) (UpdateStream, error)
+}
+
+// Options is a set of Provider settings.
+type Options struct {
+	// Prefix limits the served values to keys starting with the prefix.
+	Prefix string
+	// Watch enables streaming updates.
+	Watch bool
+	// RetryInterval is the delay before re-establishing a broken watch
+	// stream. DefaultRetryInterval is used if not set.
+	RetryInterval time.Duration
+	// CacheFile is the location of a local cache file. If set, the values
+	// are persisted to it on every successful fetch or applied update and
+	// are served from it if the config service is unavailable at set up
+	// (see config.CacheFile).
+	CacheFile string
+}
+
+// Provider serves values from a central config service. In watch mode,
+// streamed updates are applied as reloads (see `config.Repository.ApplyReload`):
+// an update rejected by the schema validation is discarded.
+type Provider struct {
+	weight   int
+	client   Client
+	options  *Options
+	registry map[string]config.Value
+	cache    config.CacheFile
+	revision int64
+	repo     *config.Repository
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mx       sync.RWMutex
+}
+
+var _ config.Provider = (*Provider)(nil)
+var _ config.ContextSetUpper = (*Provider)(nil)
+
+// NewProvider is the constructor for Provider. A nil options argument is
+// equivalent to the default options.
+func NewProvider(repo *config.Repository, weight int, client Client, options *Options) (*Provider, error) {
+	if client == nil {
+		return nil, fmt.Errorf("Config service client is not set")
+	}
+	if options == nil {
+		options = &Options{}
+	}
+	prov := &Provider{
+		weight:   weight,
+		client:   client,
+		options:  options,
+		registry: make(map[string]config.Value),
+		cache:    config.CacheFile{Path: options.CacheFile, Source: "config service"},
+	}
+	repo.RegisterProvider(prov)
+	return prov, nil
+}
+
+// Name returns provider name: grpc
+func (p *Provider) Name() string { return "grpc" }
+
+// Depends returns the list of provider dependencies: none
+func (p *Provider) Depends() []string { return []string{} }
+
+// Weight returns the provider weight
+func (p *Provider) Weight() int { return p.weight }
+
+// SetUp loads the values using a background context.
+func (p *Provider) SetUp(repo *config.Repository) error {
+	return p.SetUpContext(context.Background(), repo)
+}
+
+// SetUpContext loads the values and registers the keys in the repo. In watch
+// mode, a background routine consuming the update stream is started. If the
+// service is unavailable and CacheFile is set, the cached values are served:
+// the watch stream is established from scratch once the service is back.
+func (p *Provider) SetUpContext(ctx context.Context, repo *config.Repository) error {
+	p.repo = repo
+	ctx, span := repo.StartProviderSpan(ctx, "config.grpc.ListValues", p)
+	values, revision, err := p.client.ListValues(ctx, p.options.Prefix)
+	var registry map[string]config.Value
+	if err != nil {
+		err = fmt.Errorf("Failed to list config service values: %s", err)
+		span.RecordError(err)
+		span.End()
+		var ok bool
+		if registry, ok = p.cache.Load(p.repo, err); !ok {
+			return err
+		}
+	} else {
+		span.End()
+		registry = make(map[string]config.Value, len(values))
+		for _, kv := range values {
+			registry[kv.Key] = kv.Value
+		}
+		p.cache.Store(p.repo, registry)
+	}
+	p.mx.Lock()
+	p.registry = registry
+	p.revision = revision
+	p.mx.Unlock()
+	for k := range registry {
+		if err := repo.RegisterKey(config.NewKey(k), p); err != nil {
+			return err
+		}
+	}
+	if p.options.Watch {
+		watchCtx, cancel := context.WithCancel(context.Background())
+		p.cancel = cancel
+		p.wg.Add(1)
+		go p.watch(watchCtx)
+	}
+	return nil
+}
+
+// TearDown stops the update stream consumption (if any).
+func (p *Provider) TearDown(*config.Repository) error {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+	return nil
+}
+
+// Get returns the value for the key
+func (p *Provider) Get(key config.Key) (*config.KeyValue, bool) {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+	if v, ok := p.registry[key.String()]; ok {
+		return &config.KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
+
+// Revision returns the revision of the last applied update.
+func (p *Provider) Revision() int64 {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+	return p.revision
+}
+
+func (p *Provider) watch(ctx context.Context) {
+	defer p.wg.Done()
+	retry := p.options.RetryInterval
+	if retry <= 0 {
+		retry = DefaultRetryInterval
+	}
+	for {
+		if err := p.consume(ctx); err != nil && ctx.Err() == nil {
+			p.repo.Logger().Errorf("Config service watch stream is broken: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+// consume reads the update stream until it breaks. The stream is resumed from
+// the last applied revision.
+func (p *Provider) consume(ctx context.Context) error {
+	stream, err := p.client.WatchValues(ctx, p.options.Prefix, p.Revision())
+	if err != nil {
+		return err
+	}
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if err := p.apply(update); err != nil {
+			p.repo.Logger().Errorf("Failed to apply config service update at revision %d: %s",
+				update.Revision, err)
+		}
+	}
+}
+
+// apply applies the update atomically. A rejected update is skipped: the
+// revision is advanced anyway, so the update is not re-delivered.
+func (p *Provider) apply(update *Update) (err error) {
+	p.mx.RLock()
+	prevRegistry := p.registry
+	p.mx.RUnlock()
+	registry := make(map[string]config.Value, len(prevRegistry)+len(update.Updated))
+	for k, v := range prevRegistry {
+		registry[k] = v
+	}
+	for _, kv := range update.Updated {
+		registry[kv.Key] = kv.Value
+	}
+	for _, k := range update.Deleted {
+		delete(registry, k)
+	}
+	defer func() {
+		p.mx.Lock()
+		p.revision = update.Revision
+		p.mx.Unlock()
+		if err == nil {
+			p.cache.Store(p.repo, registry)
+		}
+	}()
+	return p.repo.ApplyReload(p, func() error {
+		p.mx.Lock()
+		p.registry = registry
+		p.mx.Unlock()
+		for _, kv := range update.Updated {
+			if err := p.repo.RegisterKey(config.NewKey(kv.Key), p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func() {
+		p.mx.Lock()
+		p.registry = prevRegistry
+		p.mx.Unlock()
+	})
+}
@@ -0,0 +1,190 @@
+package grpcconfig
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osdrv/config"
+)
+
+type testStream struct {
+	ctx     context.Context
+	updates chan *Update
+}
+
+func (s *testStream) Recv() (*Update, error) {
+	select {
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	case u, ok := <-s.updates:
+		if !ok {
+			return nil, fmt.Errorf("stream is closed")
+		}
+		return u, nil
+	}
+}
+
+type testClient struct {
+	values    []KeyValue
+	revision  int64
+	updates   chan *Update
+	watchedAt []int64
+	// err is returned by ListValues if set
+	err error
+	mx  sync.Mutex
+}
+
+var _ Client = (*testClient)(nil)
+
+func (c *testClient) ListValues(_ context.Context, prefix string) ([]KeyValue, int64, error) {
+	if c.err != nil {
+		return nil, 0, c.err
+	}
+	return c.values, c.revision, nil
+}
+
+func (c *testClient) WatchValues(ctx context.Context, prefix string, revision int64) (UpdateStream, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.watchedAt = append(c.watchedAt, revision)
+	return &testStream{ctx: ctx, updates: c.updates}, nil
+}
+
+func TestProviderWatch(t *testing.T) {
+	client := &testClient{
+		values: []KeyValue{
+			{"db.host", "localhost"},
+			{"db.user", "admin"},
+		},
+		revision: 7,
+		updates:  make(chan *Update),
+	}
+	repo := config.NewRepository()
+	prov, err := NewProvider(repo, 10, client, &Options{Watch: true})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	if v, _ := repo.Get(config.NewKey("db.host")); v != "localhost" {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", v, "localhost")
+	}
+
+	events := make(chan *config.ChangeEvent, 1)
+	repo.Subscribe(func(e *config.ChangeEvent) {
+		events <- e
+	})
+	client.updates <- &Update{
+		Updated:  []KeyValue{{"db.host", "db.internal"}},
+		Deleted:  []string{"db.user"},
+		Revision: 8,
+	}
+
+	select {
+	case e := <-events:
+		want := []config.Change{
+			{Key: config.NewKey("db.host"), Old: "localhost", New: "db.internal"},
+			{Key: config.NewKey("db.user"), Old: "admin", New: nil},
+		}
+		if !reflect.DeepEqual(e.Changes, want) {
+			t.Fatalf("Unexpected changes: got: %#v, want: %#v", e.Changes, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for a change event")
+	}
+	if rev := prov.Revision(); rev != 8 {
+		t.Fatalf("Unexpected revision: got: %d, want: %d", rev, 8)
+	}
+	client.mx.Lock()
+	defer client.mx.Unlock()
+	if !reflect.DeepEqual(client.watchedAt, []int64{7}) {
+		t.Fatalf("Unexpected watch revisions: got: %v, want: %v", client.watchedAt, []int64{7})
+	}
+}
+
+func TestProviderWatchResume(t *testing.T) {
+	client := &testClient{
+		values:   []KeyValue{{"foo", "bar"}},
+		revision: 1,
+		updates:  make(chan *Update, 1),
+	}
+	repo := config.NewRepository()
+	if _, err := NewProvider(repo, 10, client, &Options{
+		Watch:         true,
+		RetryInterval: time.Millisecond,
+	}); err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	client.updates <- &Update{Updated: []KeyValue{{"foo", "baz"}}, Revision: 2}
+	// Breaks the stream: the provider is expected to resume from revision 2
+	close(client.updates)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		client.mx.Lock()
+		watchedAt := append([]int64{}, client.watchedAt...)
+		client.mx.Unlock()
+		if len(watchedAt) >= 2 {
+			if watchedAt[1] != 2 {
+				t.Fatalf("Unexpected resume revision: got: %d, want: %d", watchedAt[1], 2)
+			}
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for the watch stream to resume")
+}
+
+func TestProviderCacheFile(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "grpc.yaml")
+	client := &testClient{
+		values:   []KeyValue{{"db.host", "localhost"}},
+		revision: 1,
+		updates:  make(chan *Update),
+	}
+	repo := config.NewRepository()
+	if _, err := NewProvider(repo, 10, client, &Options{Watch: true, CacheFile: cacheFile}); err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	events := make(chan *config.ChangeEvent, 1)
+	repo.Subscribe(func(e *config.ChangeEvent) {
+		events <- e
+	})
+	// Applied updates are cached as well
+	client.updates <- &Update{Updated: []KeyValue{{"db.user", "admin"}}, Revision: 2}
+	select {
+	case <-events:
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for a change event")
+	}
+	repo.TearDown()
+
+	repo = config.NewRepository()
+	prov, err := NewProvider(repo, 10, &testClient{err: fmt.Errorf("unavailable")}, &Options{CacheFile: cacheFile})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	want := map[string]config.Value{"db.host": "localhost", "db.user": "admin"}
+	if !reflect.DeepEqual(prov.registry, want) {
+		t.Fatalf("Unexpected registry: got: %#v, want: %#v", prov.registry, want)
+	}
+}
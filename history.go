@@ -0,0 +1,194 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// HistoryName is the name of the provider serving the rolled back values
+// (see Repository.Rollback).
+const HistoryName = "history"
+
+// Snapshot is a copy of the effective config taken once the repository is set
+// up and after every applied reload. See RepositoryOptions.HistorySize.
+type Snapshot struct {
+	// Version is the snapshot sequence number, starting with 1
+	Version int
+	Time    time.Time
+	// Provider is the name of the provider which reload triggered the
+	// snapshot. It is empty for the snapshot taken on set up.
+	Provider string
+	// Changes are the effective value changes introduced by the reload
+	Changes []Change
+	// Values maps the keys to the effective raw values: these are neither
+	// resolved nor mapped by the schema.
+	Values map[string]Value
+}
+
+// history is the provider serving the values of a rolled back snapshot. The
+// provider has the highest possible weight: the rolled back values take
+// precedence over the ones served by other providers.
+type history struct {
+	registry map[string]Value
+	mx       sync.RWMutex
+}
+
+var _ Provider = (*history)(nil)
+
+func (h *history) Name() string               { return HistoryName }
+func (h *history) Depends() []string          { return []string{} }
+func (h *history) Weight() int                { return math.MaxInt32 }
+func (h *history) SetUp(*Repository) error    { return nil }
+func (h *history) TearDown(*Repository) error { return nil }
+
+func (h *history) Get(key Key) (*KeyValue, bool) {
+	h.mx.RLock()
+	defer h.mx.RUnlock()
+	if v, ok := h.registry[key.String()]; ok {
+		return &KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
+
+func (h *history) swap(registry map[string]Value) map[string]Value {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	prev := h.registry
+	h.registry = registry
+	return prev
+}
+
+// History returns the effective config snapshots, the oldest first. The
+// history is only kept if RepositoryOptions.HistorySize is set.
+// This method is thread safe.
+func (repo *Repository) History() []*Snapshot {
+	repo.historyMx.Lock()
+	defer repo.historyMx.Unlock()
+	res := make([]*Snapshot, len(repo.snapshots))
+	copy(res, repo.snapshots)
+	return res
+}
+
+// recordSnapshot appends a snapshot of the effective config to the history.
+// Reloads only get recorded once the repository is set up.
+func (repo *Repository) recordSnapshot(cs ChangeSet, setUp bool) {
+	size := repo.options.HistorySize
+	if size <= 0 {
+		return
+	}
+	repo.historyMx.Lock()
+	defer repo.historyMx.Unlock()
+	if !setUp && repo.version == 0 {
+		return
+	}
+	repo.version++
+	repo.snapshots = append(repo.snapshots, &Snapshot{
+		Version:  repo.version,
+		Time:     timeNow(),
+		Provider: cs.Provider,
+		Changes:  cs.Changes,
+		Values:   repo.rawValues(),
+	})
+	if len(repo.snapshots) > size {
+		repo.snapshots = repo.snapshots[len(repo.snapshots)-size:]
+	}
+}
+
+// rawValues returns a flat map of the effective raw values.
+func (repo *Repository) rawValues() map[string]Value {
+	repo.mx.Lock()
+	keys := repo.root.keys(nil)
+	repo.mx.Unlock()
+	repo.viewMx.RLock()
+	defer repo.viewMx.RUnlock()
+	res := make(map[string]Value, len(keys))
+	for _, key := range keys {
+		if v, _, ok := repo.root.find(key).rawValue(repo, key, key); ok {
+			res[key.String()] = v
+		}
+	}
+	return res
+}
+
+// Rollback makes the repository serve the values of the snapshot with the
+// given version (see History). The rollback is applied as a reload (see
+// ApplyReload): it is validated against the schema and the subscribers are
+// notified. The rolled back values take precedence over the values served
+// by providers until ClearRollback is called. Keys registered after the
+// snapshot was taken keep being served.
+// Returns an error if the snapshot is no longer in the history.
+// This method is thread safe.
+func (repo *Repository) Rollback(version int) error {
+	return repo.RollbackAs("", version)
+}
+
+// RollbackAs is Rollback performed on behalf of the actor: every rolled back
+// key is authorized (see RepositoryOptions.Authorizer) and the mutation is
+// logged along with the actor.
+// This method is thread safe.
+func (repo *Repository) RollbackAs(actor string, version int) error {
+	var snap *Snapshot
+	for _, s := range repo.History() {
+		if s.Version == version {
+			snap = s
+			break
+		}
+	}
+	if snap == nil {
+		return fmt.Errorf("Config snapshot %d is not in the history", version)
+	}
+	return repo.applyHistory(actor, OpRollback, snap.Values)
+}
+
+// ClearRollback stops serving the rolled back values: the repository serves
+// the values of the providers again.
+// This method is thread safe.
+func (repo *Repository) ClearRollback() error {
+	return repo.ClearRollbackAs("")
+}
+
+// ClearRollbackAs is ClearRollback performed on behalf of the actor (see
+// RollbackAs).
+// This method is thread safe.
+func (repo *Repository) ClearRollbackAs(actor string) error {
+	return repo.applyHistory(actor, OpClearRollback, make(map[string]Value))
+}
+
+func (repo *Repository) applyHistory(actor string, op MutationOp, values map[string]Value) (err error) {
+	repo.mx.Lock()
+	if repo.rollback == nil {
+		repo.rollback = &history{registry: make(map[string]Value)}
+	}
+	h := repo.rollback
+	repo.mx.Unlock()
+	h.mx.RLock()
+	changes := diffSnapshots(h.registry, values)
+	h.mx.RUnlock()
+	keys := make([]Key, 0, len(changes))
+	for _, change := range changes {
+		keys = append(keys, change.Key)
+	}
+	if err := repo.authorize(actor, keys, op); err != nil {
+		return err
+	}
+	var prev map[string]Value
+	defer func() {
+		if err == nil {
+			repo.logMutation(actor, keys, op)
+		}
+	}()
+	return repo.ApplyReload(h, func() error {
+		prev = h.swap(values)
+		for k := range values {
+			if err := repo.RegisterKey(NewKey(k), h); err != nil {
+				h.swap(prev)
+				return err
+			}
+		}
+		return nil
+	}, func() {
+		h.swap(prev)
+	})
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	repo := NewRepositoryWithOptions(&RepositoryOptions{HistorySize: 2})
+	prov := &reloadTestProv{registry: map[string]Value{"port": 8080}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	for _, port := range []int{8081, 8082} {
+		if err := prov.reload(repo, map[string]Value{"port": port, "host": "localhost"}); err != nil {
+			t.Fatalf("Unexpected reload error: %s", err)
+		}
+	}
+
+	want := []*Snapshot{
+		{
+			Version:  2,
+			Time:     now,
+			Provider: "reload",
+			Changes: []Change{
+				{Key: NewKey("host"), New: "localhost"},
+				{Key: NewKey("port"), Old: 8080, New: 8081},
+			},
+			Values: map[string]Value{"port": 8081, "host": "localhost"},
+		},
+		{
+			Version:  3,
+			Time:     now,
+			Provider: "reload",
+			Changes:  []Change{{Key: NewKey("port"), Old: 8081, New: 8082}},
+			Values:   map[string]Value{"port": 8082, "host": "localhost"},
+		},
+	}
+	if got := repo.History(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected history: got: %#v, want: %#v", got, want)
+	}
+
+	if err := repo.Rollback(1); err == nil {
+		t.Fatalf("Expected an error rolling back to an evicted snapshot")
+	}
+	if err := repo.Rollback(2); err != nil {
+		t.Fatalf("Unexpected rollback error: %s", err)
+	}
+	if got := MustInt(repo, "port"); got != 8081 {
+		t.Fatalf("Unexpected value after rollback: got: %d, want: %d", got, 8081)
+	}
+	// Rolled back values take precedence over reloads
+	if err := prov.reload(repo, map[string]Value{"port": 8083, "host": "localhost"}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if got := MustInt(repo, "port"); got != 8081 {
+		t.Fatalf("Unexpected value after reload: got: %d, want: %d", got, 8081)
+	}
+	if err := repo.ClearRollback(); err != nil {
+		t.Fatalf("Unexpected error clearing the rollback: %s", err)
+	}
+	if got := MustInt(repo, "port"); got != 8083 {
+		t.Fatalf("Unexpected value after clearing the rollback: got: %d, want: %d", got, 8083)
+	}
+	history := repo.History()
+	if got := history[len(history)-1]; got.Provider != HistoryName || got.Version != 5 {
+		t.Fatalf("Unexpected last snapshot: got: %#v", got)
+	}
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	repo := NewRepository()
+	prov := &reloadTestProv{registry: map[string]Value{"port": 8080}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if got := repo.History(); len(got) != 0 {
+		t.Fatalf("Unexpected history: got: %#v, want: empty", got)
+	}
+}
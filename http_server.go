@@ -0,0 +1,152 @@
+package config
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// connCtxKey is the request context key of the server connection.
+type connCtxKey struct{}
+
+// httpServerSettings is the decoded server config section.
+type httpServerSettings struct {
+	addr              string
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	maxHeaderBytes    int
+}
+
+func readHTTPServerSettings(repo Getter) (*httpServerSettings, error) {
+	s := &httpServerSettings{}
+	var err error
+	if s.addr, _, err = LookupStr(repo, "addr"); err != nil {
+		return nil, err
+	}
+	if s.readTimeout, err = lookupDuration(repo, "read_timeout"); err != nil {
+		return nil, err
+	}
+	if s.readHeaderTimeout, err = lookupDuration(repo, "read_header_timeout"); err != nil {
+		return nil, err
+	}
+	if s.writeTimeout, err = lookupDuration(repo, "write_timeout"); err != nil {
+		return nil, err
+	}
+	if s.idleTimeout, err = lookupDuration(repo, "idle_timeout"); err != nil {
+		return nil, err
+	}
+	if s.maxHeaderBytes, _, err = LookupInt(repo, "max_header_bytes"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func lookupDuration(repo Getter, key string) (time.Duration, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, err
+	}
+	d, ok := toDuration(v)
+	if !ok {
+		return 0, typeMismatch(key, v, "time.Duration")
+	}
+	return d, nil
+}
+
+// HTTPServer builds an *http.Server out of a conventional server config
+// section. The keys are looked up relative to the getter, e.g. a
+// `repo.Scope("server")` view:
+//   - addr: the listen address, e.g. `:8080`;
+//   - read_timeout, read_header_timeout, write_timeout, idle_timeout: the
+//     server timeouts, a time.Duration or a string like "30s";
+//   - max_header_bytes: the request header size limit.
+//
+// All keys are optional: the http.Server defaults apply. Returns an error if
+// a value is malformed.
+func HTTPServer(repo Getter, handler http.Handler) (*http.Server, error) {
+	s, err := readHTTPServerSettings(repo)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Server{
+		Addr:              s.addr,
+		Handler:           handler,
+		ReadTimeout:       s.readTimeout,
+		ReadHeaderTimeout: s.readHeaderTimeout,
+		WriteTimeout:      s.writeTimeout,
+		IdleTimeout:       s.idleTimeout,
+		MaxHeaderBytes:    s.maxHeaderBytes,
+	}, nil
+}
+
+// BindHTTPServer is a version of HTTPServer applying the read and write
+// timeout changes on the fly. http.Server fields can not be updated on a
+// running server, hence the timeouts are enforced per request: the handler
+// is wrapped in order to reset the connection deadlines before the request is
+// served. The connection is passed to the handler by the server ConnContext
+// hook: a server built out of the returned one must keep it. Changes of the
+// other settings require a server restart: they are logged as warnings and
+// ignored.
+func (repo *Repository) BindHTTPServer(prefix string, handler http.Handler) (*http.Server, error) {
+	scope := repo.Scope(prefix)
+	initial, err := readHTTPServerSettings(scope)
+	if err != nil {
+		return nil, err
+	}
+	var readTimeout, writeTimeout DurationVar
+	var mx sync.Mutex
+	last := initial
+	if err := repo.Bind(prefix, func() error {
+		s, err := readHTTPServerSettings(scope)
+		if err != nil {
+			return err
+		}
+		atomic.StoreInt64(&readTimeout.v, int64(s.readTimeout))
+		atomic.StoreInt64(&writeTimeout.v, int64(s.writeTimeout))
+		mx.Lock()
+		prev := last
+		last = s
+		mx.Unlock()
+		if s.addr != prev.addr || s.readHeaderTimeout != prev.readHeaderTimeout ||
+			s.idleTimeout != prev.idleTimeout || s.maxHeaderBytes != prev.maxHeaderBytes {
+			repo.Logger().Warnf("Config section %q changed: the HTTP server must be restarted to apply the changes", prefix)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &http.Server{
+		Addr: initial.addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if conn, ok := r.Context().Value(connCtxKey{}).(net.Conn); ok {
+				now := time.Now()
+				if d := readTimeout.Load(); d > 0 {
+					conn.SetReadDeadline(now.Add(d))
+				} else {
+					conn.SetReadDeadline(time.Time{})
+				}
+				if d := writeTimeout.Load(); d > 0 {
+					conn.SetWriteDeadline(now.Add(d))
+				} else {
+					conn.SetWriteDeadline(time.Time{})
+				}
+			}
+			handler.ServeHTTP(w, r)
+		}),
+		ConnContext: func(ctx context.Context, conn net.Conn) context.Context {
+			return context.WithValue(ctx, connCtxKey{}, conn)
+		},
+		// The initial timeouts protect the request header read, the
+		// handler takes over from there
+		ReadTimeout:       initial.readTimeout,
+		ReadHeaderTimeout: initial.readHeaderTimeout,
+		WriteTimeout:      initial.writeTimeout,
+		IdleTimeout:       initial.idleTimeout,
+		MaxHeaderBytes:    initial.maxHeaderBytes,
+	}, nil
+}
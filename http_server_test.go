@@ -0,0 +1,155 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]Value
+		want    httpServerSettings
+		wantErr bool
+	}{
+		{"empty section", map[string]Value{}, httpServerSettings{}, false},
+		{
+			"full section",
+			map[string]Value{
+				"server.addr":                ":8080",
+				"server.read_timeout":        "5s",
+				"server.read_header_timeout": time.Second,
+				"server.write_timeout":       "10s",
+				"server.idle_timeout":        "1m",
+				"server.max_header_bytes":    "4096",
+			},
+			httpServerSettings{
+				addr:              ":8080",
+				readTimeout:       5 * time.Second,
+				readHeaderTimeout: time.Second,
+				writeTimeout:      10 * time.Second,
+				idleTimeout:       time.Minute,
+				maxHeaderBytes:    4096,
+			},
+			false,
+		},
+		{"malformed timeout", map[string]Value{"server.read_timeout": "soon"}, httpServerSettings{}, true},
+		{"malformed header bytes", map[string]Value{"server.max_header_bytes": "4k"}, httpServerSettings{}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			NewDefaultProviderWithDefaults(repo, 0, testCase.values)
+			if err := repo.SetUp(); err != nil {
+				t.Fatalf("Failed to set up the repository: %s", err)
+			}
+			srv, err := HTTPServer(repo.Scope("server"), http.NotFoundHandler())
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := httpServerSettings{
+				addr:              srv.Addr,
+				readTimeout:       srv.ReadTimeout,
+				readHeaderTimeout: srv.ReadHeaderTimeout,
+				writeTimeout:      srv.WriteTimeout,
+				idleTimeout:       srv.IdleTimeout,
+				maxHeaderBytes:    srv.MaxHeaderBytes,
+			}
+			if got != testCase.want {
+				t.Fatalf("Unexpected server settings: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestBindHTTPServer(t *testing.T) {
+	repo := NewRepository()
+	logger := &testLogger{}
+	repo.SetLogger(logger)
+	prov := &reloadTestProv{registry: map[string]Value{
+		"server.addr":          ":8080",
+		"server.write_timeout": "1m",
+	}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	srv, err := repo.BindHTTPServer("server", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	if err != nil {
+		t.Fatalf("Failed to bind the HTTP server: %s", err)
+	}
+	ts := httptest.NewUnstartedServer(srv.Handler)
+	ts.Config.WriteTimeout = srv.WriteTimeout
+	ts.Config.ConnContext = srv.ConnContext
+	ts.Start()
+	defer ts.Close()
+
+	get := func() error {
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		return nil
+	}
+	if err := get(); err != nil {
+		t.Fatalf("Unexpected request error: %s", err)
+	}
+
+	if err := prov.reload(repo, map[string]Value{
+		"server.addr":          ":8080",
+		"server.write_timeout": "10ms",
+	}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if err := get(); err == nil {
+		t.Fatalf("Unexpected request success: want the write timeout to be hit")
+	}
+	warnings := func() []string {
+		var res []string
+		for _, msg := range logger.messages {
+			if strings.HasPrefix(msg, "WARN: ") {
+				res = append(res, msg)
+			}
+		}
+		return res
+	}
+	if got := warnings(); len(got) > 0 {
+		t.Fatalf("Unexpected warnings: %#v", got)
+	}
+
+	if err := prov.reload(repo, map[string]Value{
+		"server.addr":          ":9090",
+		"server.write_timeout": "1m",
+	}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if err := get(); err != nil {
+		t.Fatalf("Unexpected request error: %s", err)
+	}
+	if got := warnings(); len(got) != 1 || !strings.Contains(got[0], "must be restarted") {
+		t.Fatalf("Unexpected warnings: %#v", got)
+	}
+
+	// The restart warning is not repeated for the changes already reported
+	if err := prov.reload(repo, map[string]Value{
+		"server.addr":          ":9090",
+		"server.write_timeout": "2m",
+	}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if got := warnings(); len(got) != 1 {
+		t.Fatalf("Unexpected warnings: %#v", got)
+	}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// IntConverter converts integers of any width, integral floats and numeric
+// strings into an integer of a fixed width, e.g. uint16. Values out of the
+// target range are rejected instead of being truncated.
+type IntConverter struct {
+	kind reflect.Kind
+}
+
+var _ Converter = (*IntConverter)(nil)
+var _ Mapper = (*IntConverter)(nil)
+
+// Initialized instances of IntConverter for every integer width. They are
+// registered as the converters for the corresponding types (see
+// ConverterFor), ToInt being the one for int.
+//
+// Example:
+//
+//	repo.DefineSchema(map[string]config.Schema{
+//		"server.port": config.ToUint16,
+//	})
+var (
+	ToInt8   = &IntConverter{kind: reflect.Int8}
+	ToInt16  = &IntConverter{kind: reflect.Int16}
+	ToInt32  = &IntConverter{kind: reflect.Int32}
+	ToInt64  = &IntConverter{kind: reflect.Int64}
+	ToUint   = &IntConverter{kind: reflect.Uint}
+	ToUint8  = &IntConverter{kind: reflect.Uint8}
+	ToUint16 = &IntConverter{kind: reflect.Uint16}
+	ToUint32 = &IntConverter{kind: reflect.Uint32}
+	ToUint64 = &IntConverter{kind: reflect.Uint64}
+)
+
+var intKindTypes = map[reflect.Kind]reflect.Type{
+	reflect.Int:    reflect.TypeOf(int(0)),
+	reflect.Int8:   reflect.TypeOf(int8(0)),
+	reflect.Int16:  reflect.TypeOf(int16(0)),
+	reflect.Int32:  reflect.TypeOf(int32(0)),
+	reflect.Int64:  reflect.TypeOf(int64(0)),
+	reflect.Uint:   reflect.TypeOf(uint(0)),
+	reflect.Uint8:  reflect.TypeOf(uint8(0)),
+	reflect.Uint16: reflect.TypeOf(uint16(0)),
+	reflect.Uint32: reflect.TypeOf(uint32(0)),
+	reflect.Uint64: reflect.TypeOf(uint64(0)),
+}
+
+// intConverterFor returns the IntConverter of the integer kind. Returns nil
+// if the kind is not an integer one.
+func intConverterFor(kind reflect.Kind) *IntConverter {
+	if _, ok := intKindTypes[kind]; !ok {
+		return nil
+	}
+	return &IntConverter{kind: kind}
+}
+
+// Convert returns the integer value of the converter width and true if the
+// value is an integer number within the range.
+func (ic *IntConverter) Convert(kv *KeyValue) (*KeyValue, bool) {
+	mkv, err := ic.Map(kv)
+	return mkv, err == nil
+}
+
+// Map converts the value. Returns an error if the value is not an integer
+// number or if it overflows the converter width.
+func (ic *IntConverter) Map(kv *KeyValue) (*KeyValue, error) {
+	t := intKindTypes[ic.kind]
+	neg, abs, ok := toSignedMagnitude(kv.Value)
+	if !ok {
+		return nil, fmt.Errorf("Failed to convert %T value for key %q: want %s", kv.Value, kv.Key, t)
+	}
+	bits := uint(t.Bits())
+	unsigned := ic.kind >= reflect.Uint && ic.kind <= reflect.Uint64
+	var max uint64
+	switch {
+	case unsigned && neg && abs != 0:
+		return nil, fmt.Errorf("Failed to convert %T value for key %q: want %s, got a negative value", kv.Value, kv.Key, t)
+	case unsigned:
+		max = math.MaxUint64 >> (64 - bits)
+	case neg:
+		max = 1 << (bits - 1)
+	default:
+		max = 1<<(bits-1) - 1
+	}
+	if abs > max {
+		return nil, fmt.Errorf("Failed to convert %T value for key %q: want %s, the value overflows it", kv.Value, kv.Key, t)
+	}
+	res := reflect.New(t).Elem()
+	if unsigned {
+		res.SetUint(abs)
+	} else if neg {
+		res.SetInt(int64(-abs))
+	} else {
+		res.SetInt(int64(abs))
+	}
+	return &KeyValue{Key: kv.Key, Value: res.Interface()}, nil
+}
+
+// JSONSchema describes the accepted values: integer within the range
+func (ic *IntConverter) JSONSchema() map[string]interface{} {
+	res := jsonType("integer")
+	bits := uint(intKindTypes[ic.kind].Bits())
+	if ic.kind >= reflect.Uint && ic.kind <= reflect.Uint64 {
+		res["minimum"] = 0
+		if bits < 64 {
+			res["maximum"] = uint64(1)<<bits - 1
+		}
+	} else if bits < 64 {
+		res["minimum"] = -(int64(1) << (bits - 1))
+		res["maximum"] = int64(1)<<(bits-1) - 1
+	}
+	return res
+}
+
+// toSignedMagnitude splits an integer number into its sign and absolute
+// value. Integral floats and numeric strings are accepted. Returns false if
+// the value is not an integer number or does not fit 64 bits.
+func toSignedMagnitude(v Value) (bool, uint64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := rv.Int(); n < 0 {
+			return true, uint64(-(n + 1)) + 1, true
+		}
+		return false, uint64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return false, rv.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || math.Abs(f) >= 1<<64 {
+			return false, 0, false
+		}
+		return f < 0, uint64(math.Abs(f)), true
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		neg := strings.HasPrefix(s, "-")
+		if neg {
+			s = s[1:]
+		} else {
+			s = strings.TrimPrefix(s, "+")
+		}
+		abs, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return false, 0, false
+		}
+		return neg, abs, true
+	}
+	return false, 0, false
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIntConverter(t *testing.T) {
+	tests := []struct {
+		name    string
+		conv    *IntConverter
+		value   Value
+		want    Value
+		wantErr string
+	}{
+		{"int8", ToInt8, 127, int8(127), ""},
+		{"int8 min", ToInt8, -128, int8(-128), ""},
+		{"int8 overflow", ToInt8, 128, nil, "want int8, the value overflows"},
+		{"int8 underflow", ToInt8, "-129", nil, "want int8, the value overflows"},
+		{"int16 string", ToInt16, " -300 ", int16(-300), ""},
+		{"int32 float", ToInt32, 42.0, int32(42), ""},
+		{"int32 fraction", ToInt32, 4.2, nil, "want int32"},
+		{"int64 min", ToInt64, int64(math.MinInt64), int64(math.MinInt64), ""},
+		{"int64 uint overflow", ToInt64, uint64(math.MaxUint64), nil, "want int64, the value overflows"},
+		{"uint", ToUint, int64(7), uint(7), ""},
+		{"uint8 max", ToUint8, "255", uint8(255), ""},
+		{"uint16 overflow", ToUint16, 70000, nil, "want uint16, the value overflows"},
+		{"uint32 negative", ToUint32, -1, nil, "negative"},
+		{"uint32 negative zero", ToUint32, "-0", uint32(0), ""},
+		{"uint64 max", ToUint64, uint64(math.MaxUint64), uint64(math.MaxUint64), ""},
+		{"malformed string", ToUint64, "-+5", nil, "want uint64"},
+		{"not a number", ToInt16, true, nil, "want int16"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			kv, err := testCase.conv.Map(&KeyValue{Key: NewKey("n"), Value: testCase.value})
+			if len(testCase.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if kv.Value != testCase.want {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", kv.Value, testCase.want)
+			}
+		})
+	}
+}
+
+func TestIntConverterJSONSchema(t *testing.T) {
+	tests := []struct {
+		conv *IntConverter
+		want map[string]interface{}
+	}{
+		{ToInt8, map[string]interface{}{"type": "integer", "minimum": int64(-128), "maximum": int64(127)}},
+		{ToUint16, map[string]interface{}{"type": "integer", "minimum": 0, "maximum": uint64(65535)}},
+		{ToInt64, map[string]interface{}{"type": "integer"}},
+	}
+	for _, testCase := range tests {
+		if got := testCase.conv.JSONSchema(); !reflect.DeepEqual(got, testCase.want) {
+			t.Fatalf("Unexpected JSON schema: got: %#v, want: %#v", got, testCase.want)
+		}
+	}
+}
+
+func TestDecodeIntWidths(t *testing.T) {
+	type limits struct {
+		Port    uint16
+		Workers int8
+		Offset  int64
+	}
+	tests := []struct {
+		name    string
+		values  map[string]Value
+		want    limits
+		wantErr string
+	}{
+		{
+			name:   "within range",
+			values: map[string]Value{"limits.port": 8080, "limits.workers": "16", "limits.offset": -5},
+			want:   limits{Port: 8080, Workers: 16, Offset: -5},
+		},
+		{
+			name:    "overflow",
+			values:  map[string]Value{"limits.port": 70000},
+			wantErr: "want uint16, the value overflows",
+		},
+		{
+			name:    "negative unsigned",
+			values:  map[string]Value{"limits.port": -1},
+			wantErr: "negative",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			NewMapProvider(repo, 0, "yaml", testCase.values)
+			if err := repo.SetUp(); err != nil {
+				t.Fatalf("Failed to set up the repository: %s", err)
+			}
+			var got limits
+			_, err := Decode(repo, "limits", &got)
+			if len(testCase.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if got != testCase.want {
+				t.Fatalf("Unexpected decoded value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestIntConverterSecret(t *testing.T) {
+	tests := []struct {
+		name  string
+		value Value
+	}{
+		{"malformed", "hunter2-secret"},
+		{"overflow", "7000042"},
+		{"negative", "-4242"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			repo.DefineSchema(map[string]Schema{"db": map[string]Schema{"port": Secret(ToUint16)}})
+			repo.RegisterKey(NewKey("db.port"), NewTestProv(testCase.value, DefaultWeight))
+			err := repo.tryGet(NewKey("db.port"))
+			if err == nil {
+				t.Fatalf("Expected a mapper error, got nil")
+			}
+			raw := fmt.Sprint(testCase.value)
+			if strings.Contains(err.Error(), raw) {
+				t.Fatalf("Unexpected secret value %q in the error: %s", raw, err)
+			}
+			var cerr *ConversionError
+			if !errors.As(err, &cerr) || strings.Contains(cerr.Err.Error(), raw) {
+				t.Fatalf("Unexpected wrapped error: got: %v, want it to redact %q", cerr, raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const (
+	// JSONSchemaDraft is the JSON Schema dialect emitted by SchemaToJSONSchema.
+	JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+)
+
+// JSONSchemaDescriber is an optional interface for converters and mappers
+// describing the values they accept as a JSON Schema fragment. Converters and
+// mappers that do not implement it are described as accepting any value.
+type JSONSchemaDescriber interface {
+	JSONSchema() map[string]interface{}
+}
+
+// SchemaToJSONSchema emits a JSON Schema document describing the keys defined
+// in the schema and the types of values they accept. The document can be used
+// by editors and CI to validate config files before deploy.
+// Wildcard keys are described as additional properties of the parent object.
+// `__self__` mappers are ignored: a parent node is described by its children.
+func SchemaToJSONSchema(schema Schema) ([]byte, error) {
+	doc, err := describeSchema(NewKey(""), schema)
+	if err != nil {
+		return nil, err
+	}
+	doc["$schema"] = JSONSchemaDraft
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func describeSchema(key Key, schema Schema) (map[string]interface{}, error) {
+	if schema == nil {
+		return map[string]interface{}{}, nil
+	} else if d, ok := schema.(*Description); ok {
+		res, err := describeSchema(key, d.Subject)
+		if err != nil {
+			return nil, err
+		}
+		d.annotate(res)
+		return res, nil
+	} else if smap, ok := schema.(map[string]Schema); ok {
+		props := make(map[string]interface{})
+		required := make([]string, 0)
+		res := map[string]interface{}{"type": "object"}
+		for subKey, subSchema := range smap {
+			if subKey == "__self__" {
+				continue
+			}
+			path := NewKey(subKey)
+			if len(path) > 1 {
+				// Composite keys are described as nested objects
+				nested := map[string]Schema{path[len(path)-1]: subSchema}
+				for i := len(path) - 2; i > 0; i-- {
+					nested = map[string]Schema{path[i]: nested}
+				}
+				subKey, subSchema = path[0], nested
+			}
+			if subKey == "**" {
+				// Multi-level wildcards can not be expressed in JSON Schema
+				continue
+			}
+			sub, err := describeSchema(key.Child(subKey), subSchema)
+			if err != nil {
+				return nil, err
+			}
+			if subKey == "*" {
+				res["additionalProperties"] = sub
+				continue
+			}
+			props[subKey] = sub
+			if isRequired(subSchema) {
+				required = append(required, subKey)
+			}
+		}
+		if len(props) > 0 {
+			res["properties"] = props
+		}
+		if len(required) > 0 {
+			sort.Strings(required)
+			res["required"] = required
+		}
+		return res, nil
+	} else if descr, ok := schema.(JSONSchemaDescriber); ok {
+		return descr.JSONSchema(), nil
+	} else if _, ok := schema.(Mapper); ok {
+		return map[string]interface{}{}, nil
+	} else if _, ok := schema.(Converter); ok {
+		return map[string]interface{}{}, nil
+	} else if t, ok := schema.(reflect.Type); ok {
+		return NewTypeMapper(t).JSONSchema(), nil
+	}
+	return nil, fmt.Errorf("Unexpected schema definition type for key %q: %#v",
+		key.String(), schema)
+}
+
+// isRequired returns true if the schema definition is a required
+// description, possibly with a default value.
+func isRequired(schema Schema) bool {
+	if d, ok := schema.(*Defaulted); ok {
+		schema = d.Subject
+	}
+	d, ok := schema.(*Description)
+	return ok && d.Required
+}
+
+// JSONSchema describes the values accepted by the wrapped converter.
+func (cm *ConvMapper) JSONSchema() map[string]interface{} {
+	return describeConverter(cm.conv)
+}
+
+// JSONSchema describes the values accepted by the composition chain.
+// CompAnd chains are described by the first component: it defines the input.
+// Other strategies are described as a union of the component descriptions.
+func (cc *CompositeConverter) JSONSchema() map[string]interface{} {
+	switch cc.strategy {
+	case CompAnd:
+		if len(cc.converters) > 0 {
+			return describeConverter(cc.converters[0])
+		}
+	case CompFirst, CompOr, CompLast:
+		variants := make([]interface{}, 0, len(cc.converters))
+		for _, conv := range cc.converters {
+			descr := describeConverter(conv)
+			if len(descr) == 0 {
+				// One of the components accepts any value
+				return map[string]interface{}{}
+			}
+			variants = appendUnique(variants, flattenAnyOf(descr)...)
+		}
+		if len(variants) == 1 {
+			return variants[0].(map[string]interface{})
+		}
+		if len(variants) > 1 {
+			return map[string]interface{}{"anyOf": variants}
+		}
+	}
+	return map[string]interface{}{}
+}
+
+func describeConverter(conv Converter) map[string]interface{} {
+	if descr, ok := conv.(JSONSchemaDescriber); ok {
+		return descr.JSONSchema()
+	}
+	return map[string]interface{}{}
+}
+
+func flattenAnyOf(descr map[string]interface{}) []interface{} {
+	if anyOf, ok := descr["anyOf"].([]interface{}); ok && len(descr) == 1 {
+		return anyOf
+	}
+	return []interface{}{descr}
+}
+
+func appendUnique(list []interface{}, items ...interface{}) []interface{} {
+	for _, item := range items {
+		found := false
+		for _, existing := range list {
+			if reflect.DeepEqual(existing, item) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func jsonType(name string) map[string]interface{} {
+	return map[string]interface{}{"type": name}
+}
+
+// JSONSchema describes the accepted values: integer
+func (*IntPtrToIntConverter) JSONSchema() map[string]interface{} { return jsonType("integer") }
+
+// JSONSchema describes the accepted values: integer
+func (*IfIntConverter) JSONSchema() map[string]interface{} { return jsonType("integer") }
+
+// JSONSchema describes the accepted values: boolean
+func (*BoolPtrToBoolConverter) JSONSchema() map[string]interface{} { return jsonType("boolean") }
+
+// JSONSchema describes the accepted values: boolean
+func (*IfBoolConverter) JSONSchema() map[string]interface{} { return jsonType("boolean") }
+
+// JSONSchema describes the accepted values: string
+func (*StrPtrToStrConverter) JSONSchema() map[string]interface{} { return jsonType("string") }
+
+// JSONSchema describes the accepted values: string
+func (*IfStrConverter) JSONSchema() map[string]interface{} { return jsonType("string") }
+
+// JSONSchema describes the accepted values: integer
+func (*IntToStrConverter) JSONSchema() map[string]interface{} { return jsonType("integer") }
+
+// JSONSchema describes the accepted values: 0 or 1
+func (*IntToBoolConverter) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "enum": []interface{}{0, 1}}
+}
+
+// JSONSchema describes the accepted values: an integer string
+func (*StrToIntConverter) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "pattern": "^[+-]?[0-9]+$"}
+}
+
+// JSONSchema describes the accepted values: boolean string forms
+func (*StrToBoolConverter) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "string",
+		"enum": []interface{}{"true", "1", "y", "false", "0", "n"},
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaToJSONSchema(t *testing.T) {
+	schema := Schema(map[string]Schema{
+		"server": map[string]Schema{
+			"__self__": &systemcfgmapper{},
+			"port":     ToInt,
+			"host":     IfStr,
+		},
+		"plugins": map[string]Schema{
+			"*": map[string]Schema{
+				"enabled": ToBool,
+			},
+		},
+		"extra": Identity,
+	})
+
+	data, err := SchemaToJSONSchema(schema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Failed to decode JSON schema: %s", err)
+	}
+
+	wantJSON := `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "server": {
+      "type": "object",
+      "properties": {
+        "port": {
+          "anyOf": [
+            {"type": "integer"},
+            {"type": "string", "pattern": "^[+-]?[0-9]+$"}
+          ]
+        },
+        "host": {"type": "string"}
+      }
+    },
+    "plugins": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "object",
+        "properties": {
+          "enabled": {
+            "anyOf": [
+              {"type": "boolean"},
+              {"type": "string", "enum": ["true", "1", "y", "false", "0", "n"]},
+              {"type": "integer", "enum": [0, 1]}
+            ]
+          }
+        }
+      }
+    },
+    "extra": {}
+  }
+}`
+	var want map[string]interface{}
+	if err := json.Unmarshal([]byte(wantJSON), &want); err != nil {
+		t.Fatalf("Failed to decode expected JSON schema: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected JSON schema: got: %s", data)
+	}
+
+	if _, err := SchemaToJSONSchema(map[string]Schema{"foo": 42}); err == nil {
+		t.Fatalf("Expected an error for a malformed schema")
+	}
+}
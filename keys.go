@@ -0,0 +1,54 @@
+package config
+
+import "strings"
+
+// NewKey parses the string key using the repository key separator (see
+// `RepositoryOptions.KeySeparator`). In case-insensitive mode, the key is
+// folded to the lower case.
+func (repo *Repository) NewKey(str string) Key {
+	return repo.foldKey(NewKeyWithSeparator(str, repo.separator()))
+}
+
+// KeyString is the opposite to NewKey: it joins the key fragments using the
+// repository key separator.
+func (repo *Repository) KeyString(key Key) string {
+	return joinKey(key, repo.separator())
+}
+
+func (repo *Repository) separator() string {
+	if len(repo.options.KeySeparator) > 0 {
+		return repo.options.KeySeparator
+	}
+	return KeySepCh
+}
+
+// foldKey returns the lower case version of the key in case-insensitive mode
+// and the key as is otherwise.
+func (repo *Repository) foldKey(key Key) Key {
+	if !repo.options.CaseInsensitive {
+		return key
+	}
+	res := make(Key, len(key))
+	for i, k := range key {
+		res[i] = strings.ToLower(k)
+	}
+	return res
+}
+
+// foldSchema returns a copy of the schema with all keys folded to the lower
+// case.
+func foldSchema(s Schema) Schema {
+	sm, ok := s.(map[string]Schema)
+	if !ok {
+		return s
+	}
+	res := make(map[string]Schema, len(sm))
+	for k, v := range sm {
+		if k == "__self__" {
+			res[k] = v
+			continue
+		}
+		res[strings.ToLower(k)] = foldSchema(v)
+	}
+	return res
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeySeparator(t *testing.T) {
+	repo := NewRepositoryWithOptions(&RepositoryOptions{KeySeparator: "/"})
+	if _, err := NewMapProvider(repo, 10, "map", map[string]Value{
+		"server.port":     8080,
+		"server.tls.cert": "cert.pem",
+	}); err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	if got := MustInt(repo, "server/port"); got != 8080 {
+		t.Fatalf("Unexpected value: got: %d, want: %d", got, 8080)
+	}
+	if got := MustStr(repo.Scope("server/tls/"), "cert"); got != "cert.pem" {
+		t.Fatalf("Unexpected value: got: %q, want: %q", got, "cert.pem")
+	}
+	want := map[string]Value{
+		"server/port":     8080,
+		"server/tls/cert": "cert.pem",
+	}
+	if got := repo.Dump(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected dump: got: %#v, want: %#v", got, want)
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	repo := NewRepositoryWithOptions(&RepositoryOptions{CaseInsensitive: true})
+	if _, err := NewMapProvider(repo, 10, "yaml", map[string]Value{
+		"Server.Port":    "8080",
+		"Server.LogPath": "/var/log",
+	}); err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	if _, err := NewMapProvider(repo, 20, "env", map[string]Value{
+		"server.logpath": "/tmp",
+	}); err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	if err := repo.DefineSchema(map[string]Schema{
+		"Server": map[string]Schema{
+			"Port":    ToInt,
+			"LogPath": ToStr,
+		},
+	}); err != nil {
+		t.Fatalf("Failed to define the schema: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	tests := []struct {
+		key  string
+		want Value
+	}{
+		{"Server.Port", 8080},
+		{"server.port", 8080},
+		{"SERVER.LOGPATH", "/tmp"},
+		{"server", map[string]Value{"port": 8080, "logpath": "/tmp"}},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.key, func(t *testing.T) {
+			got, ok := repo.Get(NewKey(testCase.key))
+			if !ok {
+				t.Fatalf("Expected lookup for key %q to find a value, none returned", testCase.key)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LazyProvider defers the wrapped provider set up until the first lookup of a
+// key under one of the declared prefixes. It is meant for expensive providers
+// (e.g. remote ones) serving keys which might be never requested in a given
+// run mode. The set up is performed once: concurrent lookups wait for it to
+// finish. A lookup triggering the set up from within the set up itself (e.g.
+// the wrapped provider reads its own keys) does not wait: the error is
+// logged and the lookup proceeds without the wrapped provider.
+//
+//	vault, _ := NewVaultProvider(repo, 20)
+//	repo.RegisterProvider(config.Lazy(vault, "secrets."))
+//
+// Since the keys are unknown until the wrapped provider is set up, these are
+// omitted by `Dump` and `Explain` and ignored by the strict mode check until
+// then. If the deferred set up fails, the error is reported through the
+// repository logger and the provider status: the wrapped provider keys are
+// never served.
+type LazyProvider struct {
+	provider Provider
+	prefixes []Key
+	repo     *Repository
+	// done is closed once the set up is over, it is nil until the set up
+	// starts
+	done chan struct{}
+	// loader is the ID of the goroutine performing the set up
+	loader uint64
+	active bool
+	// collecting is set while the wrapped provider is being set up: its key
+	// registrations are postponed (see `collect`)
+	collecting bool
+	pending    []Key
+	err        error
+	mx         sync.RWMutex
+}
+
+var _ ProviderWrapper = (*LazyProvider)(nil)
+
+// Lazy returns a new LazyProvider wrapping the provider. Prefixes define the
+// keys triggering the set up: a lookup of a key under any of the prefixes or
+// a lookup of a parent key. The returned provider must be registered in the
+// repository explicitly.
+func Lazy(provider Provider, prefixes ...string) *LazyProvider {
+	keys := make([]Key, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		keys = append(keys, prefixKey(prefix))
+	}
+	return &LazyProvider{
+		provider: provider,
+		prefixes: keys,
+		pending:  make([]Key, 0),
+	}
+}
+
+// Name returns the wrapped provider name
+func (lp *LazyProvider) Name() string { return lp.provider.Name() }
+
+// Depends returns the wrapped provider dependencies
+func (lp *LazyProvider) Depends() []string { return lp.provider.Depends() }
+
+// Weight returns the wrapped provider weight
+func (lp *LazyProvider) Weight() int { return lp.provider.Weight() }
+
+// Unwrap returns the wrapped provider
+func (lp *LazyProvider) Unwrap() []Provider { return []Provider{lp.provider} }
+
+// WrapKey returns the key as is
+func (lp *LazyProvider) WrapKey(key Key) Key { return key }
+
+// SetUp registers the provider for a deferred set up
+func (lp *LazyProvider) SetUp(repo *Repository) error {
+	lp.repo = repo
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	repo.lazy = append(repo.lazy, lp)
+	return nil
+}
+
+// TearDown tears down the wrapped provider if it has been set up
+func (lp *LazyProvider) TearDown(repo *Repository) error {
+	if !lp.Active() {
+		return nil
+	}
+	return lp.provider.TearDown(repo)
+}
+
+// Get returns the wrapped provider value if it has been set up
+func (lp *LazyProvider) Get(key Key) (*KeyValue, bool) {
+	if !lp.Active() {
+		return nil, false
+	}
+	return lp.provider.Get(key)
+}
+
+// Active returns true once the wrapped provider has been successfully set up
+func (lp *LazyProvider) Active() bool {
+	lp.mx.RLock()
+	defer lp.mx.RUnlock()
+	return lp.active
+}
+
+// triggeredBy returns true if a lookup of the key requires the set up.
+func (lp *LazyProvider) triggeredBy(key Key) bool {
+	for _, prefix := range lp.prefixes {
+		if key.HasPrefix(prefix) || prefix.HasPrefix(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// collect postpones the key registration if the wrapped provider is being set
+// up. Returns false otherwise.
+func (lp *LazyProvider) collect(key Key) bool {
+	lp.mx.Lock()
+	defer lp.mx.Unlock()
+	if !lp.collecting {
+		return false
+	}
+	lp.pending = append(lp.pending, key)
+	return true
+}
+
+// activate sets up the wrapped provider. The provider keys are registered
+// atomically once the set up succeeds. Is a no-op after the first call.
+// Returns an error if called from within the set up.
+func (lp *LazyProvider) activate() error {
+	lp.mx.Lock()
+	if done := lp.done; done != nil {
+		loader := lp.loader
+		lp.mx.Unlock()
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+		if loader == goroutineID() {
+			return fmt.Errorf("Lazy config provider %q set up looks up its own keys", lp.Name())
+		}
+		<-done
+		return nil
+	}
+	lp.done = make(chan struct{})
+	lp.loader = goroutineID()
+	lp.collecting = true
+	lp.mx.Unlock()
+	defer close(lp.done)
+
+	repo := lp.repo
+	repo.Logger().Infof("Setting up lazy config provider %q (weight: %d)", lp.Name(), lp.Weight())
+	started := time.Now()
+	err := repo.setUpProvider(context.Background(), lp.provider)
+	repo.Metrics().SetUpDuration(lp.Name(), time.Since(started))
+
+	lp.mx.Lock()
+	lp.collecting = false
+	pending := lp.pending
+	lp.pending = nil
+	lp.err = err
+	lp.mx.Unlock()
+
+	if err == nil {
+		repo.viewMx.Lock()
+		for _, key := range pending {
+			if err = repo.RegisterKey(key, lp); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			lp.mx.Lock()
+			lp.active = true
+			lp.mx.Unlock()
+		}
+		repo.viewMx.Unlock()
+	}
+	repo.updateStatus(repo.ownerOf(lp), func(st *ProviderStatus) {
+		st.LastError = err
+		if err != nil {
+			st.State = ProviderFailed
+			return
+		}
+		st.SetUpAt = time.Now()
+		st.LastRefresh = st.SetUpAt
+	})
+	if err != nil {
+		repo.Logger().Errorf("Failed to set up lazy config provider %q: %s", lp.Name(), err)
+	}
+	return nil
+}
+
+// goroutineID returns the ID of the current goroutine parsed out of the stack
+// trace header: "goroutine 42 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	fields := strings.Fields(string(buf[:n]))
+	if len(fields) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(fields[1], 10, 64)
+	return id
+}
+
+// activateLazy sets up the lazy providers triggered by a lookup of the key.
+func (repo *Repository) activateLazy(key Key) {
+	repo.mx.Lock()
+	if len(repo.lazy) == 0 {
+		repo.mx.Unlock()
+		return
+	}
+	triggered := make([]*LazyProvider, 0)
+	for _, lp := range repo.lazy {
+		if lp.triggeredBy(key) {
+			triggered = append(triggered, lp)
+		}
+	}
+	repo.mx.Unlock()
+	for _, lp := range triggered {
+		if err := lp.activate(); err != nil {
+			repo.Logger().Errorf("Failed to look up config key %q: %s", key, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingTestProv struct {
+	*MapProvider
+	setUps int32
+}
+
+func (ctp *countingTestProv) SetUp(repo *Repository) error {
+	atomic.AddInt32(&ctp.setUps, 1)
+	// Makes concurrent lookups overlap with the set up
+	time.Sleep(10 * time.Millisecond)
+	return ctp.MapProvider.SetUp(repo)
+}
+
+func TestLazyProvider(t *testing.T) {
+	repo := NewRepository()
+	if _, err := NewMapProvider(repo, 10, "map", map[string]Value{
+		"server.port": 8080,
+	}); err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	mp, _ := NewMapProvider(repo, 20, "vault", map[string]Value{
+		"secrets.db.password": "s3cr3t",
+	})
+	prov := &countingTestProv{MapProvider: mp}
+	repo.RegisterProvider(Lazy(prov, "secrets."))
+
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	if v, ok := repo.Get(NewKey("server.port")); !ok || v != 8080 {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", v, 8080)
+	}
+	if n := atomic.LoadInt32(&prov.setUps); n != 0 {
+		t.Fatalf("Unexpected number of set ups before the first lookup: got: %d, want: %d", n, 0)
+	}
+	if _, ok := repo.Dump()["secrets.db.password"]; ok {
+		t.Fatalf("Expected lazy keys to be omitted before the set up")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, ok := repo.Get(NewKey("secrets")); !ok {
+				t.Errorf("Expected lookup for key %q to find a value, none returned", "secrets")
+			} else if want := map[string]Value{"db": map[string]Value{"password": "s3cr3t"}}; !reflect.DeepEqual(v, want) {
+				t.Errorf("Unexpected value: got: %#v, want: %#v", v, want)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&prov.setUps); n != 1 {
+		t.Fatalf("Unexpected number of set ups: got: %d, want: %d", n, 1)
+	}
+}
+
+func TestLazyProviderFailure(t *testing.T) {
+	repo := NewRepository()
+	setUpErr := fmt.Errorf("connection refused")
+	repo.RegisterProvider(Lazy(&failingTestProv{TestProv: NewTestProv(1, 10), name: "vault", err: setUpErr}, "secrets"))
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if _, ok := repo.Get(NewKey("secrets.db.password")); ok {
+		t.Fatalf("Expected the lookup to fail")
+	}
+	statuses := repo.ProviderStatus()
+	if len(statuses) != 1 || statuses[0].State != ProviderFailed || statuses[0].LastError != setUpErr {
+		t.Fatalf("Unexpected provider statuses: %#v", statuses)
+	}
+}
+
+type reentrantTestProv struct {
+	*MapProvider
+}
+
+func (rtp *reentrantTestProv) SetUp(repo *Repository) error {
+	if err := rtp.MapProvider.SetUp(repo); err != nil {
+		return err
+	}
+	repo.Get(NewKey("secrets.token"))
+	return nil
+}
+
+func TestLazyProviderReentrantLookup(t *testing.T) {
+	repo := NewRepository()
+	logger := &testLogger{}
+	repo.SetLogger(logger)
+	mp, _ := NewMapProvider(repo, 20, "vault", map[string]Value{
+		"secrets.token": "t0k3n",
+	})
+	prov := &reentrantTestProv{MapProvider: mp}
+	repo.RegisterProvider(Lazy(prov, "secrets."))
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	done := make(chan Value)
+	go func() {
+		v, _ := repo.Get(NewKey("secrets.token"))
+		done <- v
+	}()
+	select {
+	case v := <-done:
+		if v != "t0k3n" {
+			t.Fatalf("Unexpected value: got: %#v, want: %#v", v, "t0k3n")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out: the re-entrant lookup deadlocked")
+	}
+	want := `ERROR: Failed to look up config key "secrets.token": Lazy config provider "vault" set up looks up its own keys`
+	found := false
+	for _, msg := range logger.messages {
+		found = found || msg == want
+	}
+	if !found {
+		t.Fatalf("Unexpected log messages: got: %#v, want to contain: %q", logger.messages, want)
+	}
+}
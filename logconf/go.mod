@@ -0,0 +1,14 @@
+module github.com/osdrv/config/logconf
+
+go 1.21
+
+require github.com/osdrv/config v0.0.0
+
+require (
+	github.com/kr/text v0.2.0 // indirect
+	go.opentelemetry.io/otel v1.0.1 // indirect
+	go.opentelemetry.io/otel/trace v1.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/osdrv/config => ../
@@ -0,0 +1,226 @@
+// Package logconf configures application loggers out of a conventional `log`
+// config section:
+//
+//	log:
+//	  level: info        # debug, info, warn or error
+//	  format: json       # text or json
+//	  output: stderr     # stdout, stderr or a file path
+//	  sampling:
+//	    initial: 100     # log the first 100 messages per second as is
+//	    thereafter: 10   # then every 10th one
+//
+// A logging library is plugged in by means of an Adapter. The package ships
+// with the log/slog adapter. Other libraries are expected to be wrapped in a
+// thin adapter, e.g. a zap adapter would build the logger out of
+// zap.NewProductionConfig() and change the level via zap.AtomicLevel, a
+// logrus adapter would call logrus.SetLevel.
+//
+// The log level is changed on the fly: the bridge subscribes to the level key
+// changes.
+package logconf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/osdrv/config"
+)
+
+const (
+	// FormatText is the human-readable log format.
+	FormatText = "text"
+	// FormatJSON is the JSON log format.
+	FormatJSON = "json"
+)
+
+var levels = map[string]config.LogLevel{
+	"debug":   config.LevelDebug,
+	"info":    config.LevelInfo,
+	"warn":    config.LevelWarn,
+	"warning": config.LevelWarn,
+	"error":   config.LevelError,
+}
+
+// Sampling limits the rate of repeated messages: the first Initial messages
+// with the same text are logged every second, then every Thereafter-th one.
+type Sampling struct {
+	Initial    int
+	Thereafter int
+}
+
+// Settings is the decoded log config section.
+type Settings struct {
+	Level  config.LogLevel
+	Format string
+	Output string
+	// Sampling is nil if sampling is disabled.
+	Sampling *Sampling
+}
+
+// Read decodes the log config section. The keys are looked up relative to
+// the getter, e.g. a `repo.Scope("log")` view. All keys are optional: the
+// defaults are info level, text format and stderr output. Returns an error if
+// a value is malformed.
+func Read(repo config.Getter) (*Settings, error) {
+	settings := &Settings{
+		Level:  config.LevelInfo,
+		Format: FormatText,
+		Output: "stderr",
+	}
+	if v, ok, err := config.LookupStr(repo, "level"); err != nil {
+		return nil, err
+	} else if ok {
+		level, err := parseLevel(v)
+		if err != nil {
+			return nil, err
+		}
+		settings.Level = level
+	}
+	if v, ok, err := config.LookupStr(repo, "format"); err != nil {
+		return nil, err
+	} else if ok {
+		switch format := strings.ToLower(v); format {
+		case FormatText, FormatJSON:
+			settings.Format = format
+		default:
+			return nil, fmt.Errorf("Unsupported log format %q", v)
+		}
+	}
+	if v, ok, err := config.LookupStr(repo, "output"); err != nil {
+		return nil, err
+	} else if ok && len(v) > 0 {
+		settings.Output = v
+	}
+	initial, initialOk, err := config.LookupInt(repo, "sampling.initial")
+	if err != nil {
+		return nil, err
+	}
+	thereafter, thereafterOk, err := config.LookupInt(repo, "sampling.thereafter")
+	if err != nil {
+		return nil, err
+	}
+	if initialOk || thereafterOk {
+		if initial < 0 || thereafter < 0 {
+			return nil, fmt.Errorf("Invalid log sampling settings: initial: %d, thereafter: %d", initial, thereafter)
+		}
+		settings.Sampling = &Sampling{Initial: initial, Thereafter: thereafter}
+	}
+	return settings, nil
+}
+
+func parseLevel(v string) (config.LogLevel, error) {
+	level, ok := levels[strings.ToLower(v)]
+	if !ok {
+		return 0, fmt.Errorf("Unsupported log level %q", v)
+	}
+	return level, nil
+}
+
+// Adapter configures a concrete logging library.
+type Adapter interface {
+	// Configure builds the logger. It is called once. The output is opened
+	// by the bridge.
+	Configure(settings *Settings, out io.Writer) error
+	// SetLevel changes the logger level on the fly. It might be called
+	// concurrently with logging.
+	SetLevel(level config.LogLevel) error
+}
+
+// Bridge keeps the adapter in sync with the log config section.
+type Bridge struct {
+	repo   *config.Repository
+	prefix string
+	cancel func()
+	closer io.Closer
+	once   sync.Once
+}
+
+// NewBridge reads the log config section under the prefix, configures the
+// adapter and subscribes to the level changes. A level that fails to parse on
+// a reload is logged and the previous level is kept. Other settings are only
+// read once. The bridge must be closed in order to cancel the subscription
+// and close the output file (if any).
+//
+// Example:
+//
+//	logs := logconf.NewSlog()
+//	bridge, err := logconf.NewBridge(repo, "log", logs)
+//	...
+//	defer bridge.Close()
+//	slog.SetDefault(logs.Logger())
+func NewBridge(repo *config.Repository, prefix string, adapter Adapter) (*Bridge, error) {
+	settings, err := Read(repo.Scope(prefix))
+	if err != nil {
+		return nil, err
+	}
+	out, closer, err := openOutput(settings.Output)
+	if err != nil {
+		return nil, err
+	}
+	if err := adapter.Configure(settings, out); err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, fmt.Errorf("Failed to configure the logger: %s", err)
+	}
+	b := &Bridge{repo: repo, prefix: prefix, closer: closer}
+	levelKey := repo.NewKey(prefix + ".level")
+	b.cancel = repo.SubscribePattern(levelKey, func(event *config.ChangeEvent) {
+		if event.Err != nil {
+			return
+		}
+		if err := b.updateLevel(adapter); err != nil {
+			repo.Logger().Errorf("Failed to update the log level: %s", err)
+		}
+	})
+	return b, nil
+}
+
+func (b *Bridge) updateLevel(adapter Adapter) error {
+	v, ok, err := config.LookupStr(b.repo, b.prefix+".level")
+	if err != nil {
+		return err
+	}
+	level := config.LevelInfo
+	if ok {
+		if level, err = parseLevel(v); err != nil {
+			return err
+		}
+	}
+	return adapter.SetLevel(level)
+}
+
+// Close cancels the level subscription and closes the output file (if any).
+// It is safe to call Close multiple times.
+func (b *Bridge) Close() error {
+	var err error
+	b.once.Do(func() {
+		b.cancel()
+		if b.closer != nil {
+			err = b.closer.Close()
+		}
+	})
+	return err
+}
+
+// Redefined in tests
+var openFile = func(path string) (io.WriteCloser, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+func openOutput(output string) (io.Writer, io.Closer, error) {
+	switch strings.ToLower(output) {
+	case "stdout":
+		return os.Stdout, nil, nil
+	case "stderr":
+		return os.Stderr, nil, nil
+	}
+	f, err := openFile(output)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to open the log output file: %s", err)
+	}
+	return f, f, nil
+}
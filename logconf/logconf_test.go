@@ -0,0 +1,225 @@
+package logconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osdrv/config"
+)
+
+type testProv struct {
+	registry map[string]config.Value
+	mx       sync.RWMutex
+}
+
+var _ config.Provider = (*testProv)(nil)
+
+func (p *testProv) Name() string      { return "test" }
+func (p *testProv) Depends() []string { return []string{} }
+func (p *testProv) Weight() int       { return 10 }
+
+func (p *testProv) SetUp(repo *config.Repository) error {
+	for k := range p.registry {
+		if err := repo.RegisterKey(config.NewKey(k), p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *testProv) TearDown(*config.Repository) error { return nil }
+
+func (p *testProv) Get(key config.Key) (*config.KeyValue, bool) {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+	if v, ok := p.registry[key.String()]; ok {
+		return &config.KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
+
+func (p *testProv) reload(repo *config.Repository, registry map[string]config.Value) error {
+	prev := p.registry
+	return repo.ApplyReload(p, func() error {
+		p.mx.Lock()
+		p.registry = registry
+		p.mx.Unlock()
+		return p.SetUp(repo)
+	}, func() {
+		p.mx.Lock()
+		p.registry = prev
+		p.mx.Unlock()
+	})
+}
+
+func newTestRepo(t *testing.T, values map[string]config.Value) (*config.Repository, *testProv) {
+	repo := config.NewRepository()
+	prov := &testProv{registry: values}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	return repo, prov
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]config.Value
+		want    *Settings
+		wantErr bool
+	}{
+		{
+			"defaults",
+			map[string]config.Value{},
+			&Settings{Level: config.LevelInfo, Format: FormatText, Output: "stderr"},
+			false,
+		},
+		{
+			"full section",
+			map[string]config.Value{
+				"log.level":               "WARNING",
+				"log.format":              "json",
+				"log.output":              "/var/log/app.log",
+				"log.sampling.initial":    100,
+				"log.sampling.thereafter": "10",
+			},
+			&Settings{
+				Level:    config.LevelWarn,
+				Format:   FormatJSON,
+				Output:   "/var/log/app.log",
+				Sampling: &Sampling{Initial: 100, Thereafter: 10},
+			},
+			false,
+		},
+		{"unknown level", map[string]config.Value{"log.level": "trace"}, nil, true},
+		{"unknown format", map[string]config.Value{"log.format": "xml"}, nil, true},
+		{"negative sampling", map[string]config.Value{"log.sampling.initial": -1}, nil, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo, _ := newTestRepo(t, testCase.values)
+			got, err := Read(repo.Scope("log"))
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected settings: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestBridgeSlog(t *testing.T) {
+	var buf bytes.Buffer
+	oldOpenFile := openFile
+	defer func() { openFile = oldOpenFile }()
+	openFile = func(path string) (io.WriteCloser, error) {
+		if path != "/var/log/app.log" {
+			t.Fatalf("Unexpected output path: %q", path)
+		}
+		return nopWriteCloser{&buf}, nil
+	}
+
+	repo, prov := newTestRepo(t, map[string]config.Value{
+		"log.level":  "warn",
+		"log.format": "json",
+		"log.output": "/var/log/app.log",
+	})
+	logs := NewSlog()
+	bridge, err := NewBridge(repo, "log", logs)
+	if err != nil {
+		t.Fatalf("Failed to create the bridge: %s", err)
+	}
+	defer bridge.Close()
+
+	logger := logs.Logger()
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	if err := prov.reload(repo, map[string]config.Value{
+		"log.level":  "debug",
+		"log.format": "json",
+		"log.output": "/var/log/app.log",
+	}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	logger.Debug("debug shown")
+
+	// A malformed level is ignored
+	if err := prov.reload(repo, map[string]config.Value{
+		"log.level":  "verbose",
+		"log.format": "json",
+		"log.output": "/var/log/app.log",
+	}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	logger.Debug("still shown")
+
+	bridge.Close()
+	if err := prov.reload(repo, map[string]config.Value{
+		"log.level":  "error",
+		"log.format": "json",
+		"log.output": "/var/log/app.log",
+	}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	logger.Debug("shown after close")
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to parse log line %q: %s", line, err)
+		}
+		got = append(got, record["msg"].(string))
+	}
+	want := []string{"shown", "debug shown", "still shown", "shown after close"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected log messages: got: %#v, want: %#v", got, want)
+	}
+}
+
+func TestSamplingHandler(t *testing.T) {
+	now := time.Unix(1000, 0)
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	timeNow = func() time.Time { return now }
+
+	var buf bytes.Buffer
+	logs := NewSlog()
+	if err := logs.Configure(&Settings{
+		Level:    config.LevelInfo,
+		Format:   FormatText,
+		Sampling: &Sampling{Initial: 2, Thereafter: 3},
+	}, &buf); err != nil {
+		t.Fatalf("Failed to configure the logger: %s", err)
+	}
+	logger := logs.Logger().With("component", "test")
+	for i := 0; i < 8; i++ {
+		logger.Info("repeated")
+	}
+	logger.Info("other")
+	now = now.Add(time.Second)
+	logger.Info("repeated")
+
+	if got, want := strings.Count(buf.String(), "msg=repeated"), 5; got != want {
+		t.Fatalf("Unexpected number of sampled messages: got: %d, want: %d", got, want)
+	}
+	if got, want := strings.Count(buf.String(), "msg=other"), 1; got != want {
+		t.Fatalf("Unexpected number of other messages: got: %d, want: %d", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package logconf
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/osdrv/config"
+)
+
+var slogLevels = map[config.LogLevel]slog.Level{
+	config.LevelDebug: slog.LevelDebug,
+	config.LevelInfo:  slog.LevelInfo,
+	config.LevelWarn:  slog.LevelWarn,
+	config.LevelError: slog.LevelError,
+}
+
+// Slog is the log/slog Adapter. The level is kept in a slog.LevelVar, the
+// logger does not need to be rebuilt on a level change.
+type Slog struct {
+	level  slog.LevelVar
+	logger *slog.Logger
+}
+
+var _ Adapter = (*Slog)(nil)
+
+// NewSlog is the constructor for Slog.
+func NewSlog() *Slog {
+	return &Slog{}
+}
+
+// Configure builds the logger: a slog.TextHandler or a slog.JSONHandler,
+// wrapped in a sampling handler if sampling is enabled.
+func (s *Slog) Configure(settings *Settings, out io.Writer) error {
+	s.level.Set(slogLevels[settings.Level])
+	opts := &slog.HandlerOptions{Level: &s.level}
+	var handler slog.Handler
+	if settings.Format == FormatJSON {
+		handler = slog.NewJSONHandler(out, opts)
+	} else {
+		handler = slog.NewTextHandler(out, opts)
+	}
+	if settings.Sampling != nil {
+		handler = newSamplingHandler(handler, settings.Sampling)
+	}
+	s.logger = slog.New(handler)
+	return nil
+}
+
+// SetLevel changes the logger level.
+func (s *Slog) SetLevel(level config.LogLevel) error {
+	s.level.Set(slogLevels[level])
+	return nil
+}
+
+// Logger returns the configured logger. It is nil until the adapter is
+// configured.
+func (s *Slog) Logger() *slog.Logger {
+	return s.logger
+}
+
+// Redefined in tests
+var timeNow = time.Now
+
+// samplingCounter counts the records with the same message within a second.
+type samplingCounter struct {
+	tick  int64
+	count int
+}
+
+type samplingHandler struct {
+	slog.Handler
+	sampling *Sampling
+	counters map[string]*samplingCounter
+	mx       *sync.Mutex
+}
+
+func newSamplingHandler(handler slog.Handler, sampling *Sampling) *samplingHandler {
+	return &samplingHandler{
+		Handler:  handler,
+		sampling: sampling,
+		counters: make(map[string]*samplingCounter),
+		mx:       &sync.Mutex{},
+	}
+}
+
+func (sh *samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !sh.sample(record.Message) {
+		return nil
+	}
+	return sh.Handler.Handle(ctx, record)
+}
+
+func (sh *samplingHandler) sample(msg string) bool {
+	tick := timeNow().Unix()
+	sh.mx.Lock()
+	defer sh.mx.Unlock()
+	counter, ok := sh.counters[msg]
+	if !ok || counter.tick != tick {
+		if !ok && len(sh.counters) > 0 {
+			// Counters of the previous ticks are dropped to keep the map small
+			for m, c := range sh.counters {
+				if c.tick != tick {
+					delete(sh.counters, m)
+				}
+			}
+		}
+		counter = &samplingCounter{tick: tick}
+		sh.counters[msg] = counter
+	}
+	counter.count++
+	if counter.count <= sh.sampling.Initial {
+		return true
+	}
+	return sh.sampling.Thereafter > 0 && (counter.count-sh.sampling.Initial)%sh.sampling.Thereafter == 0
+}
+
+// The derived handlers share the counters
+
+func (sh *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{
+		Handler:  sh.Handler.WithAttrs(attrs),
+		sampling: sh.sampling,
+		counters: sh.counters,
+		mx:       sh.mx,
+	}
+}
+
+func (sh *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{
+		Handler:  sh.Handler.WithGroup(name),
+		sampling: sh.sampling,
+		counters: sh.counters,
+		mx:       sh.mx,
+	}
+}
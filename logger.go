@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"log"
+)
+
+// Logger is a generic interface the repository and providers use in order to
+// report noteworthy events: provider set up, key registration conflicts,
+// reloads, deprecated config key usage, mapper errors. The repository is
+// silent by default: a no-op logger is used unless a custom one is provided
+// with `repo.SetLogger(logger)`.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// NopLogger is a Logger implementation discarding all messages.
+type NopLogger struct{}
+
+var _ Logger = (*NopLogger)(nil)
+
+// Debugf is a no-op.
+func (*NopLogger) Debugf(string, ...interface{}) {}
+
+// Infof is a no-op.
+func (*NopLogger) Infof(string, ...interface{}) {}
+
+// Warnf is a no-op.
+func (*NopLogger) Warnf(string, ...interface{}) {}
+
+// Errorf is a no-op.
+func (*NopLogger) Errorf(string, ...interface{}) {}
+
+// LogLevel defines the least important message level a StdLogger outputs.
+type LogLevel uint8
+
+const (
+	// LevelDebug enables all messages.
+	LevelDebug LogLevel = iota
+	// LevelInfo enables info, warning and error messages.
+	LevelInfo
+	// LevelWarn enables warning and error messages.
+	LevelWarn
+	// LevelError enables error messages only.
+	LevelError
+)
+
+// StdLogger is an adapter turning a standard library *log.Logger into a
+// Logger. Every message is prefixed with the level name.
+type StdLogger struct {
+	logger *log.Logger
+	level  LogLevel
+}
+
+var _ Logger = (*StdLogger)(nil)
+
+// NewStdLogger is the constructor for StdLogger. Messages below the level are
+// discarded.
+func NewStdLogger(logger *log.Logger, level LogLevel) *StdLogger {
+	return &StdLogger{
+		logger: logger,
+		level:  level,
+	}
+}
+
+func (sl *StdLogger) logf(level LogLevel, name string, format string, args ...interface{}) {
+	if level < sl.level {
+		return
+	}
+	sl.logger.Print(name + ": " + fmt.Sprintf(format, args...))
+}
+
+// Debugf outputs a debug message.
+func (sl *StdLogger) Debugf(format string, args ...interface{}) {
+	sl.logf(LevelDebug, "DEBUG", format, args...)
+}
+
+// Infof outputs an info message.
+func (sl *StdLogger) Infof(format string, args ...interface{}) {
+	sl.logf(LevelInfo, "INFO", format, args...)
+}
+
+// Warnf outputs a warning message.
+func (sl *StdLogger) Warnf(format string, args ...interface{}) {
+	sl.logf(LevelWarn, "WARN", format, args...)
+}
+
+// Errorf outputs an error message.
+func (sl *StdLogger) Errorf(format string, args ...interface{}) {
+	sl.logf(LevelError, "ERROR", format, args...)
+}
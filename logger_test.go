@@ -0,0 +1,45 @@
+package config
+
+import (
+	"bytes"
+	"log"
+	"testing"
+)
+
+func TestStdLogger(t *testing.T) {
+	tests := []struct {
+		name  string
+		level LogLevel
+		want  string
+	}{
+		{
+			"Debug level",
+			LevelDebug,
+			"DEBUG: d 1\nINFO: i 2\nWARN: w 3\nERROR: e 4\n",
+		},
+		{
+			"Warn level",
+			LevelWarn,
+			"WARN: w 3\nERROR: e 4\n",
+		},
+		{
+			"Error level",
+			LevelError,
+			"ERROR: e 4\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := NewStdLogger(log.New(&buf, "", 0), testCase.level)
+			logger.Debugf("d %d", 1)
+			logger.Infof("i %d", 2)
+			logger.Warnf("w %d", 3)
+			logger.Errorf("e %d", 4)
+			if got := buf.String(); got != testCase.want {
+				t.Fatalf("Unexpected log output: got: %q, want: %q", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Lookup is a non-panicking version of Must. It returns the key value and
+// a flag indicating whether the key is served by any provider. A mapper
+// failure is returned as an error instead of a panic. If the getter is nil,
+// the default repository is used (see Default).
+func Lookup(repo Getter, key string) (Value, bool, error) {
+	return tryLookup(orDefault(repo).Get, parseKey(repo, key))
+}
+
+// tryLookup calls the getter and returns a mapper failure panic as an error.
+func tryLookup(get GetFunc, key Key) (v Value, ok bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rerr, isErr := r.(error); isErr {
+				err = rerr
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+			v, ok = nil, true
+		}
+	}()
+	v, ok = get(key)
+	return v, ok, nil
+}
+
+// LookupStr returns the key value converted to string using ToStr (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupStr(repo Getter, key string) (string, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return "", ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToStr).(string); ok {
+		return tv, true, nil
+	}
+	return "", true, typeMismatch(key, v, "string")
+}
+
+// LookupInt returns the key value converted to int using ToInt (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupInt(repo Getter, key string) (int, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToInt).(int); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "int")
+}
+
+// LookupBool returns the key value converted to bool using ToBool (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupBool(repo Getter, key string) (bool, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return false, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToBool).(bool); ok {
+		return tv, true, nil
+	}
+	return false, true, typeMismatch(key, v, "bool")
+}
+
+// LookupInt8 returns the key value converted to int8 using ToInt8 (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupInt8(repo Getter, key string) (int8, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToInt8).(int8); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "int8")
+}
+
+// LookupInt16 returns the key value converted to int16 using ToInt16 (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupInt16(repo Getter, key string) (int16, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToInt16).(int16); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "int16")
+}
+
+// LookupInt32 returns the key value converted to int32 using ToInt32 (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupInt32(repo Getter, key string) (int32, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToInt32).(int32); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "int32")
+}
+
+// LookupInt64 returns the key value converted to int64 using ToInt64 (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupInt64(repo Getter, key string) (int64, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToInt64).(int64); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "int64")
+}
+
+// LookupUint returns the key value converted to uint using ToUint (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupUint(repo Getter, key string) (uint, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToUint).(uint); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "uint")
+}
+
+// LookupUint8 returns the key value converted to uint8 using ToUint8 (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupUint8(repo Getter, key string) (uint8, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToUint8).(uint8); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "uint8")
+}
+
+// LookupUint16 returns the key value converted to uint16 using ToUint16 (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupUint16(repo Getter, key string) (uint16, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToUint16).(uint16); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "uint16")
+}
+
+// LookupUint32 returns the key value converted to uint32 using ToUint32 (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupUint32(repo Getter, key string) (uint32, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToUint32).(uint32); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "uint32")
+}
+
+// LookupUint64 returns the key value converted to uint64 using ToUint64 (unless
+// the getter requires strict types). The boolean flag is false if the key is
+// not served by any provider.
+func LookupUint64(repo Getter, key string) (uint64, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToUint64).(uint64); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "uint64")
+}
+
+// LookupUintptr returns the key value if it is of type uintptr. The boolean flag is
+// false if the key is not served by any provider.
+func LookupUintptr(repo Getter, key string) (uintptr, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := v.(uintptr); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "uintptr")
+}
+
+// LookupFloat32 returns the key value converted to float32 using ToFloat32
+// (unless the getter requires strict types). The boolean flag is false if
+// the key is not served by any provider.
+func LookupFloat32(repo Getter, key string) (float32, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToFloat32).(float32); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "float32")
+}
+
+// LookupFloat64 returns the key value converted to float64 using ToFloat64
+// (unless the getter requires strict types). The boolean flag is false if
+// the key is not served by any provider.
+func LookupFloat64(repo Getter, key string) (float64, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	if tv, ok := coerce(repo, key, v, ToFloat64).(float64); ok {
+		return tv, true, nil
+	}
+	return 0, true, typeMismatch(key, v, "float64")
+}
+
+// LookupStrArr returns the key value as []string. A generic list is converted
+// element-by-element to strings. The boolean flag is false if the key is not
+// served by any provider.
+func LookupStrArr(repo Getter, key string) ([]string, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if tv, ok := toStrArr(v, isStrictTypes(repo)); ok {
+		return tv, true, nil
+	}
+	return nil, true, typeMismatch(key, v, "[]string")
+}
+
+// LookupIntArr returns the key value as []int. A generic list is converted
+// element-by-element to ints. The boolean flag is false if the key is not
+// served by any provider.
+func LookupIntArr(repo Getter, key string) ([]int, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if tv, ok := toIntArr(v, isStrictTypes(repo)); ok {
+		return tv, true, nil
+	}
+	return nil, true, typeMismatch(key, v, "[]int")
+}
+
+// LookupFloatArr returns the key value as []float64. A generic list is converted
+// element-by-element to floats. The boolean flag is false if the key is not
+// served by any provider.
+func LookupFloatArr(repo Getter, key string) ([]float64, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if tv, ok := toFloatArr(v, isStrictTypes(repo)); ok {
+		return tv, true, nil
+	}
+	return nil, true, typeMismatch(key, v, "[]float64")
+}
+
+// LookupDurationArr returns the key value as []time.Duration. A generic list is converted
+// element-by-element to durations. The boolean flag is false if the key is not
+// served by any provider.
+func LookupDurationArr(repo Getter, key string) ([]time.Duration, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if tv, ok := toDurationArr(v, isStrictTypes(repo)); ok {
+		return tv, true, nil
+	}
+	return nil, true, typeMismatch(key, v, "[]time.Duration")
+}
+
+// LookupStrMap returns the key value as map[string]string. A generic map is converted
+// element-by-element to strings. The boolean flag is false if the key is not
+// served by any provider.
+func LookupStrMap(repo Getter, key string) (map[string]string, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if tv, ok := toStrMap(v, isStrictTypes(repo)); ok {
+		return tv, true, nil
+	}
+	return nil, true, typeMismatch(key, v, "map[string]string")
+}
+
+// LookupIntMap returns the key value as map[string]int. A generic map is converted
+// element-by-element to ints. The boolean flag is false if the key is not
+// served by any provider.
+func LookupIntMap(repo Getter, key string) (map[string]int, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if tv, ok := toIntMap(v, isStrictTypes(repo)); ok {
+		return tv, true, nil
+	}
+	return nil, true, typeMismatch(key, v, "map[string]int")
+}
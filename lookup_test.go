@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLookupStr(t *testing.T) {
+	str := "bar"
+	tests := []struct {
+		name    string
+		val     Value
+		want    string
+		wantErr error
+	}{
+		{"string", "foo", "foo", nil},
+		{"string pointer", &str, "bar", nil},
+		{"int", 42, "42", nil},
+		{"bool", true, "", ErrTypeMismatch},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			repo.RegisterKey(NewKey("foo"), NewTestProv(testCase.val, DefaultWeight))
+			got, ok, err := LookupStr(repo, "foo")
+			if !ok {
+				t.Fatalf("Expected the key to be found")
+			}
+			if !errors.Is(err, testCase.wantErr) {
+				t.Fatalf("Unexpected error: got: %v, want: %v", err, testCase.wantErr)
+			}
+			if got != testCase.want {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestLookupErrors(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{"port": ToInt})
+	repo.RegisterKey(NewKey("port"), NewTestProv("abc", DefaultWeight))
+	repo.RegisterKey(NewKey("ratio"), NewTestProv(0.5, DefaultWeight))
+
+	if _, ok, err := LookupInt(repo, "missing"); ok || err != nil {
+		t.Fatalf("Unexpected missing key lookup result: got: %t, %v, want: false, <nil>", ok, err)
+	}
+
+	var cerr *ConversionError
+	if _, ok, err := LookupInt(repo, "port"); !ok || !errors.As(err, &cerr) {
+		t.Fatalf("Unexpected mapper failure lookup result: got: %t, %v, want: true, *ConversionError", ok, err)
+	}
+
+	if v, ok, err := LookupFloat64(repo, "ratio"); !ok || err != nil || v != 0.5 {
+		t.Fatalf("Unexpected lookup result: got: %#v, %t, %v, want: 0.5, true, <nil>", v, ok, err)
+	}
+	if _, ok, err := LookupInt64(repo, "ratio"); !ok || !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("Unexpected lookup result: got: %t, %v, want: true, ErrTypeMismatch", ok, err)
+	}
+}
+
+func TestLookupSizedInts(t *testing.T) {
+	tests := []struct {
+		name    string
+		lookup  func(repo Getter) (interface{}, error)
+		val     Value
+		want    interface{}
+		wantErr error
+	}{
+		{"int8 string", func(repo Getter) (interface{}, error) { v, _, err := LookupInt8(repo, "foo"); return v, err }, "8", int8(8), nil},
+		{"int8 int", func(repo Getter) (interface{}, error) { v, _, err := LookupInt8(repo, "foo"); return v, err }, 8, int8(8), nil},
+		{"int8 overflow", func(repo Getter) (interface{}, error) { v, _, err := LookupInt8(repo, "foo"); return v, err }, 300, int8(0), ErrTypeMismatch},
+		{"int16 string", func(repo Getter) (interface{}, error) { v, _, err := LookupInt16(repo, "foo"); return v, err }, "-16", int16(-16), nil},
+		{"int32 int", func(repo Getter) (interface{}, error) { v, _, err := LookupInt32(repo, "foo"); return v, err }, 32, int32(32), nil},
+		{"int64 string", func(repo Getter) (interface{}, error) { v, _, err := LookupInt64(repo, "foo"); return v, err }, "64", int64(64), nil},
+		{"uint int", func(repo Getter) (interface{}, error) { v, _, err := LookupUint(repo, "foo"); return v, err }, 1, uint(1), nil},
+		{"uint8 string", func(repo Getter) (interface{}, error) { v, _, err := LookupUint8(repo, "foo"); return v, err }, "8", uint8(8), nil},
+		{"uint16 int", func(repo Getter) (interface{}, error) { v, _, err := LookupUint16(repo, "foo"); return v, err }, 16, uint16(16), nil},
+		{"uint32 string", func(repo Getter) (interface{}, error) { v, _, err := LookupUint32(repo, "foo"); return v, err }, "32", uint32(32), nil},
+		{"uint64 int", func(repo Getter) (interface{}, error) { v, _, err := LookupUint64(repo, "foo"); return v, err }, 64, uint64(64), nil},
+		{"uint64 negative", func(repo Getter) (interface{}, error) { v, _, err := LookupUint64(repo, "foo"); return v, err }, -1, uint64(0), ErrTypeMismatch},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			repo.RegisterKey(NewKey("foo"), NewTestProv(testCase.val, DefaultWeight))
+			got, err := testCase.lookup(repo)
+			if !errors.Is(err, testCase.wantErr) {
+				t.Fatalf("Unexpected error: got: %v, want: %v", err, testCase.wantErr)
+			}
+			if got != testCase.want {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+// MapProvider serves values from a static in-memory map. Unlike
+// DefaultProvider, it has a configurable name, which allows registering
+// multiple instances in the same repository. It is handy for unit tests and
+// embedded tools injecting config without touching env vars or files.
+type MapProvider struct {
+	name     string
+	weight   int
+	registry map[string]Value
+	ready    chan struct{}
+}
+
+var _ Provider = (*MapProvider)(nil)
+
+// NewMapProvider is the constructor for MapProvider. Registry keys are
+// expected to be flattened, e.g.: map[string]Value{"foo.bar": 42}.
+func NewMapProvider(repo *Repository, weight int, name string, registry map[string]Value) (*MapProvider, error) {
+	prov := &MapProvider{
+		name:     name,
+		weight:   weight,
+		registry: registry,
+		ready:    make(chan struct{}),
+	}
+	repo.RegisterProvider(prov)
+	return prov, nil
+}
+
+// Name returns the provider name provided to the constructor
+func (mp *MapProvider) Name() string { return mp.name }
+
+// Depends returns the list of provider dependencies: none
+func (mp *MapProvider) Depends() []string { return []string{} }
+
+// Weight returns the provider weight
+func (mp *MapProvider) Weight() int { return mp.weight }
+
+// SetUp registers all keys from the registry in the repo
+func (mp *MapProvider) SetUp(repo *Repository) error {
+	defer close(mp.ready)
+	for k := range mp.registry {
+		if err := repo.RegisterKey(NewKey(k), mp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TearDown is a no-op operation for MapProvider
+func (mp *MapProvider) TearDown(*Repository) error { return nil }
+
+// Get is the primary method for fetching values from the registry
+func (mp *MapProvider) Get(key Key) (*KeyValue, bool) {
+	<-mp.ready
+	if val, ok := mp.registry[key.String()]; ok {
+		return &KeyValue{Key: key, Value: val}, ok
+	}
+	return nil, false
+}
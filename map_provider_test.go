@@ -0,0 +1,38 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapProvider(t *testing.T) {
+	repo := NewRepository()
+	low, err := NewMapProvider(repo, 10, "low", map[string]Value{
+		"foo.bar": 1,
+		"foo.baz": 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	high, err := NewMapProvider(repo, 20, "high", map[string]Value{
+		"foo.bar": 42,
+	})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	if low.Name() != "low" || high.Name() != "high" {
+		t.Fatalf("Unexpected provider names: %q, %q", low.Name(), high.Name())
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	want := map[string]Value{"bar": 42, "baz": 2}
+	got, ok := repo.Get(NewKey("foo"))
+	if !ok {
+		t.Fatalf("Expected lookup for key %q to find a value, none returned", "foo")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", got, want)
+	}
+}
@@ -2,6 +2,10 @@ package config
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync/atomic"
 )
 
 // Mapper is a generic interface for mapping actors. These co-exist hand-by-hand
@@ -18,6 +22,14 @@ type Mapper interface {
 type MapperNode struct {
 	Mpr      Mapper
 	Children map[string]*MapperNode
+	// elems caches the sequence element node lookup (see elemNode)
+	elems atomic.Value
+}
+
+// elemCache is a cached sequence element node lookup result.
+type elemCache struct {
+	node  *MapperNode
+	valid bool
 }
 
 // NewMapperNode is the constructor for MapperNode.
@@ -35,6 +47,9 @@ func NewMapperNode() *MapperNode {
 // match the search and return m.
 // Wildcards have priority: a star match has a lower precedence than the exact
 // match.
+// A double star `**` matches any number of key fragments (including none),
+// e.g. Insert(Key("**.password"), m) matches `password`, `db.password` and
+// `db.replica.password`. A double star has the lowest precedence.
 //
 // Example:
 //   Insert(Key("foo.*.baz"), m1)
@@ -52,6 +67,10 @@ func (mn *MapperNode) Insert(key Key, mpr Mapper) *MapperNode {
 			}
 			if _, ok := ptr.Children[k]; !ok {
 				ptr.Children[k] = NewMapperNode()
+				// The element node might have changed
+				if ptr.elems.Load() != nil {
+					ptr.elems.Store(elemCache{})
+				}
 			}
 			ptr = ptr.Children[k]
 		}
@@ -76,9 +95,68 @@ func (mn *MapperNode) Find(key Key) *MapperNode {
 			}
 		}
 	}
+	if next, ok := mn.Children["**"]; ok {
+		// The shortest match wins
+		for i := 0; i < len(key); i++ {
+			if res := next.Find(key[i:]); res != nil {
+				return res
+			}
+		}
+		// A trailing double star swallows the rest of the key
+		if next.Mpr != nil {
+			return next
+		}
+	}
 	return nil
 }
 
+// Covers returns true if the key is defined in the trie structure. A key is
+// considered defined if the trie path exists (wildcards are taken into
+// account) or if one of the key prefixes terminates at a leaf Mapper: in this
+// case the entire subtree is a part of the leaf value.
+func (mn *MapperNode) Covers(key Key) bool {
+	if len(key) == 0 {
+		return true
+	}
+	if mn.Mpr != nil && len(mn.Children) == 0 {
+		return true
+	}
+	for _, nextK := range []string{key[0], "*"} {
+		if next, ok := mn.Children[nextK]; ok {
+			if next.Covers(key[1:]) {
+				return true
+			}
+		}
+	}
+	if next, ok := mn.Children["**"]; ok {
+		for i := 0; i < len(key); i++ {
+			if next.Covers(key[i:]) {
+				return true
+			}
+		}
+		if next.Mpr != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns a sorted list of all leaf paths defined in the trie structure.
+func (mn *MapperNode) Keys() []string {
+	res := make([]string, 0)
+	for k, ch := range mn.Children {
+		if len(ch.Children) == 0 {
+			res = append(res, QuoteFragment(k))
+			continue
+		}
+		for _, sk := range ch.Keys() {
+			res = append(res, QuoteFragment(k)+KeySepCh+sk)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
 // DefineSchema is the primary way to bulk-register mappers in a MapperNode.
 // Schema is a very flexible structure. See Schema docs for more details.
 // If Schema is defined as a map[string]Schema, MapperNode will explicitly look
@@ -100,6 +178,10 @@ func (mn *MapperNode) Find(key Key) *MapperNode {
 // 2. Convert `foo` using FooMapper providing map[string]Value{"moo": MooVal, "bar": BarVal}
 // 3. Return result.
 //
+// Schema keys might be composite: `{"foo.bar": BarMapper}` is equivalent to
+// `{"foo": {"bar": BarMapper}}`. This is handy for wildcard definitions like:
+// schema := map[string]Schema{"**.password": PasswordMapper}
+//
 // __self__ might be set to nil in the schema definition in order to emphasise
 // an absence of the mapper for the parental key. It's fully equivalent to
 // no-definition for key __self__.
@@ -110,8 +192,19 @@ func (mn *MapperNode) DefineSchema(s Schema) error {
 func (mn *MapperNode) doDefineSchema(key Key, schema Schema) error {
 	if schema == nil {
 		return nil
+	} else if d, ok := schema.(*Description); ok {
+		return mn.doDefineSchema(key, d.Subject)
+	} else if d, ok := schema.(*Defaulted); ok {
+		if d.Subject == nil {
+			// The default value is served as is
+			mn.Insert(key, d)
+			return nil
+		}
+		return mn.doDefineSchema(key, d.Subject)
 	} else if mpr, ok := schema.(Mapper); ok {
 		mn.Insert(key, mpr)
+	} else if t, ok := schema.(reflect.Type); ok {
+		mn.Insert(key, NewTypeMapper(t))
 	} else if cnv, ok := schema.(Converter); ok {
 		mn.Insert(key, NewConvMapper(cnv))
 	} else if smap, ok := schema.(map[string]Schema); ok {
@@ -127,7 +220,7 @@ func (mn *MapperNode) doDefineSchema(key Key, schema Schema) error {
 			if subKey == "__self__" {
 				continue
 			}
-			if err := mn.doDefineSchema(append(key, subKey), subSchema); err != nil {
+			if err := mn.doDefineSchema(key.Join(NewKey(subKey)), subSchema); err != nil {
 				return err
 			}
 		}
@@ -138,9 +231,21 @@ func (mn *MapperNode) doDefineSchema(key Key, schema Schema) error {
 	return nil
 }
 
-// Map performs the actual mapping of the key-value pair.
+// Map performs the actual mapping of the key-value pair. Sequence elements
+// are mapped beforehand by the element keys, the element index being the key
+// fragment: e.g. Insert(Key("servers.*.port"), m) maps the port of every
+// element of the `servers` sequence.
 func (mn *MapperNode) Map(kv *KeyValue) (*KeyValue, error) {
-	if ptr := mn.Find(kv.Key); ptr != nil && ptr.Mpr != nil {
+	ptr := mn.Find(kv.Key)
+	// Elements are only mapped if the schema defines the element keys
+	if ptr != nil && len(ptr.Children) != 0 {
+		if v, ok, err := ptr.mapElements(kv.Key, kv.Value); err != nil {
+			return nil, err
+		} else if ok {
+			kv = &KeyValue{Key: kv.Key, Value: v}
+		}
+	}
+	if ptr != nil && ptr.Mpr != nil {
 		if mkv, err := ptr.Mpr.Map(kv); err != nil {
 			return nil, err
 		} else {
@@ -150,6 +255,94 @@ func (mn *MapperNode) Map(kv *KeyValue) (*KeyValue, error) {
 	return kv, nil
 }
 
+// elemNode returns the schema node of the sequence element: the exact index
+// node takes precedence over the wildcard one, the latter lookup is cached.
+// Returns nil if the element is not defined.
+func (mn *MapperNode) elemNode(ix int) *MapperNode {
+	if ch, ok := mn.Children[strconv.Itoa(ix)]; ok {
+		return ch
+	}
+	if c, ok := mn.elems.Load().(elemCache); ok && c.valid {
+		return c.node
+	}
+	node := mn.Find(Key{WildcardFragment})
+	mn.elems.Store(elemCache{node: node, valid: true})
+	return node
+}
+
+// mapElements maps the elements of a sequence value by the element nodes of
+// the schema node. Returns false if the value is not a sequence or none of
+// the elements is mapped.
+func (mn *MapperNode) mapElements(key Key, v Value) (Value, bool, error) {
+	var elems []Value
+	switch vv := v.(type) {
+	case []Value:
+		elems = vv
+	default:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return v, false, nil
+		}
+		elems = make([]Value, rv.Len())
+		for i := range elems {
+			elems[i] = rv.Index(i).Interface()
+		}
+	}
+	res := make([]Value, len(elems))
+	mapped := false
+	for i, e := range elems {
+		ev, ok, err := mn.elemNode(i).mapNested(append(key[:len(key):len(key)], strconv.Itoa(i)), e)
+		if err != nil {
+			return nil, false, err
+		}
+		res[i] = ev
+		mapped = mapped || ok
+	}
+	if !mapped {
+		return v, false, nil
+	}
+	return res, true, nil
+}
+
+// mapNested maps a value nested in a sequence by the schema node of its key:
+// maps and sequences are walked recursively, then the value is mapped by the
+// node mapper (if any). Returns false if nothing is mapped, e.g. if the node
+// is nil.
+func (mn *MapperNode) mapNested(key Key, v Value) (Value, bool, error) {
+	if mn == nil {
+		return v, false, nil
+	}
+	res, mapped := v, false
+	if len(mn.Children) != 0 {
+		var err error
+		if res, mapped, err = mn.mapElements(key, v); err != nil {
+			return nil, false, err
+		}
+		if vmap, ok := toValueMap(v); ok {
+			out := make(map[string]Value, len(vmap))
+			for k, e := range vmap {
+				ev, ok, err := mn.Find(Key{k}).mapNested(append(key[:len(key):len(key)], k), e)
+				if err != nil {
+					return nil, false, err
+				}
+				out[k] = ev
+				mapped = mapped || ok
+			}
+			if mapped {
+				res = out
+			}
+		}
+	}
+	if mn.Mpr != nil {
+		mkv, err := mn.Mpr.Map(&KeyValue{Key: key, Value: res})
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", key, err)
+		}
+		return mkv.Value, true, nil
+	}
+	return res, mapped, nil
+}
+
 // ConvMapper is a helper wrapper that turns a single Converter into a Mapper
 // structure with the expected bahavior: if Converter fails to convert, the
 // wrapper Mapper returns an error.
@@ -170,5 +363,7 @@ func (cm *ConvMapper) Map(kv *KeyValue) (*KeyValue, error) {
 	if mkv, ok := cm.conv.Convert(kv); ok {
 		return mkv, nil
 	}
-	return nil, fmt.Errorf("Failed to convert value %#v for key %#v", kv.Key, kv.Value)
+	// The raw value is not a part of the message: it might be a secret. The
+	// repository attaches it to the error if it's safe to display.
+	return nil, fmt.Errorf("Failed to convert %T value for key %q", kv.Value, kv.Key)
 }
@@ -408,6 +408,39 @@ func TestMap(t *testing.T) {
 			&KeyValue{Key: NewKey("foo"), Value: &fooStruct{Bar: 4}},
 			nil,
 		},
+		{
+			"Sequence element mapper",
+			map[string]Schema{
+				"foo.*.bar": convSq,
+			},
+			&KeyValue{Key: NewKey("foo"), Value: []interface{}{
+				map[string]interface{}{"bar": 2, "baz": "a"},
+				map[string]interface{}{"baz": "b"},
+			}},
+			&KeyValue{Key: NewKey("foo"), Value: []Value{
+				map[string]Value{"bar": 4, "baz": "a"},
+				map[string]interface{}{"baz": "b"},
+			}},
+			nil,
+		},
+		{
+			"Nested sequence element mapper",
+			map[string]Schema{
+				"foo.*.*": convSq,
+			},
+			&KeyValue{Key: NewKey("foo"), Value: []Value{[]Value{2, 3}}},
+			&KeyValue{Key: NewKey("foo"), Value: []Value{[]Value{4, 9}}},
+			nil,
+		},
+		{
+			"Failing sequence element mapper",
+			map[string]Schema{
+				"foo.*": errMpr,
+			},
+			&KeyValue{Key: NewKey("foo"), Value: []Value{42}},
+			nil,
+			fmt.Errorf("foo.0: %w", fmt.Errorf("This mapper returns an error")),
+		},
 		{
 			"Failing mapper",
 			map[string]Schema{
@@ -437,3 +470,110 @@ func TestMap(t *testing.T) {
 		})
 	}
 }
+
+func TestMapElementsCache(t *testing.T) {
+	convSq := NewTestMapper(func(kv *KeyValue) (*KeyValue, error) {
+		v := kv.Value.(int)
+		return &KeyValue{Key: kv.Key, Value: v * v}, nil
+	})
+	mn := NewMapperNode()
+	if err := mn.DefineSchema(map[string]Schema{"foo.bar": convSq}); err != nil {
+		t.Fatalf("Failed to call DefineSchema(): %s", err)
+	}
+	kv := &KeyValue{Key: NewKey("foo"), Value: []Value{2, 3}}
+	got, err := mn.Map(kv)
+	if err != nil || !reflect.DeepEqual(got.Value, []Value{2, 3}) {
+		t.Fatalf("Unexpected value: got: %#v, %v, want: %#v", got, err, []Value{2, 3})
+	}
+	// The cached element lookup is reset by the schema update
+	if err := mn.DefineSchema(map[string]Schema{"foo.*": convSq}); err != nil {
+		t.Fatalf("Failed to call DefineSchema(): %s", err)
+	}
+	got, err = mn.Map(kv)
+	if err != nil || !reflect.DeepEqual(got.Value, []Value{4, 9}) {
+		t.Fatalf("Unexpected value: got: %#v, %v, want: %#v", got, err, []Value{4, 9})
+	}
+}
+
+func TestMapperNodeCovers(t *testing.T) {
+	mn := NewMapperNode()
+	if err := mn.DefineSchema(map[string]Schema{
+		"foo": map[string]Schema{
+			"bar": ToInt,
+			"*": map[string]Schema{
+				"baz": ToStr,
+			},
+		},
+		"moo": ToStr,
+	}); err != nil {
+		t.Fatalf("Failed to define schema: %s", err)
+	}
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"foo", true},
+		{"foo.bar", true},
+		{"foo.boo.baz", true},
+		{"foo.boo.bar", false},
+		{"moo", true},
+		{"moo.nested.value", true},
+		{"boo", false},
+	}
+
+	for _, testCase := range tests {
+		if got := mn.Covers(NewKey(testCase.key)); got != testCase.want {
+			t.Fatalf("Unexpected Covers(%q) result: got: %t, want: %t", testCase.key, got, testCase.want)
+		}
+	}
+
+	wantKeys := []string{"foo.*.baz", "foo.bar", "moo"}
+	if gotKeys := mn.Keys(); !reflect.DeepEqual(gotKeys, wantKeys) {
+		t.Fatalf("Unexpected Keys() result: got: %#v, want: %#v", gotKeys, wantKeys)
+	}
+}
+
+func TestMapperNodeMultiLevelWildcard(t *testing.T) {
+	redact := NewTestMapper(func(kv *KeyValue) (*KeyValue, error) {
+		return &KeyValue{Key: kv.Key, Value: "******"}, nil
+	})
+	exact := NewTestMapper(func(kv *KeyValue) (*KeyValue, error) {
+		return kv, nil
+	})
+	mn := NewMapperNode()
+	if err := mn.DefineSchema(map[string]Schema{
+		"**.password": redact,
+		"db": map[string]Schema{
+			"password": exact,
+		},
+	}); err != nil {
+		t.Fatalf("Failed to define schema: %s", err)
+	}
+
+	tests := []struct {
+		key  string
+		want Mapper
+	}{
+		{"password", redact},
+		{"smtp.password", redact},
+		{"db.replica.password", redact},
+		{"db.password", exact},
+		{"db.user", nil},
+		{"user", nil},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.key, func(t *testing.T) {
+			var got Mapper
+			if ptr := mn.Find(NewKey(testCase.key)); ptr != nil {
+				got = ptr.Mpr
+			}
+			if got != testCase.want {
+				t.Fatalf("Unexpected mapper for key %q: got: %#v, want: %#v", testCase.key, got, testCase.want)
+			}
+			if covers := mn.Covers(NewKey(testCase.key)); covers != (testCase.want != nil) {
+				t.Fatalf("Unexpected Covers(%q) result: got: %t, want: %t", testCase.key, covers, testCase.want != nil)
+			}
+		})
+	}
+}
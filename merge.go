@@ -0,0 +1,143 @@
+package config
+
+import "reflect"
+
+// MergeStrategy defines how the values served by multiple providers for the
+// same key are combined. See SetMergeStrategy.
+type MergeStrategy int
+
+const (
+	// MergeReplace is the default strategy: the value served by the provider
+	// with the highest weight wins.
+	MergeReplace MergeStrategy = iota
+	// MergeDeep merges map values recursively: keys served by a provider
+	// with a higher weight override the same keys served by a provider with
+	// a lower weight. Non-map values are replaced.
+	MergeDeep
+	// MergeAppend concatenates list values: the lists served by providers
+	// with a higher weight are appended to the lists served by providers
+	// with a lower weight. Non-list values are replaced.
+	MergeAppend
+	// MergeUnique is the same as MergeAppend except it skips the list items
+	// already present in the result.
+	MergeUnique
+)
+
+func (ms MergeStrategy) String() string {
+	switch ms {
+	case MergeReplace:
+		return "replace"
+	case MergeDeep:
+		return "deep"
+	case MergeAppend:
+		return "append"
+	case MergeUnique:
+		return "unique"
+	}
+	return "unknown"
+}
+
+// mergeRule is a merge strategy registered for a key pattern.
+type mergeRule struct {
+	pattern  Key
+	strategy MergeStrategy
+}
+
+// SetMergeStrategy sets the strategy combining the values served by multiple
+// providers for the keys matching the pattern. A `*` fragment matches any
+// single key fragment (see GetAll), a pattern applies to the matching keys
+// and the keys nested under them. If multiple patterns match a key, the
+// longest one wins; among the patterns of the same length, the one set last
+// wins.
+//
+// Example:
+//
+//	repo.SetMergeStrategy(config.NewKey("plugins.*.hosts"), config.MergeUnique)
+//
+// This method is thread safe.
+func (repo *Repository) SetMergeStrategy(pattern Key, strategy MergeStrategy) {
+	pattern = repo.foldKey(pattern)
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	for _, rule := range repo.mergeRules {
+		if rule.pattern.String() == pattern.String() {
+			rule.strategy = strategy
+			return
+		}
+	}
+	repo.mergeRules = append(repo.mergeRules, &mergeRule{
+		pattern:  pattern,
+		strategy: strategy,
+	})
+}
+
+// mergeStrategy returns the merge strategy for the key.
+func (repo *Repository) mergeStrategy(key Key) MergeStrategy {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	strategy, plen := MergeReplace, -1
+	for _, rule := range repo.mergeRules {
+		if len(rule.pattern) >= plen && matchesPattern(rule.pattern, key) {
+			strategy, plen = rule.strategy, len(rule.pattern)
+		}
+	}
+	return strategy
+}
+
+// mergeValues combines the values according to the strategy. The values are
+// expected to be sorted by the provider weight in descending order.
+func mergeValues(strategy MergeStrategy, vals []Value) Value {
+	res := vals[len(vals)-1]
+	for i := len(vals) - 2; i >= 0; i-- {
+		res = mergeValue(strategy, res, vals[i])
+	}
+	return res
+}
+
+// mergeValue merges the value over the base value.
+func mergeValue(strategy MergeStrategy, base Value, v Value) Value {
+	switch strategy {
+	case MergeDeep:
+		bm, bok := toValueMap(base)
+		vm, vok := toValueMap(v)
+		if !bok || !vok {
+			return v
+		}
+		res := make(map[string]Value, len(bm)+len(vm))
+		for k, bv := range bm {
+			res[k] = bv
+		}
+		for k, mv := range vm {
+			if bv, ok := res[k]; ok {
+				res[k] = mergeValue(strategy, bv, mv)
+			} else {
+				res[k] = mv
+			}
+		}
+		return res
+	case MergeAppend, MergeUnique:
+		bl, bok := toValues(base)
+		vl, vok := toValues(v)
+		if !bok || !vok {
+			return v
+		}
+		res := make([]Value, 0, len(bl)+len(vl))
+		for _, item := range append(bl, vl...) {
+			if strategy == MergeUnique && containsValue(res, item) {
+				continue
+			}
+			res = append(res, item)
+		}
+		return res
+	}
+	return v
+}
+
+func containsValue(vals []Value, v Value) bool {
+	for _, item := range vals {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		strategy MergeStrategy
+		low      Value
+		high     Value
+		want     Value
+	}{
+		{
+			"default replace",
+			"",
+			MergeReplace,
+			[]interface{}{"a", "b"},
+			[]interface{}{"c"},
+			[]interface{}{"c"},
+		},
+		{
+			"append lists",
+			"hosts",
+			MergeAppend,
+			[]interface{}{"a", "b"},
+			[]interface{}{"b", "c"},
+			[]Value{"a", "b", "b", "c"},
+		},
+		{
+			"unique lists",
+			"hosts",
+			MergeUnique,
+			[]interface{}{"a", "b"},
+			[]string{"b", "c"},
+			[]Value{"a", "b", "c"},
+		},
+		{
+			"deep merge maps",
+			"*",
+			MergeDeep,
+			map[string]interface{}{
+				"a": 1,
+				"b": map[interface{}]interface{}{"c": 2, "d": 3},
+			},
+			map[string]interface{}{
+				"b": map[string]interface{}{"c": 4},
+				"e": 5,
+			},
+			map[string]Value{
+				"a": 1,
+				"b": map[string]Value{"c": 4, "d": 3},
+				"e": 5,
+			},
+		},
+		{
+			"non-mergeable values",
+			"hosts",
+			MergeAppend,
+			[]interface{}{"a"},
+			"b",
+			"b",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			key := NewKey("hosts")
+			repo.RegisterKey(key, NewTestProv(testCase.low, 10))
+			repo.RegisterKey(key, NewTestProv(testCase.high, 20))
+			if testCase.pattern != "" {
+				repo.SetMergeStrategy(NewKey(testCase.pattern), testCase.strategy)
+			}
+			got, ok := repo.Get(key)
+			if !ok {
+				t.Fatalf("Expected the key to be found")
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestMergeStrategyPatterns(t *testing.T) {
+	repo := NewRepository()
+	repo.SetMergeStrategy(NewKey("plugins"), MergeAppend)
+	repo.SetMergeStrategy(NewKey("plugins.*.hosts"), MergeUnique)
+	repo.SetMergeStrategy(NewKey("plugins.auth.hosts"), MergeReplace)
+
+	tests := []struct {
+		key  string
+		want MergeStrategy
+	}{
+		{"plugins.cache.hosts", MergeUnique},
+		{"plugins.auth.hosts", MergeReplace},
+		{"plugins.cache.ports", MergeAppend},
+		{"server.hosts", MergeReplace},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.key, func(t *testing.T) {
+			if got := repo.mergeStrategy(NewKey(testCase.key)); got != testCase.want {
+				t.Fatalf("Unexpected merge strategy: got: %s, want: %s", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestMergeStrategyComposite(t *testing.T) {
+	repo := NewRepository()
+	repo.RegisterKey(NewKey("server.hosts"), NewTestProv([]interface{}{"a"}, 10))
+	repo.RegisterKey(NewKey("server.hosts"), NewTestProv([]interface{}{"b"}, 20))
+	repo.SetMergeStrategy(NewKey("server"), MergeAppend)
+
+	got, ok := repo.Get(NewKey("server"))
+	if !ok {
+		t.Fatalf("Expected the key to be found")
+	}
+	want := map[string]Value{"hosts": []Value{"a", "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", got, want)
+	}
+}
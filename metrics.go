@@ -0,0 +1,43 @@
+package config
+
+import "time"
+
+// Metrics is a generic interface the repository and providers use in order to
+// report operational statistics. The repository uses a no-op implementation
+// by default, a custom one can be provided with `repo.SetMetrics(metrics)`.
+// See the github.com/osdrv/config/prometheus module for a Prometheus adapter:
+// it is a separate module so that the Prometheus client is only pulled in by
+// the applications using it.
+type Metrics interface {
+	// GetHit is reported when the provider served a key lookup.
+	GetHit(provider string)
+	// GetMiss is reported when the provider is registered for the key but
+	// did not serve the lookup.
+	GetMiss(provider string)
+	// Reload is reported when the provider reloaded its config source.
+	Reload(provider string)
+	// MapperError is reported on every mapper failure.
+	MapperError()
+	// SetUpDuration reports the time it took to set up the provider.
+	SetUpDuration(provider string, d time.Duration)
+}
+
+// NopMetrics is a Metrics implementation discarding all reports.
+type NopMetrics struct{}
+
+var _ Metrics = (*NopMetrics)(nil)
+
+// GetHit is a no-op.
+func (*NopMetrics) GetHit(string) {}
+
+// GetMiss is a no-op.
+func (*NopMetrics) GetMiss(string) {}
+
+// Reload is a no-op.
+func (*NopMetrics) Reload(string) {}
+
+// MapperError is a no-op.
+func (*NopMetrics) MapperError() {}
+
+// SetUpDuration is a no-op.
+func (*NopMetrics) SetUpDuration(string, time.Duration) {}
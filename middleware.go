@@ -0,0 +1,67 @@
+package config
+
+// GetFunc is the signature of Repository.Get.
+type GetFunc func(key Key) (Value, bool)
+
+// Middleware wraps a GetFunc, similarly to an HTTP middleware: it might
+// serve the key on its own, alter the value returned by the next function or
+// observe the lookup.
+type Middleware func(next GetFunc) GetFunc
+
+// Use adds the middlewares wrapping every Get, e.g. a logging middleware:
+//
+//	repo.Use(func(next config.GetFunc) config.GetFunc {
+//		return func(key config.Key) (config.Value, bool) {
+//			v, ok := next(key)
+//			log.Printf("config lookup %s: %v", key, ok)
+//			return v, ok
+//		}
+//	})
+//
+// The middlewares are applied in the order they are added: the first one is
+// the outermost. Getters built on top of Get (e.g. Must* and Lookup*),
+// GetTree, GetAll and Provenance go through the chain, repository-wide
+// operations (e.g. Dump) don't.
+// This method is thread safe.
+func (repo *Repository) Use(mws ...Middleware) {
+	repo.mx.Lock()
+	middlewares := make([]Middleware, 0, len(repo.middlewares)+len(mws))
+	middlewares = append(append(middlewares, repo.middlewares...), mws...)
+	repo.middlewares = middlewares
+	repo.mx.Unlock()
+	// Middlewares are user code: the chain is built with the lock released
+	chain := chainMiddlewares(middlewares, repo.lookup)
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	// A concurrent Use might have added more middlewares in the meantime:
+	// its chain wins
+	if len(repo.middlewares) == len(middlewares) {
+		repo.chain.Store(chain)
+	}
+}
+
+// chainMiddlewares wraps the GetFunc with the middlewares, the first one
+// being the outermost.
+func chainMiddlewares(mws []Middleware, get GetFunc) GetFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		get = mws[i](get)
+	}
+	return get
+}
+
+// chainOver wraps the GetFunc with the middlewares added so far, e.g. to look
+// up raw values through the chain.
+func (repo *Repository) chainOver(get GetFunc) GetFunc {
+	repo.mx.Lock()
+	mws := repo.middlewares
+	repo.mx.Unlock()
+	return chainMiddlewares(mws, get)
+}
+
+// getChain returns the GetFunc wrapped with the middlewares.
+func (repo *Repository) getChain() GetFunc {
+	if chain, ok := repo.chain.Load().(GetFunc); ok {
+		return chain
+	}
+	return repo.lookup
+}
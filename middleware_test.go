@@ -0,0 +1,107 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRepositoryUse(t *testing.T) {
+	repo := NewRepository()
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"server.port": 8080,
+		"server.host": "localhost",
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	var trace []string
+	tracer := func(name string) Middleware {
+		return func(next GetFunc) GetFunc {
+			return func(key Key) (Value, bool) {
+				trace = append(trace, name+":"+key.String())
+				return next(key)
+			}
+		}
+	}
+	override := func(next GetFunc) GetFunc {
+		return func(key Key) (Value, bool) {
+			if key.String() == "server.port" {
+				return 9090, true
+			}
+			return next(key)
+		}
+	}
+	repo.Use(tracer("outer"), override)
+	repo.Use(tracer("inner"))
+
+	tests := []struct {
+		key       string
+		want      Value
+		wantTrace []string
+	}{
+		{"server.port", 9090, []string{"outer:server.port"}},
+		{"server.host", "localhost", []string{"outer:server.host", "inner:server.host"}},
+		{"server.missing", nil, []string{"outer:server.missing", "inner:server.missing"}},
+	}
+	for _, testCase := range tests {
+		trace = nil
+		got, _ := repo.Get(NewKey(testCase.key))
+		if !reflect.DeepEqual(got, testCase.want) {
+			t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", testCase.key, got, testCase.want)
+		}
+		if !reflect.DeepEqual(trace, testCase.wantTrace) {
+			t.Fatalf("Unexpected middleware trace: got: %#v, want: %#v", trace, testCase.wantTrace)
+		}
+	}
+	if got := MustInt(repo, "server.port"); got != 9090 {
+		t.Fatalf("Unexpected typed getter value: got: %#v, want: %#v", got, 9090)
+	}
+}
+
+func TestMiddlewareCompositeLookups(t *testing.T) {
+	repo := NewRepository()
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"server.port":    8080,
+		"server.host":    "localhost",
+		"plugins.a.port": 1,
+		"plugins.b.port": 2,
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	repo.Use(func(next GetFunc) GetFunc {
+		// The chain is built with the repository lock released
+		repo.Description(NewKey("server.port"))
+		return func(key Key) (Value, bool) {
+			switch key.String() {
+			case "server.port", "plugins.b.port":
+				return 9090, true
+			}
+			return next(key)
+		}
+	})
+
+	tree, _ := repo.GetTree(NewKey("server"))
+	if want := map[string]Value{"port": 9090, "host": "localhost"}; !reflect.DeepEqual(tree, want) {
+		t.Fatalf("Unexpected tree: got: %#v, want: %#v", tree, want)
+	}
+	all := repo.GetAll(NewKey("plugins.*.port"))
+	if want := map[string]Value{"plugins.a.port": 1, "plugins.b.port": 9090}; !reflect.DeepEqual(all, want) {
+		t.Fatalf("Unexpected values: got: %#v, want: %#v", all, want)
+	}
+
+	tests := []struct {
+		key  string
+		want *ValueMeta
+	}{
+		{"server.port", &ValueMeta{}},
+		{"server.host", &ValueMeta{Provider: "default"}},
+	}
+	for _, testCase := range tests {
+		got, ok := repo.Provenance(NewKey(testCase.key))
+		if !ok || !reflect.DeepEqual(got, testCase.want) {
+			t.Fatalf("Unexpected provenance for key %q: got: %#v, want: %#v", testCase.key, got, testCase.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConfigVersionKey is the key config documents declare their layout version
+// under (see Migrations).
+const ConfigVersionKey = "config_version"
+
+// MigrationFunc upgrades the flattened config values in place, e.g. renames
+// keys or restructures sections.
+type MigrationFunc func(values map[string]Value) error
+
+// AppliedMigration describes a migration step applied to a config document.
+type AppliedMigration struct {
+	From        int
+	To          int
+	Description string
+}
+
+// MigrationReport lists the migration steps applied to a config document.
+type MigrationReport struct {
+	// From is the document version, To is the version it was migrated to.
+	From    int
+	To      int
+	Applied []AppliedMigration
+}
+
+// String satisfies Stringer interface
+func (r *MigrationReport) String() string {
+	if len(r.Applied) == 0 {
+		return fmt.Sprintf("config version %d is up to date", r.From)
+	}
+	steps := make([]string, 0, len(r.Applied))
+	for _, m := range r.Applied {
+		steps = append(steps, fmt.Sprintf("%d->%d: %s", m.From, m.To, m.Description))
+	}
+	return fmt.Sprintf("migrated config version %d to %d (%s)", r.From, r.To,
+		strings.Join(steps, "; "))
+}
+
+type migrationStep struct {
+	description string
+	migrate     MigrationFunc
+}
+
+// Migrations is a registry of the config layout upgrades. A document
+// declaring an older ConfigVersionKey value is upgraded step by step to the
+// current version at load time (see YamlProviderOptions.Migrations). A
+// document with no version is considered to be of version 0.
+//
+// Example:
+//
+//	migrations := config.NewMigrations(2).
+//		Register(0, "move http to server", config.RenameKeys(map[string]string{"http": "server"})).
+//		Register(1, "rename server.addr", config.RenameKeys(map[string]string{"server.addr": "server.host"}))
+type Migrations struct {
+	current int
+	steps   map[int]migrationStep
+}
+
+// NewMigrations returns an empty migration registry for the current config
+// version.
+func NewMigrations(current int) *Migrations {
+	return &Migrations{
+		current: current,
+		steps:   make(map[int]migrationStep),
+	}
+}
+
+// Current returns the current config version.
+func (m *Migrations) Current() int {
+	return m.current
+}
+
+// Register sets the migration upgrading the config from version `from` to
+// `from+1`. Returns the registry for chaining.
+func (m *Migrations) Register(from int, description string, migrate MigrationFunc) *Migrations {
+	m.steps[from] = migrationStep{description: description, migrate: migrate}
+	return m
+}
+
+// Apply upgrades the flattened config values to the current version in
+// place. ConfigVersionKey is set to the current version. Fails if the
+// document is newer than the current version or a migration step is missing.
+func (m *Migrations) Apply(values map[string]Value) (*MigrationReport, error) {
+	version := 0
+	if v, ok := values[ConfigVersionKey]; ok {
+		kv, ok := ToInt.Convert(&KeyValue{Key: NewKey(ConfigVersionKey), Value: v})
+		if !ok {
+			return nil, fmt.Errorf("Malformed config version: %#v", v)
+		}
+		version = kv.Value.(int)
+	}
+	if version > m.current {
+		return nil, fmt.Errorf("Config version %d is newer than the supported version %d", version, m.current)
+	}
+	report := &MigrationReport{From: version, To: m.current}
+	for ; version < m.current; version++ {
+		step, ok := m.steps[version]
+		if !ok {
+			return nil, fmt.Errorf("No config migration registered from version %d", version)
+		}
+		if err := step.migrate(values); err != nil {
+			return nil, fmt.Errorf("Failed to migrate config version %d to %d: %s", version, version+1, err)
+		}
+		report.Applied = append(report.Applied, AppliedMigration{
+			From:        version,
+			To:          version + 1,
+			Description: step.description,
+		})
+	}
+	values[ConfigVersionKey] = m.current
+	return report, nil
+}
+
+// RenameKeys returns a migration moving the keys: a key is renamed along
+// with the keys nested under it, so whole sections might be moved. Fails if
+// the new key is already defined.
+func RenameKeys(renames map[string]string) MigrationFunc {
+	return func(values map[string]Value) error {
+		olds := make([]string, 0, len(renames))
+		for old := range renames {
+			olds = append(olds, old)
+		}
+		sort.Strings(olds)
+		for _, old := range olds {
+			moved := make(map[string]Value)
+			for k, v := range values {
+				if k == old {
+					moved[renames[old]] = v
+				} else if strings.HasPrefix(k, old+KeySepCh) {
+					moved[renames[old]+k[len(old):]] = v
+				} else {
+					continue
+				}
+				delete(values, k)
+			}
+			for k, v := range moved {
+				if _, ok := values[k]; ok {
+					return fmt.Errorf("Failed to rename %q: key %q is already defined", old, k)
+				}
+				values[k] = v
+			}
+		}
+		return nil
+	}
+}
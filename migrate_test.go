@@ -0,0 +1,142 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testMigrations() *Migrations {
+	return NewMigrations(2).
+		Register(0, "move http to server", RenameKeys(map[string]string{"http": "server"})).
+		Register(1, "rename server.addr", RenameKeys(map[string]string{"server.addr": "server.host"}))
+}
+
+func TestMigrationsApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		migrations  *Migrations
+		values      map[string]Value
+		want        map[string]Value
+		wantApplied int
+		wantErr     string
+	}{
+		{
+			name:       "unversioned",
+			migrations: testMigrations(),
+			values:     map[string]Value{"http.addr": "localhost", "http.port": 8080},
+			want: map[string]Value{
+				"server.host":    "localhost",
+				"server.port":    8080,
+				ConfigVersionKey: 2,
+			},
+			wantApplied: 2,
+		},
+		{
+			name:        "partial",
+			migrations:  testMigrations(),
+			values:      map[string]Value{ConfigVersionKey: "1", "server.addr": "localhost"},
+			want:        map[string]Value{"server.host": "localhost", ConfigVersionKey: 2},
+			wantApplied: 1,
+		},
+		{
+			name:       "up to date",
+			migrations: testMigrations(),
+			values:     map[string]Value{ConfigVersionKey: 2, "server.host": "localhost"},
+			want:       map[string]Value{"server.host": "localhost", ConfigVersionKey: 2},
+		},
+		{
+			name:       "newer version",
+			migrations: testMigrations(),
+			values:     map[string]Value{ConfigVersionKey: 3},
+			wantErr:    "Config version 3 is newer than the supported version 2",
+		},
+		{
+			name:       "malformed version",
+			migrations: testMigrations(),
+			values:     map[string]Value{ConfigVersionKey: "two"},
+			wantErr:    "Malformed config version",
+		},
+		{
+			name:       "missing step",
+			migrations: NewMigrations(1),
+			values:     map[string]Value{},
+			wantErr:    "No config migration registered from version 0",
+		},
+		{
+			name:       "rename conflict",
+			migrations: testMigrations(),
+			values:     map[string]Value{"http.port": 8080, "server.port": 9090},
+			wantErr:    `key "server.port" is already defined`,
+		},
+		{
+			name: "failing step",
+			migrations: NewMigrations(1).Register(0, "fail", func(map[string]Value) error {
+				return errors.New("boom")
+			}),
+			values:  map[string]Value{},
+			wantErr: "Failed to migrate config version 0 to 1: boom",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			report, err := testCase.migrations.Apply(testCase.values)
+			if len(testCase.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(testCase.values, testCase.want) {
+				t.Fatalf("Unexpected values: got: %#v, want: %#v", testCase.values, testCase.want)
+			}
+			if len(report.Applied) != testCase.wantApplied {
+				t.Fatalf("Unexpected applied migrations: got: %#v, want %d", report.Applied, testCase.wantApplied)
+			}
+		})
+	}
+}
+
+func TestYamlProviderMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config.yaml": &fstest.MapFile{Data: []byte("http:\n  addr: localhost\n  port: 8080\n")},
+	}
+	repo := NewRepository()
+	logger := &testLogger{}
+	repo.SetLogger(logger)
+	prov, _ := NewYamlProviderFromSource(repo, 0, &YamlProviderOptions{
+		FS:         fsys,
+		Migrations: testMigrations(),
+	}, "config.yaml")
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	for key, want := range map[string]Value{
+		"server.host":    "localhost",
+		"server.port":    8080,
+		ConfigVersionKey: 2,
+	} {
+		if got, ok := repo.Get(NewKey(key)); !ok || got != want {
+			t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", key, got, want)
+		}
+	}
+	if _, ok := repo.Get(NewKey("http.addr")); ok {
+		t.Fatalf("Expected key %q to be migrated", "http.addr")
+	}
+	wantReport := "migrated config version 0 to 2 (0->1: move http to server; 1->2: rename server.addr)"
+	if got := prov.MigrationReport().String(); got != wantReport {
+		t.Fatalf("Unexpected migration report: got: %q, want: %q", got, wantReport)
+	}
+	wantMsg := `INFO: Yaml config file "config.yaml": ` + wantReport
+	if got := logger.messages[len(logger.messages)-1]; got != wantMsg {
+		t.Fatalf("Unexpected log message: got: %q, want: %q", got, wantMsg)
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// normalizeValue converts the value to the canonical dynamic type (see
+// RepositoryOptions.Normalize):
+//   - integers of any width become int64, unsigned ones exceeding the int64
+//     range are kept as is;
+//   - floats become float64;
+//   - numeric strings become int64 or float64;
+//   - boolean strings (`true`, `false` by default, see SetBoolStrings, and
+//     the YAML 1.1 `yes`, `no`, `on`, `off` in any case) become bool;
+//   - lists become []Value and maps become map[string]Value, the elements are
+//     normalized recursively.
+//
+// Other values are returned as is.
+func normalizeValue(v Value) Value {
+	switch tv := v.(type) {
+	case nil, bool, int64, float64, []byte:
+		return v
+	case string:
+		return normalizeString(tv)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u)
+		}
+		return v
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.Slice, reflect.Array:
+		res := make([]Value, rv.Len())
+		for i := range res {
+			res[i] = normalizeValue(rv.Index(i).Interface())
+		}
+		return res
+	case reflect.Map:
+		m, ok := toValueMap(v)
+		if !ok {
+			return v
+		}
+		for k, mv := range m {
+			m[k] = normalizeValue(mv)
+		}
+		return m
+	}
+	return v
+}
+
+// yamlBoolStrings are the YAML 1.1 boolean spellings the normalization
+// accepts on top of the configured ones.
+var yamlBoolStrings = makeBoolStrings(ExtendedTrueStrings, ExtendedFalseStrings)
+
+func normalizeString(s string) Value {
+	if v, ok := parseBoolWord(s); ok {
+		return v
+	}
+	if v, ok := yamlBoolStrings[strings.ToLower(s)]; ok {
+		return v
+	}
+	// Rule out the special float values, e.g. `Inf` and `NaN`
+	if len(s) == 0 || strings.Trim(s, "0123456789+-.eE") != "" {
+		return s
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value Value
+		want  Value
+	}{
+		{"nil", nil, nil},
+		{"int", 42, int64(42)},
+		{"int8", int8(-8), int64(-8)},
+		{"uint16", uint16(16), int64(16)},
+		{"large uint64", uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{"float32", float32(0.5), float64(0.5)},
+		{"int string", "8080", int64(8080)},
+		{"negative int string", "-1", int64(-1)},
+		{"float string", "1.5e3", float64(1500)},
+		{"special float string", "NaN", "NaN"},
+		{"infinity string", "Inf", "Inf"},
+		{"yes", "yes", true},
+		{"Off", "Off", false},
+		{"TRUE", "TRUE", true},
+		{"False", "False", false},
+		{"y is not a bool", "y", "y"},
+		{"plain string", "localhost", "localhost"},
+		{"duration string", "1m30s", "1m30s"},
+		{"empty string", "", ""},
+		{"bytes", []byte("raw"), []byte("raw")},
+		{"list", []int{1, 2}, []Value{int64(1), int64(2)}},
+		{
+			"nested map",
+			map[interface{}]interface{}{"port": "8080", "tls": map[string]Value{"enabled": "on"}},
+			map[string]Value{"port": int64(8080), "tls": map[string]Value{"enabled": true}},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := normalizeValue(testCase.value); !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected normalized value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryNormalize(t *testing.T) {
+	repo := NewRepositoryWithOptions(&RepositoryOptions{Normalize: true})
+	repo.DefineSchema(map[string]Schema{
+		"server": map[string]Schema{"port": ToInt},
+	})
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"workers":     4,
+		"debug":       false,
+		"server.port": 8080,
+	})
+	if _, err := NewMapProvider(repo, 10, "env", map[string]Value{
+		"ratio":       "0.75",
+		"debug":       "yes",
+		"server.port": "9090",
+	}); err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	want := map[string]Value{
+		"workers":     int64(4),
+		"ratio":       0.75,
+		"debug":       true,
+		"server.port": 9090,
+	}
+	if got := repo.Dump(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected config: got: %#v, want: %#v", got, want)
+	}
+}
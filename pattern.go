@@ -0,0 +1,102 @@
+package config
+
+// WildcardFragment matches any single key fragment in key patterns, the same
+// way it does in schema definitions.
+const WildcardFragment = "*"
+
+// matchesPattern returns true if the key or any of its parents matches the
+// pattern. A `*` pattern fragment matches any single key fragment.
+func matchesPattern(pattern Key, key Key) bool {
+	if len(key) < len(pattern) {
+		return false
+	}
+	for i, k := range pattern {
+		if k != WildcardFragment && k != key[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// match returns the list of registered keys matching the pattern exactly.
+func (n *node) match(pref Key, pattern Key) []Key {
+	providers, children := n.view()
+	if len(pattern) == 0 {
+		if len(providers) == 0 && len(children) == 0 {
+			return []Key{}
+		}
+		return []Key{pref}
+	}
+	res := make([]Key, 0)
+	if pattern[0] == WildcardFragment {
+		for k, ch := range children {
+			res = append(res, ch.match(pref.Child(k), pattern[1:])...)
+		}
+	} else if ch, ok := children[pattern[0]]; ok {
+		res = append(res, ch.match(pref.Child(pattern[0]), pattern[1:])...)
+	}
+	return res
+}
+
+// GetAll returns the values of all keys matching the pattern. A `*` fragment
+// matches any single key fragment, e.g. `plugins.*.enabled` matches both
+// `plugins.auth.enabled` and `plugins.cache.enabled`. The result is keyed by
+// the matched keys (see `KeyString`). A pattern with no wildcards is
+// equivalent to Get. The values are looked up through the middleware chain
+// (see Use).
+// This method is thread safe.
+func (repo *Repository) GetAll(pattern Key) map[string]Value {
+	pattern = repo.foldKey(pattern)
+	static := pattern
+	for i, k := range pattern {
+		if k == WildcardFragment {
+			static = pattern[:i]
+			break
+		}
+	}
+	if len(static) != 0 {
+		repo.activateLazy(static)
+	}
+	repo.mx.Lock()
+	keys := repo.root.match(nil, pattern)
+	repo.mx.Unlock()
+	res := make(map[string]Value, len(keys))
+	get := repo.getChain()
+	for _, key := range keys {
+		repo.audit("", key)
+		if v, ok := get(key); ok {
+			res[repo.KeyString(key)] = v
+		}
+	}
+	return res
+}
+
+// SubscribePattern is a version of Subscribe delivering only the changes of
+// the keys matching the pattern (see GetAll) or nested under them: e.g. a
+// subscription to `limits.*` is notified of a change of `limits.api.rps`.
+// Events carrying no matching changes are not delivered, rejected reload
+// events (see `ChangeEvent.Err`) are always delivered.
+// Returns a function cancelling the subscription.
+// This method is thread safe.
+func (repo *Repository) SubscribePattern(pattern Key, listener func(*ChangeEvent)) func() {
+	pattern = repo.foldKey(pattern)
+	return repo.Subscribe(func(event *ChangeEvent) {
+		if event.Err != nil {
+			listener(event)
+			return
+		}
+		changes := make([]Change, 0, len(event.Changes))
+		for _, change := range event.Changes {
+			if matchesPattern(pattern, change.Key) {
+				changes = append(changes, change)
+			}
+		}
+		if len(changes) == 0 {
+			return
+		}
+		listener(&ChangeEvent{
+			ChangeSet: ChangeSet{Provider: event.Provider, Changes: changes},
+			Err:       event.Err,
+		})
+	})
+}
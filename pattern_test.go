@@ -0,0 +1,105 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetAll(t *testing.T) {
+	repo := NewRepository()
+	if _, err := NewMapProvider(repo, 10, "map", map[string]Value{
+		"plugins.auth.enabled":  true,
+		"plugins.auth.path":     "/auth",
+		"plugins.cache.enabled": false,
+		"plugins.cache.size":    128,
+		"limits.api.rps":        10,
+	}); err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	tests := []struct {
+		pattern string
+		want    map[string]Value
+	}{
+		{
+			"plugins.*.enabled",
+			map[string]Value{
+				"plugins.auth.enabled":  true,
+				"plugins.cache.enabled": false,
+			},
+		},
+		{
+			"*.api",
+			map[string]Value{
+				"limits.api": map[string]Value{"rps": 10},
+			},
+		},
+		{
+			"plugins.auth.path",
+			map[string]Value{
+				"plugins.auth.path": "/auth",
+			},
+		},
+		{
+			"plugins.*.missing",
+			map[string]Value{},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			if got := repo.GetAll(NewKey(testCase.pattern)); !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected values: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestSubscribePattern(t *testing.T) {
+	repo := NewRepository()
+	registry := map[string]Value{
+		"limits.api.rps":  10,
+		"limits.web.rps":  20,
+		"server.port":     8080,
+		"server.tls.cert": "cert.pem",
+	}
+	prov, err := NewMapProvider(repo, 10, "map", registry)
+	if err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	events := make([]*ChangeEvent, 0)
+	unsubscribe := repo.SubscribePattern(NewKey("limits.*"), func(e *ChangeEvent) {
+		events = append(events, e)
+	})
+
+	reload := func(k string, v Value) {
+		if err := repo.ApplyReload(prov, func() error {
+			registry[k] = v
+			return nil
+		}, nil); err != nil {
+			t.Fatalf("Failed to apply reload: %s", err)
+		}
+	}
+	reload("server.port", 9090)
+	reload("limits.web.rps", 30)
+	unsubscribe()
+	reload("limits.api.rps", 15)
+
+	want := []*ChangeEvent{
+		{
+			ChangeSet: ChangeSet{
+				Provider: "map",
+				Changes:  []Change{{Key: NewKey("limits.web.rps"), Old: 20, New: 30}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("Unexpected events: got: %#v, want: %#v", events, want)
+	}
+}
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"sync"
+)
+
+const (
+	// PluginLoaderName is the name of the PluginLoader provider.
+	PluginLoaderName = "plugins"
+	// DefaultPluginPathKey is the default key of the plugin folder location.
+	DefaultPluginPathKey = "plugins.path"
+	// DefaultProvidersKey is the default key of the provider sections.
+	DefaultProvidersKey = "providers"
+)
+
+// ProviderFactory creates a provider out of its config section. The weight
+// is taken from the section (see PluginLoader). The settings getter is
+// scoped to the section. The factory might register the provider in the
+// repository itself (as provider constructors do): the loader registers it
+// anyway.
+type ProviderFactory func(repo *Repository, weight int, settings Getter) (Provider, error)
+
+var (
+	providerFactories   = make(map[string]ProviderFactory)
+	providerFactoriesMx sync.RWMutex
+)
+
+// RegisterProviderFactory registers the provider factory under the name.
+// Provider packages and Go plugins are expected to call it from init().
+// Registering a factory under an already registered name replaces it.
+// This function is thread safe.
+func RegisterProviderFactory(name string, factory ProviderFactory) {
+	providerFactoriesMx.Lock()
+	defer providerFactoriesMx.Unlock()
+	providerFactories[name] = factory
+}
+
+// ProviderFactoryFor returns the provider factory registered under the name.
+// This function is thread safe.
+func ProviderFactoryFor(name string) (ProviderFactory, bool) {
+	providerFactoriesMx.RLock()
+	defer providerFactoriesMx.RUnlock()
+	factory, ok := providerFactories[name]
+	return factory, ok
+}
+
+// Redefined in tests
+var openPlugin = func(path string) error {
+	_, err := plugin.Open(path)
+	return err
+}
+
+// PluginLoaderOptions is a set of PluginLoader settings.
+type PluginLoaderOptions struct {
+	// Depends are the names of the providers serving the loader settings,
+	// e.g. `[]string{"cli", "env", "yaml"}`.
+	Depends []string
+	// PluginPathKey is the key of the plugin folder location.
+	// DefaultPluginPathKey is used if not set.
+	PluginPathKey string
+	// ProvidersKey is the key of the provider sections. DefaultProvidersKey
+	// is used if not set.
+	ProvidersKey string
+}
+
+// PluginLoader is a provider creating other providers out of the config:
+// deployments might enable a provider (e.g. a Consul one) by means of
+// configuration rather than recompiling the application. It serves no keys
+// itself. Once the providers it depends on are set up, the loader:
+//   - opens all Go plugins (*.so files) found in the plugin folder (if set).
+//     Plugins are expected to register provider factories from init() (see
+//     RegisterProviderFactory);
+//   - creates a provider for every enabled section under the providers key
+//     using the factory registered under the section `type` (the section
+//     name by default) and sets it up right away.
+//
+// A provider section looks like:
+//
+//	plugins:
+//	  path: /usr/lib/myapp/plugins
+//	providers:
+//	  consul:
+//	    type: consul     # the factory name, defaults to the section name
+//	    weight: 50       # required
+//	    enabled: true    # true if not set
+//	    address: consul.local:8500
+//
+// The loaded providers are registered in the repository and torn down with
+// it.
+type PluginLoader struct {
+	options *PluginLoaderOptions
+	loaded  []Provider
+	mx      sync.Mutex
+}
+
+var _ Provider = (*PluginLoader)(nil)
+var _ ContextSetUpper = (*PluginLoader)(nil)
+
+// NewPluginLoader is the constructor for PluginLoader. A nil options
+// argument is equivalent to the default options.
+func NewPluginLoader(repo *Repository, options *PluginLoaderOptions) (*PluginLoader, error) {
+	if options == nil {
+		options = &PluginLoaderOptions{}
+	}
+	pl := &PluginLoader{options: options}
+	repo.RegisterProvider(pl)
+	return pl, nil
+}
+
+// Name returns provider name: plugins
+func (pl *PluginLoader) Name() string { return PluginLoaderName }
+
+// Depends returns the list of provider dependencies provided in the options
+func (pl *PluginLoader) Depends() []string { return pl.options.Depends }
+
+// Weight returns the provider weight: the loader serves no keys
+func (pl *PluginLoader) Weight() int { return 0 }
+
+// Get returns nothing: the loader serves no keys
+func (pl *PluginLoader) Get(Key) (*KeyValue, bool) { return nil, false }
+
+// TearDown is a no-op: the loaded providers are torn down by the repository
+func (pl *PluginLoader) TearDown(*Repository) error { return nil }
+
+// SetUp loads the providers using a background context.
+func (pl *PluginLoader) SetUp(repo *Repository) error {
+	return pl.SetUpContext(context.Background(), repo)
+}
+
+// SetUpContext opens the plugins, creates and sets up the configured
+// providers. Returns an error if a plugin can not be opened, a factory is not
+// registered or a provider fails to set up.
+func (pl *PluginLoader) SetUpContext(ctx context.Context, repo *Repository) error {
+	if err := pl.openPlugins(repo); err != nil {
+		return err
+	}
+	providersKey := pl.options.ProvidersKey
+	if len(providersKey) == 0 {
+		providersKey = DefaultProvidersKey
+	}
+	sections, ok := repo.GetTree(repo.NewKey(providersKey))
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prov, err := loadProvider(repo, repo.Scope(providersKey+"."+name), name)
+		if err != nil {
+			return err
+		}
+		if prov == nil {
+			continue
+		}
+		repo.RegisterProvider(prov)
+		if err := repo.setUpOne(ctx, prov); err != nil {
+			return err
+		}
+		pl.mx.Lock()
+		pl.loaded = append(pl.loaded, prov)
+		pl.mx.Unlock()
+	}
+	return nil
+}
+
+func (pl *PluginLoader) openPlugins(repo *Repository) error {
+	pathKey := pl.options.PluginPathKey
+	if len(pathKey) == 0 {
+		pathKey = DefaultPluginPathKey
+	}
+	dir, ok, err := LookupStr(repo, pathKey)
+	if err != nil || !ok {
+		return err
+	}
+	return openPluginDir(repo.Logger(), dir)
+}
+
+// openPluginDir opens all Go plugins (*.so files) found in the folder. An
+// empty folder path is a no-op.
+func openPluginDir(logger Logger, dir string) error {
+	if len(dir) == 0 {
+		return nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return fmt.Errorf("Failed to list config plugins in %q: %s", dir, err)
+	}
+	for _, path := range paths {
+		logger.Infof("Loading config plugin %q", path)
+		if err := openPlugin(path); err != nil {
+			return fmt.Errorf("Failed to load config plugin %q: %s", path, err)
+		}
+	}
+	return nil
+}
+
+// loadProvider creates the provider configured in the section using the
+// factory registered under the section type. Returns nil if the section is
+// disabled.
+func loadProvider(repo *Repository, section Getter, name string) (Provider, error) {
+	enabled, ok, err := LookupBool(section, "enabled")
+	if err != nil {
+		return nil, err
+	}
+	if ok && !enabled {
+		repo.Logger().Infof("Skipped disabled config provider section %q", name)
+		return nil, nil
+	}
+	typ, ok, err := LookupStr(section, "type")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		typ = name
+	}
+	weight, ok, err := LookupInt(section, "weight")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("Config provider section %q has no weight", name)
+	}
+	factory, ok := ProviderFactoryFor(typ)
+	if !ok {
+		return nil, fmt.Errorf("No config provider factory registered for type %q (section %q)", typ, name)
+	}
+	prov, err := factory(repo, weight, section)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create config provider %q: %s", name, err)
+	}
+	return prov, nil
+}
+
+// Loaded returns the providers created by the loader.
+func (pl *PluginLoader) Loaded() []Provider {
+	pl.mx.Lock()
+	defer pl.mx.Unlock()
+	return append([]Provider(nil), pl.loaded...)
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func withProviderFactory(t *testing.T, name string, factory ProviderFactory) {
+	providerFactoriesMx.Lock()
+	prev, ok := providerFactories[name]
+	providerFactoriesMx.Unlock()
+	RegisterProviderFactory(name, factory)
+	t.Cleanup(func() {
+		providerFactoriesMx.Lock()
+		defer providerFactoriesMx.Unlock()
+		if ok {
+			providerFactories[name] = prev
+		} else {
+			delete(providerFactories, name)
+		}
+	})
+}
+
+func testMapProviderFactory(repo *Repository, weight int, settings Getter) (Provider, error) {
+	name, _, err := LookupStr(settings, "name")
+	if err != nil {
+		return nil, err
+	}
+	value, _, err := LookupStr(settings, "value")
+	if err != nil {
+		return nil, err
+	}
+	return NewMapProvider(repo, weight, name, map[string]Value{"extra.value": value})
+}
+
+func TestPluginLoader(t *testing.T) {
+	withProviderFactory(t, "test-map", testMapProviderFactory)
+
+	dir := t.TempDir()
+	for _, name := range []string{"b.so", "a.so", "readme.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("Failed to create the plugin file: %s", err)
+		}
+	}
+	var opened []string
+	prevOpenPlugin := openPlugin
+	defer func() { openPlugin = prevOpenPlugin }()
+	openPlugin = func(path string) error {
+		opened = append(opened, filepath.Base(path))
+		return nil
+	}
+
+	repo := NewRepository()
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"plugins.path":                   dir,
+		"providers.primary.type":         "test-map",
+		"providers.primary.weight":       20,
+		"providers.primary.name":         "primary",
+		"providers.primary.value":        "primary value",
+		"providers.secondary.type":       "test-map",
+		"providers.secondary.weight":     "5",
+		"providers.secondary.name":       "secondary",
+		"providers.secondary.value":      "secondary value",
+		"providers.disabled.type":        "test-map",
+		"providers.disabled.enabled":     false,
+		"providers.disabled.weight":      30,
+		"providers.disabled.name":        "disabled",
+		"providers.disabled.value":       "disabled value",
+		"providers.unregistered.type":    "consul",
+		"providers.unregistered.enabled": "false",
+	})
+	pl, _ := NewPluginLoader(repo, &PluginLoaderOptions{Depends: []string{"default"}})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	if want := []string{"a.so", "b.so"}; !reflect.DeepEqual(opened, want) {
+		t.Fatalf("Unexpected opened plugins: got: %#v, want: %#v", opened, want)
+	}
+	var loaded []string
+	for _, prov := range pl.Loaded() {
+		loaded = append(loaded, prov.Name())
+	}
+	if want := []string{"primary", "secondary"}; !reflect.DeepEqual(loaded, want) {
+		t.Fatalf("Unexpected loaded providers: got: %#v, want: %#v", loaded, want)
+	}
+	if got, _, _ := LookupStr(repo, "extra.value"); got != "primary value" {
+		t.Fatalf("Unexpected value: got: %q, want: %q", got, "primary value")
+	}
+}
+
+func TestPluginLoaderErrors(t *testing.T) {
+	withProviderFactory(t, "test-map", testMapProviderFactory)
+
+	tests := []struct {
+		name    string
+		values  map[string]Value
+		wantErr string
+	}{
+		{
+			"unregistered type",
+			map[string]Value{"providers.consul.weight": 10},
+			`No config provider factory registered for type "consul"`,
+		},
+		{
+			"missing weight",
+			map[string]Value{"providers.extra.type": "test-map"},
+			`Config provider section "extra" has no weight`,
+		},
+		{
+			"malformed weight",
+			map[string]Value{"providers.extra.type": "test-map", "providers.extra.weight": "heavy"},
+			"weight",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			NewDefaultProviderWithDefaults(repo, 0, testCase.values)
+			NewPluginLoader(repo, &PluginLoaderOptions{Depends: []string{"default"}})
+			err := repo.SetUp()
+			if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+				t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import "fmt"
+
+// SetPrecedence defines the provider precedence explicitly as an alternative
+// to numeric weights. Providers are referred to by name, the first one has the
+// highest precedence. Listed providers take precedence over the unlisted ones,
+// unlisted providers are ordered by weight.
+//
+// Example:
+//
+//	repo.SetPrecedence([]string{"cli", "env", "yaml", "default"})
+//
+// Returns an error if a provider is listed more than once.
+// This method is thread safe.
+func (repo *Repository) SetPrecedence(names []string) error {
+	precedence := make(map[string]int, len(names))
+	for rank, name := range names {
+		if _, ok := precedence[name]; ok {
+			return fmt.Errorf("Config provider %q is listed more than once", name)
+		}
+		precedence[name] = rank
+	}
+	repo.viewMx.Lock()
+	defer repo.viewMx.Unlock()
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	repo.precedence = precedence
+	repo.root.walk(func(n *node) {
+		n.sort(repo.precedes)
+	})
+	return nil
+}
+
+// precedes returns true if the provider a takes precedence over the provider
+// b. The caller is expected to hold mx.
+func (repo *Repository) precedes(a, b Provider) bool {
+	// Evaluated expressions replace the expressions they originate from,
+	// rolled back values replace the values served by providers
+	if ra, rb := pinnedRank(a), pinnedRank(b); ra != rb {
+		return ra < rb
+	}
+	ra, aok := repo.precedence[a.Name()]
+	rb, bok := repo.precedence[b.Name()]
+	switch {
+	case aok && bok:
+		return ra < rb
+	case aok != bok:
+		return aok
+	}
+	return a.Weight() > b.Weight()
+}
+
+// pinnedRank ranks the internal providers which always take precedence over
+// the regular ones.
+func pinnedRank(prov Provider) int {
+	switch prov.(type) {
+	case *expressions:
+		return 0
+	case *history:
+		return 1
+	}
+	return 2
+}
+
+// walk calls the function for the node and all of its descendants.
+func (n *node) walk(fn func(*node)) {
+	fn(n)
+	_, children := n.view()
+	for _, ch := range children {
+		ch.walk(fn)
+	}
+}
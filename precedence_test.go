@@ -0,0 +1,99 @@
+package config
+
+import "testing"
+
+func TestWeightTies(t *testing.T) {
+	tests := []struct {
+		name  string
+		order []string
+	}{
+		{"first registered", []string{"first", "second", "third"}},
+		{"reversed", []string{"third", "second", "first"}},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			for _, name := range testCase.order {
+				prov, _ := NewMapProvider(repo, DefaultWeight, name, map[string]Value{"foo": name})
+				if err := prov.SetUp(repo); err != nil {
+					t.Fatalf("Failed to set up the provider: %s", err)
+				}
+			}
+			want := testCase.order[0]
+			if got, _ := repo.Get(NewKey("foo")); got != want {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestSetPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		precedence []string
+		want       Value
+	}{
+		{
+			"weights",
+			nil,
+			"env",
+		},
+		{
+			"explicit precedence",
+			[]string{"cli", "yaml", "env"},
+			"cli",
+		},
+		{
+			"listed providers first",
+			[]string{"yaml"},
+			"yaml",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			for name, weight := range map[string]int{"cli": 10, "env": 30, "yaml": 20} {
+				if _, err := NewMapProvider(repo, weight, name, map[string]Value{"foo": name}); err != nil {
+					t.Fatalf("Failed to create a map provider: %s", err)
+				}
+			}
+			if err := repo.SetUp(); err != nil {
+				t.Fatalf("Failed to set up the repository: %s", err)
+			}
+			if testCase.precedence != nil {
+				if err := repo.SetPrecedence(testCase.precedence); err != nil {
+					t.Fatalf("Failed to set the precedence: %s", err)
+				}
+			}
+			if got, _ := repo.Get(NewKey("foo")); got != testCase.want {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestSetPrecedenceBeforeSetUp(t *testing.T) {
+	repo := NewRepository()
+	if err := repo.SetPrecedence([]string{"low", "high"}); err != nil {
+		t.Fatalf("Failed to set the precedence: %s", err)
+	}
+	NewMapProvider(repo, 10, "low", map[string]Value{"foo": "low"})
+	NewMapProvider(repo, 20, "high", map[string]Value{"foo": "high"})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if got, _ := repo.Get(NewKey("foo")); got != "low" {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", got, "low")
+	}
+}
+
+func TestSetPrecedenceDuplicates(t *testing.T) {
+	repo := NewRepository()
+	err := repo.SetPrecedence([]string{"env", "yaml", "env"})
+	want := `Config provider "env" is listed more than once`
+	if err == nil || err.Error() != want {
+		t.Fatalf("Unexpected error: got: %v, want: %q", err, want)
+	}
+}
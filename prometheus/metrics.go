@@ -0,0 +1,100 @@
+// Package prometheus provides a Prometheus adapter for config.Metrics.
+package prometheus
+
+import (
+	"time"
+
+	"github.com/osdrv/config"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is a config.Metrics implementation backed by Prometheus
+// collectors. All metric names are prefixed with the namespace provided to
+// the constructor.
+type Metrics struct {
+	gets          *prom.CounterVec
+	reloads       *prom.CounterVec
+	mapperErrors  prom.Counter
+	setUpDuration *prom.GaugeVec
+}
+
+var _ config.Metrics = (*Metrics)(nil)
+var _ prom.Collector = (*Metrics)(nil)
+
+// NewMetrics is the constructor for Metrics. The returned value is a
+// prometheus.Collector and must be registered in a prometheus registry.
+//
+// Example:
+//
+//	metrics := prometheus.NewMetrics("myapp")
+//	prom.MustRegister(metrics)
+//	repo.SetMetrics(metrics)
+func NewMetrics(namespace string) *Metrics {
+	return &Metrics{
+		gets: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "config",
+			Name:      "provider_gets_total",
+			Help:      "Number of key lookups per provider and result (hit or miss).",
+		}, []string{"provider", "result"}),
+		reloads: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "config",
+			Name:      "provider_reloads_total",
+			Help:      "Number of config source reloads per provider.",
+		}, []string{"provider"}),
+		mapperErrors: prom.NewCounter(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "config",
+			Name:      "mapper_errors_total",
+			Help:      "Number of mapper failures.",
+		}),
+		setUpDuration: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "config",
+			Name:      "provider_setup_duration_seconds",
+			Help:      "Time it took to set up the provider.",
+		}, []string{"provider"}),
+	}
+}
+
+// GetHit increments the provider hit counter.
+func (m *Metrics) GetHit(provider string) {
+	m.gets.WithLabelValues(provider, "hit").Inc()
+}
+
+// GetMiss increments the provider miss counter.
+func (m *Metrics) GetMiss(provider string) {
+	m.gets.WithLabelValues(provider, "miss").Inc()
+}
+
+// Reload increments the provider reload counter.
+func (m *Metrics) Reload(provider string) {
+	m.reloads.WithLabelValues(provider).Inc()
+}
+
+// MapperError increments the mapper error counter.
+func (m *Metrics) MapperError() {
+	m.mapperErrors.Inc()
+}
+
+// SetUpDuration sets the provider set up duration gauge.
+func (m *Metrics) SetUpDuration(provider string, d time.Duration) {
+	m.setUpDuration.WithLabelValues(provider).Set(d.Seconds())
+}
+
+// Describe satisfies prometheus.Collector interface.
+func (m *Metrics) Describe(ch chan<- *prom.Desc) {
+	m.gets.Describe(ch)
+	m.reloads.Describe(ch)
+	m.mapperErrors.Describe(ch)
+	m.setUpDuration.Describe(ch)
+}
+
+// Collect satisfies prometheus.Collector interface.
+func (m *Metrics) Collect(ch chan<- prom.Metric) {
+	m.gets.Collect(ch)
+	m.reloads.Collect(ch)
+	m.mapperErrors.Collect(ch)
+	m.setUpDuration.Collect(ch)
+}
@@ -0,0 +1,70 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/osdrv/config"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type testProv struct {
+	val config.Value
+}
+
+func (tp *testProv) Name() string                      { return "test" }
+func (tp *testProv) Depends() []string                 { return []string{} }
+func (tp *testProv) SetUp(*config.Repository) error    { return nil }
+func (tp *testProv) TearDown(*config.Repository) error { return nil }
+func (tp *testProv) Weight() int                       { return 10 }
+func (tp *testProv) Get(key config.Key) (*config.KeyValue, bool) {
+	if tp.val == nil {
+		return nil, false
+	}
+	return &config.KeyValue{Key: key, Value: tp.val}, true
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics("test")
+	reg := prom.NewPedanticRegistry()
+	if err := reg.Register(metrics); err != nil {
+		t.Fatalf("Failed to register metrics: %s", err)
+	}
+
+	repo := config.NewRepository()
+	repo.SetMetrics(metrics)
+	repo.DefineSchema(map[string]config.Schema{"bar": config.ToInt})
+	repo.RegisterKey(config.NewKey("foo"), &testProv{val: 42})
+	repo.RegisterKey(config.NewKey("bar"), &testProv{val: "abc"})
+	repo.RegisterKey(config.NewKey("baz"), &testProv{})
+
+	repo.Get(config.NewKey("foo"))
+	repo.Get(config.NewKey("foo"))
+	repo.Get(config.NewKey("baz"))
+	func() {
+		defer func() { recover() }()
+		repo.Get(config.NewKey("bar"))
+	}()
+	metrics.Reload("test")
+
+	want := `
+# HELP test_config_mapper_errors_total Number of mapper failures.
+# TYPE test_config_mapper_errors_total counter
+test_config_mapper_errors_total 1
+# HELP test_config_provider_gets_total Number of key lookups per provider and result (hit or miss).
+# TYPE test_config_provider_gets_total counter
+test_config_provider_gets_total{provider="test",result="hit"} 3
+test_config_provider_gets_total{provider="test",result="miss"} 1
+# HELP test_config_provider_reloads_total Number of config source reloads per provider.
+# TYPE test_config_provider_reloads_total counter
+test_config_provider_reloads_total{provider="test"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"test_config_mapper_errors_total",
+		"test_config_provider_gets_total",
+		"test_config_provider_reloads_total",
+	); err != nil {
+		t.Fatalf("Unexpected metrics: %s", err)
+	}
+}
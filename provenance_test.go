@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestProvenance(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	fsys := fstest.MapFS{
+		"config.yaml": &fstest.MapFile{Data: []byte(
+			"base: &base\n" +
+				"  timeout: 5s\n" +
+				"server:\n" +
+				"  <<: *base\n" +
+				"  port: http\n" +
+				"  host: localhost\n")},
+	}
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"server": map[string]Schema{
+			"port": ToInt,
+		},
+	})
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{"server.debug": false})
+	NewYamlProviderFromSource(repo, 10, &YamlProviderOptions{FS: fsys}, "config.yaml")
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	tests := []struct {
+		key  string
+		want *ValueMeta
+	}{
+		{"server.host", &ValueMeta{Provider: "yaml", LoadedAt: now, File: "config.yaml", Line: 6, Column: 9}},
+		{"server.timeout", &ValueMeta{Provider: "yaml", LoadedAt: now, File: "config.yaml", Line: 2, Column: 12}},
+		{"server.debug", &ValueMeta{Provider: "default"}},
+	}
+	for _, testCase := range tests {
+		got, ok := repo.Provenance(NewKey(testCase.key))
+		if !ok || !reflect.DeepEqual(got, testCase.want) {
+			t.Fatalf("Unexpected provenance for key %q: got: %#v, want: %#v", testCase.key, got, testCase.want)
+		}
+	}
+	if _, ok := repo.Provenance(NewKey("server.missing")); ok {
+		t.Fatalf("Unexpected provenance for a missing key")
+	}
+
+	_, _, err := Lookup(repo, "server.port")
+	var cerr *ConversionError
+	if !errors.As(err, &cerr) || cerr.Location != "config.yaml:5:9" {
+		t.Fatalf("Unexpected conversion error: got: %v, want a location: %q", err, "config.yaml:5:9")
+	}
+	wantMsg := `Failed to map the value "http" for key "server.port" provided by "yaml" at config.yaml:5:9: `
+	if got := cerr.Error(); len(got) < len(wantMsg) || got[:len(wantMsg)] != wantMsg {
+		t.Fatalf("Unexpected error message: got: %q, want it to start with: %q", got, wantMsg)
+	}
+
+	sources, _ := repo.Sources(NewKey("server.host"))
+	if len(sources) != 1 || sources[0].Location != "config.yaml:6:9" {
+		t.Fatalf("Unexpected sources: got: %#v", sources)
+	}
+}
+
+func TestProvenanceMigrated(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config.yaml": &fstest.MapFile{Data: []byte(
+			"http:\n" +
+				"  port: 8080\n" +
+				"  host: localhost\n" +
+				"debug: true\n")},
+	}
+	repo := NewRepository()
+	NewYamlProviderFromSource(repo, 10, &YamlProviderOptions{
+		FS: fsys,
+		Migrations: NewMigrations(1).
+			Register(0, "move http to server", RenameKeys(map[string]string{"http": "server"})),
+	}, "config.yaml")
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"server.port", "config.yaml:2:9"},
+		{"server.host", "config.yaml:3:9"},
+		{"debug", "config.yaml:4:8"},
+		{ConfigVersionKey, "config.yaml"},
+	}
+	for _, testCase := range tests {
+		got, ok := repo.Provenance(NewKey(testCase.key))
+		if !ok || got.Location() != testCase.want {
+			t.Fatalf("Unexpected provenance for key %q: got: %#v, want location: %q", testCase.key, got, testCase.want)
+		}
+	}
+	if _, ok := repo.Provenance(NewKey("http.port")); ok {
+		t.Fatalf("Unexpected provenance for a migrated key")
+	}
+}
+
+func TestValueMetaLocation(t *testing.T) {
+	tests := []struct {
+		meta *ValueMeta
+		want string
+	}{
+		{nil, ""},
+		{&ValueMeta{Provider: "env"}, ""},
+		{&ValueMeta{File: "config.yaml"}, "config.yaml"},
+		{&ValueMeta{File: "config.yaml", Line: 3}, "config.yaml:3"},
+		{&ValueMeta{File: "config.yaml", Line: 3, Column: 7}, "config.yaml:3:7"},
+	}
+	for _, testCase := range tests {
+		if got := testCase.meta.Location(); got != testCase.want {
+			t.Fatalf("Unexpected location: got: %q, want: %q", got, testCase.want)
+		}
+	}
+}
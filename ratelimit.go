@@ -0,0 +1,279 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimit is a rate limit: Events per Per.
+type RateLimit struct {
+	Events int
+	Per    time.Duration
+	// Burst is the maximum number of events allowed at once. 0 stands for
+	// Events.
+	Burst int
+}
+
+var rateLimitUnits = map[string]time.Duration{
+	"ms":  time.Millisecond,
+	"s":   time.Second,
+	"sec": time.Second,
+	"m":   time.Minute,
+	"min": time.Minute,
+	"h":   time.Hour,
+}
+
+// ParseRateLimit parses a rate limit string like "100/s", "5/min" or
+// "10/30s". The supported units are ms, s (sec), m (min) and h, any duration
+// string is accepted as well.
+func ParseRateLimit(s string) (RateLimit, error) {
+	events, per, found := strings.Cut(strings.TrimSpace(s), "/")
+	if !found {
+		return RateLimit{}, fmt.Errorf("Failed to parse rate limit %q: want <events>/<period>", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(events))
+	if err != nil {
+		return RateLimit{}, fmt.Errorf("Failed to parse rate limit %q: malformed number of events", s)
+	}
+	per = strings.TrimSpace(per)
+	d, ok := rateLimitUnits[per]
+	if !ok {
+		if d, err = time.ParseDuration(per); err != nil {
+			return RateLimit{}, fmt.Errorf("Failed to parse rate limit %q: malformed period", s)
+		}
+	}
+	rl := RateLimit{Events: n, Per: d}
+	if err := rl.Validate(); err != nil {
+		return RateLimit{}, err
+	}
+	return rl, nil
+}
+
+// Validate returns an error if the rate limit settings are inconsistent.
+func (rl RateLimit) Validate() error {
+	switch {
+	case rl.Events < 0:
+		return fmt.Errorf("Rate limit events must not be negative, got: %d", rl.Events)
+	case rl.Per <= 0:
+		return fmt.Errorf("Rate limit period must be positive, got: %s", rl.Per)
+	case rl.Burst < 0:
+		return fmt.Errorf("Rate limit burst must not be negative, got: %d", rl.Burst)
+	}
+	return nil
+}
+
+// PerSecond returns the limit in events per second, e.g. for
+// `rate.Limit(rl.PerSecond())`.
+func (rl RateLimit) PerSecond() float64 {
+	if rl.Per <= 0 {
+		return 0
+	}
+	return float64(rl.Events) / rl.Per.Seconds()
+}
+
+// MaxBurst returns Burst or Events if Burst is not set.
+func (rl RateLimit) MaxBurst() int {
+	if rl.Burst > 0 {
+		return rl.Burst
+	}
+	return rl.Events
+}
+
+// String returns the limit in the ParseRateLimit format. The burst is not
+// a part of it.
+func (rl RateLimit) String() string {
+	switch rl.Per {
+	case time.Millisecond:
+		return fmt.Sprintf("%d/ms", rl.Events)
+	case time.Second:
+		return fmt.Sprintf("%d/s", rl.Events)
+	case time.Minute:
+		return fmt.Sprintf("%d/m", rl.Events)
+	case time.Hour:
+		return fmt.Sprintf("%d/h", rl.Events)
+	}
+	return fmt.Sprintf("%d/%s", rl.Events, rl.Per)
+}
+
+// RateLimitConverter converts a rate limit string (see ParseRateLimit) or a
+// section into a RateLimit. The section keys are: rate (a rate limit string)
+// or events and per (a duration, 1s if not set), and burst.
+type RateLimitConverter struct{}
+
+var _ Converter = (*RateLimitConverter)(nil)
+var _ Mapper = (*RateLimitConverter)(nil)
+
+// ToRateLimit is an initialized instance of RateLimitConverter. It is
+// registered as the converter for the RateLimit type (see ConverterFor).
+var ToRateLimit = &RateLimitConverter{}
+
+// Convert returns the RateLimit value and true if the value is a valid rate
+// limit string, section or a RateLimit.
+func (rc *RateLimitConverter) Convert(kv *KeyValue) (*KeyValue, bool) {
+	mkv, err := rc.Map(kv)
+	return mkv, err == nil
+}
+
+// Map converts the value. Returns an error describing the malformed setting.
+func (rc *RateLimitConverter) Map(kv *KeyValue) (*KeyValue, error) {
+	switch v := kv.Value.(type) {
+	case RateLimit:
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+		return &KeyValue{Key: kv.Key, Value: v}, nil
+	case string:
+		rl, err := ParseRateLimit(v)
+		if err != nil {
+			return nil, err
+		}
+		return &KeyValue{Key: kv.Key, Value: rl}, nil
+	}
+	section, ok := toValueMap(kv.Value)
+	if !ok {
+		return nil, fmt.Errorf("Failed to convert %T value for key %q: want a rate limit", kv.Value, kv.Key)
+	}
+	rl := RateLimit{Per: time.Second}
+	if rate, ok := section["rate"]; ok {
+		_, hasEvents := section["events"]
+		_, hasPer := section["per"]
+		if hasEvents || hasPer {
+			return nil, fmt.Errorf("Rate limit for key %q must have either rate or events and per set", kv.Key)
+		}
+		s, ok := rate.(string)
+		if !ok {
+			return nil, fmt.Errorf("Failed to convert %T value of rate limit setting \"rate\" for key %q", rate, kv.Key)
+		}
+		var err error
+		if rl, err = ParseRateLimit(s); err != nil {
+			return nil, err
+		}
+	}
+	for name, v := range section {
+		var ok bool
+		switch name {
+		case "rate":
+			continue
+		case "events":
+			var iv Value
+			if iv, ok = convert(ToInt, v); ok {
+				rl.Events = iv.(int)
+			}
+		case "per":
+			rl.Per, ok = toDuration(v)
+		case "burst":
+			var iv Value
+			if iv, ok = convert(ToInt, v); ok {
+				rl.Burst = iv.(int)
+			}
+		default:
+			return nil, fmt.Errorf("Unexpected rate limit setting %q for key %q", name, kv.Key)
+		}
+		if !ok {
+			return nil, fmt.Errorf("Failed to convert %T value of rate limit setting %q for key %q", v, name, kv.Key)
+		}
+	}
+	if err := rl.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid rate limit for key %q: %s", kv.Key, err)
+	}
+	return &KeyValue{Key: kv.Key, Value: rl}, nil
+}
+
+// JSONSchema describes the accepted values: a rate limit string or a section.
+func (rc *RateLimitConverter) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "string", "pattern": `^\s*\d+\s*/\s*\S+\s*$`},
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"rate":   map[string]interface{}{"type": "string"},
+					"events": map[string]interface{}{"type": "integer", "minimum": 0},
+					"per":    map[string]interface{}{"type": "string"},
+					"burst":  map[string]interface{}{"type": "integer", "minimum": 0},
+				},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
+
+// LookupRateLimit returns the rate limit under the key (see
+// RateLimitConverter). The boolean flag is false if the key is not served by
+// any provider.
+func LookupRateLimit(repo Getter, key string) (RateLimit, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return RateLimit{}, ok, err
+	}
+	kv, err := ToRateLimit.Map(&KeyValue{Key: parseKey(repo, key), Value: v})
+	if err != nil {
+		return RateLimit{}, true, err
+	}
+	return kv.Value.(RateLimit), true, nil
+}
+
+// RateLimitVar is a RateLimit config value binding. It is safe for
+// concurrent use.
+type RateLimitVar struct {
+	v atomic.Value
+}
+
+// Load returns the bound value.
+func (rv *RateLimitVar) Load() RateLimit {
+	if v, ok := rv.v.Load().(RateLimit); ok {
+		return v
+	}
+	return RateLimit{}
+}
+
+// BindRateLimit binds the key to the variable. See Bind.
+func (repo *Repository) BindRateLimit(key string, rv *RateLimitVar) error {
+	return repo.Bind(key, func() error {
+		rl, ok, err := LookupRateLimit(repo, key)
+		if ok && err == nil {
+			rv.v.Store(rl)
+		}
+		return err
+	})
+}
+
+// WatchRateLimit calls the listener with the current rate limit under the
+// key (if any) and every time the limit changes, e.g. in order to retune a
+// limiter:
+//
+//	cancel, err := repo.WatchRateLimit("api.limit", func(rl config.RateLimit) {
+//		limiter.SetLimit(rate.Limit(rl.PerSecond()))
+//		limiter.SetBurst(rl.MaxBurst())
+//	})
+//
+// A malformed limit on a reload is logged and the listener is not called.
+// Returns a function cancelling the subscription and the error of the
+// initial lookup.
+func (repo *Repository) WatchRateLimit(key string, listener func(RateLimit)) (func(), error) {
+	rl, ok, err := LookupRateLimit(repo, key)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		listener(rl)
+	}
+	var last atomic.Value
+	last.Store(rl)
+	return repo.SubscribePattern(repo.NewKey(key), func(event *ChangeEvent) {
+		if event.Err != nil {
+			return
+		}
+		rl, ok, err := LookupRateLimit(repo, key)
+		if err != nil {
+			repo.Logger().Errorf("Failed to update rate limit %q: %s", key, err)
+			return
+		}
+		if ok && last.Swap(rl) != rl {
+			listener(rl)
+		}
+	}), nil
+}
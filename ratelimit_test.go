@@ -0,0 +1,147 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    RateLimit
+		wantErr bool
+	}{
+		{"100/s", RateLimit{Events: 100, Per: time.Second}, false},
+		{" 5 / min ", RateLimit{Events: 5, Per: time.Minute}, false},
+		{"10/30s", RateLimit{Events: 10, Per: 30 * time.Second}, false},
+		{"1/ms", RateLimit{Events: 1, Per: time.Millisecond}, false},
+		{"100", RateLimit{}, true},
+		{"many/s", RateLimit{}, true},
+		{"100/fortnight", RateLimit{}, true},
+		{"-1/s", RateLimit{}, true},
+		{"1/0s", RateLimit{}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.input, func(t *testing.T) {
+			got, err := ParseRateLimit(testCase.input)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if got != testCase.want {
+				t.Fatalf("Unexpected rate limit: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitConverter(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   Value
+		want    RateLimit
+		wantErr string
+	}{
+		{"string", "100/s", RateLimit{Events: 100, Per: time.Second}, ""},
+		{"rate section", map[string]Value{"rate": "60/m", "burst": "10"}, RateLimit{Events: 60, Per: time.Minute, Burst: 10}, ""},
+		{"events section", map[string]Value{"events": 5, "per": "10s"}, RateLimit{Events: 5, Per: 10 * time.Second}, ""},
+		{"default period", map[string]Value{"events": 5}, RateLimit{Events: 5, Per: time.Second}, ""},
+		{"rate limit value", RateLimit{Events: 1, Per: time.Hour}, RateLimit{Events: 1, Per: time.Hour}, ""},
+		{"both forms", map[string]Value{"rate": "1/s", "events": 5}, RateLimit{}, "either rate or events"},
+		{"unknown setting", map[string]Value{"events": 5, "window": "1s"}, RateLimit{}, `Unexpected rate limit setting "window"`},
+		{"malformed burst", map[string]Value{"events": 5, "burst": "lots"}, RateLimit{}, `rate limit setting "burst"`},
+		{"negative burst", map[string]Value{"events": 5, "burst": -1}, RateLimit{}, "burst must not be negative"},
+		{"not a rate limit", 42, RateLimit{}, "want a rate limit"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			kv, err := ToRateLimit.Map(&KeyValue{Key: NewKey("limit"), Value: testCase.value})
+			if len(testCase.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("Unexpected error: got: %v, want: %q", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(kv.Value, testCase.want) {
+				t.Fatalf("Unexpected rate limit: got: %#v, want: %#v", kv.Value, testCase.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitHelpers(t *testing.T) {
+	rl := RateLimit{Events: 30, Per: time.Minute}
+	if got := rl.PerSecond(); got != 0.5 {
+		t.Fatalf("Unexpected rate: got: %v, want: %v", got, 0.5)
+	}
+	if got := rl.MaxBurst(); got != 30 {
+		t.Fatalf("Unexpected burst: got: %d, want: %d", got, 30)
+	}
+	if got := rl.String(); got != "30/m" {
+		t.Fatalf("Unexpected string: got: %q, want: %q", got, "30/m")
+	}
+	rl = RateLimit{Events: 10, Per: 30 * time.Second, Burst: 2}
+	if got := rl.MaxBurst(); got != 2 {
+		t.Fatalf("Unexpected burst: got: %d, want: %d", got, 2)
+	}
+	if got := rl.String(); got != "10/30s" {
+		t.Fatalf("Unexpected string: got: %q, want: %q", got, "10/30s")
+	}
+}
+
+func TestWatchRateLimit(t *testing.T) {
+	repo := NewRepository()
+	prov := &reloadTestProv{registry: map[string]Value{
+		"api.limit":  "100/s",
+		"api.listen": ":8080",
+	}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	var rv RateLimitVar
+	if err := repo.BindRateLimit("api.limit", &rv); err != nil {
+		t.Fatalf("Failed to bind the rate limit: %s", err)
+	}
+	var got []RateLimit
+	cancel, err := repo.WatchRateLimit("api.limit", func(rl RateLimit) {
+		got = append(got, rl)
+	})
+	if err != nil {
+		t.Fatalf("Failed to watch the rate limit: %s", err)
+	}
+	defer cancel()
+
+	reloads := []map[string]Value{
+		// An unrelated change is not reported
+		{"api.limit": "100/s", "api.listen": ":9090"},
+		{"api.limit": map[string]Value{"events": 50, "burst": 5}, "api.listen": ":9090"},
+		// A malformed limit is skipped
+		{"api.limit": "fast", "api.listen": ":9090"},
+		{"api.limit": "10/m", "api.listen": ":9090"},
+	}
+	for _, registry := range reloads {
+		if err := prov.reload(repo, registry); err != nil {
+			t.Fatalf("Unexpected reload error: %s", err)
+		}
+	}
+
+	want := []RateLimit{
+		{Events: 100, Per: time.Second},
+		{Events: 50, Per: time.Second, Burst: 5},
+		{Events: 10, Per: time.Minute},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected notifications: got: %#v, want: %#v", got, want)
+	}
+	if rl := rv.Load(); rl != want[2] {
+		t.Fatalf("Unexpected bound value: got: %#v, want: %#v", rl, want[2])
+	}
+}
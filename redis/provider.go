@@ -0,0 +1,302 @@
+// Package redis provides a config provider serving values stored in Redis.
+//
+// The package does not depend on a Redis client library: the provider talks
+// to Redis through the minimal Client interface, which is expected to be
+// satisfied by a thin adapter over the client of choice, e.g.
+// github.com/go-redis/redis.
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/osdrv/config"
+)
+
+const (
+	// DefaultKeySeparator is the default separator of Redis key fragments.
+	DefaultKeySeparator = ":"
+	// DefaultTimeout is the default timeout of a single values fetch.
+	DefaultTimeout = 5 * time.Second
+)
+
+// Client is the subset of the Redis API used by the provider.
+type Client interface {
+	// HGetAll returns all fields of the hash stored at the key.
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	// Keys returns all keys starting with the prefix. Implementations are
+	// expected to use SCAN rather than KEYS.
+	Keys(ctx context.Context, prefix string) ([]string, error)
+	// MGet returns the string values of the keys. The result is expected to
+	// be aligned with the keys: a missing key is reported with ok set to
+	// false.
+	MGet(ctx context.Context, keys ...string) (values []string, ok []bool, err error)
+	// PSubscribe subscribes to the channel pattern. The returned channel
+	// delivers the name of the channel every message was published to. It
+	// is expected to be closed once the context is cancelled.
+	PSubscribe(ctx context.Context, pattern string) (<-chan string, error)
+}
+
+// Options is a set of Provider settings. Either Hash or Prefix must be set.
+type Options struct {
+	// Hash is the key of a Redis hash. Hash fields are served as config
+	// keys, e.g. field `db.host` is served under the key `db.host`.
+	Hash string
+	// Prefix selects all Redis keys starting with the prefix. The prefix is
+	// stripped and the rest of the key is split by KeySeparator, e.g. with
+	// prefix `myapp:`, Redis key `myapp:db:host` is served under the key
+	// `db.host`.
+	Prefix string
+	// KeySeparator is the separator of Redis key fragments.
+	// DefaultKeySeparator is used if not set.
+	KeySeparator string
+	// Notify is a pub/sub channel pattern. If set, the provider subscribes to
+	// it and reloads the values on every message. Use KeyspacePattern to
+	// receive keyspace notifications.
+	Notify string
+	// Timeout limits a single values fetch. DefaultTimeout is used if not
+	// set.
+	Timeout time.Duration
+	// CacheFile is the location of a local cache file. If set, the values
+	// are persisted to it on every successful fetch and are served from it
+	// if Redis is unavailable at set up (see config.CacheFile).
+	CacheFile string
+}
+
+// KeyspacePattern returns the keyspace notification channel pattern matching
+// all changes of keys starting with the prefix in the database. Keyspace
+// notifications must be enabled on the Redis server (see
+// `notify-keyspace-events`).
+func KeyspacePattern(db int, prefix string) string {
+	return fmt.Sprintf("__keyspace@%d__:%s*", db, prefix)
+}
+
+// Provider serves values loaded from Redis. Values are served as strings.
+type Provider struct {
+	weight   int
+	client   Client
+	options  *Options
+	registry map[string]config.Value
+	cache    config.CacheFile
+	repo     *config.Repository
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mx       sync.RWMutex
+}
+
+var _ config.Provider = (*Provider)(nil)
+var _ config.ContextSetUpper = (*Provider)(nil)
+
+// NewProvider is the constructor for Provider.
+func NewProvider(repo *config.Repository, weight int, client Client, options *Options) (*Provider, error) {
+	if client == nil {
+		return nil, fmt.Errorf("Redis client is not set")
+	}
+	if options == nil || (len(options.Hash) == 0 && len(options.Prefix) == 0) {
+		return nil, fmt.Errorf("Either Redis hash or key prefix must be set")
+	}
+	prov := &Provider{
+		weight:   weight,
+		client:   client,
+		options:  options,
+		registry: make(map[string]config.Value),
+		cache:    config.CacheFile{Path: options.CacheFile, Source: "Redis"},
+	}
+	repo.RegisterProvider(prov)
+	return prov, nil
+}
+
+// Name returns provider name: redis
+func (p *Provider) Name() string { return "redis" }
+
+// Depends returns the list of provider dependencies: none
+func (p *Provider) Depends() []string { return []string{} }
+
+// Weight returns the provider weight
+func (p *Provider) Weight() int { return p.weight }
+
+// SetUp loads the values using a background context.
+func (p *Provider) SetUp(repo *config.Repository) error {
+	return p.SetUpContext(context.Background(), repo)
+}
+
+// SetUpContext loads the values and registers the keys in the repo. If Notify
+// is set, the provider subscribes to the channel pattern. If Redis is
+// unavailable and CacheFile is set, the cached values are served.
+func (p *Provider) SetUpContext(ctx context.Context, repo *config.Repository) error {
+	p.repo = repo
+	registry, err := p.fetch(ctx)
+	cached := false
+	if err != nil {
+		if registry, cached = p.cache.Load(p.repo, err); !cached {
+			return err
+		}
+	} else {
+		p.cache.Store(p.repo, registry)
+	}
+	p.mx.Lock()
+	p.registry = registry
+	p.mx.Unlock()
+	for k := range registry {
+		if err := repo.RegisterKey(config.NewKey(k), p); err != nil {
+			return err
+		}
+	}
+	if len(p.options.Notify) > 0 {
+		subCtx, cancel := context.WithCancel(context.Background())
+		messages, err := p.client.PSubscribe(subCtx, p.options.Notify)
+		if err != nil {
+			cancel()
+			err = fmt.Errorf("Failed to subscribe to Redis channel %q: %s", p.options.Notify, err)
+			if !cached {
+				return err
+			}
+			// Redis is unavailable: the cached values are served as is
+			p.repo.Logger().Errorf("%s", err)
+			return nil
+		}
+		p.cancel = cancel
+		p.wg.Add(1)
+		go p.listen(messages)
+	}
+	return nil
+}
+
+// TearDown cancels the subscription (if any).
+func (p *Provider) TearDown(*config.Repository) error {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+	return nil
+}
+
+// Get returns the value for the key
+func (p *Provider) Get(key config.Key) (*config.KeyValue, bool) {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+	if v, ok := p.registry[key.String()]; ok {
+		return &config.KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
+
+// Reload re-fetches the values and applies the new key set atomically (see
+// `config.Repository.ApplyReload`). If the new values fail the schema
+// validation, the previous values are kept.
+func (p *Provider) Reload(ctx context.Context) (err error) {
+	registry, err := p.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	p.mx.RLock()
+	prevRegistry := p.registry
+	p.mx.RUnlock()
+	defer func() {
+		if err == nil {
+			p.cache.Store(p.repo, registry)
+		}
+	}()
+	return p.repo.ApplyReload(p, func() error {
+		p.mx.Lock()
+		p.registry = registry
+		p.mx.Unlock()
+		for k := range registry {
+			if err := p.repo.RegisterKey(config.NewKey(k), p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func() {
+		p.mx.Lock()
+		p.registry = prevRegistry
+		p.mx.Unlock()
+	})
+}
+
+func (p *Provider) listen(messages <-chan string) {
+	defer p.wg.Done()
+	for range messages {
+		// Messages arriving while reloading are coalesced: a single reload
+		// picks up all changes made so far.
+	drain:
+		for {
+			select {
+			case _, ok := <-messages:
+				if !ok {
+					break drain
+				}
+			default:
+				break drain
+			}
+		}
+		if err := p.Reload(context.Background()); err != nil {
+			p.repo.Logger().Errorf("Failed to reload Redis config: %s", err)
+		}
+	}
+}
+
+func (p *Provider) fetch(ctx context.Context) (map[string]config.Value, error) {
+	timeout := p.options.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ctx, span := p.repo.StartProviderSpan(ctx, "config.redis.Fetch", p)
+	defer span.End()
+
+	registry := make(map[string]config.Value)
+	if len(p.options.Hash) > 0 {
+		fields, err := p.client.HGetAll(ctx, p.options.Hash)
+		if err != nil {
+			err = fmt.Errorf("Failed to read Redis hash %q: %s", p.options.Hash, err)
+			span.RecordError(err)
+			return nil, err
+		}
+		for k, v := range fields {
+			registry[k] = v
+		}
+	}
+	if len(p.options.Prefix) > 0 {
+		keys, err := p.client.Keys(ctx, p.options.Prefix)
+		if err != nil {
+			err = fmt.Errorf("Failed to list Redis keys by prefix %q: %s", p.options.Prefix, err)
+			span.RecordError(err)
+			return nil, err
+		}
+		if len(keys) == 0 {
+			return registry, nil
+		}
+		values, found, err := p.client.MGet(ctx, keys...)
+		if err != nil {
+			err = fmt.Errorf("Failed to read Redis keys by prefix %q: %s", p.options.Prefix, err)
+			span.RecordError(err)
+			return nil, err
+		}
+		for i, k := range keys {
+			// A key might be gone between the listing and the read
+			if i < len(found) && found[i] {
+				registry[p.keyOf(k)] = values[i]
+			}
+		}
+	}
+	return registry, nil
+}
+
+func (p *Provider) keyOf(key string) string {
+	sep := p.options.KeySeparator
+	if len(sep) == 0 {
+		sep = DefaultKeySeparator
+	}
+	// Fragments might contain dots, e.g. hash fields like `db.host`
+	res := make(config.Key, 0)
+	for _, frag := range config.NewKeyWithSeparator(strings.TrimPrefix(key, p.options.Prefix), sep) {
+		res = res.Join(config.NewKey(frag))
+	}
+	return res.String()
+}
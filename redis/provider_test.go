@@ -0,0 +1,244 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osdrv/config"
+)
+
+type testClient struct {
+	hashes   map[string]map[string]string
+	keys     map[string]string
+	messages chan string
+	// err is returned by all reads if set
+	err error
+	mx  sync.Mutex
+}
+
+var _ Client = (*testClient)(nil)
+
+func newTestClient() *testClient {
+	return &testClient{
+		hashes: map[string]map[string]string{
+			"myapp": {
+				"db.host": "localhost",
+				"db.port": "5432",
+			},
+		},
+		keys: map[string]string{
+			"myapp:http:port": "8080",
+			"myapp:log:level": "info",
+			"other:key":       "value",
+		},
+		messages: make(chan string, 16),
+	}
+}
+
+func (c *testClient) HGetAll(_ context.Context, key string) (map[string]string, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	res := make(map[string]string)
+	for k, v := range c.hashes[key] {
+		res[k] = v
+	}
+	return res, nil
+}
+
+func (c *testClient) Keys(_ context.Context, prefix string) ([]string, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	res := make([]string, 0)
+	for k := range c.keys {
+		if strings.HasPrefix(k, prefix) {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (c *testClient) MGet(_ context.Context, keys ...string) ([]string, []bool, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	values := make([]string, len(keys))
+	found := make([]bool, len(keys))
+	for i, k := range keys {
+		values[i], found[i] = c.keys[k]
+	}
+	return values, found, nil
+}
+
+func (c *testClient) PSubscribe(ctx context.Context, pattern string) (<-chan string, error) {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-c.messages:
+				select {
+				case out <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (c *testClient) set(key, value string) {
+	c.mx.Lock()
+	c.keys[key] = value
+	c.mx.Unlock()
+	c.messages <- "__keyspace@0__:" + key
+}
+
+func TestProviderSetUp(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *Options
+		want    map[string]config.Value
+	}{
+		{
+			"A hash",
+			&Options{Hash: "myapp"},
+			map[string]config.Value{
+				"db.host": "localhost",
+				"db.port": "5432",
+			},
+		},
+		{
+			"A key prefix",
+			&Options{Prefix: "myapp:"},
+			map[string]config.Value{
+				"http.port": "8080",
+				"log.level": "info",
+			},
+		},
+		{
+			"A hash and a key prefix",
+			&Options{Hash: "myapp", Prefix: "myapp:"},
+			map[string]config.Value{
+				"db.host":   "localhost",
+				"db.port":   "5432",
+				"http.port": "8080",
+				"log.level": "info",
+			},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := config.NewRepository()
+			prov, err := NewProvider(repo, 10, newTestClient(), testCase.options)
+			if err != nil {
+				t.Fatalf("Failed to initialize a new provider: %s", err)
+			}
+			if err := repo.SetUp(); err != nil {
+				t.Fatalf("Failed to set up the repository: %s", err)
+			}
+			defer repo.TearDown()
+			if !reflect.DeepEqual(prov.registry, testCase.want) {
+				t.Fatalf("Unexpected registry: got: %#v, want: %#v", prov.registry, testCase.want)
+			}
+			for k, want := range testCase.want {
+				if got, ok := repo.Get(config.NewKey(k)); !ok || got != want {
+					t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderNotify(t *testing.T) {
+	client := newTestClient()
+	repo := config.NewRepository()
+	if _, err := NewProvider(repo, 10, client, &Options{
+		Prefix: "myapp:",
+		Notify: KeyspacePattern(0, "myapp:"),
+	}); err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	events := make(chan *config.ChangeEvent, 1)
+	repo.Subscribe(func(e *config.ChangeEvent) {
+		events <- e
+	})
+	client.set("myapp:http:port", "9090")
+
+	select {
+	case e := <-events:
+		want := []config.Change{{Key: config.NewKey("http.port"), Old: "8080", New: "9090"}}
+		if !reflect.DeepEqual(e.Changes, want) {
+			t.Fatalf("Unexpected changes: got: %#v, want: %#v", e.Changes, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for a change event")
+	}
+}
+
+func TestProviderCacheFile(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "redis.yaml")
+	want := map[string]config.Value{"db.host": "localhost", "db.port": "5432"}
+
+	repo := config.NewRepository()
+	if _, err := NewProvider(repo, 10, newTestClient(), &Options{Hash: "myapp", CacheFile: cacheFile}); err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	repo.TearDown()
+
+	client := newTestClient()
+	client.err = errors.New("connection refused")
+	for _, options := range []*Options{
+		{Hash: "myapp", CacheFile: cacheFile},
+		{Hash: "myapp", CacheFile: cacheFile, Notify: "__keyspace@0__:*"},
+	} {
+		repo := config.NewRepository()
+		prov, err := NewProvider(repo, 10, client, options)
+		if err != nil {
+			t.Fatalf("Failed to initialize a new provider: %s", err)
+		}
+		if err := repo.SetUp(); err != nil {
+			t.Fatalf("Failed to set up the repository: %s", err)
+		}
+		repo.TearDown()
+		if !reflect.DeepEqual(prov.registry, want) {
+			t.Fatalf("Unexpected registry: got: %#v, want: %#v", prov.registry, want)
+		}
+	}
+
+	repo = config.NewRepository()
+	NewProvider(repo, 10, client, &Options{Hash: "myapp", CacheFile: filepath.Join(t.TempDir(), "missing.yaml")})
+	if err := repo.SetUp(); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("Unexpected error: got: %v, want: %q", err, "connection refused")
+	}
+}
+
+func TestKeyspacePattern(t *testing.T) {
+	if got, want := KeyspacePattern(2, "myapp:"), "__keyspace@2__:myapp:*"; got != want {
+		t.Fatalf("Unexpected pattern: got: %q, want: %q", got, want)
+	}
+}
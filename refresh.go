@@ -0,0 +1,224 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RefreshPolicy defines the schedule of provider refreshes.
+type RefreshPolicy interface {
+	// NextRefresh returns the delay before the next refresh. Returns false
+	// if no refresh should be scheduled.
+	NextRefresh() (time.Duration, bool)
+}
+
+type ttlPolicy struct {
+	ttl time.Duration
+}
+
+// TTL returns a policy refreshing values once they are older than the ttl.
+func TTL(ttl time.Duration) RefreshPolicy {
+	return &ttlPolicy{ttl: ttl}
+}
+
+func (p *ttlPolicy) NextRefresh() (time.Duration, bool) {
+	return p.ttl, p.ttl > 0
+}
+
+// Redefined in tests
+var randInt63n = rand.Int63n
+
+type jitteredPolicy struct {
+	interval time.Duration
+	jitter   time.Duration
+}
+
+// Jittered returns a policy refreshing values every interval +/- a random
+// jitter. The jitter spreads the load on a remote config source shared by
+// multiple instances.
+func Jittered(interval, jitter time.Duration) RefreshPolicy {
+	return &jitteredPolicy{interval: interval, jitter: jitter}
+}
+
+func (p *jitteredPolicy) NextRefresh() (time.Duration, bool) {
+	if p.interval <= 0 {
+		return 0, false
+	}
+	d := p.interval
+	if p.jitter > 0 {
+		d += time.Duration(randInt63n(int64(2*p.jitter)+1)) - p.jitter
+	}
+	if d <= 0 {
+		d = p.interval
+	}
+	return d, true
+}
+
+type manualPolicy struct{}
+
+// Manual returns a policy that never schedules refreshes: values are only
+// refreshed on explicit `RefreshScheduler.RefreshNow` calls.
+func Manual() RefreshPolicy {
+	return &manualPolicy{}
+}
+
+func (*manualPolicy) NextRefresh() (time.Duration, bool) { return 0, false }
+
+// RefreshFunc refreshes the values under the key prefix. An empty prefix
+// stands for all provider values.
+type RefreshFunc func(ctx context.Context, prefix Key) error
+
+// ReplacePrefix returns a copy of the registry with the values under the key
+// prefix replaced by the fresh ones: fresh values outside of the prefix are
+// ignored, values under the prefix missing from the fresh ones are dropped.
+// Registry keys are expected to be the config keys the provider serves, i.e.
+// after the provider key mapping. An empty prefix stands for all values. It
+// helps a RefreshFunc honor the prefix while fetching all values.
+func ReplacePrefix(registry, fresh map[string]Value, prefix Key) map[string]Value {
+	if len(prefix) == 0 {
+		return fresh
+	}
+	res := make(map[string]Value, len(registry))
+	for k, v := range registry {
+		if !NewKey(k).HasPrefix(prefix) {
+			res[k] = v
+		}
+	}
+	for k, v := range fresh {
+		if NewKey(k).HasPrefix(prefix) {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// RefreshScheduler runs provider refreshes according to refresh policies
+// configured per provider or per key prefix. It is a building block for
+// remote providers: a provider implements the refresh logic, the scheduler
+// takes care of the timers.
+//
+// Example:
+//
+//	rs := config.NewRefreshScheduler(repo, prov, prov.refresh)
+//	rs.SetPolicy("", config.TTL(time.Minute))
+//	rs.SetPolicy("feature_flags", config.Jittered(5*time.Second, time.Second))
+//	rs.Start()
+//	defer rs.Stop()
+type RefreshScheduler struct {
+	repo     *Repository
+	prov     Provider
+	refresh  RefreshFunc
+	policies map[string]RefreshPolicy
+	done     chan struct{}
+	started  bool
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+	mx       sync.Mutex
+}
+
+// NewRefreshScheduler is the constructor for RefreshScheduler.
+func NewRefreshScheduler(repo *Repository, prov Provider, refresh RefreshFunc) *RefreshScheduler {
+	return &RefreshScheduler{
+		repo:     repo,
+		prov:     prov,
+		refresh:  refresh,
+		policies: make(map[string]RefreshPolicy),
+		done:     make(chan struct{}),
+	}
+}
+
+// SetPolicy sets the refresh policy for the key prefix. An empty prefix
+// defines the provider-wide policy. Policies must be set before the
+// scheduler is started.
+func (rs *RefreshScheduler) SetPolicy(prefix string, policy RefreshPolicy) {
+	rs.mx.Lock()
+	defer rs.mx.Unlock()
+	rs.policies[prefixKey(prefix).String()] = policy
+}
+
+// Start starts a refresh routine per configured policy. Is a no-op if the
+// scheduler is already started.
+func (rs *RefreshScheduler) Start() {
+	rs.mx.Lock()
+	defer rs.mx.Unlock()
+	if rs.started {
+		return
+	}
+	rs.started = true
+	prefixes := make([]string, 0, len(rs.policies))
+	for prefix := range rs.policies {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		rs.wg.Add(1)
+		go rs.run(NewKey(prefix), rs.policies[prefix])
+	}
+}
+
+// Stop terminates the refresh routines and waits for in-flight refreshes to
+// finish.
+func (rs *RefreshScheduler) Stop() {
+	rs.stopOnce.Do(func() { close(rs.done) })
+	rs.wg.Wait()
+}
+
+// RefreshNow refreshes the values under the prefix immediately.
+func (rs *RefreshScheduler) RefreshNow(ctx context.Context, prefix string) error {
+	return rs.refresh(ctx, prefixKey(prefix))
+}
+
+func (rs *RefreshScheduler) run(prefix Key, policy RefreshPolicy) {
+	defer rs.wg.Done()
+	for {
+		d, ok := policy.NextRefresh()
+		if !ok {
+			return
+		}
+		timer := time.NewTimer(d)
+		select {
+		case <-rs.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := rs.refresh(context.Background(), prefix); err != nil {
+			rs.repo.Logger().Errorf("Failed to refresh config provider %q (prefix: %q): %s",
+				rs.prov.Name(), prefix, err)
+		}
+	}
+}
+
+// RefreshProvider re-reads the values of the registered provider by name.
+// The provider is expected to implement Refresher (e.g. EnvProvider) or
+// `Reload(context.Context) error` (e.g. remote providers). Returns an error if the provider is not registered or does not
+// support refreshes.
+func (repo *Repository) RefreshProvider(ctx context.Context, name string) error {
+	repo.mx.Lock()
+	prov, ok := repo.providers[name]
+	repo.mx.Unlock()
+	if !ok {
+		return fmt.Errorf("Config provider %q is not registered", name)
+	}
+	refresh, ok := refreshFunc(prov)
+	if !ok {
+		return fmt.Errorf("Config provider %q does not support refreshes", name)
+	}
+	return refresh(ctx)
+}
+
+// refreshFunc returns the refresh function of the provider if it supports
+// refreshes.
+func refreshFunc(prov Provider) (func(context.Context) error, bool) {
+	switch p := prov.(type) {
+	case Refresher:
+		return p.Refresh, true
+	case interface{ Reload(context.Context) error }:
+		return p.Reload, true
+	}
+	return nil, false
+}
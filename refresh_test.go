@@ -0,0 +1,107 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRefreshPolicies(t *testing.T) {
+	oldRandInt63n := randInt63n
+	randInt63n = func(n int64) int64 { return n - 1 }
+	defer func() { randInt63n = oldRandInt63n }()
+
+	tests := []struct {
+		name   string
+		policy RefreshPolicy
+		want   time.Duration
+		wantOk bool
+	}{
+		{"TTL", TTL(time.Minute), time.Minute, true},
+		{"A zero TTL", TTL(0), 0, false},
+		{"Jittered", Jittered(time.Minute, time.Second), time.Minute + time.Second, true},
+		{"Jittered with no jitter", Jittered(time.Minute, 0), time.Minute, true},
+		{"Manual", Manual(), 0, false},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, ok := testCase.policy.NextRefresh()
+			if got != testCase.want || ok != testCase.wantOk {
+				t.Fatalf("Unexpected next refresh: got: %s, %t, want: %s, %t", got, ok, testCase.want, testCase.wantOk)
+			}
+		})
+	}
+}
+
+func TestRefreshScheduler(t *testing.T) {
+	repo := NewRepository()
+	prov := NewTestProv(1, 10)
+
+	var mx sync.Mutex
+	refreshed := make(map[string]int)
+	calls := make(chan struct{}, 16)
+	rs := NewRefreshScheduler(repo, prov, func(_ context.Context, prefix Key) error {
+		mx.Lock()
+		refreshed[prefix.String()]++
+		mx.Unlock()
+		select {
+		case calls <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	rs.SetPolicy("", Manual())
+	rs.SetPolicy("feature_flags.", TTL(time.Millisecond))
+	rs.Start()
+
+	select {
+	case <-calls:
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for a scheduled refresh")
+	}
+	rs.Stop()
+
+	if err := rs.RefreshNow(context.Background(), ""); err != nil {
+		t.Fatalf("Unexpected refresh error: %s", err)
+	}
+
+	mx.Lock()
+	defer mx.Unlock()
+	if refreshed[""] != 1 || refreshed["feature_flags"] < 1 || len(refreshed) != 2 {
+		t.Fatalf("Unexpected refreshes: %#v", refreshed)
+	}
+}
+
+func TestReplacePrefix(t *testing.T) {
+	registry := map[string]Value{
+		"db.host":       "localhost",
+		"db.user":       "admin",
+		"feature.flags": "a",
+	}
+	fresh := map[string]Value{
+		"db.host":       "db.internal",
+		"feature.flags": "b",
+	}
+	tests := []struct {
+		name   string
+		prefix Key
+		want   map[string]Value
+	}{
+		{"All values", nil, fresh},
+		{
+			"Values under the prefix",
+			NewKey("db"),
+			map[string]Value{"db.host": "db.internal", "feature.flags": "a"},
+		},
+		{"No values under the prefix", NewKey("cache"), registry},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := ReplacePrefix(registry, fresh, testCase.prefix); !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected registry: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Change describes a single key value change. A nil Old value means the key
+// has been added, a nil New value means the key has been deleted.
+type Change struct {
+	Key Key
+	Old Value
+	New Value
+}
+
+// ChangeType is the kind of a key value change.
+type ChangeType uint8
+
+const (
+	// ChangeAdded stands for a key that had no value before.
+	ChangeAdded ChangeType = iota
+	// ChangeUpdated stands for a key value replaced with a different one.
+	ChangeUpdated
+	// ChangeDeleted stands for a key that has no value anymore.
+	ChangeDeleted
+)
+
+func (ct ChangeType) String() string {
+	switch ct {
+	case ChangeAdded:
+		return "added"
+	case ChangeUpdated:
+		return "updated"
+	case ChangeDeleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// Type returns the kind of the change.
+func (c Change) Type() ChangeType {
+	switch {
+	case c.Old == nil:
+		return ChangeAdded
+	case c.New == nil:
+		return ChangeDeleted
+	}
+	return ChangeUpdated
+}
+
+// ChangeSet is a batch of key value changes originating from a single
+// provider.
+type ChangeSet struct {
+	// Provider is the name of the provider the changes originate from.
+	Provider string
+	Changes  []Change
+}
+
+// Added returns the changes of the keys that had no value before.
+func (cs ChangeSet) Added() []Change { return cs.filter(ChangeAdded) }
+
+// Updated returns the changes of the keys that had a different value before.
+func (cs ChangeSet) Updated() []Change { return cs.filter(ChangeUpdated) }
+
+// Deleted returns the changes of the keys that have no value anymore.
+func (cs ChangeSet) Deleted() []Change { return cs.filter(ChangeDeleted) }
+
+func (cs ChangeSet) filter(ct ChangeType) []Change {
+	res := make([]Change, 0, len(cs.Changes))
+	for _, change := range cs.Changes {
+		if change.Type() == ct {
+			res = append(res, change)
+		}
+	}
+	return res
+}
+
+// ChangeEvent is emitted once per applied reload. It carries the name of the
+// reloaded provider and the effective value diff. If the reload was rejected
+// by validation, Err is set and Changes is empty.
+type ChangeEvent struct {
+	ChangeSet
+	Err error
+}
+
+// Subscribe registers a listener notified on every applied reload that
+// changed at least 1 effective value and on every rejected reload (see
+// ChangeEvent.Err). Listeners are called synchronously after the reload is
+// processed. Returns a function cancelling the subscription.
+// This method is thread safe.
+func (repo *Repository) Subscribe(listener func(*ChangeEvent)) func() {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	id := repo.subID
+	repo.subID++
+	repo.subscribers[id] = listener
+	return func() {
+		repo.mx.Lock()
+		defer repo.mx.Unlock()
+		delete(repo.subscribers, id)
+	}
+}
+
+// ApplyReload is the way for watching providers to apply a new key set
+// atomically. The apply function is expected to swap the provider registry
+// and register new keys in the repo. It is called under the repository write
+// lock: lookups either observe all the old values or all the new ones.
+// apply must not call Get.
+// Once applied, the new values are validated against the schema: every
+// affected key (and its parents) must be mapped successfully. If apply
+// returns an error or the validation fails, rollback is called (under the
+// same lock) in order to restore the previous registry: the repository keeps
+// serving the last good configuration. rollback might be nil if the provider
+// can not roll back.
+// Once the reload is processed, subscribers receive a single change event
+// carrying the effective value diff or the validation error.
+// If the provider is wrapped (see ProviderWrapper), the reload is processed on
+// behalf of the outermost wrapper.
+func (repo *Repository) ApplyReload(prov Provider, apply func() error, rollback func()) error {
+	prov = repo.ownerOf(prov)
+	repo.viewMx.Lock()
+	before := repo.snapshot(prov)
+	if err := apply(); err != nil {
+		// apply might have failed half-way
+		if rollback != nil {
+			rollback()
+		}
+		repo.viewMx.Unlock()
+		repo.Logger().Errorf("Failed to reload config provider %q: %s", prov.Name(), err)
+		repo.setReloadError(prov, err)
+		return err
+	}
+	if err := repo.validateReload(prov); err != nil {
+		if rollback != nil {
+			rollback()
+		}
+		repo.viewMx.Unlock()
+		repo.Logger().Errorf("%s", err)
+		repo.setReloadError(prov, err)
+		repo.notify(&ChangeEvent{
+			ChangeSet: ChangeSet{Provider: prov.Name(), Changes: []Change{}},
+			Err:       err,
+		})
+		return err
+	}
+	after := repo.snapshot(prov)
+	repo.viewMx.Unlock()
+	repo.setReloadError(prov, nil)
+
+	repo.Metrics().Reload(prov.Name())
+	event := &ChangeEvent{
+		ChangeSet: ChangeSet{
+			Provider: prov.Name(),
+			Changes:  diffSnapshots(before, after),
+		},
+	}
+	repo.Logger().Infof("Reloaded config provider %q: %d key(s) changed", prov.Name(), len(event.Changes))
+	if len(event.Changes) > 0 {
+		repo.notify(event)
+	}
+	return nil
+}
+
+// LastReloadError returns the error of the most recent reload. Returns nil if
+// the most recent reload succeeded or no reloads happened yet.
+// This method is thread safe.
+func (repo *Repository) LastReloadError() error {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	return repo.lastReloadErr
+}
+
+func (repo *Repository) setReloadError(prov Provider, err error) {
+	repo.updateStatus(prov, func(st *ProviderStatus) {
+		st.LastError = err
+		if err == nil {
+			st.LastRefresh = time.Now()
+		}
+	})
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	repo.lastReloadErr = err
+}
+
+// validateReload looks up all keys the provider is registered for along with
+// their parents and returns an error if at least 1 of them fails to map. In
+// strict mode, keys unknown to the schema are rejected as well. The caller
+// is expected to hold viewMx.
+func (repo *Repository) validateReload(prov Provider) error {
+	repo.mx.Lock()
+	keys := repo.root.providerKeys(nil, prov)
+	repo.mx.Unlock()
+	errs := make([]error, 0)
+	// Parents of the failed keys are not checked: these would fail too
+	failed := make(map[string]bool)
+	for _, key := range keys {
+		if repo.options.Strict && !repo.mappers.Covers(key) {
+			repo.mx.Lock()
+			provKey := repo.root.find(key).provKey(prov, key)
+			repo.mx.Unlock()
+			if _, ok := prov.Get(provKey); ok {
+				errs = append(errs, fmt.Errorf("key %q is not defined in the schema", key))
+			}
+		}
+		// Mapping errors carry the key already
+		if err := repo.tryGet(key); err != nil {
+			errs = append(errs, err)
+			for l := len(key) - 1; l > 0; l-- {
+				failed[key[:l].String()] = true
+			}
+		}
+	}
+	visited := make(map[string]bool)
+	for _, key := range keys {
+		for l := len(key) - 1; l > 0; l-- {
+			k := key[:l]
+			if visited[k.String()] || failed[k.String()] {
+				continue
+			}
+			visited[k.String()] = true
+			if err := repo.tryGet(k); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Provider: prov.Name(), Errors: errs}
+}
+
+// tryGet performs a key lookup and returns the mapper error (if any).
+func (repo *Repository) tryGet(key Key) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rerr, ok := r.(error); ok {
+				err = rerr
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	repo.get(key)
+	return nil
+}
+
+// notify logs the changes, records a history snapshot and delivers the event
+// to the subscribers.
+func (repo *Repository) notify(event *ChangeEvent) {
+	repo.logChanges(event.ChangeSet)
+	if event.Err == nil {
+		repo.recordSnapshot(event.ChangeSet, false)
+	}
+	repo.mx.Lock()
+	ids := make([]int, 0, len(repo.subscribers))
+	for id := range repo.subscribers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	listeners := make([]func(*ChangeEvent), 0, len(ids))
+	for _, id := range ids {
+		listeners = append(listeners, repo.subscribers[id])
+	}
+	repo.mx.Unlock()
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// logChanges logs every change of the set at the debug level. Secret values
+// are redacted.
+func (repo *Repository) logChanges(cs ChangeSet) {
+	logger := repo.Logger()
+	for _, change := range cs.Changes {
+		switch change.Type() {
+		case ChangeAdded:
+			logger.Debugf("Config key %q added by %q: %v", change.Key,
+				cs.Provider, repo.displayValue(change.Key, change.New))
+		case ChangeUpdated:
+			logger.Debugf("Config key %q updated by %q: %v -> %v", change.Key,
+				cs.Provider, repo.displayValue(change.Key, change.Old), repo.displayValue(change.Key, change.New))
+		case ChangeDeleted:
+			logger.Debugf("Config key %q deleted by %q", change.Key, cs.Provider)
+		}
+	}
+}
+
+// snapshot returns the effective values of all keys the provider is
+// registered for. The caller is expected to hold viewMx.
+func (repo *Repository) snapshot(prov Provider) map[string]Value {
+	repo.mx.Lock()
+	keys := repo.root.providerKeys(nil, prov)
+	repo.mx.Unlock()
+	res := make(map[string]Value, len(keys))
+	for _, key := range keys {
+		if v, ok := repo.safeGet(key); ok {
+			res[key.String()] = v
+		}
+	}
+	return res
+}
+
+// safeGet is a version of get turning mapper panics into a lookup miss.
+func (repo *Repository) safeGet(key Key) (v Value, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			v, ok = nil, false
+		}
+	}()
+	return repo.get(key)
+}
+
+// providerKeys returns the list of keys the provider is registered for.
+func (n *node) providerKeys(pref Key, prov Provider) []Key {
+	res := make([]Key, 0)
+	providers, children := n.view()
+	for _, p := range providers {
+		if p == prov {
+			res = append(res, pref)
+			break
+		}
+	}
+	for k, ch := range children {
+		key := make(Key, len(pref), len(pref)+1)
+		copy(key, pref)
+		res = append(res, ch.providerKeys(append(key, k), prov)...)
+	}
+	return res
+}
+
+func diffSnapshots(before, after map[string]Value) []Change {
+	changes := make([]Change, 0)
+	for k, old := range before {
+		if nv, ok := after[k]; !ok {
+			changes = append(changes, Change{Key: NewKey(k), Old: old})
+		} else if !reflect.DeepEqual(old, nv) {
+			changes = append(changes, Change{Key: NewKey(k), Old: old, New: nv})
+		}
+	}
+	for k, nv := range after {
+		if _, ok := before[k]; !ok {
+			changes = append(changes, Change{Key: NewKey(k), New: nv})
+		}
+	}
+	sort.Slice(changes, func(a, b int) bool {
+		return changes[a].Key.String() < changes[b].Key.String()
+	})
+	return changes
+}
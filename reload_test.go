@@ -0,0 +1,296 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type reloadTestProv struct {
+	registry map[string]Value
+	mx       sync.RWMutex
+}
+
+func (rtp *reloadTestProv) Name() string                 { return "reload" }
+func (rtp *reloadTestProv) Depends() []string            { return []string{} }
+func (rtp *reloadTestProv) Weight() int                  { return DefaultWeight }
+func (rtp *reloadTestProv) SetUp(repo *Repository) error { return rtp.register(repo) }
+func (rtp *reloadTestProv) TearDown(*Repository) error   { return nil }
+
+func (rtp *reloadTestProv) Get(key Key) (*KeyValue, bool) {
+	rtp.mx.RLock()
+	defer rtp.mx.RUnlock()
+	if v, ok := rtp.registry[key.String()]; ok {
+		return &KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
+
+func (rtp *reloadTestProv) register(repo *Repository) error {
+	rtp.mx.RLock()
+	defer rtp.mx.RUnlock()
+	for k := range rtp.registry {
+		if err := repo.RegisterKey(NewKey(k), rtp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rtp *reloadTestProv) reload(repo *Repository, registry map[string]Value) error {
+	rtp.mx.RLock()
+	prev := rtp.registry
+	rtp.mx.RUnlock()
+	return repo.ApplyReload(rtp, func() error {
+		rtp.mx.Lock()
+		rtp.registry = registry
+		rtp.mx.Unlock()
+		return rtp.register(repo)
+	}, func() {
+		rtp.mx.Lock()
+		rtp.registry = prev
+		rtp.mx.Unlock()
+	})
+}
+
+func TestApplyReloadChangeEvent(t *testing.T) {
+	repo := NewRepository()
+	prov := &reloadTestProv{registry: map[string]Value{
+		"foo.a": 1,
+		"foo.b": 1,
+		"foo.c": 1,
+	}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	events := make([]*ChangeEvent, 0)
+	unsubscribe := repo.Subscribe(func(event *ChangeEvent) {
+		events = append(events, event)
+	})
+
+	if err := prov.reload(repo, map[string]Value{"foo.a": 1, "foo.b": 2, "foo.d": 3}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	// A reload with no changes emits no events
+	if err := prov.reload(repo, map[string]Value{"foo.a": 1, "foo.b": 2, "foo.d": 3}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+
+	want := []*ChangeEvent{
+		{
+			ChangeSet: ChangeSet{
+				Provider: "reload",
+				Changes: []Change{
+					{Key: NewKey("foo.b"), Old: 1, New: 2},
+					{Key: NewKey("foo.c"), Old: 1, New: nil},
+					{Key: NewKey("foo.d"), Old: nil, New: 3},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("Unexpected change events: got: %#v, want: %#v", events, want)
+	}
+
+	unsubscribe()
+	if err := prov.reload(repo, map[string]Value{"foo.a": 5}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Unexpected change events after unsubscribe: %#v", events)
+	}
+}
+
+func TestApplyReloadAtomicity(t *testing.T) {
+	repo := NewRepository()
+	prov := &reloadTestProv{registry: map[string]Value{"foo.a": 0, "foo.b": 0}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 200; i++ {
+			prov.reload(repo, map[string]Value{"foo.a": i, "foo.b": i})
+		}
+		close(done)
+	}()
+
+	for {
+		select {
+		case <-done:
+			wg.Wait()
+			return
+		default:
+		}
+		v, ok := repo.Get(NewKey("foo"))
+		if !ok {
+			t.Fatalf("Expected lookup for key %q to find a value, none returned", "foo")
+		}
+		vmap := v.(map[string]Value)
+		if vmap["a"] != vmap["b"] {
+			t.Fatalf("Observed a partially applied reload: %#v", vmap)
+		}
+	}
+}
+
+func TestApplyReloadRollback(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"server": map[string]Schema{
+			"port": ToInt,
+		},
+	})
+	prov := &reloadTestProv{registry: map[string]Value{"server.port": "8080"}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	events := make([]*ChangeEvent, 0)
+	repo.Subscribe(func(event *ChangeEvent) {
+		events = append(events, event)
+	})
+
+	err := prov.reload(repo, map[string]Value{"server.port": "abc", "server.host": "localhost"})
+	wantErr := `Rejected config provider "reload" reload: Failed to map the value "abc" for key "server.port" provided by "reload": Failed to convert string value for key "server.port"`
+	if err == nil || err.Error() != wantErr {
+		t.Fatalf("Unexpected reload error: got: %v, want: %s", err, wantErr)
+	}
+	if repo.LastReloadError() != err {
+		t.Fatalf("Unexpected last reload error: got: %v, want: %v", repo.LastReloadError(), err)
+	}
+	if len(events) != 1 || events[0].Err != err || len(events[0].Changes) != 0 {
+		t.Fatalf("Unexpected change events: %#v", events)
+	}
+	// The previous configuration is still served
+	if v, _ := repo.Get(NewKey("server.port")); v != 8080 {
+		t.Fatalf("Unexpected value after a rejected reload: got: %#v, want: %#v", v, 8080)
+	}
+	if _, ok := repo.Get(NewKey("server.host")); ok {
+		t.Fatalf("Expected rejected reload keys to be absent")
+	}
+
+	if err := prov.reload(repo, map[string]Value{"server.port": "9090"}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if repo.LastReloadError() != nil {
+		t.Fatalf("Expected last reload error to be reset, got: %v", repo.LastReloadError())
+	}
+	if v, _ := repo.Get(NewKey("server.port")); v != 9090 {
+		t.Fatalf("Unexpected value after a reload: got: %#v, want: %#v", v, 9090)
+	}
+}
+
+func TestApplyReloadRollbackOnApplyError(t *testing.T) {
+	repo := NewRepository()
+	prov := &reloadTestProv{registry: map[string]Value{"server.port": 8080}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	rolledBack := false
+	err := repo.ApplyReload(prov, func() error {
+		// The registry is swapped before the failure
+		prov.mx.Lock()
+		prov.registry = map[string]Value{"server.port": 9090}
+		prov.mx.Unlock()
+		return errors.New("Failed to register keys")
+	}, func() {
+		rolledBack = true
+		prov.mx.Lock()
+		prov.registry = map[string]Value{"server.port": 8080}
+		prov.mx.Unlock()
+	})
+	if err == nil || err.Error() != "Failed to register keys" {
+		t.Fatalf("Unexpected reload error: got: %v, want: %s", err, "Failed to register keys")
+	}
+	if !rolledBack {
+		t.Fatalf("Expected a failed apply to be rolled back")
+	}
+	if v, _ := repo.Get(NewKey("server.port")); v != 8080 {
+		t.Fatalf("Unexpected value after a failed apply: got: %#v, want: %#v", v, 8080)
+	}
+}
+
+func TestChangeSet(t *testing.T) {
+	cs := ChangeSet{
+		Provider: "test",
+		Changes: []Change{
+			{Key: NewKey("a"), Old: nil, New: 1},
+			{Key: NewKey("b"), Old: 1, New: 2},
+			{Key: NewKey("c"), Old: 1, New: nil},
+		},
+	}
+
+	tests := []struct {
+		name string
+		got  []Change
+		want ChangeType
+	}{
+		{"added", cs.Added(), ChangeAdded},
+		{"updated", cs.Updated(), ChangeUpdated},
+		{"deleted", cs.Deleted(), ChangeDeleted},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if len(testCase.got) != 1 {
+				t.Fatalf("Unexpected changes: got: %#v, want a single change", testCase.got)
+			}
+			if ct := testCase.got[0].Type(); ct != testCase.want {
+				t.Fatalf("Unexpected change type: got: %s, want: %s", ct, testCase.want)
+			}
+		})
+	}
+}
+
+type debugTestLogger struct {
+	testLogger
+}
+
+func (dtl *debugTestLogger) Debugf(format string, args ...interface{}) {
+	dtl.logf("DEBUG", format, args...)
+}
+
+func TestChangeLogging(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"user":     ToStr,
+		"password": Secret(ToStr),
+		"host":     ToStr,
+	})
+	prov := &reloadTestProv{registry: map[string]Value{"user": "admin", "password": "foo", "host": "localhost"}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	logger := &debugTestLogger{}
+	repo.SetLogger(logger)
+
+	if err := prov.reload(repo, map[string]Value{"user": "root", "password": "bar", "port": "80"}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	got := make([]string, 0)
+	for _, msg := range logger.messages {
+		if strings.HasPrefix(msg, "DEBUG: Config key") {
+			got = append(got, msg)
+		}
+	}
+	want := []string{
+		`DEBUG: Config key "host" deleted by "reload"`,
+		`DEBUG: Config key "password" updated by "reload": ****** -> ******`,
+		`DEBUG: Config key "port" added by "reload": 80`,
+		`DEBUG: Config key "user" updated by "reload": admin -> root`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected log messages: got: %#v, want: %#v", got, want)
+	}
+}
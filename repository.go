@@ -1,9 +1,18 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Provider is a generic interface for config providers.
@@ -27,10 +36,18 @@ var (
 // as a producing function.
 type Constructor func(*Repository, int) (Provider, error)
 
+// node is a key tree node. Nodes are safe for concurrent use: the node
+// providers and children are replaced (copy on write) under the node lock,
+// readers take a snapshot of them (see view) and never hold the lock while
+// calling providers.
 type node struct {
 	providers []Provider
 	//listeners []Listener
 	children map[string]*node
+	// origKeys keeps the original key spelling per provider if it differs
+	// from the node path (see `RepositoryOptions.CaseInsensitive`)
+	origKeys map[Provider]Key
+	mx       sync.RWMutex
 }
 
 func newNode() *node {
@@ -41,49 +58,145 @@ func newNode() *node {
 	}
 }
 
-func (n *node) explain(key Key) map[string]interface{} {
+// view returns a snapshot of the node providers and children. The returned
+// slice and map must not be modified.
+func (n *node) view() ([]Provider, map[string]*node) {
+	n.mx.RLock()
+	defer n.mx.RUnlock()
+	return n.providers, n.children
+}
+
+func (n *node) explain(repo *Repository, key Key) map[string]interface{} {
 	res := map[string]interface{}{}
-	if len(n.providers) > 0 {
-		valdescr := make([]map[string]interface{}, 0, len(n.providers))
-		for _, prov := range n.providers {
-			if kv, ok := prov.Get(key); ok {
-				valdescr = append(valdescr, map[string]interface{}{
+	if d, ok := repo.Description(key); ok {
+		res["__description__"] = d.Text
+		if len(d.Examples) > 0 {
+			res["__examples__"] = d.Examples
+		}
+	}
+	providers, children := n.view()
+	if len(providers) > 0 {
+		valdescr := make([]map[string]interface{}, 0, len(providers))
+		for _, prov := range providers {
+			if kv, ok := prov.Get(n.provKey(prov, key)); ok {
+				vd := map[string]interface{}{
 					"provider_name":   prov.Name(),
 					"provider_weight": prov.Weight(),
 					"value":           kv.Value,
-				})
+				}
+				if loc := repo.provenanceOf(prov, n.provKey(prov, key)).Location(); len(loc) > 0 {
+					vd["location"] = loc
+				}
+				valdescr = append(valdescr, vd)
 			}
 		}
 		res["__value__"] = valdescr
-	} else if len(n.children) > 0 {
-		for k, ch := range n.children {
-			res[k] = ch.explain(append(key, k))
+	} else if len(children) > 0 {
+		for k, ch := range children {
+			res[k] = ch.explain(repo, key.Child(k))
 		}
 	}
 	return res
 }
 
-func (n *node) add(key Key, prov Provider) {
-	ptr := n
-	for _, k := range key {
-		if _, ok := ptr.children[k]; !ok {
-			ptr.children[k] = newNode()
+// add registers the provider for the key. The node providers are kept sorted
+// using the less function, providers of equal precedence keep the
+// registration order. Returns the list of providers previously registered for
+// the same key with the same precedence: these take precedence over the new
+// one.
+func (n *node) add(key Key, prov Provider, less func(a, b Provider) bool) []Provider {
+	ptr := n.findOrCreate(key)
+	ptr.mx.Lock()
+	defer ptr.mx.Unlock()
+	conflicts := make([]Provider, 0)
+	for _, p := range ptr.providers {
+		if p == prov {
+			// The provider is already registered for the key
+			return conflicts
+		}
+		if !less(p, prov) && !less(prov, p) {
+			conflicts = append(conflicts, p)
 		}
-		ptr = ptr.children[k]
 	}
-	ptr.providers = append(ptr.providers, prov)
-	sort.Slice(ptr.providers, func(a, b int) bool {
-		return ptr.providers[a].Weight() > ptr.providers[b].Weight()
+	providers := make([]Provider, len(ptr.providers), len(ptr.providers)+1)
+	copy(providers, ptr.providers)
+	ptr.providers = sortProviders(append(providers, prov), less)
+	return conflicts
+}
+
+// sort orders the node providers using the less function. The sort is
+// stable: providers of equal precedence keep the registration order.
+func (n *node) sort(less func(a, b Provider) bool) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	providers := make([]Provider, len(n.providers))
+	copy(providers, n.providers)
+	n.providers = sortProviders(providers, less)
+}
+
+func sortProviders(providers []Provider, less func(a, b Provider) bool) []Provider {
+	sort.SliceStable(providers, func(a, b int) bool {
+		return less(providers[a], providers[b])
 	})
+	return providers
+}
+
+// provKey returns the key the provider registered for the node.
+func (n *node) provKey(prov Provider, key Key) Key {
+	n.mx.RLock()
+	defer n.mx.RUnlock()
+	if orig, ok := n.origKeys[prov]; ok {
+		return orig
+	}
+	return key
+}
+
+func (n *node) setOrigKey(prov Provider, key Key) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	if n.origKeys == nil {
+		n.origKeys = make(map[Provider]Key)
+	}
+	n.origKeys[prov] = key
+}
+
+// child returns the child node for the key fragment.
+func (n *node) child(k string) (*node, bool) {
+	n.mx.RLock()
+	defer n.mx.RUnlock()
+	ch, ok := n.children[k]
+	return ch, ok
+}
+
+// childOrCreate returns the child node for the key fragment. The child is
+// created if missing.
+func (n *node) childOrCreate(k string) *node {
+	if ch, ok := n.child(k); ok {
+		return ch
+	}
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	if ch, ok := n.children[k]; ok {
+		return ch
+	}
+	children := make(map[string]*node, len(n.children)+1)
+	for ck, ch := range n.children {
+		children[ck] = ch
+	}
+	ch := newNode()
+	children[k] = ch
+	n.children = children
+	return ch
 }
 
 func (n *node) find(key Key) *node {
 	ptr := n
 	for _, k := range key {
-		if _, ok := ptr.children[k]; !ok {
+		ch, ok := ptr.child(k)
+		if !ok {
 			return nil
 		}
-		ptr = ptr.children[k]
+		ptr = ch
 	}
 	return ptr
 }
@@ -91,58 +204,133 @@ func (n *node) find(key Key) *node {
 func (n *node) findOrCreate(key Key) *node {
 	ptr := n
 	for _, k := range key {
-		if _, ok := ptr.children[k]; !ok {
-			ptr.children[k] = newNode()
-		}
-		ptr = ptr.children[k]
+		ptr = ptr.childOrCreate(k)
 	}
 	return ptr
 }
 
+// keys returns the list of all keys served by at least 1 provider in the
+// subtree.
+func (n *node) keys(pref Key) []Key {
+	res := make([]Key, 0)
+	providers, children := n.view()
+	if len(providers) > 0 {
+		res = append(res, pref)
+	}
+	for k, ch := range children {
+		key := make(Key, len(pref), len(pref)+1)
+		copy(key, pref)
+		res = append(res, ch.keys(append(key, k))...)
+	}
+	return res
+}
+
 func (n *node) get(repo *Repository, key Key) (*KeyValue, bool) {
-	ptr := n.find(key)
+	return n.getAs(repo, key, key)
+}
+
+// getAs looks up the value stored under the key `lookup` and maps it as if it
+// was stored under the key `as`. It is used for key aliasing.
+func (n *node) getAs(repo *Repository, lookup Key, as Key) (*KeyValue, bool) {
+	ptr := n.find(lookup)
 	if ptr == nil {
 		return nil, false
 	}
-	if len(ptr.providers) != 0 {
-		for _, prov := range ptr.providers {
-			if kv, ok := prov.Get(key); ok {
-				if mkv, err := repo.doMap(kv); err != nil {
-					panic(err)
-				} else {
-					return mkv, ok
-				}
-			}
-		}
-		return nil, false
+	providers, children := ptr.view()
+	if len(providers) != 0 {
+		return ptr.value(repo, lookup, as)
 	}
-	if len(ptr.children) != 0 {
-		return ptr.getAll(repo, key), true
+	if len(children) != 0 {
+		return ptr.getAllAs(repo, lookup, as), true
 	}
 	return nil, false
 }
 
+// rawGet is the unmapped counterpart of getAs: the value of a composite key
+// is nil.
+func (n *node) rawGet(repo *Repository, lookup Key, as Key) (Value, bool) {
+	ptr := n.find(lookup)
+	if ptr == nil {
+		return nil, false
+	}
+	providers, children := ptr.view()
+	if len(providers) != 0 {
+		v, _, ok := ptr.rawValue(repo, lookup, as)
+		return v, ok
+	}
+	return nil, len(children) != 0
+}
+
+// value returns the mapped value served by the node providers. The value
+// served by the provider with the highest weight wins unless a merge strategy
+// is set for the key (see SetMergeStrategy).
+func (n *node) value(repo *Repository, lookup Key, as Key) (*KeyValue, bool) {
+	v, top, ok := n.rawValue(repo, lookup, as)
+	if !ok {
+		return nil, false
+	}
+	mkv, err := repo.mapValue(top, as, v)
+	if err != nil {
+		panic(err)
+	}
+	return mkv, true
+}
+
+// rawValue returns the value served by the node providers as is, along with
+// the provider serving it. If the values of multiple providers are merged,
+// the provider with the highest weight is returned.
+func (n *node) rawValue(repo *Repository, lookup Key, as Key) (Value, Provider, bool) {
+	strategy := repo.mergeStrategy(as)
+	var top Provider
+	vals := make([]Value, 0, 1)
+	providers, _ := n.view()
+	// Providers are expected to be sorted
+	for _, prov := range providers {
+		kv, ok := prov.Get(n.provKey(prov, lookup))
+		repo.reportGet(prov, ok)
+		// A null value (e.g. `key: ~` in YAML) unsets the key: the next
+		// provider serves it
+		if !ok || kv.Value == nil {
+			continue
+		}
+		if top == nil {
+			top = prov
+		}
+		vals = append(vals, kv.Value)
+		if strategy == MergeReplace {
+			break
+		}
+	}
+	if top == nil {
+		return nil, nil, false
+	}
+	return mergeValues(strategy, vals), top, true
+}
+
 func (n *node) getAll(repo *Repository, pref Key) *KeyValue {
+	return n.getAllAs(repo, pref, pref)
+}
+
+func (n *node) getAllAs(repo *Repository, pref Key, as Key) *KeyValue {
 	res := make(map[string]Value)
-	for k, ch := range n.children {
-		key := Key(append(pref, k))
-		if len(ch.providers) > 0 {
-			// Providers are expected to be sorted
-			for _, prov := range ch.providers {
-				if kv, ok := prov.Get(key); ok {
-					mkv, err := repo.doMap(kv)
-					if err != nil {
-						panic(err)
-					}
-					res[k] = mkv.Value
-					break
-				}
+	_, children := n.view()
+	for k, ch := range children {
+		key := make(Key, len(pref), len(pref)+1)
+		copy(key, pref)
+		key = append(key, k)
+		askey := make(Key, len(as), len(as)+1)
+		copy(askey, as)
+		askey = append(askey, k)
+		if providers, _ := ch.view(); len(providers) > 0 {
+			if mkv, ok := ch.value(repo, key, askey); ok {
+				res[k] = mkv.Value
 			}
 		} else {
-			res[k] = ch.getAll(repo, key).Value
+			res[k] = ch.getAllAs(repo, key, askey).Value
 		}
 	}
-	mkv, err := repo.doMap(&KeyValue{Key: pref, Value: res})
+	repo.fillWildcardDefaults(as, res)
+	mkv, err := repo.doMap(&KeyValue{Key: as, Value: res}, nil)
 	if err != nil {
 		panic(err)
 	}
@@ -157,19 +345,170 @@ func (n *node) getAll(repo *Repository, pref Key) *KeyValue {
 // Plugin code can instantiate and use locally defined repositories. Having
 // independent repositories is practical.
 type Repository struct {
-	mappers   *MapperNode
-	root      *node
-	providers map[string]Provider
+	mappers *MapperNode
+	// descriptions is a trie holding *Description values as mappers
+	descriptions *MapperNode
+	root         *node
+	providers    map[string]Provider
+	options      *RepositoryOptions
+	logger       Logger
+	metrics      Metrics
+	// tracerProvider is nil unless explicitly set: the global one is used
+	tracerProvider trace.TracerProvider
+	// aliases maps an old key to the new one
+	aliases map[string]Key
+	// deprecations maps a deprecated key to the deprecation message
+	deprecations map[string]string
+	// warned keeps track of deprecation warnings already emitted
+	warned map[string]bool
+	// subscribers are notified on every applied reload
+	subscribers map[int]func(*ChangeEvent)
+	subID       int
+	// resolvers maps value prefixes to value reference resolvers
+	resolvers map[string]Resolver
+	// owners maps wrapped providers to their wrappers
+	owners map[Provider]ProviderWrapper
+	// watches are the change set consumers of the Watcher providers
+	watches map[Provider]*watch
+	// lazy is the list of providers with a deferred set up
+	lazy []*LazyProvider
+	// precedence maps provider names to their explicit ranks (see
+	// SetPrecedence)
+	precedence map[string]int
+	// tenants are the per-tenant views (see ForTenant)
+	tenants map[string]*TenantRepo
+	// exprs serves the evaluated expression values
+	exprs *expressions
+	// rollback serves the rolled back values (see Rollback)
+	rollback *history
+	// snapshots are the effective config history (see History)
+	snapshots []*Snapshot
+	version   int
+	historyMx sync.Mutex
+	// mergeRules are the merge strategies set for key patterns
+	mergeRules []*mergeRule
+	// defaults serves the schema default values (see DefaultValue)
+	defaults *schemaDefaults
+	// bindings are the update functions of the bound variables (see Bind)
+	bindings []func() error
+	// sections are the names of the module sections defined in the
+	// repository (see RegisterSection)
+	sections map[string]bool
+	// statuses keeps track of the provider health
+	statuses map[string]*ProviderStatus
+	// reads keeps track of the keys retrieved at least once (see UnusedKeys)
+	reads sync.Map
+	// middlewares wrap every Get (see Use), chain keeps the composed GetFunc
+	middlewares []Middleware
+	chain       atomic.Value
+	// audits are the key reads recorded in the audit mode
+	audits  map[string]*AuditRecord
+	auditMx sync.Mutex
+	// lastReloadErr is the error of the most recent reload
+	lastReloadErr error
+	// failurePolicies maps provider names to their failure policies (see
+	// RegisterProviderWithPolicy)
+	failurePolicies map[string]FailurePolicy
+	// closers release the repository resources on TearDown
+	closers   []func()
+	closeOnce sync.Once
+	closeErr  error
 	mx        sync.Mutex
+	// viewMx guarantees readers observe a consistent view of the config:
+	// lookups hold a read lock, reloads are applied under the write lock.
+	viewMx sync.RWMutex
+}
+
+// RepositoryOptions is a set of Repository behavior settings.
+type RepositoryOptions struct {
+	// Strict makes SetUp fail if providers registered keys that are not
+	// defined in the schema. Helps to catch typos in config files which
+	// would otherwise be silently ignored.
+	Strict bool
+	// KeySeparator is the key fragment separator used by the repository
+	// methods accepting string keys, e.g. `Scope`, `Alias` and the `Must*`
+	// helpers, and by `Dump`. KeySepCh is used if not set.
+	KeySeparator string
+	// CaseInsensitive makes key lookups case-insensitive: `Server.Port` and
+	// `server.port` refer to the same key. Keys are folded to the lower
+	// case: composite values and `Dump` use the lower case keys.
+	CaseInsensitive bool
+	// SetUpConcurrency is the maximum number of providers set up
+	// concurrently. Providers are set up sequentially if it is less than 2.
+	// Dependencies are respected either way: a provider is set up once all
+	// of its dependencies are set up.
+	SetUpConcurrency int
+	// Audit enables the audit mode: the repository records which keys were
+	// read, when and by whom (see Repository.Caller and
+	// Repository.AuditLog). On TearDown, the keys registered by providers but
+	// never read are reported as a warning.
+	Audit bool
+	// TrackUnused records the keys retrieved at least once, so
+	// Repository.UnusedKeys reports the ones never retrieved. It is implied
+	// by Audit. Reads are not recorded otherwise.
+	TrackUnused bool
+	// HistorySize is the number of the effective config snapshots kept in
+	// the history (see Repository.History). The history is disabled if
+	// zero.
+	HistorySize int
+	// Normalize converts the values to canonical dynamic types before the
+	// schema mapping, so a key has the same value type regardless of the
+	// provider serving it: integers become int64, floats and numeric strings
+	// become int64 or float64, boolean strings (`true`, the YAML 1.1 `yes`,
+	// `off`, etc., see SetBoolStrings) become bool, lists become []Value and maps become map[string]Value.
+	// Keys defined in the schema are typed by the schema converters instead.
+	Normalize bool
+	// StrictTypes disables the value coercion in Must* and Lookup* getters:
+	// a value must be of the requested type exactly. By default, the getters
+	// convert the value using the standard converters, e.g. MustInt accepts
+	// "8080" served by the env provider.
+	StrictTypes bool
+	// Expressions enables the config expressions: string values wrapped in
+	// double curly braces, e.g. `{{ .system.maxprocs * 2 }}`, are evaluated
+	// once all providers are set up. Disabled by default: values are served
+	// as is, braces included.
+	Expressions bool
+	// ProviderCache keeps the values of the providers registered with the
+	// ServeStaleCache failure policy (see RegisterProviderWithPolicy).
+	ProviderCache ProviderCache
+	// Authorizer is consulted before every runtime config mutation: a
+	// rollback or a reload triggered by the admin handler. Every key is
+	// authorized separately. All mutations are allowed if not set.
+	Authorizer Authorizer
 }
 
 // NewRepository returns a new instance of an empty Repository.
 func NewRepository() *Repository {
+	return NewRepositoryWithOptions(&RepositoryOptions{})
+}
+
+// NewRepositoryWithOptions returns a new instance of an empty Repository
+// configured with the provided options. nil options are equivalent to the
+// zero value options.
+func NewRepositoryWithOptions(options *RepositoryOptions) *Repository {
+	if options == nil {
+		options = &RepositoryOptions{}
+	}
 	return &Repository{
-		mappers:   NewMapperNode(),
-		root:      newNode(),
-		providers: make(map[string]Provider),
-		mx:        sync.Mutex{},
+		mappers:         NewMapperNode(),
+		descriptions:    NewMapperNode(),
+		root:            newNode(),
+		providers:       make(map[string]Provider),
+		options:         options,
+		logger:          &NopLogger{},
+		metrics:         &NopMetrics{},
+		aliases:         make(map[string]Key),
+		deprecations:    make(map[string]string),
+		warned:          make(map[string]bool),
+		subscribers:     make(map[int]func(*ChangeEvent)),
+		statuses:        make(map[string]*ProviderStatus),
+		resolvers:       make(map[string]Resolver),
+		owners:          make(map[Provider]ProviderWrapper),
+		watches:         make(map[Provider]*watch),
+		tenants:         make(map[string]*TenantRepo),
+		audits:          make(map[string]*AuditRecord),
+		failurePolicies: make(map[string]FailurePolicy),
+		mx:              sync.Mutex{},
 	}
 }
 
@@ -178,36 +517,259 @@ func NewRepository() *Repository {
 // they defined using `Depends()` method.
 // Firstly, it sets up providers with no dependencies and progresses forward
 // as providers with non-zero dependencies turn to be unblocked.
-// Returns an error if at least 1 provider failed to call `SetUp`.
+// Returns an error if at least 1 provider failed to call `SetUp`. If providers
+// are set up concurrently (see `RepositoryOptions.SetUpConcurrency`), the
+// error lists all failed providers.
+// If the repository is in strict mode, returns an error if providers
+// registered keys unknown to the schema.
+// Once all providers are set up, bound variables (see Bind) are updated and
+// the registered module sections (see RegisterSection) are decoded.
 func (repo *Repository) SetUp() error {
+	return repo.SetUpContext(context.Background())
+}
+
+// SetUpContext is equivalent to SetUp. The context is used as a parent for
+// the tracing spans and is passed to providers implementing ContextSetUpper.
+func (repo *Repository) SetUpContext(ctx context.Context) (err error) {
+	ctx, span := repo.Tracer().Start(ctx, "config.SetUp")
+	defer func() { endSpan(span, err) }()
+
+	if err := repo.defineSections(); err != nil {
+		return err
+	}
 	providers, err := repo.traverseProviders()
 	if err != nil {
 		return err
 	}
-	for _, prov := range providers {
-		if err := prov.SetUp(repo); err != nil {
+	logger := repo.Logger()
+	if repo.options.SetUpConcurrency > 1 {
+		if err := repo.setUpParallel(ctx, providers, repo.options.SetUpConcurrency); err != nil {
+			return err
+		}
+	} else {
+		for _, prov := range providers {
+			if err := repo.setUpOne(ctx, prov); err != nil {
+				return err
+			}
+		}
+	}
+	if err := repo.evaluateExpressions(); err != nil {
+		return err
+	}
+	if err := repo.checkRequired(); err != nil {
+		logger.Errorf("%s", err)
+		return err
+	}
+	if repo.options.Strict {
+		if err := repo.checkStrict(); err != nil {
+			logger.Errorf("%s", err)
 			return err
 		}
 	}
+	if err := repo.applyBindings(); err != nil {
+		logger.Errorf("Failed to apply config bindings: %s", err)
+		return err
+	}
+	if err := repo.decodeSections(); err != nil {
+		logger.Errorf("%s", err)
+		return err
+	}
+	repo.recordSnapshot(ChangeSet{}, true)
+
+	return nil
+}
 
+// setUpOne sets up the provider and keeps track of the set up duration and
+// the provider status.
+func (repo *Repository) setUpOne(ctx context.Context, prov Provider) error {
+	logger := repo.Logger()
+	logger.Infof("Setting up config provider %q (weight: %d)", prov.Name(), prov.Weight())
+	started := time.Now()
+	err := repo.setUpProvider(ctx, prov)
+	repo.Metrics().SetUpDuration(prov.Name(), time.Since(started))
+	repo.updateStatus(prov, func(st *ProviderStatus) {
+		st.LastError = err
+		if err != nil {
+			st.State = ProviderFailed
+			return
+		}
+		st.State = ProviderReady
+		st.SetUpAt = time.Now()
+		st.LastRefresh = st.SetUpAt
+	})
+	if err != nil {
+		logger.Errorf("Failed to set up config provider %q: %s", prov.Name(), err)
+		return repo.handleSetUpFailure(prov, err)
+	}
+	repo.storeProviderCache(prov)
+	if w, ok := prov.(Watcher); ok {
+		repo.startWatch(prov, w)
+	}
 	return nil
 }
 
+func (repo *Repository) setUpProvider(ctx context.Context, prov Provider) (err error) {
+	ctx, span := repo.StartProviderSpan(ctx, "config.provider.SetUp", prov)
+	defer func() { endSpan(span, err) }()
+	if csu, ok := prov.(ContextSetUpper); ok {
+		return csu.SetUpContext(ctx, repo)
+	}
+	return prov.SetUp(repo)
+}
+
+// unknownKeys returns a sorted list of keys registered by providers but not
+// covered by the schema.
+func (repo *Repository) unknownKeys() []Key {
+	repo.mx.Lock()
+	keys := repo.root.keys(nil)
+	repo.mx.Unlock()
+	res := make([]Key, 0)
+	for _, key := range keys {
+		if !repo.mappers.Covers(key) {
+			res = append(res, key)
+		}
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].String() < res[b].String()
+	})
+	return res
+}
+
+func (repo *Repository) checkStrict() error {
+	unknown := repo.unknownKeys()
+	if len(unknown) == 0 {
+		return nil
+	}
+	known := repo.mappers.Keys()
+	errs := make([]error, 0, len(unknown))
+	for _, key := range unknown {
+		if matches := nearestKeys(key, known, 3); len(matches) > 0 {
+			errs = append(errs, fmt.Errorf("%s (did you mean: %s?)",
+				key, strings.Join(matches, ", ")))
+		} else {
+			errs = append(errs, errors.New(key.String()))
+		}
+	}
+	return &ValidationError{Errors: errs}
+}
+
+// checkRequired returns an error wrapping ErrKeyNotFound if any of the
+// required keys (see Description.Required) is not served.
+func (repo *Repository) checkRequired() error {
+	repo.mx.Lock()
+	required := requiredKeys(nil, repo.descriptions)
+	repo.mx.Unlock()
+	missing := make([]string, 0)
+	for _, key := range required {
+		// Mapper failures are reported by the getters
+		if _, ok, _ := Lookup(repo, repo.KeyString(key)); !ok {
+			missing = append(missing, repo.KeyString(key))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("Required config keys are not set: %s: %w", strings.Join(missing, ", "), ErrKeyNotFound)
+}
+
+// requiredKeys returns the keys of the required descriptions in the trie.
+// Wildcard keys are skipped.
+func requiredKeys(pref Key, descriptions *MapperNode) []Key {
+	res := make([]Key, 0)
+	if d, ok := descriptions.Mpr.(*Description); ok && d.Required {
+		res = append(res, pref)
+	}
+	for k, ch := range descriptions.Children {
+		if k == WildcardFragment || k == "**" {
+			continue
+		}
+		key := make(Key, len(pref), len(pref)+1)
+		copy(key, pref)
+		res = append(res, requiredKeys(append(key, k), ch)...)
+	}
+	return res
+}
+
 // TearDown does the opposite to `SetUp`: it prepares providers to get
-// unloaded. The sequence of `provider.TearDown(repo)` is exactly the same
-// as SetUp(): topologically sorted dependency list.
-// Returns an error if at least 1 provider failed to call `TearDown`.
+// unloaded. Providers are torn down in the reverse SetUp() order: a provider
+// is torn down before the providers it depends on. Watching goroutines
+// started by the repository (see Watcher and ReloadOnSignal) are stopped
+// beforehand. All providers are torn down even if some of them fail.
+// Returns the original error if exactly 1 provider failed to call
+// `TearDown` or an aggregated error otherwise.
 func (repo *Repository) TearDown() error {
+	repo.runClosers()
 	providers, err := repo.traverseProviders()
 	if err != nil {
 		return err
 	}
-	for _, prov := range providers {
-		if err := prov.TearDown(repo); err != nil {
-			return err
+	logger := repo.Logger()
+	var errs []error
+	var descr []string
+	for i := len(providers) - 1; i >= 0; i-- {
+		prov := providers[i]
+		logger.Debugf("Tearing down config provider %q", prov.Name())
+		release := repo.stopWatch(prov)
+		err := prov.TearDown(repo)
+		release()
+		repo.updateStatus(prov, func(st *ProviderStatus) {
+			st.LastError = err
+			if err == nil {
+				st.State = ProviderTornDown
+			}
+		})
+		if err != nil {
+			logger.Errorf("Failed to tear down config provider %q: %s", prov.Name(), err)
+			errs = append(errs, err)
+			descr = append(descr, fmt.Sprintf("%s: %s", prov.Name(), err))
 		}
 	}
-	return nil
+	repo.reportUnread()
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return fmt.Errorf("Failed to tear down config providers: %s", strings.Join(descr, "; "))
+}
+
+// Close tears the repository down (see TearDown). Unlike TearDown, it is
+// idempotent: subsequent calls return the result of the first one. It makes
+// the repository an io.Closer, e.g.:
+//
+//	repo, err := config.New(config.WithYamlFile(path))
+//	if err != nil {
+//		return err
+//	}
+//	defer repo.Close()
+func (repo *Repository) Close() error {
+	repo.closeOnce.Do(func() {
+		repo.closeErr = repo.TearDown()
+	})
+	return repo.closeErr
+}
+
+var _ io.Closer = (*Repository)(nil)
+
+// addCloser registers a function releasing a repository resource on
+// TearDown, e.g. a signal handling goroutine.
+func (repo *Repository) addCloser(fn func()) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	repo.closers = append(repo.closers, fn)
+}
+
+// runClosers calls the registered closers in the reverse order.
+func (repo *Repository) runClosers() {
+	repo.mx.Lock()
+	closers := repo.closers
+	repo.closers = nil
+	repo.mx.Unlock()
+	for i := len(closers) - 1; i >= 0; i-- {
+		closers[i]()
+	}
 }
 
 func (repo *Repository) traverseProviders() ([]Provider, error) {
@@ -235,13 +797,151 @@ func (repo *Repository) traverseProviders() ([]Provider, error) {
 // DefineSchema registers a schema in the repo.
 // Multiple non-overlapping schemas might be registered sequentually with
 // an equivalence of registering a composite schema at once.
-// Returns an error if the root mapper node failes to register the schema.
+// Default values defined in the schema (see DefaultValue) are served if no
+// provider supplies the key.
+// Returns an error if the root mapper node failes to register the schema or
+// if a wildcard default value fails its schema mapping.
 func (repo *Repository) DefineSchema(s Schema) error {
-	return repo.mappers.DefineSchema(s)
+	if repo.options.CaseInsensitive {
+		s = foldSchema(s)
+	}
+	if err := repo.mappers.DefineSchema(s); err != nil {
+		return err
+	}
+	repo.mx.Lock()
+	collectDescriptions(NewKey(""), s, repo.descriptions)
+	repo.mx.Unlock()
+	return repo.registerSchemaDefaults(NewKey(""), s)
 }
 
-func (repo *Repository) doMap(kv *KeyValue) (*KeyValue, error) {
-	return repo.mappers.Map(kv)
+func (repo *Repository) addDescription(key Key, d *Description) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	repo.descriptions.Insert(key, d)
+}
+
+// Description returns the description attached to the key (see Describe).
+// Wildcard schema definitions are taken into account.
+// This method is thread safe.
+func (repo *Repository) Description(key Key) (*Description, bool) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	if ptr := repo.descriptions.Find(key); ptr != nil && ptr.Mpr != nil {
+		return ptr.Mpr.(*Description), true
+	}
+	return nil, false
+}
+
+// doMap maps the key-value pair using the schema. prov is the provider the
+// value originates from, it is nil for composite values. Mapper errors are
+// wrapped in a ConversionError.
+func (repo *Repository) doMap(kv *KeyValue, prov Provider) (*KeyValue, error) {
+	mkv, err := repo.mappers.Map(kv)
+	if err != nil {
+		cerr := &ConversionError{Key: kv.Key, Err: err}
+		if prov != nil {
+			cerr.Value = repo.displayValue(kv.Key, kv.Value)
+			cerr.Provider = prov.Name()
+			cerr.Location = repo.provenanceOf(prov, kv.Key).Location()
+		}
+		repo.Logger().Errorf("%s", cerr)
+		repo.Metrics().MapperError()
+		return nil, cerr
+	}
+	return mkv, nil
+}
+
+// mapValue resolves the value reference (if any), normalizes and maps the
+// value.
+func (repo *Repository) mapValue(prov Provider, key Key, v Value) (*KeyValue, error) {
+	rv, err := repo.resolve(v)
+	if err != nil {
+		err = fmt.Errorf("Failed to resolve the value for key %q provided by %q: %w", key, prov.Name(), err)
+		repo.Logger().Errorf("%s", err)
+		return nil, err
+	}
+	if repo.options.Normalize {
+		// Mapped values are typed by the schema
+		if ptr := repo.mappers.Find(key); ptr == nil || ptr.Mpr == nil {
+			rv = normalizeValue(rv)
+		}
+	}
+	return repo.doMap(&KeyValue{Key: key, Value: rv}, prov)
+}
+
+// RedactedValue is the placeholder substituting secret values in error
+// messages.
+const RedactedValue = "******"
+
+// displayValue returns the raw key value as is or RedactedValue for secret
+// keys. Composite values are walked: the values of secret sub-keys are
+// redacted. Composite values the sub-keys can not be told apart in (e.g. the
+// structs mapped by the schema) are redacted entirely if any sub-key is
+// secret.
+func (repo *Repository) displayValue(key Key, v Value) Value {
+	if repo.isSecret(key) {
+		return RedactedValue
+	}
+	switch vv := v.(type) {
+	case map[string]Value:
+		res := make(map[string]Value, len(vv))
+		for k, sv := range vv {
+			res[k] = repo.displayValue(key.Child(k), sv)
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(vv))
+		for k, sv := range vv {
+			res[k] = repo.displayValue(key.Child(k), sv)
+		}
+		return res
+	case []Value:
+		res := make([]Value, 0, len(vv))
+		for i, sv := range vv {
+			res = append(res, repo.displayValue(key.Child(strconv.Itoa(i)), sv))
+		}
+		return res
+	}
+	if repo.hasSecrets(key) {
+		return RedactedValue
+	}
+	return v
+}
+
+// isSecret returns true if the key is marked as secret (see Secret).
+func (repo *Repository) isSecret(key Key) bool {
+	d, ok := repo.Description(key)
+	return ok && d.Secret
+}
+
+// hasSecrets returns true if any sub-key of the key is marked as secret.
+func (repo *Repository) hasSecrets(key Key) bool {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	var walk func(mn *MapperNode) bool
+	walk = func(mn *MapperNode) bool {
+		for _, child := range mn.Children {
+			if d, ok := child.Mpr.(*Description); ok && d.Secret {
+				return true
+			}
+			if walk(child) {
+				return true
+			}
+		}
+		return false
+	}
+	if ptr := repo.descriptions.Find(key); ptr != nil {
+		return walk(ptr)
+	}
+	return false
+}
+
+func (repo *Repository) reportGet(prov Provider, ok bool) {
+	if ok {
+		repo.Metrics().GetHit(prov.Name())
+	} else {
+		repo.Metrics().GetMiss(prov.Name())
+	}
 }
 
 // RegisterProvider marks a provider as known to the repository.
@@ -253,6 +953,15 @@ func (repo *Repository) RegisterProvider(prov Provider) {
 	repo.mx.Lock()
 	defer repo.mx.Unlock()
 	repo.providers[prov.Name()] = prov
+	if wrapper, ok := prov.(ProviderWrapper); ok {
+		// Wrapped providers are managed by the wrapper
+		for _, inner := range wrapper.Unwrap() {
+			if registered, ok := repo.providers[inner.Name()]; ok && registered == inner {
+				delete(repo.providers, inner.Name())
+			}
+			repo.owners[inner] = wrapper
+		}
+	}
 }
 
 // RegisterKey registers a provider as a potential servant for the specified
@@ -266,7 +975,22 @@ func (repo *Repository) RegisterKey(key Key, prov Provider) error {
 	}
 	repo.mx.Lock()
 	defer repo.mx.Unlock()
-	repo.root.add(key, prov)
+	for wrapper, ok := repo.owners[prov]; ok; wrapper, ok = repo.owners[prov] {
+		key, prov = wrapper.WrapKey(key), wrapper
+		if lp, ok := prov.(*LazyProvider); ok && lp.collect(key) {
+			return nil
+		}
+	}
+	orig := key
+	key = repo.foldKey(key)
+	for _, other := range repo.root.add(key, prov, repo.precedes) {
+		repo.logger.Warnf("Config providers %q and %q share the same weight %d for key %q: %q takes precedence as registered first",
+			other.Name(), prov.Name(), prov.Weight(), key, other.Name())
+	}
+	if !key.Equals(orig) {
+		repo.root.find(key).setOrigKey(prov, orig)
+	}
+	repo.logger.Debugf("Registered config key %q for provider %q", key, prov.Name())
 	if _, ok := repo.providers[prov.Name()]; !ok {
 		repo.providers[prov.Name()] = prov
 	}
@@ -274,28 +998,269 @@ func (repo *Repository) RegisterKey(key Key, prov Provider) error {
 	return nil
 }
 
+// ownerOf returns the outermost wrapper of the provider or the provider
+// itself if it is not wrapped.
+func (repo *Repository) ownerOf(prov Provider) Provider {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	for wrapper, ok := repo.owners[prov]; ok; wrapper, ok = repo.owners[prov] {
+		prov = wrapper
+	}
+	return prov
+}
+
 //func (repo *Repository) Subscribe(key cast.Key, listener Listener) {
 //	repo.root.subscribe(key, listener)
 //}
 
+// SetLogger replaces the repository logger. The repository uses a no-op
+// logger by default.
+// This method is thread safe.
+func (repo *Repository) SetLogger(logger Logger) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	repo.logger = logger
+}
+
+// Logger returns the repository logger.
+func (repo *Repository) Logger() Logger {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	return repo.logger
+}
+
+// SetMetrics replaces the repository metrics collector. The repository uses a
+// no-op collector by default.
+// This method is thread safe.
+func (repo *Repository) SetMetrics(metrics Metrics) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	repo.metrics = metrics
+}
+
+// Metrics returns the repository metrics collector. Providers are expected to
+// use it to report reloads.
+func (repo *Repository) Metrics() Metrics {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	return repo.metrics
+}
+
+// Alias registers the key `old` as an alias for the key `new`. A lookup of
+// the new key falls back to the values served under the old one. A lookup of
+// the old key is redirected to the new one. If a value was served from the
+// old key, a deprecation warning is emitted (once per key) using the
+// repository logger.
+// This method is thread safe.
+func (repo *Repository) Alias(old, new string) error {
+	oldKey, newKey := repo.NewKey(old), repo.NewKey(new)
+	if len(oldKey) == 0 || len(newKey) == 0 {
+		return fmt.Errorf("Alias keys can not be empty")
+	}
+	if oldKey.Equals(newKey) {
+		return fmt.Errorf("Key %q can not be an alias to itself", old)
+	}
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	if _, ok := repo.aliases[newKey.String()]; ok {
+		return fmt.Errorf("Key %q is an alias itself and can not be an alias target", new)
+	}
+	if target, ok := repo.aliases[oldKey.String()]; ok {
+		return fmt.Errorf("Key %q is already an alias for %q", old, target)
+	}
+	for _, target := range repo.aliases {
+		if target.Equals(oldKey) {
+			return fmt.Errorf("Key %q is an alias target and can not be an alias", old)
+		}
+	}
+	repo.aliases[oldKey.String()] = newKey
+	return nil
+}
+
+// Deprecate marks the key as deprecated. If a value was served from this key,
+// a warning containing the message is emitted (once per key) using the
+// repository logger.
+// This method is thread safe.
+func (repo *Repository) Deprecate(key string, message string) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	repo.deprecations[repo.NewKey(key).String()] = message
+}
+
+// aliasTarget returns the new key if the key is a registered alias.
+// Returns the original key otherwise.
+func (repo *Repository) aliasTarget(key Key) Key {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	if target, ok := repo.aliases[key.String()]; ok {
+		return target
+	}
+	return key
+}
+
+// aliasesOf returns a sorted list of old keys aliased to the key.
+func (repo *Repository) aliasesOf(key Key) []Key {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	res := make([]Key, 0)
+	for old, target := range repo.aliases {
+		if target.Equals(key) {
+			res = append(res, NewKey(old))
+		}
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].String() < res[b].String()
+	})
+	return res
+}
+
+// warnDeprecated emits a deprecation warning for the key (if any). Every
+// warning is emitted once.
+func (repo *Repository) warnDeprecated(key Key) {
+	if msg, logger := repo.deprecationWarning(key); len(msg) > 0 {
+		// The logger is called with the lock released: it might read the
+		// repository.
+		logger.Warnf("%s", msg)
+	}
+}
+
+// deprecationWarning returns the deprecation warning for the key and the
+// logger to emit it with. Marks the key as warned. Returns an empty message
+// if the key is not deprecated or if the warning was already emitted.
+func (repo *Repository) deprecationWarning(key Key) (string, Logger) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	k := key.String()
+	if repo.warned[k] {
+		return "", nil
+	}
+	var msg string
+	if target, ok := repo.aliases[k]; ok {
+		msg = fmt.Sprintf("Config key %q is deprecated, use %q instead", k, target)
+	}
+	if descr, ok := repo.deprecations[k]; ok {
+		if len(msg) > 0 {
+			msg += ": " + descr
+		} else {
+			msg = fmt.Sprintf("Config key %q is deprecated: %s", k, descr)
+		}
+	}
+	if len(msg) == 0 {
+		return "", nil
+	}
+	repo.warned[k] = true
+	return msg, repo.logger
+}
+
 // Get is the primary interface for the stored data retrieval.
 // Returns the fetched value and a bool flag indicating the lookup result.
 // If no value was retrived from the providers, bool flag is set to false.
+// Aliased keys are resolved transparently: see `Alias` for more details.
 func (repo *Repository) Get(key Key) (Value, bool) {
+	return repo.getFor("", key)
+}
+
+// IsSet returns true if any provider serves the key, even with an empty
+// value, e.g. `key: ""` in YAML. A null value (`key: ~` or `key:`) leaves the
+// key unset: the next provider serves it, if any. It lets tri-state options
+// tell an explicit empty value from an absent one:
+//
+//	if repo.IsSet(config.NewKey("proxy.url")) {
+//		// "" explicitly disables the system proxy
+//	}
+//
+// The value is neither mapped nor recorded as read (see UnusedKeys), the
+// middlewares (see Use) are applied.
+// This method is thread safe.
+func (repo *Repository) IsSet(key Key) bool {
+	_, ok := repo.chainOver(repo.rawLookup)(key)
+	return ok
+}
+
+// rawLookup is the unmapped counterpart of lookup: it returns the raw value
+// served for the key. The value of a composite key is nil.
+func (repo *Repository) rawLookup(key Key) (Value, bool) {
+	if len(key) == 0 {
+		return nil, false
+	}
+	repo.activateLazy(key)
+	repo.activateLazy(repo.aliasTarget(key))
+	repo.viewMx.RLock()
+	defer repo.viewMx.RUnlock()
+	key = repo.aliasTarget(repo.foldKey(key))
+	for _, lookup := range append([]Key{key}, repo.aliasesOf(key)...) {
+		if v, ok := repo.root.rawGet(repo, lookup, key); ok {
+			return v, true
+		}
+	}
+	if d, ok := repo.wildcardDefaulted(key); ok {
+		return d.Value, true
+	}
+	return nil, false
+}
+
+// getFor is a version of Get recording the read on behalf of the caller in
+// the audit mode.
+func (repo *Repository) getFor(caller string, key Key) (Value, bool) {
+	repo.audit(caller, key)
+	return repo.getChain()(key)
+}
+
+// lookup is the innermost GetFunc of the middleware chain.
+func (repo *Repository) lookup(key Key) (Value, bool) {
+	if len(key) != 0 {
+		repo.activateLazy(key)
+		repo.activateLazy(repo.aliasTarget(key))
+	}
+	repo.viewMx.RLock()
+	defer repo.viewMx.RUnlock()
+	return repo.get(key)
+}
+
+// get is the lock-free version of Get. The caller is expected to hold
+// viewMx.
+func (repo *Repository) get(key Key) (Value, bool) {
 	// Non-empty key check prevents users from accessing a protected
 	// root node
 	if len(key) != 0 {
+		key = repo.aliasTarget(repo.foldKey(key))
 		if kv, ok := repo.root.get(repo, key); ok {
+			repo.warnDeprecated(key)
 			return kv.Value, ok
 		}
+		for _, old := range repo.aliasesOf(key) {
+			if kv, ok := repo.root.getAs(repo, old, key); ok {
+				repo.warnDeprecated(old)
+				return kv.Value, ok
+			}
+		}
+		return repo.wildcardDefault(key)
 	}
 	return nil, false
 }
 
+// Dump returns a flat map of all registered keys and the values resolved
+// according to the provider weights and the schema.
+func (repo *Repository) Dump() map[string]Value {
+	repo.mx.Lock()
+	keys := repo.root.keys(nil)
+	repo.mx.Unlock()
+	res := make(map[string]Value, len(keys))
+	repo.viewMx.RLock()
+	defer repo.viewMx.RUnlock()
+	for _, key := range keys {
+		if v, ok := repo.get(key); ok {
+			res[repo.KeyString(key)] = v
+		}
+	}
+	return res
+}
+
 // Explain returns a structure with a detailed explanation of the repository.
 // The resulting map mimics the original config map structure and leafs
 // indicate per-provider breakdown with a corresponding value returned by
-// each of them.
+// each of them. Described keys (see Describe) carry the description and the
+// examples under `__description__` and `__examples__` keys.
 func (repo *Repository) Explain() map[string]interface{} {
-	return repo.root.explain(nil)
+	return repo.root.explain(repo, nil)
 }
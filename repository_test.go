@@ -2,8 +2,15 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
+
+	"go.uber.org/goleak"
 )
 
 func strptr(v string) *string { return &v }
@@ -617,3 +624,461 @@ func TestExplain(t *testing.T) {
 		t.Fatalf("repo.Explain() = %#v, want: %#v", got, want)
 	}
 }
+
+func TestSetUpStrict(t *testing.T) {
+	schema := Schema(map[string]Schema{
+		"server": map[string]Schema{
+			"port": ToInt,
+			"host": ToStr,
+		},
+		"plugins": map[string]Schema{
+			"*": map[string]Schema{
+				"enabled": ToBool,
+			},
+		},
+		"extra": &IdentityConverter{},
+	})
+
+	tests := []struct {
+		name    string
+		strict  bool
+		keys    []string
+		wantErr string
+	}{
+		{
+			"Non-strict mode ignores unknown keys",
+			false,
+			[]string{"server.prot"},
+			"",
+		},
+		{
+			"All keys are known",
+			true,
+			[]string{"server.port", "server.host", "plugins.foo.enabled", "extra.foo.bar"},
+			"",
+		},
+		{
+			"A typo in the key",
+			true,
+			[]string{"server.prot", "server.host"},
+			"Unexpected config keys not defined in the schema: server.prot (did you mean: server.port?)",
+		},
+		{
+			"Multiple unknown keys",
+			true,
+			[]string{"server.prot", "database.url"},
+			"Unexpected config keys not defined in the schema: database.url; server.prot (did you mean: server.port?)",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepositoryWithOptions(&RepositoryOptions{Strict: testCase.strict})
+			if err := repo.DefineSchema(schema); err != nil {
+				t.Fatalf("Failed to define schema: %s", err)
+			}
+			for _, k := range testCase.keys {
+				repo.RegisterKey(NewKey(k), NewTestProv(42, DefaultWeight))
+			}
+			err := repo.SetUp()
+			if testCase.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want: %s", err, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewRepositoryWithNilOptions(t *testing.T) {
+	repo := NewRepositoryWithOptions(nil)
+	repo.RegisterKey(NewKey("server.port"), NewTestProv(42, DefaultWeight))
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if v, ok := repo.Get(NewKey("server.port")); !ok || v != 42 {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", v, 42)
+	}
+}
+
+type testLogger struct {
+	mx       sync.Mutex
+	messages []string
+}
+
+func (tl *testLogger) logf(level string, format string, args ...interface{}) {
+	tl.mx.Lock()
+	defer tl.mx.Unlock()
+	tl.messages = append(tl.messages, level+": "+fmt.Sprintf(format, args...))
+}
+
+func (tl *testLogger) Debugf(format string, args ...interface{}) {}
+
+func (tl *testLogger) Infof(format string, args ...interface{}) {
+	tl.logf("INFO", format, args...)
+}
+
+func (tl *testLogger) Warnf(format string, args ...interface{}) {
+	tl.logf("WARN", format, args...)
+}
+
+func (tl *testLogger) Errorf(format string, args ...interface{}) {
+	tl.logf("ERROR", format, args...)
+}
+
+func TestAlias(t *testing.T) {
+	repo := NewRepository()
+	logger := &testLogger{}
+	repo.SetLogger(logger)
+	repo.DefineSchema(map[string]Schema{
+		"server": map[string]Schema{
+			"port": ToInt,
+		},
+	})
+	if err := repo.Alias("http.port", "server.port"); err != nil {
+		t.Fatalf("Failed to register alias: %s", err)
+	}
+	repo.RegisterKey(NewKey("http.port"), NewTestProv("8080", DefaultWeight))
+
+	for _, k := range []string{"server.port", "http.port"} {
+		v, ok := repo.Get(NewKey(k))
+		if !ok {
+			t.Fatalf("Expected lookup for key %q to find a value, none returned", k)
+		}
+		if v != 8080 {
+			t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", k, v, 8080)
+		}
+	}
+
+	wantMsgs := []string{`WARN: Config key "http.port" is deprecated, use "server.port" instead`}
+	if !reflect.DeepEqual(logger.messages, wantMsgs) {
+		t.Fatalf("Unexpected log messages: got: %#v, want: %#v", logger.messages, wantMsgs)
+	}
+
+	// The new key takes precedence over the old one
+	repo.RegisterKey(NewKey("server.port"), NewTestProv(9090, DefaultWeight))
+	if v, _ := repo.Get(NewKey("http.port")); v != 9090 {
+		t.Fatalf("Unexpected value for aliased key: got: %#v, want: %#v", v, 9090)
+	}
+
+	for _, pair := range [][2]string{
+		{"server.port", "server.port"},
+		{"http.port", "server.addr"},
+		{"server.addr", "http.port"},
+		{"", "server.port"},
+	} {
+		if err := repo.Alias(pair[0], pair[1]); err == nil {
+			t.Fatalf("Expected Alias(%q, %q) to fail", pair[0], pair[1])
+		}
+	}
+}
+
+func TestDeprecate(t *testing.T) {
+	repo := NewRepository()
+	logger := &testLogger{}
+	repo.SetLogger(logger)
+	repo.Deprecate("system.maxprocs", "the runtime sets it automatically")
+	repo.RegisterKey(NewKey("system.maxprocs"), NewTestProv(4, DefaultWeight))
+
+	for i := 0; i < 3; i++ {
+		if v, ok := repo.Get(NewKey("system.maxprocs")); !ok || v != 4 {
+			t.Fatalf("Unexpected lookup result: got: %#v, %t", v, ok)
+		}
+	}
+
+	wantMsgs := []string{`WARN: Config key "system.maxprocs" is deprecated: the runtime sets it automatically`}
+	if !reflect.DeepEqual(logger.messages, wantMsgs) {
+		t.Fatalf("Unexpected log messages: got: %#v, want: %#v", logger.messages, wantMsgs)
+	}
+}
+
+// reentrantLogger reads the repository while logging a warning.
+type reentrantLogger struct {
+	testLogger
+	repo *Repository
+}
+
+func (rl *reentrantLogger) Warnf(format string, args ...interface{}) {
+	rl.repo.Get(NewKey("server.port"))
+	rl.testLogger.Warnf(format, args...)
+}
+
+func TestDeprecateReentrantLogger(t *testing.T) {
+	repo := NewRepository()
+	logger := &reentrantLogger{repo: repo}
+	repo.SetLogger(logger)
+	if err := repo.Alias("http.port", "server.port"); err != nil {
+		t.Fatalf("Failed to register alias: %s", err)
+	}
+	repo.RegisterKey(NewKey("http.port"), NewTestProv(8080, DefaultWeight))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		repo.Get(NewKey("http.port"))
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Get deadlocked on a logger reading the repository")
+	}
+	wantMsgs := []string{`WARN: Config key "http.port" is deprecated, use "server.port" instead`}
+	if !reflect.DeepEqual(logger.messages, wantMsgs) {
+		t.Fatalf("Unexpected log messages: got: %#v, want: %#v", logger.messages, wantMsgs)
+	}
+}
+
+func TestLoggingHooks(t *testing.T) {
+	repo := NewRepository()
+	logger := &testLogger{}
+	repo.SetLogger(logger)
+	repo.DefineSchema(map[string]Schema{"foo": ToInt})
+
+	prov1 := NewTestProv("abc", DefaultWeight)
+	prov2 := NewTestProv("def", DefaultWeight)
+	repo.RegisterKey(NewKey("foo"), prov1)
+	repo.RegisterKey(NewKey("foo"), prov2)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Unexpected set up error: %s", err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("Expected Get to panic on a mapper error")
+			}
+		}()
+		repo.Get(NewKey("foo"))
+	}()
+
+	wantMsgs := []string{
+		`WARN: Config providers "test" and "test" share the same weight 10 for key "foo": "test" takes precedence as registered first`,
+		`INFO: Setting up config provider "test" (weight: 10)`,
+		`ERROR: Failed to map the value "abc" for key "foo" provided by "test": Failed to convert string value for key "foo"`,
+	}
+	if !reflect.DeepEqual(logger.messages, wantMsgs) {
+		t.Fatalf("Unexpected log messages: got: %#v, want: %#v", logger.messages, wantMsgs)
+	}
+}
+
+func TestMapperErrorContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  map[string]Schema
+		wantErr string
+	}{
+		{
+			"plain value",
+			map[string]Schema{"db": map[string]Schema{"password": ToInt}},
+			`Failed to map the value "abc" for key "db.password" provided by "test": Failed to convert string value for key "db.password"`,
+		},
+		{
+			"secret value",
+			map[string]Schema{"db": map[string]Schema{"password": Secret(ToInt)}},
+			`Failed to map the value ****** for key "db.password" provided by "test": Failed to convert string value for key "db.password"`,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			repo.DefineSchema(testCase.schema)
+			repo.RegisterKey(NewKey("db.password"), NewTestProv("abc", DefaultWeight))
+			err := repo.tryGet(NewKey("db.password"))
+			if err == nil || err.Error() != testCase.wantErr {
+				t.Fatalf("Unexpected mapper error: got: %v, want: %s", err, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestDump(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"foo": map[string]Schema{
+			"bar": ToInt,
+		},
+	})
+	repo.RegisterKey(NewKey("foo.bar"), NewTestProv("42", 10))
+	repo.RegisterKey(NewKey("foo.baz"), NewTestProv("moo", 10))
+	repo.RegisterKey(NewKey("foo.baz"), NewTestProv("zoo", 20))
+
+	want := map[string]Value{
+		"foo.bar": 42,
+		"foo.baz": "zoo",
+	}
+	if got := repo.Dump(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("repo.Dump() = %#v, want: %#v", got, want)
+	}
+}
+
+type teardownTestProv struct {
+	name    string
+	depends []string
+	err     error
+	log     *[]string
+}
+
+func (tp *teardownTestProv) Name() string              { return tp.name }
+func (tp *teardownTestProv) Depends() []string         { return tp.depends }
+func (tp *teardownTestProv) Weight() int               { return 0 }
+func (tp *teardownTestProv) SetUp(*Repository) error   { return nil }
+func (tp *teardownTestProv) Get(Key) (*KeyValue, bool) { return nil, false }
+func (tp *teardownTestProv) TearDown(*Repository) error {
+	*tp.log = append(*tp.log, tp.name)
+	return tp.err
+}
+
+func TestTearDownOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		errs    map[string]error
+		wantErr string
+	}{
+		{"no errors", nil, ""},
+		{"single error", map[string]error{"env": fmt.Errorf("boom")}, "boom"},
+		{
+			"multiple errors",
+			map[string]error{"env": fmt.Errorf("boom"), "cli": fmt.Errorf("bang")},
+			"Failed to tear down config providers: env: boom; cli: bang",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			var log []string
+			repo := NewRepository()
+			for _, prov := range []*teardownTestProv{
+				{name: "yaml", depends: []string{"env"}},
+				{name: "env", depends: []string{"cli"}},
+				{name: "cli"},
+			} {
+				prov.err = testCase.errs[prov.name]
+				prov.log = &log
+				repo.RegisterProvider(prov)
+			}
+			if err := repo.SetUp(); err != nil {
+				t.Fatalf("Failed to set up the repository: %s", err)
+			}
+			err := repo.TearDown()
+			if len(testCase.wantErr) == 0 && err != nil || len(testCase.wantErr) > 0 && (err == nil || err.Error() != testCase.wantErr) {
+				t.Fatalf("Unexpected error: got: %v, want: %q", err, testCase.wantErr)
+			}
+			// All providers are torn down, dependents first
+			if want := []string{"yaml", "env", "cli"}; !reflect.DeepEqual(log, want) {
+				t.Fatalf("Unexpected tear down order: got: %#v, want: %#v", log, want)
+			}
+		})
+	}
+}
+
+func TestClose(t *testing.T) {
+	var log []string
+	repo := NewRepository()
+	repo.RegisterProvider(&teardownTestProv{name: "test", err: fmt.Errorf("boom"), log: &log})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := repo.Close(); err == nil || err.Error() != "boom" {
+			t.Fatalf("Unexpected error: got: %v, want: %q", err, "boom")
+		}
+	}
+	if want := []string{"test"}; !reflect.DeepEqual(log, want) {
+		t.Fatalf("Unexpected tear down calls: got: %#v, want: %#v", log, want)
+	}
+}
+
+func TestCloseStopsGoroutines(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+	oldSignalNotify, oldSignalStop := signalNotify, signalStop
+	defer func() { signalNotify, signalStop = oldSignalNotify, oldSignalStop }()
+	signalNotify = func(chan<- os.Signal, ...os.Signal) {}
+	signalStop = func(chan<- os.Signal) {}
+
+	repo := NewRepository()
+	if _, err := NewYamlProviderFromSource(repo, 0, &YamlProviderOptions{
+		Watch:         true,
+		WatchInterval: time.Millisecond,
+		FS:            fstest.MapFS{"config.yaml": &fstest.MapFile{Data: []byte("foo: 1\n")}},
+	}, "config.yaml"); err != nil {
+		t.Fatalf("Failed to initialize a new yaml provider: %s", err)
+	}
+	repo.RegisterProvider(&watchTestProv{reloadTestProv: reloadTestProv{registry: map[string]Value{"bar": 2}}})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	repo.ReloadOnSignal(nil)
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func TestIsSet(t *testing.T) {
+	repo := NewRepository()
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"log.level":  "info",
+		"proxy.url":  "http://proxy.local",
+		"cache.size": 100,
+	})
+	if _, err := NewYamlProviderFromReader(repo, 20, strings.NewReader(
+		"log:\n  level: ~\nproxy:\n  url: ''\nfeature:\n  enabled:\n",
+	)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	tests := []struct {
+		key       string
+		wantSet   bool
+		wantValue Value
+	}{
+		{"log.level", true, "info"},
+		{"proxy.url", true, ""},
+		{"cache.size", true, 100},
+		{"feature.enabled", false, nil},
+		{"missing", false, nil},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.key, func(t *testing.T) {
+			key := NewKey(testCase.key)
+			if got := repo.IsSet(key); got != testCase.wantSet {
+				t.Fatalf("Unexpected IsSet result: got: %t, want: %t", got, testCase.wantSet)
+			}
+			if got, _ := repo.Get(key); got != testCase.wantValue {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.wantValue)
+			}
+		})
+	}
+	if !repo.Scope("proxy").IsSet(NewKey("url")) {
+		t.Fatalf("Expected the scoped key to be set")
+	}
+	if _, ok, err := LookupBool(repo, "feature.enabled"); ok || err != nil {
+		t.Fatalf("Unexpected lookup result for a null value: got: %t, %v, want: false, nil", ok, err)
+	}
+}
+
+func TestIsSetUnmapped(t *testing.T) {
+	repo := NewRepositoryWithOptions(&RepositoryOptions{TrackUnused: true})
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{"port": "abc"})
+	if err := repo.DefineSchema(map[string]Schema{"port": ToInt}); err != nil {
+		t.Fatalf("Failed to define the schema: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	// The value failing its mapper is set, it is not mapped
+	if !repo.IsSet(NewKey("port")) {
+		t.Fatalf("Expected the key to be set")
+	}
+	if got, want := repo.UnusedKeys(), []Key{NewKey("port")}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected unused keys: got: %#v, want: %#v", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// Base64Prefix is the conventional prefix of base64-encoded values.
+	Base64Prefix = "base64:"
+	// FilePrefix is the conventional prefix of file references.
+	FilePrefix = "file://"
+)
+
+// Resolver turns a value reference into the actual value. Resolvers are
+// registered in the repository under a prefix: every string value starting
+// with the prefix is resolved at Get time, before the mapping. The prefix is
+// stripped before the reference is passed to the resolver.
+type Resolver interface {
+	Resolve(ref string) (Value, error)
+}
+
+// Base64Resolver decodes standard base64-encoded strings.
+type Base64Resolver struct{}
+
+var _ Resolver = (*Base64Resolver)(nil)
+
+// NewBase64Resolver is the constructor for Base64Resolver.
+func NewBase64Resolver() *Base64Resolver {
+	return &Base64Resolver{}
+}
+
+// Resolve returns the decoded string.
+func (*Base64Resolver) Resolve(ref string) (Value, error) {
+	data, err := base64.StdEncoding.DecodeString(ref)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode base64 value: %s", err)
+	}
+	return string(data), nil
+}
+
+// FileResolver reads file contents. The contents are cached: every file is
+// read once unless the cache is invalidated.
+type FileResolver struct {
+	fsys  fs.FS
+	cache map[string]string
+	mx    sync.Mutex
+}
+
+var _ Resolver = (*FileResolver)(nil)
+
+// NewFileResolver is the constructor for FileResolver. Files are read from
+// OSFS.
+func NewFileResolver() *FileResolver {
+	return NewFileResolverWithFS(OSFS)
+}
+
+// NewFileResolverWithFS is a version of NewFileResolver reading files from
+// the filesystem, e.g. a fstest.MapFS.
+func NewFileResolverWithFS(fsys fs.FS) *FileResolver {
+	return &FileResolver{
+		fsys:  fsys,
+		cache: make(map[string]string),
+	}
+}
+
+// Resolve returns the file contents as a string. The reference is a path,
+// e.g. for a value `file:///etc/tls/cert.pem` the path is `/etc/tls/cert.pem`.
+func (fr *FileResolver) Resolve(ref string) (Value, error) {
+	fr.mx.Lock()
+	defer fr.mx.Unlock()
+	if data, ok := fr.cache[ref]; ok {
+		return data, nil
+	}
+	data, err := fs.ReadFile(fr.fsys, ref)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve file reference: %s", err)
+	}
+	fr.cache[ref] = string(data)
+	return fr.cache[ref], nil
+}
+
+// Invalidate drops the cached file contents.
+func (fr *FileResolver) Invalidate() {
+	fr.mx.Lock()
+	defer fr.mx.Unlock()
+	fr.cache = make(map[string]string)
+}
+
+// RegisterResolver registers the resolver for string values starting with the
+// prefix. Conventional resolvers can be registered at once with
+// `RegisterDefaultResolvers`.
+// This method is thread safe.
+func (repo *Repository) RegisterResolver(prefix string, resolver Resolver) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	repo.resolvers[prefix] = resolver
+}
+
+// RegisterDefaultResolvers registers a Base64Resolver for values prefixed with
+// `base64:` and a FileResolver for values prefixed with `file://`.
+func (repo *Repository) RegisterDefaultResolvers() {
+	repo.RegisterResolver(Base64Prefix, NewBase64Resolver())
+	repo.RegisterResolver(FilePrefix, NewFileResolver())
+}
+
+// resolve returns the resolved value if it is a string reference matching one
+// of the registered resolver prefixes. The longest prefix wins. Returns the
+// value as is otherwise.
+func (repo *Repository) resolve(v Value) (Value, error) {
+	sv, ok := v.(string)
+	if !ok {
+		return v, nil
+	}
+	repo.mx.Lock()
+	if len(repo.resolvers) == 0 {
+		repo.mx.Unlock()
+		return v, nil
+	}
+	prefixes := make([]string, 0, len(repo.resolvers))
+	for prefix := range repo.resolvers {
+		if strings.HasPrefix(sv, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Slice(prefixes, func(a, b int) bool {
+		return len(prefixes[a]) > len(prefixes[b])
+	})
+	var resolver Resolver
+	if len(prefixes) > 0 {
+		resolver = repo.resolvers[prefixes[0]]
+	}
+	repo.mx.Unlock()
+	if resolver == nil {
+		return v, nil
+	}
+	return resolver.Resolve(sv[len(prefixes[0]):])
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolvers(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/tls/cert.pem": &fstest.MapFile{Data: []byte("-----BEGIN CERTIFICATE-----")},
+	}
+
+	tests := []struct {
+		name    string
+		value   Value
+		want    Value
+		wantErr bool
+	}{
+		{
+			"A plain value",
+			"hello",
+			"hello",
+			false,
+		},
+		{
+			"A non-string value",
+			42,
+			42,
+			false,
+		},
+		{
+			"A base64 value",
+			"base64:aGVsbG8=",
+			"hello",
+			false,
+		},
+		{
+			"A malformed base64 value",
+			"base64:%%%",
+			nil,
+			true,
+		},
+		{
+			"A file reference",
+			"file://etc/tls/cert.pem",
+			"-----BEGIN CERTIFICATE-----",
+			false,
+		},
+		{
+			"A missing file reference",
+			"file://etc/tls/key.pem",
+			nil,
+			true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			repo.RegisterDefaultResolvers()
+			repo.RegisterResolver(FilePrefix, NewFileResolverWithFS(fsys))
+			if _, err := NewMapProvider(repo, 10, "map", map[string]Value{
+				"foo": testCase.value,
+			}); err != nil {
+				t.Fatalf("Failed to initialize a new map provider: %s", err)
+			}
+			if err := repo.SetUp(); err != nil {
+				t.Fatalf("Failed to set up the repository: %s", err)
+			}
+			var got Value
+			var err error
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						err = fmt.Errorf("%s", r)
+					}
+				}()
+				got, _ = repo.Get(NewKey("foo"))
+			}()
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestFileResolverCache(t *testing.T) {
+	reads := 0
+	fr := NewFileResolverWithFS(funcFS(func(string) []byte {
+		reads++
+		return []byte(fmt.Sprintf("read %d", reads))
+	}))
+	for i := 0; i < 3; i++ {
+		got, err := fr.Resolve("foo")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if got != "read 1" {
+			t.Fatalf("Unexpected value: got: %#v, want: %#v", got, "read 1")
+		}
+	}
+	fr.Invalidate()
+	got, err := fr.Resolve("foo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got != "read 2" {
+		t.Fatalf("Unexpected value after invalidation: got: %#v, want: %#v", got, "read 2")
+	}
+}
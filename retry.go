@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// RetryPolicy is an exponential backoff retry policy.
+type RetryPolicy struct {
+	// InitialInterval is the delay before the first retry.
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between retries.
+	MaxInterval time.Duration
+	// Multiplier is the delay growth factor, 1 stands for a constant delay.
+	Multiplier float64
+	// MaxAttempts limits the number of attempts, the first one included.
+	// 0 stands for unlimited attempts.
+	MaxAttempts int
+	// Jitter is the randomization factor in the range [0, 1]: a delay d is
+	// randomized in the range [d - d*Jitter, d + d*Jitter].
+	Jitter float64
+}
+
+// DefaultRetryPolicy provides the values for the keys missing in a retry
+// policy section.
+var DefaultRetryPolicy = RetryPolicy{
+	InitialInterval: 100 * time.Millisecond,
+	MaxInterval:     10 * time.Second,
+	Multiplier:      2,
+	MaxAttempts:     0,
+	Jitter:          0.2,
+}
+
+// Validate returns an error if the policy settings are inconsistent.
+func (rp RetryPolicy) Validate() error {
+	switch {
+	case rp.InitialInterval <= 0:
+		return fmt.Errorf("Retry initial interval must be positive, got: %s", rp.InitialInterval)
+	case rp.MaxInterval < rp.InitialInterval:
+		return fmt.Errorf("Retry max interval %s is less than the initial interval %s", rp.MaxInterval, rp.InitialInterval)
+	case rp.Multiplier < 1 || math.IsNaN(rp.Multiplier) || math.IsInf(rp.Multiplier, 0):
+		return fmt.Errorf("Retry multiplier must be a finite number of at least 1, got: %v", rp.Multiplier)
+	case rp.MaxAttempts < 0:
+		return fmt.Errorf("Retry max attempts must not be negative, got: %d", rp.MaxAttempts)
+	case math.IsNaN(rp.Jitter) || rp.Jitter < 0 || rp.Jitter > 1:
+		return fmt.Errorf("Retry jitter must be in the range [0, 1], got: %v", rp.Jitter)
+	}
+	return nil
+}
+
+// Backoff returns the delay before the attempt: attempt 1 is the first
+// retry. Returns false if the attempts are exhausted.
+func (rp RetryPolicy) Backoff(attempt int) (time.Duration, bool) {
+	if attempt < 1 || (rp.MaxAttempts > 0 && attempt >= rp.MaxAttempts) {
+		return 0, false
+	}
+	d := float64(rp.InitialInterval) * math.Pow(rp.Multiplier, float64(attempt-1))
+	if d > float64(rp.MaxInterval) {
+		d = float64(rp.MaxInterval)
+	}
+	delay := time.Duration(d)
+	if delta := int64(d * rp.Jitter); delta > 0 {
+		delay += time.Duration(randInt63n(2*delta+1) - delta)
+	}
+	return delay, true
+}
+
+// RetryPolicyConverter converts a retry policy section into a RetryPolicy.
+// The section keys are: initial_interval, max_interval, multiplier,
+// max_attempts and jitter. Intervals might be time.Duration values or strings
+// like "100ms". Missing keys are taken from DefaultRetryPolicy.
+type RetryPolicyConverter struct{}
+
+var _ Converter = (*RetryPolicyConverter)(nil)
+var _ Mapper = (*RetryPolicyConverter)(nil)
+
+// ToRetryPolicy is an initialized instance of RetryPolicyConverter. It is
+// registered as the converter for the RetryPolicy type (see ConverterFor).
+//
+// Example:
+//
+//	repo.DefineSchema(map[string]config.Schema{
+//		"clients.billing.retry": config.ToRetryPolicy,
+//	})
+var ToRetryPolicy = &RetryPolicyConverter{}
+
+// Convert returns the RetryPolicy value and true if the value is a valid
+// retry policy section or a RetryPolicy.
+func (rc *RetryPolicyConverter) Convert(kv *KeyValue) (*KeyValue, bool) {
+	mkv, err := rc.Map(kv)
+	return mkv, err == nil
+}
+
+// Map converts the section. Returns an error describing the malformed or
+// inconsistent setting.
+func (rc *RetryPolicyConverter) Map(kv *KeyValue) (*KeyValue, error) {
+	if rp, ok := kv.Value.(RetryPolicy); ok {
+		if err := rp.Validate(); err != nil {
+			return nil, err
+		}
+		return &KeyValue{Key: kv.Key, Value: rp}, nil
+	}
+	section, ok := toValueMap(kv.Value)
+	if !ok {
+		return nil, fmt.Errorf("Failed to convert %T value for key %q: want a retry policy section", kv.Value, kv.Key)
+	}
+	rp := DefaultRetryPolicy
+	for name, v := range section {
+		var ok bool
+		switch name {
+		case "initial_interval":
+			rp.InitialInterval, ok = toDuration(v)
+		case "max_interval":
+			rp.MaxInterval, ok = toDuration(v)
+		case "multiplier":
+			rp.Multiplier, ok = toFloat(v)
+		case "max_attempts":
+			var iv Value
+			if iv, ok = convert(ToInt, v); ok {
+				rp.MaxAttempts = iv.(int)
+			}
+		case "jitter":
+			rp.Jitter, ok = toFloat(v)
+		default:
+			return nil, fmt.Errorf("Unexpected retry policy setting %q for key %q", name, kv.Key)
+		}
+		if !ok {
+			return nil, fmt.Errorf("Failed to convert %T value of retry policy setting %q for key %q", v, name, kv.Key)
+		}
+	}
+	if err := rp.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid retry policy for key %q: %s", kv.Key, err)
+	}
+	return &KeyValue{Key: kv.Key, Value: rp}, nil
+}
+
+// JSONSchema describes the retry policy section.
+func (rc *RetryPolicyConverter) JSONSchema() map[string]interface{} {
+	duration := map[string]interface{}{"type": "string"}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"initial_interval": duration,
+			"max_interval":     duration,
+			"multiplier":       map[string]interface{}{"type": "number", "minimum": 1},
+			"max_attempts":     map[string]interface{}{"type": "integer", "minimum": 0},
+			"jitter":           map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// LookupRetryPolicy returns the retry policy section under the key (see
+// RetryPolicyConverter). The boolean flag is false if the key is not served
+// by any provider.
+func LookupRetryPolicy(repo Getter, key string) (RetryPolicy, bool, error) {
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return RetryPolicy{}, ok, err
+	}
+	kv, err := ToRetryPolicy.Map(&KeyValue{Key: parseKey(repo, key), Value: v})
+	if err != nil {
+		return RetryPolicy{}, true, err
+	}
+	return kv.Value.(RetryPolicy), true, nil
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyConverter(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   Value
+		want    RetryPolicy
+		wantErr string
+	}{
+		{
+			"full section",
+			map[string]Value{
+				"initial_interval": "50ms",
+				"max_interval":     5 * time.Second,
+				"multiplier":       1.5,
+				"max_attempts":     "5",
+				"jitter":           "0.1",
+			},
+			RetryPolicy{
+				InitialInterval: 50 * time.Millisecond,
+				MaxInterval:     5 * time.Second,
+				Multiplier:      1.5,
+				MaxAttempts:     5,
+				Jitter:          0.1,
+			},
+			"",
+		},
+		{
+			"defaults",
+			map[string]Value{"max_attempts": 3},
+			RetryPolicy{
+				InitialInterval: DefaultRetryPolicy.InitialInterval,
+				MaxInterval:     DefaultRetryPolicy.MaxInterval,
+				Multiplier:      DefaultRetryPolicy.Multiplier,
+				MaxAttempts:     3,
+				Jitter:          DefaultRetryPolicy.Jitter,
+			},
+			"",
+		},
+		{"policy value", RetryPolicy{InitialInterval: time.Second, MaxInterval: time.Second, Multiplier: 1}, RetryPolicy{InitialInterval: time.Second, MaxInterval: time.Second, Multiplier: 1}, ""},
+		{"not a section", "fast", RetryPolicy{}, "want a retry policy section"},
+		{"unknown setting", map[string]Value{"timeout": "1s"}, RetryPolicy{}, `Unexpected retry policy setting "timeout"`},
+		{"malformed interval", map[string]Value{"initial_interval": "soon"}, RetryPolicy{}, `retry policy setting "initial_interval"`},
+		{"max below initial", map[string]Value{"initial_interval": "1m"}, RetryPolicy{}, "less than the initial interval"},
+		{"multiplier below 1", map[string]Value{"multiplier": 0.5}, RetryPolicy{}, "multiplier must be a finite number of at least 1"},
+		{"multiplier NaN", map[string]Value{"multiplier": "NaN"}, RetryPolicy{}, "multiplier must be a finite number"},
+		{"multiplier infinity", map[string]Value{"multiplier": "+Inf"}, RetryPolicy{}, "multiplier must be a finite number"},
+		{"jitter out of range", map[string]Value{"jitter": 2}, RetryPolicy{}, "jitter must be in the range"},
+		{"jitter NaN", map[string]Value{"jitter": "NaN"}, RetryPolicy{}, "jitter must be in the range"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			kv, err := ToRetryPolicy.Map(&KeyValue{Key: NewKey("retry"), Value: testCase.value})
+			if len(testCase.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("Unexpected error: got: %v, want: %q", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(kv.Value, testCase.want) {
+				t.Fatalf("Unexpected policy: got: %#v, want: %#v", kv.Value, testCase.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	oldRand := randInt63n
+	defer func() { randInt63n = oldRand }()
+	randInt63n = func(n int64) int64 { return n - 1 } // the max jitter
+
+	rp := RetryPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     time.Second,
+		Multiplier:      3,
+		MaxAttempts:     5,
+	}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+		wantOk  bool
+	}{
+		{0, 0, false},
+		{1, 100 * time.Millisecond, true},
+		{2, 300 * time.Millisecond, true},
+		{3, 900 * time.Millisecond, true},
+		{4, time.Second, true},
+		{5, 0, false},
+	}
+	for _, testCase := range tests {
+		got, ok := rp.Backoff(testCase.attempt)
+		if got != testCase.want || ok != testCase.wantOk {
+			t.Fatalf("Unexpected backoff for attempt %d: got: %s, %t, want: %s, %t",
+				testCase.attempt, got, ok, testCase.want, testCase.wantOk)
+		}
+	}
+
+	rp.Jitter = 0.5
+	if got, _ := rp.Backoff(1); got != 150*time.Millisecond {
+		t.Fatalf("Unexpected jittered backoff: got: %s, want: %s", got, 150*time.Millisecond)
+	}
+}
+
+func TestLookupRetryPolicy(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"clients": map[string]Schema{
+			"search": map[string]Schema{"retry": reflect.TypeOf(RetryPolicy{})},
+		},
+	})
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"clients.billing.retry.initial_interval": "1s",
+		"clients.billing.retry.max_interval":     "1m",
+		"clients.search.retry.max_attempts":      "2",
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	rp, ok, err := LookupRetryPolicy(repo, "clients.billing.retry")
+	if !ok || err != nil {
+		t.Fatalf("Unexpected lookup result: got: %t, %v, want: true, nil", ok, err)
+	}
+	if rp.InitialInterval != time.Second || rp.MaxInterval != time.Minute {
+		t.Fatalf("Unexpected policy: got: %#v", rp)
+	}
+
+	// The schema maps the section to a RetryPolicy
+	v, ok, err := Lookup(repo, "clients.search.retry")
+	if !ok || err != nil {
+		t.Fatalf("Unexpected lookup result: got: %t, %v, want: true, nil", ok, err)
+	}
+	if rp, isPolicy := v.(RetryPolicy); !isPolicy || rp.MaxAttempts != 2 {
+		t.Fatalf("Unexpected value: got: %#v, want: a RetryPolicy with 2 max attempts", v)
+	}
+
+	if _, ok, err := LookupRetryPolicy(repo, "clients.missing.retry"); ok || err != nil {
+		t.Fatalf("Unexpected lookup result: got: %t, %v, want: false, nil", ok, err)
+	}
+}
@@ -4,5 +4,6 @@ package config
 // It might be:
 // * a Mapper
 // * a Converter
+// * a reflect.Type with a registered converter (see RegisterConverter)
 // * a map[string]Schema
 type Schema interface{}
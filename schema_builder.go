@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaBuilder is a fluent alternative to nested map literals for schema
+// definitions, e.g.:
+//
+//	schema, err := config.NewSchema().
+//		Key("server.port", config.ToInt, config.Required(), config.DefaultsTo(8080)).
+//		Key("server.host", config.ToStr, config.Doc("Listen address")).
+//		Key("db.password", config.ToStr, config.Sensitive()).
+//		Build()
+//
+// Every key is defined once: Build returns an error listing the keys defined
+// more than once.
+type SchemaBuilder struct {
+	keys  []*keySpec
+	index map[string]*keySpec
+	dups  []string
+}
+
+// KeyOption is an option of a key defined with SchemaBuilder.Key.
+type KeyOption func(*keySpec)
+
+type keySpec struct {
+	key        Key
+	subject    Schema
+	required   bool
+	hasDefault bool
+	value      Value
+	text       string
+	examples   []string
+	secret     bool
+}
+
+// NewSchema is the constructor for SchemaBuilder.
+func NewSchema() *SchemaBuilder {
+	return &SchemaBuilder{index: make(map[string]*keySpec)}
+}
+
+// Required makes the repository set up fail if no provider serves the key
+// (see Description.Required).
+func Required() KeyOption {
+	return func(ks *keySpec) { ks.required = true }
+}
+
+// DefaultsTo sets the key default value (see DefaultValue).
+func DefaultsTo(value Value) KeyOption {
+	return func(ks *keySpec) {
+		ks.hasDefault = true
+		ks.value = value
+	}
+}
+
+// Doc describes the key with a text and a list of examples (see Describe).
+func Doc(text string, examples ...string) KeyOption {
+	return func(ks *keySpec) {
+		ks.text = text
+		ks.examples = examples
+	}
+}
+
+// Sensitive marks the key value as sensitive (see Secret).
+func Sensitive() KeyOption {
+	return func(ks *keySpec) { ks.secret = true }
+}
+
+// Key defines the key with the schema definition: a Mapper, a Converter, a
+// reflect.Type or nil. The key might contain wildcards, e.g.
+// `workers.*.queue`.
+func (sb *SchemaBuilder) Key(key string, subject Schema, opts ...KeyOption) *SchemaBuilder {
+	ks := &keySpec{key: NewKey(key), subject: subject}
+	for _, opt := range opts {
+		opt(ks)
+	}
+	str := ks.key.String()
+	if _, ok := sb.index[str]; ok {
+		sb.dups = append(sb.dups, str)
+		return sb
+	}
+	sb.index[str] = ks
+	sb.keys = append(sb.keys, ks)
+	return sb
+}
+
+// Build returns the schema as a map[string]Schema nested by the key
+// fragments. A key having both a definition and child keys is defined under
+// `__self__`. Returns an error if a key is defined more than once.
+func (sb *SchemaBuilder) Build() (Schema, error) {
+	if len(sb.dups) > 0 {
+		return nil, fmt.Errorf("Schema keys are defined more than once: %s", strings.Join(sb.dups, ", "))
+	}
+	res := make(map[string]Schema)
+	for _, ks := range sb.keys {
+		ptr := res
+		for i, k := range ks.key {
+			k = QuoteFragment(k)
+			if i == len(ks.key)-1 {
+				if sub, ok := ptr[k].(map[string]Schema); ok {
+					sub["__self__"] = ks.schema()
+				} else {
+					ptr[k] = ks.schema()
+				}
+				break
+			}
+			sub, ok := ptr[k].(map[string]Schema)
+			if !ok {
+				sub = make(map[string]Schema)
+				if self, ok := ptr[k]; ok {
+					sub["__self__"] = self
+				}
+				ptr[k] = sub
+			}
+			ptr = sub
+		}
+	}
+	return res, nil
+}
+
+// MustBuild is Build panicking on error.
+func (sb *SchemaBuilder) MustBuild() Schema {
+	schema, err := sb.Build()
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// schema wraps the key subject with the description and the default value
+// as set by the key options.
+func (ks *keySpec) schema() Schema {
+	res := ks.subject
+	if ks.hasDefault {
+		res = DefaultValue(res, ks.value)
+	}
+	if ks.required || ks.secret || len(ks.text) > 0 || len(ks.examples) > 0 {
+		res = &Description{
+			Subject:  res,
+			Text:     ks.text,
+			Examples: ks.examples,
+			Secret:   ks.secret,
+			Required: ks.required,
+		}
+	}
+	return res
+}
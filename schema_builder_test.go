@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemaBuilder(t *testing.T) {
+	fooMpr := NewTestMapper(func(kv *KeyValue) (*KeyValue, error) { return kv, nil })
+	schema, err := NewSchema().
+		Key("server.port", ToInt, Required(), DefaultsTo(8080)).
+		Key("server.host", ToStr, Doc("Listen address", "0.0.0.0")).
+		Key("db.password", ToStr, Sensitive()).
+		Key("db", fooMpr).
+		Key("workers.*.queue", ToStr).
+		Key(`labels."app.kubernetes.io/name"`, nil, DefaultsTo("app")).
+		Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := map[string]Schema{
+		"server": map[string]Schema{
+			"port": &Description{Subject: DefaultValue(ToInt, 8080), Required: true},
+			"host": &Description{Subject: ToStr, Text: "Listen address", Examples: []string{"0.0.0.0"}},
+		},
+		"db": map[string]Schema{
+			"__self__": fooMpr,
+			"password": &Description{Subject: ToStr, Secret: true},
+		},
+		"workers": map[string]Schema{
+			"*": map[string]Schema{"queue": ToStr},
+		},
+		"labels": map[string]Schema{
+			`"app.kubernetes.io/name"`: DefaultValue(nil, "app"),
+		},
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Fatalf("Unexpected schema: got: %#v, want: %#v", schema, want)
+	}
+
+	_, err = NewSchema().
+		Key("server.port", ToInt).
+		Key("server.host", ToStr).
+		Key("server.port", ToStr).
+		Build()
+	if err == nil || !strings.Contains(err.Error(), "server.port") {
+		t.Fatalf("Unexpected error: got: %v, want a duplicate key error", err)
+	}
+}
+
+func TestRequiredKeys(t *testing.T) {
+	schema := NewSchema().
+		Key("server.port", ToInt, Required()).
+		Key("server.host", ToStr, Required(), DefaultsTo("localhost")).
+		Key("workers.*.queue", ToStr, Required()).
+		MustBuild()
+
+	tests := []struct {
+		name    string
+		values  map[string]Value
+		wantErr string
+	}{
+		{"all set", map[string]Value{"server.port": 8080}, ""},
+		{"missing", map[string]Value{}, "Required config keys are not set: server.port"},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			NewMapProvider(repo, 0, "yaml", testCase.values)
+			if err := repo.DefineSchema(schema); err != nil {
+				t.Fatalf("Failed to define the schema: %s", err)
+			}
+			err := repo.SetUp()
+			if len(testCase.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), testCase.wantErr) || !errors.Is(err, ErrKeyNotFound) {
+				t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+			}
+		})
+	}
+
+	doc, err := SchemaToJSONSchema(schema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !strings.Contains(string(doc), `"required": [
+        "host",
+        "port"
+      ]`) {
+		t.Fatalf("Unexpected JSON schema, want the required keys listed: %s", doc)
+	}
+}
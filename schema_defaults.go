@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+)
+
+// Defaulted is a schema definition carrying a default value. The default
+// value is served if no provider supplies the key.
+type Defaulted struct {
+	// Subject is the schema definition: a Mapper, a Converter or nil.
+	Subject Schema
+	// Value is the default value. It goes through the schema mapping like
+	// any provider value.
+	Value Value
+}
+
+var _ Mapper = (*Defaulted)(nil)
+var _ JSONSchemaDescriber = (*Defaulted)(nil)
+
+// DefaultValue wraps a schema definition with a default value. It is an
+// alternative to maintaining a DefaultProvider registry parallel to the
+// schema.
+//
+// Example:
+//
+//	schema := map[string]Schema{
+//		"port": DefaultValue(ToInt, 8080),
+//	}
+func DefaultValue(subject Schema, value Value) *Defaulted {
+	return &Defaulted{
+		Subject: subject,
+		Value:   value,
+	}
+}
+
+// Map delegates the mapping to the schema definition. If the subject is
+// neither a Mapper nor a Converter, the key-value pair is returned as is.
+func (d *Defaulted) Map(kv *KeyValue) (*KeyValue, error) {
+	switch s := d.Subject.(type) {
+	case Mapper:
+		return s.Map(kv)
+	case Converter:
+		return NewConvMapper(s).Map(kv)
+	case reflect.Type:
+		return NewTypeMapper(s).Map(kv)
+	}
+	return kv, nil
+}
+
+// JSONSchema describes the subject and annotates it with the default value.
+func (d *Defaulted) JSONSchema() map[string]interface{} {
+	res, err := describeSchema(NewKey(""), d.Subject)
+	if err != nil {
+		res = map[string]interface{}{}
+	}
+	res["default"] = d.Value
+	return res
+}
+
+// SchemaDefaultsName is the name of the provider serving the schema default
+// values (see DefaultValue).
+const SchemaDefaultsName = "schema"
+
+// schemaDefaults is the provider serving the schema default values. It has
+// the lowest possible weight: any other provider overrides it.
+type schemaDefaults struct {
+	registry map[string]Value
+	// wildcards keeps the defaults of the wildcard keys (see
+	// fillWildcardDefaults)
+	wildcards *MapperNode
+	mx        sync.RWMutex
+}
+
+var _ Provider = (*schemaDefaults)(nil)
+
+func (sd *schemaDefaults) Name() string               { return SchemaDefaultsName }
+func (sd *schemaDefaults) Depends() []string          { return []string{} }
+func (sd *schemaDefaults) Weight() int                { return math.MinInt32 }
+func (sd *schemaDefaults) SetUp(*Repository) error    { return nil }
+func (sd *schemaDefaults) TearDown(*Repository) error { return nil }
+
+func (sd *schemaDefaults) Get(key Key) (*KeyValue, bool) {
+	sd.mx.RLock()
+	defer sd.mx.RUnlock()
+	if v, ok := sd.registry[key.String()]; ok {
+		return &KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
+
+// registerSchemaDefaults walks the schema and registers the default values.
+// Wildcard keys are not registered: their defaults are filled in composite
+// values (see fillWildcardDefaults). Returns an error if a wildcard default
+// fails its schema mapping.
+func (repo *Repository) registerSchemaDefaults(key Key, schema Schema) error {
+	switch s := schema.(type) {
+	case *Description:
+		return repo.registerSchemaDefaults(key, s.Subject)
+	case *Defaulted:
+		repo.mx.Lock()
+		if repo.defaults == nil {
+			repo.defaults = &schemaDefaults{registry: make(map[string]Value), wildcards: NewMapperNode()}
+		}
+		sd := repo.defaults
+		repo.mx.Unlock()
+		for _, k := range key {
+			if k == WildcardFragment || k == "**" {
+				// Wildcard defaults are only mapped on a lookup: a
+				// malformed one is reported upfront
+				if _, err := s.Map(&KeyValue{Key: key, Value: s.Value}); err != nil {
+					return fmt.Errorf("Invalid default value for key %q: %s", key, err)
+				}
+				sd.mx.Lock()
+				sd.wildcards.Insert(key, s)
+				sd.mx.Unlock()
+				return nil
+			}
+		}
+		sd.mx.Lock()
+		sd.registry[key.String()] = s.Value
+		sd.mx.Unlock()
+		if err := repo.RegisterKey(key, sd); err != nil {
+			return err
+		}
+		return repo.registerSchemaDefaults(key, s.Subject)
+	case map[string]Schema:
+		for subKey, subSchema := range s {
+			subPath := key
+			if subKey != "__self__" {
+				subPath = key.Join(NewKey(subKey))
+			}
+			if err := repo.registerSchemaDefaults(subPath, subSchema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fillWildcardDefaults adds the wildcard schema default values missing in
+// the composite value of the key, e.g. the `workers.*.concurrency` default is
+// added to the value of every `workers` entry. Exact default keys are
+// registered upfront (see registerSchemaDefaults). Leaf lookups are served by
+// wildcardDefault.
+func (repo *Repository) fillWildcardDefaults(key Key, res map[string]Value) {
+	repo.mx.Lock()
+	sd := repo.defaults
+	repo.mx.Unlock()
+	if sd == nil || len(key) == 0 {
+		return
+	}
+	sd.mx.RLock()
+	defer sd.mx.RUnlock()
+	ptr := sd.wildcards.Find(key)
+	if ptr == nil {
+		return
+	}
+	for k, ch := range ptr.Children {
+		if _, ok := res[k]; ok || k == WildcardFragment || k == "**" {
+			continue
+		}
+		d, ok := ch.Mpr.(*Defaulted)
+		if !ok {
+			continue
+		}
+		mkv, err := repo.doMap(&KeyValue{Key: key.Join(Key{k}), Value: d.Value}, nil)
+		if err != nil {
+			panic(err)
+		}
+		res[k] = mkv.Value
+	}
+}
+
+// wildcardDefault returns the wildcard schema default value of a leaf key
+// missing in its section, e.g. the `workers.*.concurrency` default for
+// `workers.fast.concurrency` if `workers.fast` is served. It is the leaf
+// lookup counterpart of fillWildcardDefaults. Returns false if the section is
+// not served or no wildcard default matches the key.
+func (repo *Repository) wildcardDefault(key Key) (Value, bool) {
+	d, ok := repo.wildcardDefaulted(key)
+	if !ok {
+		return nil, false
+	}
+	mkv, err := repo.doMap(&KeyValue{Key: key, Value: d.Value}, nil)
+	if err != nil {
+		panic(err)
+	}
+	return mkv.Value, true
+}
+
+// wildcardDefaulted returns the wildcard schema definition carrying the
+// default value of the key (see wildcardDefault).
+func (repo *Repository) wildcardDefaulted(key Key) (*Defaulted, bool) {
+	repo.mx.Lock()
+	sd := repo.defaults
+	repo.mx.Unlock()
+	if sd == nil || len(key) < 2 {
+		return nil, false
+	}
+	sd.mx.RLock()
+	ptr := sd.wildcards.Find(key)
+	sd.mx.RUnlock()
+	if ptr == nil {
+		return nil, false
+	}
+	d, ok := ptr.Mpr.(*Defaulted)
+	if !ok {
+		return nil, false
+	}
+	section := repo.root.find(key[:len(key)-1])
+	if section == nil {
+		return nil, false
+	}
+	if _, children := section.view(); len(children) == 0 {
+		return nil, false
+	}
+	return d, true
+}
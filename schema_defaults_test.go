@@ -0,0 +1,76 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSchemaDefaults(t *testing.T) {
+	repo := NewRepository()
+	if err := repo.DefineSchema(map[string]Schema{
+		"server": map[string]Schema{
+			"host":  DefaultValue(ToStr, "localhost"),
+			"port":  Describe(DefaultValue(ToInt, "8080"), "HTTP listen port"),
+			"debug": DefaultValue(nil, false),
+			"*": map[string]Schema{
+				"enabled": DefaultValue(ToBool, true),
+			},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to define schema: %s", err)
+	}
+	if _, err := NewMapProvider(repo, DefaultWeight, "test", map[string]Value{
+		"server.host": "example.com",
+	}); err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	tests := []struct {
+		key  string
+		want Value
+	}{
+		{"server.host", "example.com"},
+		{"server.port", 8080},
+		{"server.debug", false},
+	}
+	for _, testCase := range tests {
+		if got, ok := repo.Get(NewKey(testCase.key)); !ok || got != testCase.want {
+			t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", testCase.key, got, testCase.want)
+		}
+	}
+	if _, ok := repo.Get(NewKey("server.*.enabled")); ok {
+		t.Fatalf("Wildcard defaults are not expected to be served")
+	}
+	if d, ok := repo.Description(NewKey("server.port")); !ok || d.Text != "HTTP listen port" {
+		t.Fatalf("Unexpected description: got: %#v", d)
+	}
+
+	var buf bytes.Buffer
+	if err := GenerateDocs(&buf, map[string]Schema{
+		"port": DefaultValue(ToInt, 8080),
+	}, nil, nil); err != nil {
+		t.Fatalf("Failed to generate docs: %s", err)
+	}
+	if !strings.Contains(buf.String(), "| `port` | integer | `8080` |") {
+		t.Fatalf("Unexpected docs: %s", buf.String())
+	}
+}
+
+func TestSchemaDefaultsInvalidWildcard(t *testing.T) {
+	repo := NewRepository()
+	err := repo.DefineSchema(map[string]Schema{
+		"workers": map[string]Schema{
+			"*": map[string]Schema{
+				"x": DefaultValue(ToInt, "abc"),
+			},
+		},
+	})
+	want := `Invalid default value for key "workers.*.x"`
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("Unexpected schema definition error: got: %v, want it to contain: %q", err, want)
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MergeSchemas composes the schemas contributed by independent modules into
+// a single schema, e.g.:
+//
+//	schema, err := config.MergeSchemas(
+//		billing.Schema, // {"billing": {"port": ToInt}}
+//		storage.Schema, // {"storage.db": {"host": ToStr}}
+//	)
+//
+// Schemas are merged key by key: composite keys like `storage.db` and nested
+// maps are equivalent. A key might be defined by one schema only: returns an
+// error listing the keys defined by more than one schema. A key definition
+// and the definitions of its child keys coming from different schemas are
+// merged under `__self__`. nil definitions are ignored. The input schemas are
+// not modified.
+func MergeSchemas(schemas ...Schema) (Schema, error) {
+	res := make(map[string]Schema)
+	conflicts := make(map[string]bool)
+	for _, schema := range schemas {
+		mergeSchema(res, NewKey(""), schema, conflicts)
+	}
+	if len(conflicts) > 0 {
+		keys := make([]string, 0, len(conflicts))
+		for k := range conflicts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return nil, fmt.Errorf("Failed to merge schemas: keys are defined more than once: %s", strings.Join(keys, ", "))
+	}
+	return compactSchema(res), nil
+}
+
+// mergeSchema merges the schema of the key into the node holding the key
+// definitions. Conflicting keys are collected in conflicts.
+func mergeSchema(node map[string]Schema, key Key, schema Schema, conflicts map[string]bool) {
+	smap, ok := schema.(map[string]Schema)
+	if !ok {
+		if schema == nil {
+			return
+		}
+		if self, ok := node["__self__"]; ok && self != nil {
+			conflicts[key.String()] = true
+			return
+		}
+		node["__self__"] = schema
+		return
+	}
+	for subKey, subSchema := range smap {
+		if subKey == "__self__" {
+			mergeSchema(node, key, subSchema, conflicts)
+			continue
+		}
+		ptr, subPath := node, NewKey(subKey)
+		for _, k := range subPath {
+			k = QuoteFragment(k)
+			child, ok := ptr[k].(map[string]Schema)
+			if !ok {
+				child = make(map[string]Schema)
+				ptr[k] = child
+			}
+			ptr = child
+		}
+		mergeSchema(ptr, key.Join(subPath), subSchema, conflicts)
+	}
+}
+
+// compactSchema replaces the nodes holding nothing but a key definition with
+// the definition itself.
+func compactSchema(node map[string]Schema) Schema {
+	for k, v := range node {
+		if child, ok := v.(map[string]Schema); ok {
+			node[k] = compactSchema(child)
+		}
+	}
+	if self, ok := node["__self__"]; ok && len(node) == 1 {
+		return self
+	}
+	return node
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeSchemas(t *testing.T) {
+	fooMpr := NewTestMapper(func(kv *KeyValue) (*KeyValue, error) { return kv, nil })
+	portDefault := DefaultValue(ToInt, 8080)
+
+	tests := []struct {
+		name    string
+		schemas []Schema
+		want    Schema
+		wantErr string
+	}{
+		{
+			name:    "no schemas",
+			schemas: nil,
+			want:    map[string]Schema{},
+		},
+		{
+			name: "disjoint sections",
+			schemas: []Schema{
+				map[string]Schema{"billing": map[string]Schema{"port": portDefault}},
+				map[string]Schema{"storage.db": map[string]Schema{"host": ToStr}},
+				nil,
+			},
+			want: map[string]Schema{
+				"billing": map[string]Schema{"port": portDefault},
+				"storage": map[string]Schema{"db": map[string]Schema{"host": ToStr}},
+			},
+		},
+		{
+			name: "shared parent key",
+			schemas: []Schema{
+				map[string]Schema{"server": map[string]Schema{"port": ToInt, "__self__": nil}},
+				map[string]Schema{"server.host": ToStr},
+				map[string]Schema{"server": fooMpr},
+			},
+			want: map[string]Schema{
+				"server": map[string]Schema{
+					"__self__": fooMpr,
+					"port":     ToInt,
+					"host":     ToStr,
+				},
+			},
+		},
+		{
+			name: "quoted and wildcard keys",
+			schemas: []Schema{
+				map[string]Schema{`labels."app.kubernetes.io/name"`: ToStr},
+				map[string]Schema{"workers": map[string]Schema{"*": map[string]Schema{"queue": ToStr}}},
+				map[string]Schema{"workers.*.size": ToInt},
+			},
+			want: map[string]Schema{
+				"labels": map[string]Schema{`"app.kubernetes.io/name"`: ToStr},
+				"workers": map[string]Schema{
+					"*": map[string]Schema{"queue": ToStr, "size": ToInt},
+				},
+			},
+		},
+		{
+			name: "conflicting keys",
+			schemas: []Schema{
+				map[string]Schema{"server": map[string]Schema{"port": ToInt, "host": ToStr}},
+				map[string]Schema{"server.port": ToStr, "server": map[string]Schema{"__self__": fooMpr}},
+				map[string]Schema{"server": map[string]Schema{"host": ToStr, "__self__": fooMpr}},
+			},
+			wantErr: "Failed to merge schemas: keys are defined more than once: server, server.host, server.port",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			schema, err := MergeSchemas(testCase.schemas...)
+			if len(testCase.wantErr) > 0 {
+				if err == nil || err.Error() != testCase.wantErr {
+					t.Fatalf("Unexpected error: got: %v, want: %s", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(schema, testCase.want) {
+				t.Fatalf("Unexpected schema: got: %#v, want: %#v", schema, testCase.want)
+			}
+		})
+	}
+}
+
+func TestMergeSchemasDefine(t *testing.T) {
+	schema, err := MergeSchemas(
+		map[string]Schema{"billing": map[string]Schema{"port": DefaultValue(ToInt, 8080)}},
+		NewSchema().Key("billing.host", ToStr, DefaultsTo("localhost")).MustBuild(),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	repo := NewRepository()
+	NewMapProvider(repo, 0, "yaml", map[string]Value{"billing.port": "9090"})
+	if err := repo.DefineSchema(schema); err != nil {
+		t.Fatalf("Failed to define the schema: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := map[string]Value{"port": 9090, "host": "localhost"}
+	if got, ok := repo.Get(NewKey("billing")); !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", got, want)
+	}
+}
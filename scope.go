@@ -0,0 +1,58 @@
+package config
+
+import "strings"
+
+// ScopedRepo is a read-only view of a repository subtree. Keys are resolved
+// relative to the view prefix: with prefix `server`, a lookup of `port`
+// resolves `server.port`. Views allow subsystems to receive only their slice
+// of the configuration without knowing the global key layout.
+type ScopedRepo struct {
+	repo   *Repository
+	prefix Key
+	// caller is the label of the view reads (see Repository.Caller)
+	caller string
+}
+
+// Scope returns a view of the repository subtree under the prefix.
+func (repo *Repository) Scope(prefix string) *ScopedRepo {
+	return &ScopedRepo{
+		repo:   repo,
+		prefix: repo.NewKey(strings.TrimSuffix(prefix, repo.separator())),
+	}
+}
+
+// Scope returns a nested view, e.g.: `repo.Scope("server").Scope("tls")` is
+// equivalent to `repo.Scope("server.tls")`.
+func (sr *ScopedRepo) Scope(prefix string) *ScopedRepo {
+	return &ScopedRepo{
+		repo:   sr.repo,
+		prefix: sr.prefix.Join(sr.NewKey(prefix)),
+		caller: sr.caller,
+	}
+}
+
+// Prefix returns the absolute key of the view root.
+func (sr *ScopedRepo) Prefix() Key { return sr.prefix }
+
+// Repository returns the underlying repository.
+func (sr *ScopedRepo) Repository() *Repository { return sr.repo }
+
+// NewKey parses the key relative to the view prefix. See
+// `Repository.NewKey`.
+func (sr *ScopedRepo) NewKey(str string) Key {
+	return sr.repo.NewKey(strings.TrimSuffix(str, sr.repo.separator()))
+}
+
+// Get looks up the key relative to the view prefix. An empty key resolves the
+// view root.
+// This method is thread safe.
+func (sr *ScopedRepo) Get(key Key) (Value, bool) {
+	return sr.repo.getFor(sr.caller, sr.prefix.Join(key))
+}
+
+// IsSet reports whether the key is set relative to the scope prefix (see
+// Repository.IsSet).
+func (sr *ScopedRepo) IsSet(key Key) bool {
+	_, ok := sr.Get(key)
+	return ok
+}
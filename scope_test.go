@@ -0,0 +1,59 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	repo := NewRepository()
+	if _, err := NewMapProvider(repo, 10, "map", map[string]Value{
+		"server.port":     8080,
+		"server.tls.cert": "cert.pem",
+		"db.host":         "localhost",
+	}); err != nil {
+		t.Fatalf("Failed to initialize a new map provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	server := repo.Scope("server")
+	tests := []struct {
+		name   string
+		scope  *ScopedRepo
+		key    string
+		want   Value
+		wantOk bool
+	}{
+		{"A leaf key", server, "port", 8080, true},
+		{"A nested key", server, "tls.cert", "cert.pem", true},
+		{"A nested scope", server.Scope("tls."), "cert", "cert.pem", true},
+		{"A key out of scope", server, "db.host", nil, false},
+		{
+			"The scope root",
+			server,
+			"",
+			map[string]Value{
+				"port": 8080,
+				"tls":  map[string]Value{"cert": "cert.pem"},
+			},
+			true,
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, ok := testCase.scope.Get(NewKey(testCase.key))
+			if ok != testCase.wantOk {
+				t.Fatalf("Unexpected lookup result for key %q: got: %t, want: %t", testCase.key, ok, testCase.wantOk)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+
+	if got := MustInt(server, "port"); got != 8080 {
+		t.Fatalf("Unexpected value: got: %d, want: %d", got, 8080)
+	}
+}
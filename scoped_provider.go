@@ -0,0 +1,80 @@
+package config
+
+import (
+	"context"
+)
+
+// ScopedProvider namespaces all keys of the wrapped provider under a prefix.
+// It allows mounting the same config source at different points of the key
+// tree, e.g. a yaml file describing a database connection:
+//
+//	yp, _ := config.NewYamlProviderFromSource(repo, 10, nil, "db.yaml")
+//	repo.RegisterProvider(config.Scoped(yp, "database."))
+//
+// A key `host` defined in the file is served under the key `database.host`.
+type ScopedProvider struct {
+	provider Provider
+	prefix   Key
+}
+
+var _ ProviderWrapper = (*ScopedProvider)(nil)
+var _ ContextSetUpper = (*ScopedProvider)(nil)
+
+// Scoped returns a new ScopedProvider wrapping the provider. A trailing key
+// separator in the prefix is optional. The returned provider must be
+// registered in the repository explicitly.
+func Scoped(provider Provider, prefix string) *ScopedProvider {
+	return &ScopedProvider{
+		provider: provider,
+		prefix:   prefixKey(prefix),
+	}
+}
+
+// Name returns the wrapped provider name followed by the prefix, e.g.:
+// yaml@database
+func (sp *ScopedProvider) Name() string {
+	return sp.provider.Name() + "@" + sp.prefix.String()
+}
+
+// Depends returns the wrapped provider dependencies
+func (sp *ScopedProvider) Depends() []string { return sp.provider.Depends() }
+
+// Weight returns the wrapped provider weight
+func (sp *ScopedProvider) Weight() int { return sp.provider.Weight() }
+
+// Prefix returns the key prefix
+func (sp *ScopedProvider) Prefix() Key { return sp.prefix }
+
+// Unwrap returns the wrapped provider
+func (sp *ScopedProvider) Unwrap() []Provider { return []Provider{sp.provider} }
+
+// WrapKey prepends the prefix to the key
+func (sp *ScopedProvider) WrapKey(key Key) Key {
+	return sp.prefix.Join(key)
+}
+
+// SetUp sets up the wrapped provider using a background context.
+func (sp *ScopedProvider) SetUp(repo *Repository) error {
+	return sp.SetUpContext(context.Background(), repo)
+}
+
+// SetUpContext sets up the wrapped provider
+func (sp *ScopedProvider) SetUpContext(ctx context.Context, repo *Repository) error {
+	return repo.setUpProvider(ctx, sp.provider)
+}
+
+// TearDown tears down the wrapped provider
+func (sp *ScopedProvider) TearDown(repo *Repository) error {
+	return sp.provider.TearDown(repo)
+}
+
+// Get strips the prefix and looks up the key in the wrapped provider
+func (sp *ScopedProvider) Get(key Key) (*KeyValue, bool) {
+	if len(key) <= len(sp.prefix) || !key.HasPrefix(sp.prefix) {
+		return nil, false
+	}
+	if kv, ok := sp.provider.Get(key[len(sp.prefix):]); ok {
+		return &KeyValue{Key: key, Value: kv.Value}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScopedProvider(t *testing.T) {
+	repo := NewRepository()
+	registry := map[string]Value{
+		"host": "localhost",
+		"port": 5432,
+	}
+	primary, _ := NewMapProvider(repo, 10, "db", registry)
+	replica, _ := NewMapProvider(repo, 10, "replica", registry)
+	scoped := Scoped(primary, "database.primary.")
+	repo.RegisterProvider(scoped)
+	repo.RegisterProvider(Scoped(replica, "database.replica"))
+
+	if got, want := scoped.Name(), "db@database.primary"; got != want {
+		t.Fatalf("Unexpected name: got: %q, want: %q", got, want)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	tests := []struct {
+		key    string
+		want   Value
+		wantOk bool
+	}{
+		{"database.primary.host", "localhost", true},
+		{"database.replica.port", 5432, true},
+		{
+			"database",
+			map[string]Value{
+				"primary": map[string]Value{"host": "localhost", "port": 5432},
+				"replica": map[string]Value{"host": "localhost", "port": 5432},
+			},
+			true,
+		},
+		{"host", nil, false},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.key, func(t *testing.T) {
+			got, ok := repo.Get(NewKey(testCase.key))
+			if ok != testCase.wantOk {
+				t.Fatalf("Unexpected lookup result for key %q: got: %t, want: %t", testCase.key, ok, testCase.wantOk)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// section is a config section registered by a module (see RegisterSection).
+type section struct {
+	name   string
+	schema Schema
+	target interface{}
+}
+
+var (
+	sections   []*section
+	sectionsMx sync.Mutex
+)
+
+// Validator is implemented by section targets validating themselves once
+// decoded (see RegisterSection).
+type Validator interface {
+	Validate() error
+}
+
+// RegisterSection declares the config section of a module: the schema is
+// defined under the section name in every repository being set up, the
+// section value is decoded into the target once the providers are set up. It
+// lets modules of a large application own their config without main()
+// knowing about it. Typically called from the module init():
+//
+//	type Config struct {
+//		Host    string
+//		Port    int
+//		Timeout time.Duration `config:"request_timeout"`
+//	}
+//
+//	var cfg Config
+//
+//	func init() {
+//		config.RegisterSection("billing", map[string]config.Schema{
+//			"port": config.DefaultValue(config.ToInt, 8080),
+//		}, &cfg)
+//	}
+//
+// The target must be a non-nil pointer. Struct fields are matched against the
+// section keys by the `config` tag or by name, case-insensitively with
+// underscores ignored: `request_timeout` matches field RequestTimeout. Leaf
+// values are converted with the converter registered for the field type (see
+// ConverterFor). If the target implements Validator, it is validated after
+// decoding: a validation or a decoding error fails the repository set up.
+// The target is only decoded at set up: use Bind in order to follow reloads.
+// It panics if the section name is already registered or if the target is
+// not a pointer.
+// This function is thread safe.
+func RegisterSection(name string, schema Schema, target interface{}) {
+	if rv := reflect.ValueOf(target); rv.Kind() != reflect.Ptr || rv.IsNil() {
+		panic(fmt.Sprintf("Config section %q target must be a non-nil pointer, got: %T", name, target))
+	}
+	sectionsMx.Lock()
+	defer sectionsMx.Unlock()
+	for _, s := range sections {
+		if s.name == name {
+			panic(fmt.Sprintf("Config section %q is already registered", name))
+		}
+	}
+	sections = append(sections, &section{name: name, schema: schema, target: target})
+}
+
+func registeredSections() []*section {
+	sectionsMx.Lock()
+	defer sectionsMx.Unlock()
+	return append([]*section(nil), sections...)
+}
+
+// defineSections defines the schema of the registered sections not yet
+// known to the repository.
+func (repo *Repository) defineSections() error {
+	for _, s := range registeredSections() {
+		repo.mx.Lock()
+		defined := repo.sections[s.name]
+		if repo.sections == nil {
+			repo.sections = make(map[string]bool)
+		}
+		repo.sections[s.name] = true
+		repo.mx.Unlock()
+		if defined || s.schema == nil {
+			continue
+		}
+		if err := repo.DefineSchema(map[string]Schema{s.name: s.schema}); err != nil {
+			return fmt.Errorf("Failed to define config section %q schema: %s", s.name, err)
+		}
+	}
+	return nil
+}
+
+// decodeSections decodes the registered section values into the targets.
+func (repo *Repository) decodeSections() error {
+	for _, s := range registeredSections() {
+		if v, ok := repo.Get(repo.NewKey(s.name)); ok {
+			if err := decodeValue(reflect.ValueOf(s.target).Elem(), v); err != nil {
+				return fmt.Errorf("Failed to decode config section %q: %s", s.name, err)
+			}
+		}
+		if validator, ok := s.target.(Validator); ok {
+			if err := validator.Validate(); err != nil {
+				return fmt.Errorf("Invalid config section %q: %s", s.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Decode decodes the key value into the target, which must be a non-nil
+// pointer, e.g. a sequence of servers:
+//
+//	var servers []ServerConfig
+//	ok, err := config.Decode(repo, "servers", &servers)
+//
+// The value is decoded following the section rules (see RegisterSection):
+// structs, pointers, slices and string-keyed maps are decoded recursively.
+// The boolean flag is false if the key is not served by any provider. If the
+// getter is nil, the default repository is used (see Default).
+func Decode(repo Getter, key string, target interface{}) (bool, error) {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return false, fmt.Errorf("Failed to decode key %q: the target must be a non-nil pointer, got: %T", key, target)
+	}
+	v, ok, err := Lookup(repo, key)
+	if !ok || err != nil {
+		return ok, err
+	}
+	if err := decodeValue(rv.Elem(), v); err != nil {
+		return true, fmt.Errorf("Failed to decode key %q: %s", key, err)
+	}
+	return true, nil
+}
+
+// decodeValue stores the config value into dst. Structs, pointers, slices
+// and string-keyed maps are decoded recursively.
+func decodeValue(dst reflect.Value, v Value) error {
+	if v == nil {
+		return nil
+	}
+	sv := reflect.ValueOf(v)
+	if sv.Type().AssignableTo(dst.Type()) {
+		dst.Set(sv)
+		return nil
+	}
+	if conv, ok := ConverterFor(dst.Type()); ok {
+		if kv, ok := conv.Convert(&KeyValue{Value: v}); ok && reflect.TypeOf(kv.Value) == dst.Type() {
+			dst.Set(reflect.ValueOf(kv.Value))
+			return nil
+		}
+	}
+	switch dst.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(dst.Type().Elem())
+		if err := decodeValue(elem.Elem(), v); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	case reflect.Struct:
+		return decodeStruct(dst, v)
+	case reflect.Slice:
+		elems, ok := toValues(v)
+		if !ok {
+			break
+		}
+		res := reflect.MakeSlice(dst.Type(), len(elems), len(elems))
+		for i, e := range elems {
+			if err := decodeValue(res.Index(i), e); err != nil {
+				return fmt.Errorf("[%d]: %s", i, err)
+			}
+		}
+		dst.Set(res)
+		return nil
+	case reflect.Map:
+		vmap, ok := toValueMap(v)
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			break
+		}
+		res := reflect.MakeMapWithSize(dst.Type(), len(vmap))
+		for k, e := range vmap {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := decodeValue(elem, e); err != nil {
+				return fmt.Errorf("%s: %s", k, err)
+			}
+			res.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), elem)
+		}
+		dst.Set(res)
+		return nil
+	default:
+		// Integers are range checked, e.g. 70000 does not fit uint16
+		if ic := intConverterFor(dst.Kind()); ic != nil {
+			mkv, err := ic.Map(&KeyValue{Value: v})
+			if err != nil {
+				return err
+			}
+			dst.Set(reflect.ValueOf(mkv.Value).Convert(dst.Type()))
+			return nil
+		}
+		if dst.Kind() == reflect.Float32 || dst.Kind() == reflect.Float64 {
+			mkv, err := (&FloatConverter{Bits: dst.Type().Bits()}).Map(&KeyValue{Value: v})
+			if err != nil {
+				return err
+			}
+			dst.Set(reflect.ValueOf(mkv.Value).Convert(dst.Type()))
+			return nil
+		}
+		// Numeric types are converted to each other, e.g. int64 to float64
+		if isNumericKind(sv.Kind()) && isNumericKind(dst.Kind()) ||
+			sv.Kind() == reflect.String && dst.Kind() == reflect.String {
+			dst.Set(sv.Convert(dst.Type()))
+			return nil
+		}
+	}
+	return fmt.Errorf("Failed to decode %T value into %s", v, dst.Type())
+}
+
+func decodeStruct(dst reflect.Value, v Value) error {
+	vmap, ok := toValueMap(v)
+	if !ok {
+		return fmt.Errorf("Failed to decode %T value into %s", v, dst.Type())
+	}
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := field.Tag.Get("config")
+		if tag == "-" {
+			continue
+		}
+		for k, fv := range vmap {
+			if !matchField(k, tag, field.Name) {
+				continue
+			}
+			if err := decodeValue(dst.Field(i), fv); err != nil {
+				return fmt.Errorf("%s: %s", k, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// matchField returns true if the key matches the field tag or, if the tag
+// is not set, the field name: case-insensitively with underscores ignored.
+func matchField(key, tag, name string) bool {
+	if len(tag) > 0 {
+		return key == tag
+	}
+	return strings.EqualFold(strings.ReplaceAll(key, "_", ""), name)
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
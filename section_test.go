@@ -0,0 +1,279 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testSectionEndpoint struct {
+	URL     string
+	Weight  int
+	Retries *int
+}
+
+type testSection struct {
+	Host      string
+	Port      int
+	Timeout   time.Duration `config:"request_timeout"`
+	Ratio     float64
+	Tags      []string
+	Labels    map[string]string
+	Endpoints []testSectionEndpoint
+	Limit     RateLimit
+	Ignored   string `config:"-"`
+	private   string
+}
+
+func (ts *testSection) Validate() error {
+	if ts.Port == 0 {
+		return errors.New("port is not set")
+	}
+	return nil
+}
+
+func withSections(t *testing.T) {
+	sectionsMx.Lock()
+	prev := sections
+	sections = nil
+	sectionsMx.Unlock()
+	t.Cleanup(func() {
+		sectionsMx.Lock()
+		sections = prev
+		sectionsMx.Unlock()
+	})
+}
+
+func TestRegisterSection(t *testing.T) {
+	withSections(t)
+	var billing testSection
+	RegisterSection("billing", map[string]Schema{
+		"port": DefaultValue(ToInt, 8080),
+	}, &billing)
+
+	repo := NewRepository()
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		"billing.host":            "billing.local",
+		"billing.request_timeout": "3s",
+		"billing.ratio":           0.5,
+		"billing.tags":            []Value{"a", "b"},
+		"billing.labels.team":     "payments",
+		"billing.endpoints": []Value{
+			map[string]Value{"url": "http://a", "weight": "2", "retries": 3},
+		},
+		"billing.limit":   "10/s",
+		"billing.ignored": "value",
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	retries := 3
+	want := testSection{
+		Host:      "billing.local",
+		Port:      8080,
+		Timeout:   3 * time.Second,
+		Ratio:     0.5,
+		Tags:      []string{"a", "b"},
+		Labels:    map[string]string{"team": "payments"},
+		Endpoints: []testSectionEndpoint{{URL: "http://a", Weight: 2, Retries: &retries}},
+		Limit:     RateLimit{Events: 10, Per: time.Second},
+	}
+	if !reflect.DeepEqual(billing, want) {
+		t.Fatalf("Unexpected section: got: %#v, want: %#v", billing, want)
+	}
+}
+
+func TestRegisterSectionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]Value
+		wantErr string
+	}{
+		{"validation", map[string]Value{"billing.host": "billing.local"}, "port is not set"},
+		{"missing section is validated", map[string]Value{}, "port is not set"},
+		{"malformed value", map[string]Value{"billing.port": 1, "billing.request_timeout": "soon"}, "request_timeout"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			withSections(t)
+			var billing testSection
+			RegisterSection("billing", nil, &billing)
+			repo := NewRepository()
+			NewDefaultProviderWithDefaults(repo, 0, testCase.values)
+			err := repo.SetUp()
+			if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+				t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterSectionPanics(t *testing.T) {
+	withSections(t)
+	var billing testSection
+	RegisterSection("billing", nil, &billing)
+
+	for name, register := range map[string]func(){
+		"duplicate":   func() { RegisterSection("billing", nil, &testSection{}) },
+		"non-pointer": func() { RegisterSection("other", nil, testSection{}) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("Unexpected success: want a panic")
+				}
+			}()
+			register()
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	repo := NewRepository()
+	NewMapProvider(repo, 0, "servers", map[string]Value{
+		"servers": []interface{}{
+			map[string]interface{}{"url": "a.local", "weight": "2"},
+			map[string]interface{}{"url": "b.local"},
+		},
+		"port": 8080,
+	})
+	if err := repo.DefineSchema(map[string]Schema{
+		"servers.*.weight": ToInt,
+	}); err != nil {
+		t.Fatalf("Failed to define the schema: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	var servers []testSectionEndpoint
+	if ok, err := Decode(repo, "servers", &servers); !ok || err != nil {
+		t.Fatalf("Unexpected decoding result: got: %t, %v, want: true, nil", ok, err)
+	}
+	want := []testSectionEndpoint{{URL: "a.local", Weight: 2}, {URL: "b.local"}}
+	if !reflect.DeepEqual(servers, want) {
+		t.Fatalf("Unexpected decoded value: got: %#v, want: %#v", servers, want)
+	}
+
+	if ok, err := Decode(repo, "missing", &servers); ok || err != nil {
+		t.Fatalf("Unexpected decoding result: got: %t, %v, want: false, nil", ok, err)
+	}
+	if _, err := Decode(repo, "port", &servers); err == nil {
+		t.Fatalf("Expected a decoding error")
+	}
+	if _, err := Decode(repo, "servers", servers); err == nil {
+		t.Fatalf("Expected an error for a non-pointer target")
+	}
+}
+
+func TestDecodeDuration(t *testing.T) {
+	type pool struct {
+		Timeout time.Duration
+	}
+	tests := []struct {
+		name  string
+		value Value
+		want  time.Duration
+	}{
+		{"integer", 30, 30 * time.Second},
+		{"numeric string", "30", 30 * time.Second},
+		{"duration string", "1m", time.Minute},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			NewMapProvider(repo, 0, "pool", map[string]Value{"pool.timeout": testCase.value})
+			if err := repo.SetUp(); err != nil {
+				t.Fatalf("Failed to set up the repository: %s", err)
+			}
+			var got pool
+			if ok, err := Decode(repo, "pool", &got); !ok || err != nil {
+				t.Fatalf("Unexpected decoding result: got: %t, %v, want: true, nil", ok, err)
+			}
+			if got.Timeout != testCase.want {
+				t.Fatalf("Unexpected timeout: got: %s, want: %s", got.Timeout, testCase.want)
+			}
+			// Decode agrees with the ToDuration schema
+			if kv, ok := ToDuration.Convert(&KeyValue{Value: testCase.value}); !ok || kv.Value != got.Timeout {
+				t.Fatalf("Unexpected ToDuration result: got: %#v, want: %s", kv, got.Timeout)
+			}
+		})
+	}
+}
+
+func TestDecodeMap(t *testing.T) {
+	type workerConfig struct {
+		Concurrency int
+		Queue       string
+	}
+	repo := NewRepository()
+	NewMapProvider(repo, 0, "workers", map[string]Value{
+		"workers.fast.concurrency": "8",
+		"workers.fast.queue":       "fast-jobs",
+		"workers.slow.queue":       "slow-jobs",
+	})
+	if err := repo.DefineSchema(map[string]Schema{
+		"workers.*.concurrency": DefaultValue(ToInt, 1),
+		"workers.*.queue":       ToStr,
+	}); err != nil {
+		t.Fatalf("Failed to define the schema: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	var workers map[string]workerConfig
+	if ok, err := Decode(repo, "workers", &workers); !ok || err != nil {
+		t.Fatalf("Unexpected decoding result: got: %t, %v, want: true, nil", ok, err)
+	}
+	want := map[string]workerConfig{
+		"fast": {Concurrency: 8, Queue: "fast-jobs"},
+		"slow": {Concurrency: 1, Queue: "slow-jobs"},
+	}
+	if !reflect.DeepEqual(workers, want) {
+		t.Fatalf("Unexpected decoded value: got: %#v, want: %#v", workers, want)
+	}
+	tree, _ := repo.GetTree(NewKey("workers.slow"))
+	if wantTree := map[string]Value{"concurrency": 1, "queue": "slow-jobs"}; !reflect.DeepEqual(tree, wantTree) {
+		t.Fatalf("Unexpected tree: got: %#v, want: %#v", tree, wantTree)
+	}
+	// Wildcard defaults are served for both composite and leaf lookups
+	tests := []struct {
+		key    string
+		want   Value
+		wantOk bool
+	}{
+		{"workers.slow", map[string]Value{"concurrency": 1, "queue": "slow-jobs"}, true},
+		{"workers.slow.concurrency", 1, true},
+		{"workers.fast.concurrency", 8, true},
+		{"workers.none.concurrency", nil, false},
+		{"workers.slow.queue.concurrency", nil, false},
+	}
+	for _, testCase := range tests {
+		got, ok := repo.Get(NewKey(testCase.key))
+		if ok != testCase.wantOk || !reflect.DeepEqual(got, testCase.want) {
+			t.Fatalf("Unexpected value for key %q: got: %#v, %t, want: %#v, %t", testCase.key, got, ok, testCase.want, testCase.wantOk)
+		}
+		if set := repo.IsSet(NewKey(testCase.key)); set != testCase.wantOk {
+			t.Fatalf("Unexpected IsSet result for key %q: got: %t, want: %t", testCase.key, set, testCase.wantOk)
+		}
+	}
+
+	repo = NewRepository()
+	NewMapProvider(repo, 0, "workers", map[string]Value{
+		"workers.fast.concurrency": "many",
+	})
+	repo.DefineSchema(map[string]Schema{
+		"workers.*.concurrency": ToInt,
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if _, err := Decode(repo, "workers", &workers); err == nil || !strings.Contains(err.Error(), "workers.fast.concurrency") {
+		t.Fatalf("Unexpected error: got: %v, want it to mention the invalid entry", err)
+	}
+}
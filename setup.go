@@ -0,0 +1,112 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// setUpParallel sets up the providers using up to `limit` goroutines. A
+// provider is started once all of its dependencies are set up. Providers
+// depending (transitively) on a failed provider are skipped. Returns the
+// original error if exactly 1 provider failed or an aggregated error
+// otherwise.
+func (repo *Repository) setUpParallel(ctx context.Context, providers []Provider, limit int) error {
+	byName := make(map[string]Provider, len(providers))
+	for _, prov := range providers {
+		byName[prov.Name()] = prov
+	}
+	// pending keeps the number of unsatisfied dependencies per provider
+	pending := make(map[string]int, len(providers))
+	dependents := make(map[string][]string)
+	for _, prov := range providers {
+		seen := make(map[string]bool)
+		for _, dep := range prov.Depends() {
+			if _, ok := byName[dep]; !ok || seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			pending[prov.Name()]++
+			dependents[dep] = append(dependents[dep], prov.Name())
+		}
+	}
+
+	type result struct {
+		name string
+		err  error
+	}
+	results := make(chan result)
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	start := func(prov Provider) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			err := repo.setUpOne(ctx, prov)
+			<-sem
+			results <- result{name: prov.Name(), err: err}
+		}()
+	}
+
+	running := 0
+	// Providers are started in the topological order for the sake of
+	// determinism
+	for _, prov := range providers {
+		if pending[prov.Name()] == 0 {
+			start(prov)
+			running++
+		}
+	}
+
+	errs := make(map[string]error)
+	skipped := make(map[string]bool)
+	var skip func(name string)
+	skip = func(name string) {
+		for _, dep := range dependents[name] {
+			if !skipped[dep] {
+				skipped[dep] = true
+				repo.Logger().Warnf("Skipped config provider %q set up: dependency %q failed", dep, name)
+				skip(dep)
+			}
+		}
+	}
+	for running > 0 {
+		res := <-results
+		running--
+		if res.err != nil {
+			errs[res.name] = res.err
+			skip(res.name)
+			continue
+		}
+		for _, dep := range dependents[res.name] {
+			pending[dep]--
+			if pending[dep] == 0 && !skipped[dep] {
+				start(byName[dep])
+				running++
+			}
+		}
+	}
+	wg.Wait()
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		for _, err := range errs {
+			return err
+		}
+	}
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	descr := make([]string, 0, len(names))
+	for _, name := range names {
+		descr = append(descr, fmt.Sprintf("%s: %s", name, errs[name]))
+	}
+	return fmt.Errorf("Failed to set up config providers: %s", strings.Join(descr, "; "))
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// setUpTracker records the provider set up order and the concurrency level
+type setUpTracker struct {
+	order   []string
+	running int
+	maxRun  int
+	mx      sync.Mutex
+}
+
+type trackedTestProv struct {
+	*TestProv
+	name    string
+	deps    []string
+	err     error
+	tracker *setUpTracker
+}
+
+func (ttp *trackedTestProv) Name() string      { return ttp.name }
+func (ttp *trackedTestProv) Depends() []string { return ttp.deps }
+func (ttp *trackedTestProv) SetUp(*Repository) error {
+	ttp.tracker.mx.Lock()
+	ttp.tracker.running++
+	if ttp.tracker.running > ttp.tracker.maxRun {
+		ttp.tracker.maxRun = ttp.tracker.running
+	}
+	ttp.tracker.mx.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	ttp.tracker.mx.Lock()
+	ttp.tracker.running--
+	ttp.tracker.order = append(ttp.tracker.order, ttp.name)
+	ttp.tracker.mx.Unlock()
+	return ttp.err
+}
+
+func TestSetUpParallel(t *testing.T) {
+	tracker := &setUpTracker{}
+	repo := NewRepositoryWithOptions(&RepositoryOptions{SetUpConcurrency: 2})
+	for _, name := range []string{"a", "b", "c", "d"} {
+		repo.RegisterProvider(&trackedTestProv{TestProv: NewTestProv(1, 10), name: name, tracker: tracker})
+	}
+	repo.RegisterProvider(&trackedTestProv{TestProv: NewTestProv(1, 10), name: "e", deps: []string{"a", "b", "c", "d"}, tracker: tracker})
+
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if tracker.maxRun != 2 {
+		t.Fatalf("Unexpected max concurrency: got: %d, want: %d", tracker.maxRun, 2)
+	}
+	if len(tracker.order) != 5 || tracker.order[4] != "e" {
+		t.Fatalf("Unexpected set up order: %v", tracker.order)
+	}
+}
+
+func TestSetUpParallelErrors(t *testing.T) {
+	tracker := &setUpTracker{}
+	repo := NewRepositoryWithOptions(&RepositoryOptions{SetUpConcurrency: 4})
+	repo.RegisterProvider(&trackedTestProv{TestProv: NewTestProv(1, 10), name: "ok", tracker: tracker})
+	repo.RegisterProvider(&trackedTestProv{TestProv: NewTestProv(1, 10), name: "consul", err: fmt.Errorf("timeout"), tracker: tracker})
+	repo.RegisterProvider(&trackedTestProv{TestProv: NewTestProv(1, 10), name: "vault", err: fmt.Errorf("forbidden"), tracker: tracker})
+	repo.RegisterProvider(&trackedTestProv{TestProv: NewTestProv(1, 10), name: "dependent", deps: []string{"consul"}, tracker: tracker})
+
+	err := repo.SetUp()
+	want := fmt.Errorf("Failed to set up config providers: consul: timeout; vault: forbidden")
+	if !reflect.DeepEqual(err, want) {
+		t.Fatalf("Unexpected error: got: %v, want: %v", err, want)
+	}
+	for _, name := range tracker.order {
+		if name == "dependent" {
+			t.Fatalf("Expected a provider depending on a failed one to be skipped")
+		}
+	}
+	for _, st := range repo.ProviderStatus() {
+		if st.Name == "dependent" && st.State != ProviderPending {
+			t.Fatalf("Unexpected skipped provider status: %#v", st)
+		}
+	}
+}
+
+// registeringTestProv registers a number of keys on set up once the reader
+// is started
+type registeringTestProv struct {
+	*TestProv
+	name    string
+	keys    int
+	started chan struct{}
+}
+
+func (rtp *registeringTestProv) Name() string { return rtp.name }
+func (rtp *registeringTestProv) SetUp(repo *Repository) error {
+	<-rtp.started
+	for i := 0; i < rtp.keys; i++ {
+		if err := repo.RegisterKey(NewKey(fmt.Sprintf("%s.key%d", rtp.name, i)), rtp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readingTestProv looks up keys on set up until the last key is registered
+type readingTestProv struct {
+	*TestProv
+	keys    []string
+	last    string
+	started chan struct{}
+}
+
+func (rtp *readingTestProv) Name() string { return "reader" }
+func (rtp *readingTestProv) SetUp(repo *Repository) error {
+	close(rtp.started)
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, k := range rtp.keys {
+			repo.Get(NewKey(k))
+		}
+		if _, ok := repo.Get(NewKey(rtp.last)); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("Timed out waiting for key %q", rtp.last)
+}
+
+// Meant to be run with -race: providers set up in parallel register keys
+// while others look them up.
+func TestSetUpParallelRegisterKey(t *testing.T) {
+	repo := NewRepositoryWithOptions(&RepositoryOptions{SetUpConcurrency: 3})
+	started := make(chan struct{})
+	repo.RegisterProvider(&registeringTestProv{TestProv: NewTestProv(1, 10), name: "a", keys: 200, started: started})
+	repo.RegisterProvider(&registeringTestProv{TestProv: NewTestProv(2, 20), name: "b", keys: 200, started: started})
+	repo.RegisterProvider(&readingTestProv{
+		TestProv: NewTestProv(3, 10),
+		keys:     []string{"a", "b.key1", "b"},
+		last:     "b.key199",
+		started:  started,
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if v, ok := repo.Get(NewKey("b.key199")); !ok || v != 2 {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", v, 2)
+	}
+}
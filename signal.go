@@ -0,0 +1,109 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Redefined in tests
+var (
+	signalNotify = signal.Notify
+	signalStop   = signal.Stop
+)
+
+// SignalReloadOptions is a set of ReloadOnSignal settings.
+type SignalReloadOptions struct {
+	// Signals are the signals triggering a refresh. Defaults to SIGHUP.
+	Signals []os.Signal
+	// Veto is called on every received signal before the refresh. A non-nil
+	// error vetoes the refresh, e.g. while a critical operation is in
+	// flight: the refresh is skipped and the error is logged.
+	Veto func() error
+}
+
+// ReloadOnSignal refreshes all refreshable providers (see RefreshAll) when the
+// process receives SIGHUP, the conventional reload signal of Unix daemons.
+// Refresh errors are logged. Returns a function stopping the signal handling.
+// The signal handling is stopped on the repository TearDown as well.
+// Options might be nil.
+func (repo *Repository) ReloadOnSignal(options *SignalReloadOptions) func() {
+	if options == nil {
+		options = &SignalReloadOptions{}
+	}
+	signals := options.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signalNotify(ch, signals...)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-ch:
+				logger := repo.Logger()
+				if options.Veto != nil {
+					if err := options.Veto(); err != nil {
+						logger.Warnf("Skipped config reload on signal %s: %s", sig, err)
+						continue
+					}
+				}
+				logger.Infof("Reloading config on signal %s", sig)
+				if err := repo.RefreshAll(context.Background()); err != nil {
+					logger.Errorf("%s", err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			signalStop(ch)
+			close(done)
+			wg.Wait()
+		})
+	}
+	repo.addCloser(stop)
+	return stop
+}
+
+// RefreshAll refreshes all registered providers supporting refreshes (see
+// RefreshProvider). Providers not supporting refreshes are skipped. Returns
+// an error listing the failed providers.
+func (repo *Repository) RefreshAll(ctx context.Context) error {
+	repo.mx.Lock()
+	names := make([]string, 0, len(repo.providers))
+	for name := range repo.providers {
+		names = append(names, name)
+	}
+	repo.mx.Unlock()
+	sort.Strings(names)
+	descr := make([]string, 0)
+	for _, name := range names {
+		repo.mx.Lock()
+		prov := repo.providers[name]
+		repo.mx.Unlock()
+		refresh, ok := refreshFunc(prov)
+		if !ok {
+			continue
+		}
+		if err := refresh(ctx); err != nil {
+			descr = append(descr, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+	if len(descr) > 0 {
+		return fmt.Errorf("Failed to refresh config providers: %s", strings.Join(descr, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type signalTestProv struct {
+	reloadTestProv
+	name      string
+	err       error
+	refreshes chan struct{}
+}
+
+func (stp *signalTestProv) Name() string { return stp.name }
+
+func (stp *signalTestProv) SetUp(repo *Repository) error {
+	for k := range stp.registry {
+		if err := repo.RegisterKey(NewKey(k), stp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (stp *signalTestProv) Refresh(context.Context) error {
+	stp.refreshes <- struct{}{}
+	return stp.err
+}
+
+func TestRefreshAll(t *testing.T) {
+	repo := NewRepository()
+	ok := &signalTestProv{name: "ok", refreshes: make(chan struct{}, 1)}
+	failing := &signalTestProv{name: "failing", err: errors.New("boom"), refreshes: make(chan struct{}, 1)}
+	repo.RegisterProvider(ok)
+	repo.RegisterProvider(failing)
+	repo.RegisterProvider(NewTestProv(nil, 0))
+
+	err := repo.RefreshAll(context.Background())
+	want := "Failed to refresh config providers: failing: boom"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Unexpected error: got: %v, want: %q", err, want)
+	}
+	if len(ok.refreshes) != 1 || len(failing.refreshes) != 1 {
+		t.Fatalf("Expected all refreshable providers to be refreshed")
+	}
+}
+
+func TestReloadOnSignal(t *testing.T) {
+	oldNotify, oldStop := signalNotify, signalStop
+	defer func() { signalNotify, signalStop = oldNotify, oldStop }()
+	var sigCh chan<- os.Signal
+	var notified []os.Signal
+	signalNotify = func(ch chan<- os.Signal, sigs ...os.Signal) {
+		sigCh, notified = ch, sigs
+	}
+	stopped := false
+	signalStop = func(chan<- os.Signal) { stopped = true }
+
+	repo := NewRepository()
+	prov := &signalTestProv{name: "signal", refreshes: make(chan struct{})}
+	repo.RegisterProvider(prov)
+	vetoed := make(chan struct{})
+	veto := errors.New("critical operation in flight")
+	inFlight := true
+	stop := repo.ReloadOnSignal(&SignalReloadOptions{
+		Veto: func() error {
+			if inFlight {
+				defer close(vetoed)
+				return veto
+			}
+			return nil
+		},
+	})
+	if len(notified) != 1 || notified[0] != syscall.SIGHUP {
+		t.Fatalf("Unexpected signals: got: %v, want: %v", notified, []os.Signal{syscall.SIGHUP})
+	}
+
+	sigCh <- syscall.SIGHUP
+	<-vetoed
+	inFlight = false
+	sigCh <- syscall.SIGHUP
+	select {
+	case <-prov.refreshes:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the provider to be refreshed")
+	}
+
+	stop()
+	stop()
+	if !stopped {
+		t.Fatalf("Expected the signal handling to be stopped")
+	}
+	select {
+	case <-prov.refreshes:
+		t.Fatalf("Expected the vetoed signal not to refresh the provider")
+	default:
+	}
+}
+
+func TestReloadOnSignalYamlProvider(t *testing.T) {
+	oldNotify, oldStop := signalNotify, signalStop
+	defer func() { signalNotify, signalStop = oldNotify, oldStop }()
+	var sigCh chan<- os.Signal
+	signalNotify = func(ch chan<- os.Signal, sigs ...os.Signal) { sigCh = ch }
+	signalStop = func(chan<- os.Signal) {}
+
+	fsys := fstest.MapFS{"config.yaml": {Data: []byte("server:\n  port: 8080\n")}}
+	repo := NewRepository()
+	NewYamlProviderFromSource(repo, DefaultWeight, &YamlProviderOptions{FS: fsys}, "config.yaml")
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+	events := make(chan *ChangeEvent, 1)
+	repo.Subscribe(func(event *ChangeEvent) { events <- event })
+	repo.ReloadOnSignal(nil)
+
+	fsys["config.yaml"] = &fstest.MapFile{Data: []byte("server:\n  port: 9090\n")}
+	sigCh <- syscall.SIGHUP
+	select {
+	case <-events:
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for the config reload")
+	}
+	if got := MustInt(repo, "server.port"); got != 9090 {
+		t.Fatalf("Unexpected value: got: %d, want: %d", got, 9090)
+	}
+}
@@ -0,0 +1,250 @@
+// Package sqldb provides a config provider serving key-value rows stored in a
+// SQL database.
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/osdrv/config"
+)
+
+const (
+	// DefaultQuery is the query used if no custom query is provided. The
+	// column names avoid reserved words (e.g. `key` in MySQL) so that the
+	// query runs unquoted on any database.
+	DefaultQuery = "SELECT config_key, config_value FROM config"
+	// DefaultTimeout is the default timeout of a single query.
+	DefaultTimeout = 5 * time.Second
+)
+
+// Options is a set of Provider settings.
+type Options struct {
+	// Query selects the config rows. It must return exactly 2 columns: the
+	// key and the value. DefaultQuery is used if not set.
+	Query string
+	// Args are the query arguments, e.g. a tenant ID.
+	Args []interface{}
+	// Prefix is prepended to all keys, e.g. with prefix `tenants.acme`, a
+	// row with key `db.host` is served under the key `tenants.acme.db.host`.
+	Prefix string
+	// RefreshInterval enables periodic reloads. Rows are only loaded once if
+	// not set.
+	RefreshInterval time.Duration
+	// RefreshPolicy defines the reload schedule. It takes precedence over
+	// RefreshInterval.
+	RefreshPolicy config.RefreshPolicy
+	// Timeout limits a single query. DefaultTimeout is used if not set.
+	Timeout time.Duration
+	// CacheFile is the location of a local cache file. If set, the values
+	// are persisted to it on every successful fetch and are served from it
+	// if the database is unavailable at set up (see config.CacheFile).
+	CacheFile string
+}
+
+// Provider serves values from a SQL table. Keys are expected to be
+// flattened, e.g. `db.host`. Text values are served as strings, other column
+// types are served as returned by the driver. Rows with NULL values are
+// skipped.
+type Provider struct {
+	weight    int
+	db        *sql.DB
+	options   *Options
+	registry  map[string]config.Value
+	cache     config.CacheFile
+	repo      *config.Repository
+	refresher *config.RefreshScheduler
+	mx        sync.RWMutex
+}
+
+var _ config.Provider = (*Provider)(nil)
+var _ config.ContextSetUpper = (*Provider)(nil)
+
+// NewProvider is the constructor for Provider. A nil options argument is
+// equivalent to the default options.
+func NewProvider(repo *config.Repository, weight int, db *sql.DB, options *Options) (*Provider, error) {
+	if db == nil {
+		return nil, fmt.Errorf("SQL database handle is not set")
+	}
+	if options == nil {
+		options = &Options{}
+	}
+	prov := &Provider{
+		weight:   weight,
+		db:       db,
+		options:  options,
+		registry: make(map[string]config.Value),
+		cache:    config.CacheFile{Path: options.CacheFile, Source: "SQL"},
+	}
+	repo.RegisterProvider(prov)
+	return prov, nil
+}
+
+// Name returns provider name: sql
+func (p *Provider) Name() string { return "sql" }
+
+// Depends returns the list of provider dependencies: none
+func (p *Provider) Depends() []string { return []string{} }
+
+// Weight returns the provider weight
+func (p *Provider) Weight() int { return p.weight }
+
+// SetUp loads the rows using a background context.
+func (p *Provider) SetUp(repo *config.Repository) error {
+	return p.SetUpContext(context.Background(), repo)
+}
+
+// SetUpContext loads the rows and registers the keys in the repo. Reloads
+// are scheduled according to RefreshPolicy or RefreshInterval (if set). If
+// the database is unavailable and CacheFile is set, the cached values are served.
+func (p *Provider) SetUpContext(ctx context.Context, repo *config.Repository) error {
+	p.repo = repo
+	registry, err := p.fetch(ctx)
+	if err != nil {
+		var ok bool
+		if registry, ok = p.cache.Load(p.repo, err); !ok {
+			return err
+		}
+	} else {
+		p.cache.Store(p.repo, registry)
+	}
+	p.mx.Lock()
+	p.registry = registry
+	p.mx.Unlock()
+	for k := range registry {
+		if err := repo.RegisterKey(config.NewKey(k), p); err != nil {
+			return err
+		}
+	}
+	policy := p.options.RefreshPolicy
+	if policy == nil && p.options.RefreshInterval > 0 {
+		policy = config.TTL(p.options.RefreshInterval)
+	}
+	if policy != nil {
+		p.refresher = config.NewRefreshScheduler(repo, p, p.refresh)
+		p.refresher.SetPolicy("", policy)
+		p.refresher.Start()
+	}
+	return nil
+}
+
+// TearDown stops the scheduled reloads. The database handle is owned
+// by the caller and is not closed.
+func (p *Provider) TearDown(*config.Repository) error {
+	if p.refresher != nil {
+		p.refresher.Stop()
+	}
+	return nil
+}
+
+// Get returns the value for the key
+func (p *Provider) Get(key config.Key) (*config.KeyValue, bool) {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+	if v, ok := p.registry[key.String()]; ok {
+		return &config.KeyValue{Key: key, Value: v}, true
+	}
+	return nil, false
+}
+
+// Reload re-reads the rows and applies the new key set atomically (see
+// `config.Repository.ApplyReload`). If the new values fail the schema
+// validation, the previous values are kept.
+func (p *Provider) Reload(ctx context.Context) error {
+	return p.refresh(ctx, nil)
+}
+
+// refresh re-fetches the values and applies the ones under the config key
+// prefix (see config.RefreshFunc). Fetched values are keyed the same way as
+// at set up, so the prefix is matched against the served keys.
+func (p *Provider) refresh(ctx context.Context, prefix config.Key) (err error) {
+	fresh, err := p.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	p.mx.RLock()
+	prevRegistry := p.registry
+	p.mx.RUnlock()
+	registry := config.ReplacePrefix(prevRegistry, fresh, prefix)
+	defer func() {
+		if err == nil {
+			p.cache.Store(p.repo, registry)
+		}
+	}()
+	return p.repo.ApplyReload(p, func() error {
+		p.mx.Lock()
+		p.registry = registry
+		p.mx.Unlock()
+		for k := range registry {
+			if err := p.repo.RegisterKey(config.NewKey(k), p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func() {
+		p.mx.Lock()
+		p.registry = prevRegistry
+		p.mx.Unlock()
+	})
+}
+
+func (p *Provider) fetch(ctx context.Context) (map[string]config.Value, error) {
+	timeout := p.options.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ctx, span := p.repo.StartProviderSpan(ctx, "config.sql.Fetch", p)
+	defer span.End()
+
+	registry, err := p.query(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return registry, nil
+}
+
+func (p *Provider) query(ctx context.Context) (map[string]config.Value, error) {
+	query := p.options.Query
+	if len(query) == 0 {
+		query = DefaultQuery
+	}
+	rows, err := p.db.QueryContext(ctx, query, p.options.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query SQL config: %s", err)
+	}
+	defer rows.Close()
+	registry := make(map[string]config.Value)
+	for rows.Next() {
+		var k string
+		var v interface{}
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, fmt.Errorf("Failed to scan SQL config row: %s", err)
+		}
+		if v == nil {
+			continue
+		}
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		registry[p.keyOf(k)] = v
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read SQL config rows: %s", err)
+	}
+	return registry, nil
+}
+
+func (p *Provider) keyOf(key string) string {
+	if len(p.options.Prefix) == 0 {
+		return key
+	}
+	return config.NewKey(strings.TrimSuffix(p.options.Prefix, config.KeySepCh)).Join(config.NewKey(key)).String()
+}
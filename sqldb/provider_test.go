@@ -0,0 +1,276 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/osdrv/config"
+)
+
+// testRow is a row of the fake config table
+type testRow struct {
+	tenant string
+	key    string
+	value  driver.Value
+}
+
+// testDriver is a fake database/sql driver serving rows from an in-memory
+// table. A query containing a `?` placeholder filters the rows by tenant.
+type testDriver struct {
+	rows []testRow
+	// err is returned by all queries if set
+	err error
+	mx  sync.Mutex
+}
+
+func (d *testDriver) Open(string) (driver.Conn, error) { return &testConn{d}, nil }
+
+func (d *testDriver) set(rows []testRow) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.rows = rows
+}
+
+func (d *testDriver) fail(err error) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.err = err
+}
+
+type testConn struct{ d *testDriver }
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	if !strings.HasPrefix(query, "SELECT") {
+		return nil, fmt.Errorf("unsupported query: %q", query)
+	}
+	return &testStmt{d: c.d, filter: strings.Contains(query, "?")}, nil
+}
+func (c *testConn) Close() error              { return nil }
+func (c *testConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("not supported") }
+
+type testStmt struct {
+	d      *testDriver
+	filter bool
+}
+
+func (s *testStmt) Close() error { return nil }
+func (s *testStmt) NumInput() int {
+	if s.filter {
+		return 1
+	}
+	return 0
+}
+func (s *testStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("not supported")
+}
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mx.Lock()
+	defer s.d.mx.Unlock()
+	if s.d.err != nil {
+		return nil, s.d.err
+	}
+	res := make([][]driver.Value, 0, len(s.d.rows))
+	for _, row := range s.d.rows {
+		if s.filter && row.tenant != args[0] {
+			continue
+		}
+		res = append(res, []driver.Value{[]byte(row.key), row.value})
+	}
+	return &testRows{rows: res}, nil
+}
+
+type testRows struct {
+	rows [][]driver.Value
+	pos  int
+}
+
+func (r *testRows) Columns() []string { return []string{"config_key", "config_value"} }
+func (r *testRows) Close() error      { return nil }
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+var testDrv = &testDriver{}
+
+func init() {
+	sql.Register("sqldbtest", testDrv)
+}
+
+func TestProviderSetUp(t *testing.T) {
+	testDrv.set([]testRow{
+		{"acme", "db.host", []byte("acme.db")},
+		{"acme", "db.port", int64(5432)},
+		{"acme", "feature.beta", nil},
+		{"globex", "db.host", []byte("globex.db")},
+	})
+
+	tests := []struct {
+		name    string
+		options *Options
+		want    map[string]config.Value
+	}{
+		{
+			"Default options",
+			nil,
+			map[string]config.Value{
+				"db.host": "globex.db",
+				"db.port": int64(5432),
+			},
+		},
+		{
+			"A tenant query with a prefix",
+			&Options{
+				Query:  "SELECT config_key, config_value FROM config WHERE tenant = ?",
+				Args:   []interface{}{"acme"},
+				Prefix: "tenants.acme",
+			},
+			map[string]config.Value{
+				"tenants.acme.db.host": "acme.db",
+				"tenants.acme.db.port": int64(5432),
+			},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			db, err := sql.Open("sqldbtest", "")
+			if err != nil {
+				t.Fatalf("Failed to open the test database: %s", err)
+			}
+			defer db.Close()
+			repo := config.NewRepository()
+			prov, err := NewProvider(repo, 10, db, testCase.options)
+			if err != nil {
+				t.Fatalf("Failed to initialize a new provider: %s", err)
+			}
+			if err := repo.SetUp(); err != nil {
+				t.Fatalf("Failed to set up the repository: %s", err)
+			}
+			defer repo.TearDown()
+			if !reflect.DeepEqual(prov.registry, testCase.want) {
+				t.Fatalf("Unexpected registry: got: %#v, want: %#v", prov.registry, testCase.want)
+			}
+		})
+	}
+}
+
+func TestProviderReload(t *testing.T) {
+	testDrv.set([]testRow{
+		{"acme", "db.host", []byte("localhost")},
+	})
+	db, err := sql.Open("sqldbtest", "")
+	if err != nil {
+		t.Fatalf("Failed to open the test database: %s", err)
+	}
+	defer db.Close()
+	repo := config.NewRepository()
+	prov, err := NewProvider(repo, 10, db, nil)
+	if err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	testDrv.set([]testRow{
+		{"acme", "db.host", []byte("db.internal")},
+	})
+	if err := prov.Reload(context.Background()); err != nil {
+		t.Fatalf("Failed to reload the provider: %s", err)
+	}
+	if v, _ := repo.Get(config.NewKey("db.host")); v != "db.internal" {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", v, "db.internal")
+	}
+}
+
+func TestProviderRefreshPrefix(t *testing.T) {
+	testDrv.set([]testRow{
+		{"acme", "db.host", []byte("localhost")},
+		{"acme", "cache.host", []byte("localhost")},
+	})
+	db, err := sql.Open("sqldbtest", "")
+	if err != nil {
+		t.Fatalf("Failed to open the test database: %s", err)
+	}
+	defer db.Close()
+	repo := config.NewRepository()
+	prov, err := NewProvider(repo, 10, db, &Options{Prefix: "tenants.acme"})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	testDrv.set([]testRow{
+		{"acme", "db.host", []byte("db.internal")},
+		{"acme", "cache.host", []byte("cache.internal")},
+	})
+	if err := prov.refresh(context.Background(), config.NewKey("tenants.acme.db")); err != nil {
+		t.Fatalf("Failed to refresh the provider: %s", err)
+	}
+	want := map[string]config.Value{
+		"tenants.acme.db.host":    "db.internal",
+		"tenants.acme.cache.host": "localhost",
+	}
+	if !reflect.DeepEqual(prov.registry, want) {
+		t.Fatalf("Unexpected registry: got: %#v, want: %#v", prov.registry, want)
+	}
+}
+
+func TestProviderCacheFile(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "sqldb.yaml")
+	testDrv.set([]testRow{
+		{"acme", "db.host", []byte("localhost")},
+	})
+	defer testDrv.fail(nil)
+	db, err := sql.Open("sqldbtest", "")
+	if err != nil {
+		t.Fatalf("Failed to open the test database: %s", err)
+	}
+	defer db.Close()
+	repo := config.NewRepository()
+	prov, err := NewProvider(repo, 10, db, &Options{CacheFile: cacheFile})
+	if err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	// Reloaded values are cached as well
+	testDrv.set([]testRow{
+		{"acme", "db.host", []byte("db.internal")},
+	})
+	if err := prov.Reload(context.Background()); err != nil {
+		t.Fatalf("Failed to reload the provider: %s", err)
+	}
+	repo.TearDown()
+
+	testDrv.fail(fmt.Errorf("connection refused"))
+	repo = config.NewRepository()
+	if _, err := NewProvider(repo, 10, db, &Options{CacheFile: cacheFile}); err != nil {
+		t.Fatalf("Failed to initialize a new provider: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+	if v, _ := repo.Get(config.NewKey("db.host")); v != "db.internal" {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", v, "db.internal")
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// ProviderState is a family of constants defining the provider lifecycle
+// stage.
+type ProviderState uint8
+
+const (
+	// ProviderPending means the provider is registered but not set up yet.
+	ProviderPending ProviderState = iota
+	// ProviderReady means the provider has been successfully set up.
+	ProviderReady
+	// ProviderFailed means the provider failed to set up.
+	ProviderFailed
+	// ProviderTornDown means the provider has been torn down.
+	ProviderTornDown
+)
+
+// String satisfies Stringer interface
+func (ps ProviderState) String() string {
+	switch ps {
+	case ProviderPending:
+		return "pending"
+	case ProviderReady:
+		return "ready"
+	case ProviderFailed:
+		return "failed"
+	case ProviderTornDown:
+		return "torn_down"
+	}
+	return "unknown"
+}
+
+// ProviderStatus is a snapshot of a provider health information.
+type ProviderStatus struct {
+	Name   string
+	Weight int
+	State  ProviderState
+	// SetUpAt is the time the provider set up finished.
+	SetUpAt time.Time
+	// LastRefresh is the time of the most recent successful reload. It is
+	// equal to SetUpAt if no reloads happened.
+	LastRefresh time.Time
+	// LastError is the error of the most recent set up or reload attempt.
+	LastError error
+}
+
+// MarshalJSON satisfies json.Marshaler interface. Zero times are omitted.
+func (ps ProviderStatus) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{
+		"name":   ps.Name,
+		"weight": ps.Weight,
+		"state":  ps.State.String(),
+	}
+	if !ps.SetUpAt.IsZero() {
+		out["set_up_at"] = ps.SetUpAt
+	}
+	if !ps.LastRefresh.IsZero() {
+		out["last_refresh"] = ps.LastRefresh
+	}
+	if ps.LastError != nil {
+		out["last_error"] = ps.LastError.Error()
+	}
+	return json.Marshal(out)
+}
+
+// ProviderStatus returns the status of every registered provider sorted by
+// provider name. The result is suitable for exposing on a health endpoint.
+// This method is thread safe.
+func (repo *Repository) ProviderStatus() []ProviderStatus {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	res := make([]ProviderStatus, 0, len(repo.providers))
+	for name, prov := range repo.providers {
+		status := ProviderStatus{
+			Name:   name,
+			Weight: prov.Weight(),
+			State:  ProviderPending,
+		}
+		if st, ok := repo.statuses[name]; ok {
+			status = *st
+		}
+		res = append(res, status)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].Name < res[b].Name
+	})
+	return res
+}
+
+// updateStatus applies the update function to the provider status.
+func (repo *Repository) updateStatus(prov Provider, update func(*ProviderStatus)) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	st, ok := repo.statuses[prov.Name()]
+	if !ok {
+		st = &ProviderStatus{Name: prov.Name(), State: ProviderPending}
+		repo.statuses[prov.Name()] = st
+	}
+	st.Weight = prov.Weight()
+	update(st)
+}
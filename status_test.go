@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+type failingTestProv struct {
+	*TestProv
+	name string
+	deps []string
+	err  error
+}
+
+func (ftp *failingTestProv) Name() string            { return ftp.name }
+func (ftp *failingTestProv) SetUp(*Repository) error { return ftp.err }
+func (ftp *failingTestProv) Depends() []string       { return ftp.deps }
+
+func TestProviderStatus(t *testing.T) {
+	repo := NewRepository()
+	setUpErr := fmt.Errorf("connection refused")
+	repo.RegisterProvider(&failingTestProv{TestProv: NewTestProv(1, 10), name: "ok"})
+	repo.RegisterProvider(&failingTestProv{TestProv: NewTestProv(1, 20), name: "remote", deps: []string{"ok"}, err: setUpErr})
+
+	statuses := repo.ProviderStatus()
+	if len(statuses) != 2 || statuses[0].State != ProviderPending || statuses[1].State != ProviderPending {
+		t.Fatalf("Unexpected statuses before set up: %#v", statuses)
+	}
+
+	if err := repo.SetUp(); err != setUpErr {
+		t.Fatalf("Unexpected set up error: got: %v, want: %v", err, setUpErr)
+	}
+
+	statuses = repo.ProviderStatus()
+	ok, remote := statuses[0], statuses[1]
+	if ok.Name != "ok" || ok.Weight != 10 || ok.State != ProviderReady || ok.LastError != nil || ok.SetUpAt.IsZero() || ok.LastRefresh != ok.SetUpAt {
+		t.Fatalf("Unexpected status for a healthy provider: %#v", ok)
+	}
+	if remote.Name != "remote" || remote.Weight != 20 || remote.State != ProviderFailed || remote.LastError != setUpErr || !remote.SetUpAt.IsZero() {
+		t.Fatalf("Unexpected status for a failed provider: %#v", remote)
+	}
+
+	data, err := json.Marshal(remote)
+	if err != nil {
+		t.Fatalf("Failed to marshal provider status: %s", err)
+	}
+	want := `{"last_error":"connection refused","name":"remote","state":"failed","weight":20}`
+	if string(data) != want {
+		t.Fatalf("Unexpected provider status JSON: got: %s, want: %s", data, want)
+	}
+
+	if err := repo.TearDown(); err != nil {
+		t.Fatalf("Unexpected tear down error: %s", err)
+	}
+	for _, st := range repo.ProviderStatus() {
+		if st.State != ProviderTornDown {
+			t.Fatalf("Unexpected status after tear down: %#v", st)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs returns the functions available in config file templates (see
+// YamlProviderOptions.Template). The set is restricted on purpose: templates
+// look up values, they do not run arbitrary logic.
+//
+//	env "NAME"         returns the env variable value or an empty string
+//	file "PATH"        returns the file contents without the trailing newline,
+//	                   the file is read from the provider filesystem (see
+//	                   YamlProviderOptions.FS)
+//	default "D" VALUE  returns D if VALUE is empty, e.g. `{{ env "PORT" | default "8080" }}`
+//	secret "REF"       resolves the reference by the repository resolvers (see
+//	                   RegisterResolver) without the trailing newline, e.g.
+//	                   `{{ secret "file:///run/secrets/db" | quote }}`
+//	quote VALUE        returns VALUE as a double-quoted YAML string, so values
+//	                   like `p4ss: #1` are not parsed as YAML
+//
+// Secrets and other arbitrary strings are expected to be quoted.
+func templateFuncs(repo *Repository, fsys fs.FS) template.FuncMap {
+	return template.FuncMap{
+		"env": func(name string) string {
+			prefix := name + "="
+			for _, kv := range envVars() {
+				if strings.HasPrefix(kv, prefix) {
+					return kv[len(prefix):]
+				}
+			}
+			return ""
+		},
+		"file": func(path string) (string, error) {
+			data, err := fs.ReadFile(fsys, path)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimRight(string(data), "\r\n"), nil
+		},
+		"default": func(def interface{}, v interface{}) interface{} {
+			if v == nil || fmt.Sprint(v) == "" {
+				return def
+			}
+			return v
+		},
+		"quote": func(v interface{}) string {
+			return strconv.Quote(fmt.Sprint(v))
+		},
+		"secret": func(ref string) (string, error) {
+			if repo == nil {
+				return "", fmt.Errorf("Failed to resolve secret %q: no repository", ref)
+			}
+			v, err := repo.resolve(ref)
+			if err != nil {
+				return "", err
+			}
+			if s, ok := v.(string); ok && s == ref {
+				return "", fmt.Errorf("Failed to resolve secret %q: no matching resolver", ref)
+			}
+			return strings.TrimRight(fmt.Sprint(v), "\r\n"), nil
+		},
+	}
+}
+
+// renderTemplate renders the config file template. Files are read from fsys.
+// Referring to missing template data is an error.
+func renderTemplate(repo *Repository, fsys fs.FS, name string, data []byte) ([]byte, error) {
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(templateFuncs(repo, fsys)).
+		Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse config template %q: %s", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return nil, fmt.Errorf("Failed to render config template %q: %s", name, err)
+	}
+	return buf.Bytes(), nil
+}
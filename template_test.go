@@ -0,0 +1,73 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	oldEnvVars := envVars
+	defer func() { envVars = oldEnvVars }()
+	envVars = func() []string { return []string{"PORT=9090", "EMPTY="} }
+	fsys := fstest.MapFS{
+		"run/secrets/token": &fstest.MapFile{Data: []byte("s3cr3t\n")},
+	}
+
+	repo := NewRepository()
+	repo.RegisterDefaultResolvers()
+	repo.RegisterResolver(FilePrefix, NewFileResolverWithFS(fsys))
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		{"env", `port: {{ env "PORT" }}`, "port: 9090", false},
+		{"default", `host: {{ env "HOST" | default "localhost" }}`, "host: localhost", false},
+		{"default empty", `v: {{ env "EMPTY" | default 42 }}`, "v: 42", false},
+		{"default set", `port: {{ env "PORT" | default "8080" }}`, "port: 9090", false},
+		{"file", `token: {{ file "run/secrets/token" }}`, "token: s3cr3t", false},
+		{"secret", `token: {{ secret "file://run/secrets/token" }}`, "token: s3cr3t", false},
+		{"secret base64", `user: {{ secret "base64:YWRtaW4=" }}`, "user: admin", false},
+		{"quoted secret", `pass: {{ secret "base64:cDRzczogIzE=" | quote }}`, `pass: "p4ss: #1"`, false},
+		{"quote", `v: {{ env "PORT" | quote }}`, `v: "9090"`, false},
+		{"unresolved secret", `token: {{ secret "vault:token" }}`, "", true},
+		{"missing file", `token: {{ file "missing" }}`, "", true},
+		{"unknown function", `cmd: {{ exec "ls" }}`, "", true},
+		{"missing data", `v: {{ .foo.bar }}`, "", true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := renderTemplate(repo, fsys, "test.yaml", []byte(testCase.tmpl))
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if err == nil && string(got) != testCase.want {
+				t.Fatalf("Unexpected render result: got: %q, want: %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestYamlProviderTemplate(t *testing.T) {
+	oldEnvVars := envVars
+	defer func() { envVars = oldEnvVars }()
+	envVars = func() []string { return []string{"DB_HOST=db.local"} }
+	fsys := fstest.MapFS{
+		"config.yaml": &fstest.MapFile{Data: []byte("db:\n  host: {{ env \"DB_HOST\" }}\n  port: {{ env \"DB_PORT\" | default 5432 }}\n")},
+	}
+
+	repo := NewRepository()
+	NewYamlProviderFromSource(repo, DefaultWeight, &YamlProviderOptions{Template: true, FS: fsys}, "config.yaml")
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	got, _ := repo.GetTree(NewKey("db"))
+	want := map[string]Value{"host": "db.local", "port": 5432}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected config: got: %#v, want: %#v", got, want)
+	}
+}
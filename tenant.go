@@ -0,0 +1,115 @@
+package config
+
+import "sync"
+
+// TenantsKey is the root key of the tenant overrides: the overrides of the
+// tenant `acme` live under `tenants.acme`.
+const TenantsKey = "tenants"
+
+// TenantRepo is a read-only view of a repository with per-tenant overrides:
+// a lookup of `limits.rps` for the tenant `acme` resolves
+// `tenants.acme.limits.rps` and falls back to the global `limits.rps` key. If
+// both the tenant and the global values are maps, the tenant one is merged
+// over the global one deeply.
+// Lookup results are cached per tenant, the cache is dropped on every applied
+// reload.
+type TenantRepo struct {
+	repo   *Repository
+	name   string
+	prefix Key
+	cache  map[string]Value
+	// gen is incremented on every cache invalidation: lookups running
+	// concurrently with a reload must not cache stale values
+	gen int
+	mx  sync.RWMutex
+}
+
+var _ Getter = (*TenantRepo)(nil)
+
+// ForTenant returns the view of the repository for the tenant. Views are
+// created once per tenant, subsequent calls share the view and its cache.
+// This method is thread safe.
+func (repo *Repository) ForTenant(name string) *TenantRepo {
+	repo.mx.Lock()
+	if tr, ok := repo.tenants[name]; ok {
+		repo.mx.Unlock()
+		return tr
+	}
+	tr := &TenantRepo{
+		repo:   repo,
+		name:   name,
+		prefix: Key{TenantsKey, name},
+		cache:  make(map[string]Value),
+	}
+	repo.tenants[name] = tr
+	repo.mx.Unlock()
+	repo.Subscribe(func(event *ChangeEvent) {
+		if event.Err == nil {
+			tr.Invalidate()
+		}
+	})
+	return tr
+}
+
+// Name returns the tenant name.
+func (tr *TenantRepo) Name() string { return tr.name }
+
+// Repository returns the underlying repository.
+func (tr *TenantRepo) Repository() *Repository { return tr.repo }
+
+// NewKey parses the key. See `Repository.NewKey`.
+func (tr *TenantRepo) NewKey(str string) Key { return tr.repo.NewKey(str) }
+
+func (tr *TenantRepo) strictTypes() bool { return tr.repo.strictTypes() }
+
+// Get looks up the tenant override of the key and falls back to the global
+// key value.
+// This method is thread safe.
+func (tr *TenantRepo) Get(key Key) (Value, bool) {
+	cacheKey := tr.repo.foldKey(key).String()
+	tr.mx.RLock()
+	v, ok := tr.cache[cacheKey]
+	gen := tr.gen
+	tr.mx.RUnlock()
+	if ok {
+		tr.repo.audit("", key)
+		return v, true
+	}
+	v, ok = tr.lookup(key)
+	if !ok {
+		// Misses are not cached: the key might be registered later
+		return nil, false
+	}
+	tr.mx.Lock()
+	if tr.gen == gen {
+		tr.cache[cacheKey] = v
+	}
+	tr.mx.Unlock()
+	return v, true
+}
+
+func (tr *TenantRepo) lookup(key Key) (Value, bool) {
+	tv, tok := tr.repo.Get(tr.prefix.Join(key))
+	if tok {
+		if _, isMap := toValueMap(tv); !isMap {
+			return tv, true
+		}
+	}
+	gv, gok := tr.repo.Get(key)
+	switch {
+	case tok && gok:
+		return mergeValue(MergeDeep, gv, tv), true
+	case tok:
+		return tv, true
+	}
+	return gv, gok
+}
+
+// Invalidate drops the cached lookup results.
+// This method is thread safe.
+func (tr *TenantRepo) Invalidate() {
+	tr.mx.Lock()
+	defer tr.mx.Unlock()
+	tr.cache = make(map[string]Value)
+	tr.gen++
+}
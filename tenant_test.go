@@ -0,0 +1,85 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForTenant(t *testing.T) {
+	repo := NewRepository()
+	prov := &reloadTestProv{registry: map[string]Value{
+		"limits.rps":                   100,
+		"limits.burst":                 200,
+		"region":                       "eu",
+		"tenants.acme.limits.rps":      1000,
+		"tenants.acme.region":          "us",
+		"tenants.globex.limits.burst":  50,
+		"tenants.globex.features.beta": true,
+	}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	tests := []struct {
+		name   string
+		tenant string
+		key    string
+		want   Value
+		wantOk bool
+	}{
+		{"An overridden key", "acme", "limits.rps", 1000, true},
+		{"A global key", "acme", "limits.burst", 200, true},
+		{"A tenant-only key", "globex", "features.beta", true, true},
+		{"An unknown tenant", "initech", "region", "eu", true},
+		{"A missing key", "acme", "limits.max", nil, false},
+		{
+			"A merged subtree",
+			"acme",
+			"limits",
+			map[string]Value{"rps": 1000, "burst": 200},
+			true,
+		},
+		{
+			"A merged subtree of another tenant",
+			"globex",
+			"limits",
+			map[string]Value{"rps": 100, "burst": 50},
+			true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, ok := repo.ForTenant(testCase.tenant).Get(NewKey(testCase.key))
+			if ok != testCase.wantOk {
+				t.Fatalf("Unexpected lookup result for key %q: got: %t, want: %t", testCase.key, ok, testCase.wantOk)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected value: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+
+	acme := repo.ForTenant("acme")
+	if repo.ForTenant("acme") != acme {
+		t.Fatalf("Expected tenant views to be shared")
+	}
+	if got := MustInt(acme, "limits.rps"); got != 1000 {
+		t.Fatalf("Unexpected value: got: %d, want: %d", got, 1000)
+	}
+
+	// The cache is dropped on reload
+	if err := prov.reload(repo, map[string]Value{
+		"limits.rps":              100,
+		"tenants.acme.limits.rps": 2000,
+	}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if got := MustInt(acme, "limits.rps"); got != 2000 {
+		t.Fatalf("Unexpected value after reload: got: %d, want: %d", got, 2000)
+	}
+	if _, ok := acme.Get(NewKey("region")); ok {
+		t.Fatalf("Expected the deleted key to be dropped from the cache")
+	}
+}
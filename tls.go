@@ -0,0 +1,161 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+)
+
+// Redefined in tests
+var readFile = ioutil.ReadFile
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var tlsClientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require_any":        tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// TLSConfig builds a *tls.Config out of a conventional TLS config section.
+// The keys are looked up relative to the getter, e.g. a
+// `repo.Scope("server.tls")` view:
+//   - cert_file, key_file: the PEM-encoded certificate and key files, both or
+//     none must be set;
+//   - ca_file: the PEM-encoded CA bundle used to verify peer certificates;
+//   - min_version: the minimum TLS version: 1.0, 1.1, 1.2 or 1.3;
+//   - client_auth: the client certificate policy: none, request, require_any,
+//     verify_if_given or require_and_verify.
+//
+// All keys are optional. Returns an error if a file can not be loaded or a
+// value is malformed.
+func TLSConfig(repo Getter) (*tls.Config, error) {
+	cfg := &tls.Config{}
+	cert, err := loadTLSCertificate(repo)
+	if err != nil {
+		return nil, err
+	}
+	if cert != nil {
+		cfg.Certificates = []tls.Certificate{*cert}
+	}
+	if caFile, ok, err := LookupStr(repo, "ca_file"); err != nil {
+		return nil, err
+	} else if ok {
+		data, err := readFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the TLS CA file: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("Failed to parse the TLS CA file %q: no PEM certificates found", caFile)
+		}
+		cfg.RootCAs, cfg.ClientCAs = pool, pool
+	}
+	if v, ok, err := LookupStr(repo, "min_version"); err != nil {
+		return nil, err
+	} else if ok {
+		version, known := tlsVersions[strings.TrimPrefix(strings.ToLower(v), "tls")]
+		if !known {
+			return nil, fmt.Errorf("Unsupported TLS version %q", v)
+		}
+		cfg.MinVersion = version
+	}
+	if v, ok, err := LookupStr(repo, "client_auth"); err != nil {
+		return nil, err
+	} else if ok {
+		auth, known := tlsClientAuthTypes[strings.ToLower(v)]
+		if !known {
+			return nil, fmt.Errorf("Unsupported TLS client auth type %q", v)
+		}
+		cfg.ClientAuth = auth
+	}
+	return cfg, nil
+}
+
+// loadTLSCertificate loads the certificate key pair. Returns nil if neither
+// cert_file nor key_file is set.
+func loadTLSCertificate(repo Getter) (*tls.Certificate, error) {
+	certFile, certOk, err := LookupStr(repo, "cert_file")
+	if err != nil {
+		return nil, err
+	}
+	keyFile, keyOk, err := LookupStr(repo, "key_file")
+	if err != nil {
+		return nil, err
+	}
+	if !certOk && !keyOk {
+		return nil, nil
+	}
+	if certOk != keyOk {
+		return nil, fmt.Errorf("Failed to load the TLS certificate: both cert_file and key_file must be set")
+	}
+	certPEM, err := readFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the TLS certificate file: %s", err)
+	}
+	keyPEM, err := readFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the TLS key file: %s", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the TLS certificate: %s", err)
+	}
+	return &cert, nil
+}
+
+// BindTLSConfig is a version of TLSConfig reloading the certificate on the
+// fly: the certificate is re-read every time a key under the prefix changes
+// (see Bind). The returned config serves the certificate by means of the
+// GetCertificate and GetClientCertificate callbacks. Other settings are read
+// once. If the certificate reload fails, the error is logged and the
+// previous certificate keeps being served.
+func (repo *Repository) BindTLSConfig(prefix string) (*tls.Config, error) {
+	scope := repo.Scope(prefix)
+	cfg, err := TLSConfig(scope)
+	if err != nil {
+		return nil, err
+	}
+	var current atomic.Value
+	if len(cfg.Certificates) > 0 {
+		current.Store(&cfg.Certificates[0])
+	}
+	cfg.Certificates = nil
+	if err := repo.Bind(prefix, func() error {
+		cert, err := loadTLSCertificate(scope)
+		if err != nil {
+			return err
+		}
+		if cert != nil {
+			current.Store(cert)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		cert, ok := current.Load().(*tls.Certificate)
+		if !ok {
+			return nil, fmt.Errorf("No TLS certificate configured under %q", prefix)
+		}
+		return cert, nil
+	}
+	cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		if cert, ok := current.Load().(*tls.Certificate); ok {
+			return cert, nil
+		}
+		// No certificate is sent to the server
+		return &tls.Certificate{}, nil
+	}
+	return cfg, nil
+}
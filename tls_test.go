@@ -0,0 +1,186 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testCertificate generates a self-signed certificate and returns the PEM
+// encoded certificate and key.
+func testCertificate(t *testing.T, cn string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate a key: %s", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create a certificate: %s", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal the key: %s", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestTLSConfig(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t, "test")
+	oldReadFile := readFile
+	defer func() { readFile = oldReadFile }()
+	files := map[string][]byte{
+		"/tls/cert.pem": certPEM,
+		"/tls/key.pem":  keyPEM,
+		"/tls/ca.pem":   certPEM,
+		"/tls/bad.pem":  []byte("garbage"),
+	}
+	readFile = func(path string) ([]byte, error) {
+		if data, ok := files[path]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+
+	tests := []struct {
+		name           string
+		values         map[string]Value
+		wantCerts      int
+		wantCA         bool
+		wantMinVersion uint16
+		wantClientAuth tls.ClientAuthType
+		wantErr        bool
+	}{
+		{"empty section", map[string]Value{}, 0, false, 0, tls.NoClientCert, false},
+		{
+			"full section",
+			map[string]Value{
+				"tls.cert_file":   "/tls/cert.pem",
+				"tls.key_file":    "/tls/key.pem",
+				"tls.ca_file":     "/tls/ca.pem",
+				"tls.min_version": "TLS1.2",
+				"tls.client_auth": "require_and_verify",
+			},
+			1, true, tls.VersionTLS12, tls.RequireAndVerifyClientCert, false,
+		},
+		{"missing key file", map[string]Value{"tls.cert_file": "/tls/cert.pem"}, 0, false, 0, 0, true},
+		{"unreadable cert file", map[string]Value{"tls.cert_file": "/missing", "tls.key_file": "/tls/key.pem"}, 0, false, 0, 0, true},
+		{"malformed ca file", map[string]Value{"tls.ca_file": "/tls/bad.pem"}, 0, false, 0, 0, true},
+		{"unknown version", map[string]Value{"tls.min_version": "1.4"}, 0, false, 0, 0, true},
+		{"unknown client auth", map[string]Value{"tls.client_auth": "always"}, 0, false, 0, 0, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			NewDefaultProviderWithDefaults(repo, 0, testCase.values)
+			if err := repo.SetUp(); err != nil {
+				t.Fatalf("Failed to set up the repository: %s", err)
+			}
+			cfg, err := TLSConfig(repo.Scope("tls"))
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(cfg.Certificates) != testCase.wantCerts {
+				t.Fatalf("Unexpected number of certificates: got: %d, want: %d", len(cfg.Certificates), testCase.wantCerts)
+			}
+			if (cfg.RootCAs != nil) != testCase.wantCA {
+				t.Fatalf("Unexpected CA pool: got: %v, want set: %t", cfg.RootCAs, testCase.wantCA)
+			}
+			if cfg.MinVersion != testCase.wantMinVersion {
+				t.Fatalf("Unexpected min version: got: %d, want: %d", cfg.MinVersion, testCase.wantMinVersion)
+			}
+			if cfg.ClientAuth != testCase.wantClientAuth {
+				t.Fatalf("Unexpected client auth: got: %v, want: %v", cfg.ClientAuth, testCase.wantClientAuth)
+			}
+		})
+	}
+}
+
+func TestBindTLSConfig(t *testing.T) {
+	certA, keyA := testCertificate(t, "a")
+	certB, keyB := testCertificate(t, "b")
+	oldReadFile := readFile
+	defer func() { readFile = oldReadFile }()
+	files := map[string][]byte{
+		"/a/cert.pem": certA,
+		"/a/key.pem":  keyA,
+		"/b/cert.pem": certB,
+		"/b/key.pem":  keyB,
+	}
+	readFile = func(path string) ([]byte, error) {
+		if data, ok := files[path]; ok {
+			return data, nil
+		}
+		return nil, errors.New("no such file")
+	}
+
+	repo := NewRepository()
+	prov := &reloadTestProv{registry: map[string]Value{
+		"server.tls.cert_file": "/a/cert.pem",
+		"server.tls.key_file":  "/a/key.pem",
+	}}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	cfg, err := repo.BindTLSConfig("server.tls")
+	if err != nil {
+		t.Fatalf("Failed to bind the TLS config: %s", err)
+	}
+	commonName := func() string {
+		cert, err := cfg.GetCertificate(&tls.ClientHelloInfo{})
+		if err != nil {
+			t.Fatalf("Failed to get the certificate: %s", err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatalf("Failed to parse the certificate: %s", err)
+		}
+		return leaf.Subject.CommonName
+	}
+	if got := commonName(); got != "a" {
+		t.Fatalf("Unexpected certificate: got: %q, want: %q", got, "a")
+	}
+
+	if err := prov.reload(repo, map[string]Value{
+		"server.tls.cert_file": "/b/cert.pem",
+		"server.tls.key_file":  "/b/key.pem",
+	}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if got := commonName(); got != "b" {
+		t.Fatalf("Unexpected certificate after reload: got: %q, want: %q", got, "b")
+	}
+
+	// A broken certificate does not replace the previous one
+	if err := prov.reload(repo, map[string]Value{
+		"server.tls.cert_file": "/missing.pem",
+		"server.tls.key_file":  "/b/key.pem",
+	}); err != nil {
+		t.Fatalf("Unexpected reload error: %s", err)
+	}
+	if got := commonName(); got != "b" {
+		t.Fatalf("Unexpected certificate after a failed reload: got: %q, want: %q", got, "b")
+	}
+}
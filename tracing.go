@@ -0,0 +1,64 @@
+package config
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracerName is the instrumentation name used for all spans created by
+	// the repository.
+	TracerName = "github.com/osdrv/config"
+)
+
+// ContextSetUpper is an optional interface for providers that need the set up
+// context, e.g. providers performing remote fetches. If a provider implements
+// it, the repository calls `SetUpContext` instead of `SetUp`. The context
+// carries the parent tracing span: a provider is expected to start child
+// spans using `repo.Tracer()`.
+type ContextSetUpper interface {
+	SetUpContext(context.Context, *Repository) error
+}
+
+// SetTracerProvider replaces the OpenTelemetry tracer provider used by the
+// repository. The global tracer provider is used by default.
+// This method is thread safe.
+func (repo *Repository) SetTracerProvider(tp trace.TracerProvider) {
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	repo.tracerProvider = tp
+}
+
+// Tracer returns the OpenTelemetry tracer used by the repository. Providers
+// are expected to use it to instrument remote fetches and reloads.
+func (repo *Repository) Tracer() trace.Tracer {
+	repo.mx.Lock()
+	tp := repo.tracerProvider
+	repo.mx.Unlock()
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(TracerName)
+}
+
+// StartProviderSpan starts a new span annotated with the provider attributes.
+// It is a helper for providers instrumenting remote fetches and reloads.
+func (repo *Repository) StartProviderSpan(ctx context.Context, name string, prov Provider) (context.Context, trace.Span) {
+	return repo.Tracer().Start(ctx, name, trace.WithAttributes(
+		attribute.String("config.provider.name", prov.Name()),
+		attribute.Int("config.provider.weight", prov.Weight()),
+	))
+}
+
+// endSpan records the error (if any) and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
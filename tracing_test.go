@@ -0,0 +1,96 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxTestProv struct {
+	*TestProv
+	err error
+}
+
+func (ctp *ctxTestProv) Name() string { return "ctxtest" }
+
+func (ctp *ctxTestProv) SetUpContext(ctx context.Context, repo *Repository) error {
+	_, span := repo.StartProviderSpan(ctx, "ctxtest.fetch", ctp)
+	span.End()
+	return ctp.err
+}
+
+func TestSetUpTracing(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantSpans []string
+		wantCode  codes.Code
+	}{
+		{
+			"Successful set up",
+			nil,
+			[]string{"config.SetUp", "config.provider.SetUp", "config.provider.SetUp", "ctxtest.fetch"},
+			codes.Unset,
+		},
+		{
+			"Failed set up",
+			fmt.Errorf("remote is unavailable"),
+			[]string{"config.SetUp", "config.provider.SetUp", "ctxtest.fetch"},
+			codes.Error,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			repo := NewRepository()
+			repo.SetTracerProvider(tp)
+			prov := &ctxTestProv{TestProv: NewTestProv(42, DefaultWeight), err: testCase.err}
+			repo.RegisterProvider(prov)
+			if testCase.err == nil {
+				repo.RegisterProvider(NewTestProv(42, DefaultWeight))
+			}
+
+			if err := repo.SetUpContext(context.Background()); err != testCase.err {
+				t.Fatalf("Unexpected set up error: got: %v, want: %v", err, testCase.err)
+			}
+
+			spans := recorder.Ended()
+			gotSpans := make([]string, 0, len(spans))
+			byID := make(map[trace.SpanID]sdktrace.ReadOnlySpan)
+			for _, span := range spans {
+				gotSpans = append(gotSpans, span.Name())
+				byID[span.SpanContext().SpanID()] = span
+			}
+			sort.Strings(gotSpans)
+			if !reflect.DeepEqual(gotSpans, testCase.wantSpans) {
+				t.Fatalf("Unexpected spans: got: %#v, want: %#v", gotSpans, testCase.wantSpans)
+			}
+			wantParents := map[string]string{
+				"config.provider.SetUp": "config.SetUp",
+				"ctxtest.fetch":         "config.provider.SetUp",
+			}
+			for _, span := range spans {
+				if span.Name() == "config.SetUp" {
+					if span.Status().Code != testCase.wantCode {
+						t.Fatalf("Unexpected root span status: got: %v, want: %v", span.Status().Code, testCase.wantCode)
+					}
+					continue
+				}
+				parent, ok := byID[span.Parent().SpanID()]
+				if !ok || parent.Name() != wantParents[span.Name()] {
+					t.Fatalf("Span %q is expected to be a child of %q", span.Name(), wantParents[span.Name()])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+// GetTree returns the subtree under the key as a nested map: e.g. for
+// `server.host` and `server.tls.cert` served by any providers, the tree of
+// `server` is:
+//
+//	map[string]Value{
+//		"host": "localhost",
+//		"tls":  map[string]Value{"cert": "/etc/cert.pem"},
+//	}
+//
+// Leaf values are resolved according to the provider precedence and mapped by
+// the schema, map leaf values are converted to map[string]Value. Unlike Get,
+// composite values are never mapped: the result is a plain map even if the
+// schema maps the subtree to a struct. An empty key returns the entire config
+// tree. If the key is a leaf, the result is its map value. Leaf values are
+// looked up through the middleware chain (see Use). Returns false if the key
+// is not registered or the leaf value is not a map.
+// This method is thread safe.
+func (repo *Repository) GetTree(key Key) (map[string]Value, bool) {
+	key = repo.aliasTarget(repo.foldKey(key))
+	// An empty key activates all lazy providers
+	repo.activateLazy(key)
+	repo.audit("", key)
+	ptr := repo.root.find(key)
+	if ptr == nil {
+		return nil, false
+	}
+	get := repo.getChain()
+	providers, children := ptr.view()
+	if len(providers) != 0 {
+		v, ok := get(key)
+		if !ok {
+			return nil, false
+		}
+		return toValueMap(v)
+	}
+	if len(children) == 0 {
+		return nil, false
+	}
+	return ptr.tree(repo, key, get), true
+}
+
+// tree returns the nested map of the node descendants values, the leaf values
+// are looked up by get.
+func (n *node) tree(repo *Repository, pref Key, get GetFunc) map[string]Value {
+	_, children := n.view()
+	res := make(map[string]Value, len(children))
+	for k, ch := range children {
+		key := make(Key, len(pref), len(pref)+1)
+		copy(key, pref)
+		key = append(key, k)
+		if providers, _ := ch.view(); len(providers) != 0 {
+			if v, ok := get(key); ok {
+				if m, ok := toValueMap(v); ok {
+					res[k] = m
+				} else {
+					res[k] = v
+				}
+			}
+		} else {
+			res[k] = ch.tree(repo, key, get)
+		}
+	}
+	repo.fillWildcardDefaults(pref, res)
+	return res
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetTree(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"server": map[string]Schema{
+			"__self__": NewTestMapper(func(kv *KeyValue) (*KeyValue, error) {
+				return &KeyValue{Key: kv.Key, Value: "mapped"}, nil
+			}),
+			"port": ToInt,
+		},
+	})
+	NewMapProvider(repo, 10, "low", map[string]Value{
+		"server.host":     "localhost",
+		"server.port":     "80",
+		"server.tls.cert": "/etc/cert.pem",
+		"labels":          map[string]interface{}{"env": "dev"},
+	})
+	NewMapProvider(repo, 20, "high", map[string]Value{
+		"server.port": "8080",
+		"debug":       true,
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	server := map[string]Value{
+		"host": "localhost",
+		"port": 8080,
+		"tls":  map[string]Value{"cert": "/etc/cert.pem"},
+	}
+	tests := []struct {
+		name   string
+		key    string
+		want   map[string]Value
+		wantOk bool
+	}{
+		{"subtree", "server", server, true},
+		{"nested subtree", "server.tls", map[string]Value{"cert": "/etc/cert.pem"}, true},
+		{"map leaf", "labels", map[string]Value{"env": "dev"}, true},
+		{
+			"entire tree",
+			"",
+			map[string]Value{
+				"server": server,
+				"labels": map[string]Value{"env": "dev"},
+				"debug":  true,
+			},
+			true,
+		},
+		{"scalar leaf", "debug", nil, false},
+		{"missing key", "client", nil, false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, ok := repo.GetTree(NewKey(testCase.key))
+			if ok != testCase.wantOk {
+				t.Fatalf("Unexpected lookup result: got: %t, want: %t", ok, testCase.wantOk)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected tree: got: %#v, want: %#v", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ByteSize is a size in bytes.
+type ByteSize int64
+
+// Decimal and binary byte size units.
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"":    Byte,
+	"b":   Byte,
+	"k":   KiB,
+	"kb":  KB,
+	"kib": KiB,
+	"m":   MiB,
+	"mb":  MB,
+	"mib": MiB,
+	"g":   GiB,
+	"gb":  GB,
+	"gib": GiB,
+	"t":   TiB,
+	"tb":  TB,
+	"tib": TiB,
+}
+
+// ParseByteSize parses a byte size string like "512", "64KB", "1.5GiB" or
+// "10M". Units are case-insensitive: KB, MB, GB and TB are decimal (powers
+// of 1000), KiB, MiB, GiB and TiB as well as the single letter units K, M, G
+// and T are binary (powers of 1024). A number without a unit is a number of
+// bytes.
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.TrimSpace(s)
+	ix := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if ix < 0 {
+		ix = len(str)
+	}
+	unit, ok := byteSizeUnits[strings.ToLower(strings.TrimSpace(str[ix:]))]
+	if !ok {
+		return 0, fmt.Errorf("Failed to parse byte size %q: unknown unit", s)
+	}
+	n, err := strconv.ParseFloat(str[:ix], 64)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to parse byte size %q: malformed number", s)
+	}
+	return scaleByteSize(n, unit)
+}
+
+func scaleByteSize(n float64, unit ByteSize) (ByteSize, error) {
+	size := n * float64(unit)
+	if size < 0 || size >= math.MaxInt64 {
+		return 0, fmt.Errorf("Byte size %v is out of range", n)
+	}
+	return ByteSize(size), nil
+}
+
+// String returns the size in the largest binary unit it is a multiple of,
+// e.g. "64MiB", or in bytes, e.g. "1000B".
+func (bs ByteSize) String() string {
+	for _, u := range []struct {
+		size ByteSize
+		name string
+	}{{TiB, "TiB"}, {GiB, "GiB"}, {MiB, "MiB"}, {KiB, "KiB"}} {
+		if bs != 0 && bs%u.size == 0 {
+			return fmt.Sprintf("%d%s", bs/u.size, u.name)
+		}
+	}
+	return fmt.Sprintf("%dB", int64(bs))
+}
+
+// DurationConverter converts duration strings with a unit suffix (see
+// time.ParseDuration) and bare numbers into time.Duration. Bare numbers,
+// either YAML numbers or numeric strings, are counted in Unit. An explicit
+// unit saves the readers of a config the guess whether `timeout: 30` means
+// seconds or milliseconds.
+type DurationConverter struct {
+	// Unit is the unit of bare numbers. 0 stands for time.Second.
+	Unit time.Duration
+}
+
+var _ Converter = (*DurationConverter)(nil)
+var _ Mapper = (*DurationConverter)(nil)
+
+// ToDuration is an initialized instance of DurationConverter counting bare
+// numbers in seconds. It is registered as the converter for the time.Duration
+// type (see ConverterFor).
+//
+// Example:
+//
+//	repo.DefineSchema(map[string]config.Schema{
+//		"server.timeout":  config.ToDuration,
+//		"server.poll_lag": config.DurationIn(time.Millisecond),
+//	})
+var ToDuration = &DurationConverter{Unit: time.Second}
+
+// DurationIn returns a DurationConverter counting bare numbers in the unit.
+func DurationIn(unit time.Duration) *DurationConverter {
+	return &DurationConverter{Unit: unit}
+}
+
+// Convert returns the time.Duration value and true if the value is a
+// duration, a number or a duration string.
+func (dc *DurationConverter) Convert(kv *KeyValue) (*KeyValue, bool) {
+	mkv, err := dc.Map(kv)
+	return mkv, err == nil
+}
+
+// Map converts the value. Returns an error describing the malformed setting.
+func (dc *DurationConverter) Map(kv *KeyValue) (*KeyValue, error) {
+	unit := dc.Unit
+	if unit == 0 {
+		unit = time.Second
+	}
+	if d, ok := kv.Value.(time.Duration); ok {
+		return &KeyValue{Key: kv.Key, Value: d}, nil
+	}
+	if s, ok := kv.Value.(string); ok {
+		if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil {
+			return &KeyValue{Key: kv.Key, Value: d}, nil
+		}
+	}
+	n, ok := toFloat(kv.Value)
+	if !ok {
+		return nil, fmt.Errorf("Failed to convert %T value for key %q: want a duration", kv.Value, kv.Key)
+	}
+	d := n * float64(unit)
+	if math.Abs(d) >= math.MaxInt64 {
+		return nil, fmt.Errorf("Duration %v%s for key %q is out of range", n, unitName(unit), kv.Key)
+	}
+	return &KeyValue{Key: kv.Key, Value: time.Duration(d)}, nil
+}
+
+// JSONSchema describes the accepted values: a number or a string
+func (dc *DurationConverter) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"anyOf": []interface{}{jsonType("number"), jsonType("string")}}
+}
+
+func unitName(unit time.Duration) string {
+	return strings.TrimPrefix(unit.String(), "1")
+}
+
+// ByteSizeConverter converts byte size strings (see ParseByteSize) and bare
+// numbers into ByteSize. Bare numbers, either YAML numbers or numeric
+// strings, are counted in Unit.
+type ByteSizeConverter struct {
+	// Unit is the unit of bare numbers. 0 stands for Byte.
+	Unit ByteSize
+}
+
+var _ Converter = (*ByteSizeConverter)(nil)
+var _ Mapper = (*ByteSizeConverter)(nil)
+
+// ToByteSize is an initialized instance of ByteSizeConverter counting bare
+// numbers in bytes. It is registered as the converter for the ByteSize type
+// (see ConverterFor).
+var ToByteSize = &ByteSizeConverter{Unit: Byte}
+
+// ByteSizeIn returns a ByteSizeConverter counting bare numbers in the unit.
+func ByteSizeIn(unit ByteSize) *ByteSizeConverter {
+	return &ByteSizeConverter{Unit: unit}
+}
+
+// Convert returns the ByteSize value and true if the value is a byte size,
+// a number or a byte size string.
+func (bc *ByteSizeConverter) Convert(kv *KeyValue) (*KeyValue, bool) {
+	mkv, err := bc.Map(kv)
+	return mkv, err == nil
+}
+
+// Map converts the value. Returns an error describing the malformed setting.
+func (bc *ByteSizeConverter) Map(kv *KeyValue) (*KeyValue, error) {
+	unit := bc.Unit
+	if unit == 0 {
+		unit = Byte
+	}
+	if bs, ok := kv.Value.(ByteSize); ok {
+		return &KeyValue{Key: kv.Key, Value: bs}, nil
+	}
+	if n, ok := toFloat(kv.Value); ok {
+		bs, err := scaleByteSize(n, unit)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to convert byte size for key %q: %s", kv.Key, err)
+		}
+		return &KeyValue{Key: kv.Key, Value: bs}, nil
+	}
+	s, ok := kv.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("Failed to convert %T value for key %q: want a byte size", kv.Value, kv.Key)
+	}
+	bs, err := ParseByteSize(s)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyValue{Key: kv.Key, Value: bs}, nil
+}
+
+// JSONSchema describes the accepted values: a number or a string
+func (bc *ByteSizeConverter) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"anyOf": []interface{}{jsonType("number"), jsonType("string")}}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ByteSize
+		wantErr bool
+	}{
+		{"512", 512 * Byte, false},
+		{"64KB", 64 * KB, false},
+		{"64kib", 64 * KiB, false},
+		{" 1.5 GiB ", 3 * GiB / 2, false},
+		{"10M", 10 * MiB, false},
+		{"2TB", 2 * TB, false},
+		{"10 bytes", 0, true},
+		{"GB", 0, true},
+		{"-1KB", 0, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.input, func(t *testing.T) {
+			got, err := ParseByteSize(testCase.input)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want error: %t", err, testCase.wantErr)
+			}
+			if got != testCase.want {
+				t.Fatalf("Unexpected byte size: got: %d, want: %d", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	for size, want := range map[ByteSize]string{
+		0:        "0B",
+		1000:     "1000B",
+		64 * KiB: "64KiB",
+		3 * GiB:  "3GiB",
+		2 * TiB:  "2TiB",
+	} {
+		if got := size.String(); got != want {
+			t.Fatalf("Unexpected byte size string: got: %q, want: %q", got, want)
+		}
+	}
+}
+
+func TestDurationConverter(t *testing.T) {
+	tests := []struct {
+		name    string
+		conv    *DurationConverter
+		value   Value
+		want    time.Duration
+		wantErr string
+	}{
+		{"duration string", ToDuration, "1m30s", 90 * time.Second, ""},
+		{"bare int", ToDuration, 30, 30 * time.Second, ""},
+		{"bare float", ToDuration, 1.5, 1500 * time.Millisecond, ""},
+		{"numeric string", ToDuration, "45", 45 * time.Second, ""},
+		{"duration", ToDuration, time.Minute, time.Minute, ""},
+		{"milliseconds", DurationIn(time.Millisecond), 250, 250 * time.Millisecond, ""},
+		{"suffix overrides the unit", DurationIn(time.Millisecond), "2s", 2 * time.Second, ""},
+		{"zero unit", &DurationConverter{}, int64(5), 5 * time.Second, ""},
+		{"malformed", ToDuration, "soon", 0, "want a duration"},
+		{"out of range", DurationIn(time.Hour), 1e10, 0, "out of range"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			kv, err := testCase.conv.Map(&KeyValue{Key: NewKey("timeout"), Value: testCase.value})
+			if len(testCase.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if kv.Value != testCase.want {
+				t.Fatalf("Unexpected duration: got: %#v, want: %#v", kv.Value, testCase.want)
+			}
+		})
+	}
+}
+
+func TestByteSizeConverter(t *testing.T) {
+	tests := []struct {
+		name    string
+		conv    *ByteSizeConverter
+		value   Value
+		want    ByteSize
+		wantErr string
+	}{
+		{"size string", ToByteSize, "16MiB", 16 * MiB, ""},
+		{"bare int", ToByteSize, 4096, 4 * KiB, ""},
+		{"numeric string", ToByteSize, "100", 100, ""},
+		{"byte size", ToByteSize, 2 * GB, 2 * GB, ""},
+		{"kilobytes", ByteSizeIn(KiB), 64, 64 * KiB, ""},
+		{"suffix overrides the unit", ByteSizeIn(KiB), "1MB", MB, ""},
+		{"negative", ToByteSize, -1, 0, "out of range"},
+		{"malformed", ToByteSize, "big", 0, "unknown unit"},
+		{"not a size", ToByteSize, true, 0, "want a byte size"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			kv, err := testCase.conv.Map(&KeyValue{Key: NewKey("size"), Value: testCase.value})
+			if len(testCase.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if kv.Value != testCase.want {
+				t.Fatalf("Unexpected byte size: got: %#v, want: %#v", kv.Value, testCase.want)
+			}
+		})
+	}
+}
+
+func TestUnitsSchema(t *testing.T) {
+	repo := NewRepository()
+	NewMapProvider(repo, 0, "yaml", map[string]Value{
+		"server.timeout":      30,
+		"server.poll_lag":     "250",
+		"server.max_body":     "8MiB",
+		"server.buffer":       64,
+		"server.idle_timeout": "1m",
+	})
+	if err := repo.DefineSchema(map[string]Schema{
+		"server.timeout":      ToDuration,
+		"server.poll_lag":     DurationIn(time.Millisecond),
+		"server.max_body":     ToByteSize,
+		"server.buffer":       ByteSizeIn(KiB),
+		"server.idle_timeout": ToDuration,
+	}); err != nil {
+		t.Fatalf("Failed to define the schema: %s", err)
+	}
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	for key, want := range map[string]Value{
+		"server.timeout":      30 * time.Second,
+		"server.poll_lag":     250 * time.Millisecond,
+		"server.max_body":     8 * MiB,
+		"server.buffer":       64 * KiB,
+		"server.idle_timeout": time.Minute,
+	} {
+		if got, _ := repo.Get(NewKey(key)); got != want {
+			t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Redefined in tests
+var exit = os.Exit
+
+// Validate is a dry run of New: it builds a repository with the schema and
+// the providers configured by the options, sets it up and checks every
+// value. Returns all the problems found: a set up failure, the keys not
+// covered by the schema (if the schema is not nil) and the mapper failures.
+// Returns nil if the config is valid. The repository is torn down before
+// Validate returns.
+func Validate(schema Schema, opts ...Option) []error {
+	b := NewBuilder(opts...)
+	if schema != nil {
+		b.With(WithSchema(schema))
+	}
+	repo, err := b.Build()
+	if err != nil {
+		return []error{err}
+	}
+	defer repo.TearDown()
+	// Unknown keys are reported along with the rest of the errors
+	repo.options.Strict = false
+	if err := repo.SetUp(); err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	if schema != nil {
+		for _, key := range repo.UnknownKeys() {
+			errs = append(errs, fmt.Errorf("Unexpected config key %q not defined in the schema", key))
+		}
+	}
+	repo.mx.Lock()
+	keys := repo.root.keys(nil)
+	repo.mx.Unlock()
+	sort.Slice(keys, func(a, b int) bool {
+		return keys[a].String() < keys[b].String()
+	})
+	for _, key := range keys {
+		if _, _, err := Lookup(repo, repo.KeyString(key)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// ValidateAndExit runs Validate and terminates the program: the errors are
+// printed to stderr and the exit code is 1 if the config is invalid, 0
+// otherwise. It is meant to back a `--validate-config` flag, e.g. in a CI
+// pipeline:
+//
+//	validateOnly := flag.Bool("validate-config", false, "validate the config and exit")
+//	flag.Parse()
+//	if *validateOnly {
+//		config.ValidateAndExit(schema, opts...)
+//	}
+//	cfg, err := config.New(append(opts, config.WithSchema(schema))...)
+func ValidateAndExit(schema Schema, opts ...Option) {
+	exit(printValidation(os.Stdout, os.Stderr, Validate(schema, opts...)))
+}
+
+// printValidation reports the validation result and returns the exit code.
+func printValidation(stdout, stderr io.Writer, errs []error) int {
+	if len(errs) == 0 {
+		fmt.Fprintln(stdout, "config is valid")
+		return 0
+	}
+	for _, err := range errs {
+		fmt.Fprintln(stderr, err)
+	}
+	return 1
+}
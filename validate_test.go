@@ -0,0 +1,91 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	schema := map[string]Schema{
+		"server": map[string]Schema{
+			"port": ToInt,
+			"host": ToStr,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		schema   Schema
+		opts     []Option
+		wantErrs []string
+	}{
+		{
+			name:   "valid",
+			schema: schema,
+			opts:   []Option{WithDefaults(map[string]Value{"server.port": "8080", "server.host": "localhost"})},
+		},
+		{
+			name:   "invalid",
+			schema: schema,
+			opts: []Option{
+				WithRepositoryOptions(RepositoryOptions{Strict: true}),
+				WithDefaults(map[string]Value{"server.port": "http", "server.hots": "localhost"}),
+			},
+			wantErrs: []string{
+				`Unexpected config key "server.hots" not defined in the schema`,
+				`Failed to map the value "http" for key "server.port" provided by "default"`,
+			},
+		},
+		{
+			name:     "no schema",
+			opts:     []Option{WithDefaults(map[string]Value{"server.hots": "localhost"})},
+			wantErrs: nil,
+		},
+		{
+			name:   "set up failure",
+			schema: schema,
+			opts: []Option{WithProvider(func(repo *Repository) error {
+				repo.RegisterProvider(&flakyTestProv{err: errors.New("unreachable")})
+				return nil
+			})},
+			wantErrs: []string{"unreachable"},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			errs := Validate(testCase.schema, testCase.opts...)
+			if len(errs) != len(testCase.wantErrs) {
+				t.Fatalf("Unexpected errors: got: %v, want: %q", errs, testCase.wantErrs)
+			}
+			for ix, err := range errs {
+				if !strings.Contains(err.Error(), testCase.wantErrs[ix]) {
+					t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErrs[ix])
+				}
+			}
+		})
+	}
+}
+
+func TestPrintValidation(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := printValidation(&stdout, &stderr, nil); code != 0 || stdout.String() != "config is valid\n" {
+		t.Fatalf("Unexpected result: code: %d, stdout: %q", code, stdout.String())
+	}
+	stdout.Reset()
+	errs := []error{errors.New("first"), errors.New("second")}
+	if code := printValidation(&stdout, &stderr, errs); code != 1 || stderr.String() != "first\nsecond\n" {
+		t.Fatalf("Unexpected result: code: %d, stderr: %q", code, stderr.String())
+	}
+
+	oldExit := exit
+	defer func() { exit = oldExit }()
+	var gotCode int
+	exit = func(code int) { gotCode = code }
+	ValidateAndExit(map[string]Schema{"port": ToInt}, WithDefaults(map[string]Value{"port": "http"}))
+	if gotCode != 1 {
+		t.Fatalf("Unexpected exit code: got: %d, want: %d", gotCode, 1)
+	}
+}
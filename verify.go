@@ -0,0 +1,99 @@
+package config
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Verifier checks the integrity of a config document before it is applied
+// (see YamlProviderOptions.Verifier). A tampered document is rejected with an
+// error wrapping ErrIntegrity.
+type Verifier interface {
+	Verify(data []byte) error
+}
+
+// VerifierFunc is a function adapter for Verifier.
+type VerifierFunc func(data []byte) error
+
+var _ Verifier = VerifierFunc(nil)
+
+// Verify satisfies Verifier interface.
+func (f VerifierFunc) Verify(data []byte) error {
+	return f(data)
+}
+
+// NewChecksumVerifier returns a Verifier accepting the documents with the
+// SHA-256 checksum. The checksum is hex-encoded, e.g. the `sha256sum` output.
+func NewChecksumVerifier(checksum string) (Verifier, error) {
+	want, err := hex.DecodeString(strings.TrimSpace(checksum))
+	if err != nil || len(want) != sha256.Size {
+		return nil, fmt.Errorf("Malformed SHA-256 checksum %q", checksum)
+	}
+	return VerifierFunc(func(data []byte) error {
+		got := sha256.Sum256(data)
+		if !bytes.Equal(got[:], want) {
+			return fmt.Errorf("%w: SHA-256 checksum mismatch: got: %x, want: %x", ErrIntegrity, got, want)
+		}
+		return nil
+	}), nil
+}
+
+// NewSignatureVerifier returns a Verifier accepting the documents the
+// detached Ed25519 signature is valid for. The signature is either raw or
+// base64-encoded.
+func NewSignatureVerifier(publicKey ed25519.PublicKey, signature []byte) (Verifier, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("Malformed Ed25519 public key: got %d bytes, want: %d", len(publicKey), ed25519.PublicKeySize)
+	}
+	sig, err := decodeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	return VerifierFunc(func(data []byte) error {
+		if !ed25519.Verify(publicKey, data, sig) {
+			return fmt.Errorf("%w: invalid Ed25519 signature", ErrIntegrity)
+		}
+		return nil
+	}), nil
+}
+
+// NewSignatureFileVerifier is NewSignatureVerifier reading the detached
+// signature from the file, e.g. `config.yaml.sig`. The file is read on every
+// verification, so a config file and its signature might be replaced
+// together while the provider is watching.
+func NewSignatureFileVerifier(publicKey ed25519.PublicKey, fsys fs.FS, path string) (Verifier, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("Malformed Ed25519 public key: got %d bytes, want: %d", len(publicKey), ed25519.PublicKeySize)
+	}
+	if fsys == nil {
+		fsys = OSFS
+	}
+	return VerifierFunc(func(data []byte) error {
+		signature, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("%w: failed to read signature file %q: %s", ErrIntegrity, path, err)
+		}
+		verifier, err := NewSignatureVerifier(publicKey, signature)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrIntegrity, err)
+		}
+		return verifier.Verify(data)
+	}), nil
+}
+
+func decodeSignature(signature []byte) ([]byte, error) {
+	if len(signature) == ed25519.SignatureSize {
+		return signature, nil
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("Malformed Ed25519 signature")
+	}
+	return sig, nil
+}
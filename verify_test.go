@@ -0,0 +1,117 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestVerifiers(t *testing.T) {
+	doc := []byte("server:\n  port: 8080\n")
+	tampered := []byte("server:\n  port: 6666\n")
+	sum := sha256.Sum256(doc)
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate a key: %s", err)
+	}
+	sig := ed25519.Sign(priv, doc)
+	b64Sig := []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+
+	checksum, err := NewChecksumVerifier(hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	rawSig, err := NewSignatureVerifier(pub, sig)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	encSig, err := NewSignatureVerifier(pub, b64Sig)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	fileSig, err := NewSignatureFileVerifier(pub, fstest.MapFS{
+		"config.yaml.sig": &fstest.MapFile{Data: b64Sig},
+	}, "config.yaml.sig")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	missingSig, err := NewSignatureFileVerifier(pub, fstest.MapFS{}, "config.yaml.sig")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		verifier Verifier
+		data     []byte
+		wantErr  bool
+	}{
+		{"checksum", checksum, doc, false},
+		{"checksum mismatch", checksum, tampered, true},
+		{"raw signature", rawSig, doc, false},
+		{"raw signature mismatch", rawSig, tampered, true},
+		{"base64 signature", encSig, doc, false},
+		{"signature file", fileSig, doc, false},
+		{"signature file mismatch", fileSig, tampered, true},
+		{"missing signature file", missingSig, doc, true},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.verifier.Verify(testCase.data)
+			if testCase.wantErr != (err != nil) {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err != nil && !errors.Is(err, ErrIntegrity) {
+				t.Fatalf("Unexpected error: got: %v, want it to wrap: %v", err, ErrIntegrity)
+			}
+		})
+	}
+
+	if _, err := NewChecksumVerifier("abc"); err == nil {
+		t.Fatalf("Expected an error for a malformed checksum, got nil")
+	}
+	if _, err := NewSignatureVerifier(pub[:10], sig); err == nil {
+		t.Fatalf("Expected an error for a malformed public key, got nil")
+	}
+	if _, err := NewSignatureVerifier(pub, []byte("garbage")); err == nil {
+		t.Fatalf("Expected an error for a malformed signature, got nil")
+	}
+}
+
+func TestYamlProviderVerifier(t *testing.T) {
+	doc := []byte("server:\n  port: 8080\n")
+	sum := sha256.Sum256(doc)
+	verifier, err := NewChecksumVerifier(hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	fsys := fstest.MapFS{"config.yaml": &fstest.MapFile{Data: doc}}
+
+	repo := NewRepository()
+	prov, _ := NewYamlProviderFromSource(repo, 0, &YamlProviderOptions{FS: fsys, Verifier: verifier}, "config.yaml")
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	// A tampered file is rejected on reload, the previous values are kept
+	fsys["config.yaml"] = &fstest.MapFile{Data: []byte("server:\n  port: 6666\n")}
+	if err := prov.Reload(); !errors.Is(err, ErrIntegrity) {
+		t.Fatalf("Unexpected reload error: got: %v, want it to wrap: %v", err, ErrIntegrity)
+	}
+	if got, _ := repo.Get(NewKey("server.port")); got != 8080 {
+		t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", "server.port", got, 8080)
+	}
+
+	repo = NewRepository()
+	NewYamlProviderFromSource(repo, 0, &YamlProviderOptions{FS: fsys, Verifier: verifier}, "config.yaml")
+	wantErr := `Failed to verify yaml config file "config.yaml"`
+	if err := repo.SetUp(); err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, wantErr)
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"context"
+	"sync"
+)
+
+// Watcher is an optional provider capability: a provider implementing it
+// pushes value changes to the repository. Watch is called once the provider
+// is set up. It is expected to return right away and to send a ChangeSet to
+// the channel every time the provider values change, until the provider is
+// torn down. The provider is expected to serve the new values by the moment
+// the change set is sent. Change keys are the provider keys, Old and New are
+// the values served by the provider before and after the change. Change sets
+// sent while the provider is being torn down are discarded.
+type Watcher interface {
+	Watch(ch chan<- ChangeSet)
+}
+
+// Refresher is an optional provider capability: a provider implementing it
+// re-reads its source on demand (see Repository.RefreshProvider).
+type Refresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// watch consumes the change sets sent by a Watcher provider.
+type watch struct {
+	done     chan struct{}
+	draining chan struct{}
+	released chan struct{}
+	wg       sync.WaitGroup
+}
+
+// startWatch starts consuming the change sets sent by the provider.
+func (repo *Repository) startWatch(prov Provider, w Watcher) {
+	repo.viewMx.RLock()
+	before := repo.snapshot(prov)
+	repo.viewMx.RUnlock()
+	ch := make(chan ChangeSet)
+	wt := &watch{
+		done:     make(chan struct{}),
+		draining: make(chan struct{}),
+		released: make(chan struct{}),
+	}
+	repo.mx.Lock()
+	repo.watches[prov] = wt
+	repo.mx.Unlock()
+	wt.wg.Add(1)
+	go func() {
+		defer wt.wg.Done()
+		for {
+			select {
+			case cs := <-ch:
+				before = repo.applyChangeSet(prov, cs, before)
+			case <-wt.done:
+				// The provider might be sending a change set while being
+				// torn down: drain the channel so it is never blocked.
+				close(wt.draining)
+				for {
+					select {
+					case <-ch:
+					case <-wt.released:
+						return
+					}
+				}
+			}
+		}
+	}()
+	w.Watch(ch)
+}
+
+// stopWatch stops applying the change sets sent by the provider. The change
+// sets are discarded until the returned function is called: it is expected to
+// be called once the provider is torn down. Is a no-op if the provider is not
+// watched.
+func (repo *Repository) stopWatch(prov Provider) func() {
+	repo.mx.Lock()
+	wt, ok := repo.watches[prov]
+	delete(repo.watches, prov)
+	repo.mx.Unlock()
+	if !ok {
+		return func() {}
+	}
+	close(wt.done)
+	<-wt.draining
+	return func() {
+		close(wt.released)
+		wt.wg.Wait()
+	}
+}
+
+// applyChangeSet registers the keys added by the provider and notifies the
+// subscribers on the effective value diff. Unlike ApplyReload, the provider
+// already serves the new values: these are validated but can not be rolled
+// back. Returns the effective values of the provider keys.
+func (repo *Repository) applyChangeSet(prov Provider, cs ChangeSet, before map[string]Value) map[string]Value {
+	repo.viewMx.Lock()
+	for _, change := range cs.Changes {
+		if change.New != nil {
+			repo.RegisterKey(change.Key, prov)
+		}
+	}
+	err := repo.validateReload(prov)
+	after := repo.snapshot(prov)
+	repo.viewMx.Unlock()
+	repo.setReloadError(prov, err)
+	if err != nil {
+		repo.Logger().Errorf("%s", err)
+		repo.notify(&ChangeEvent{
+			ChangeSet: ChangeSet{Provider: prov.Name(), Changes: []Change{}},
+			Err:       err,
+		})
+		return after
+	}
+
+	repo.Metrics().Reload(prov.Name())
+	event := &ChangeEvent{
+		ChangeSet: ChangeSet{
+			Provider: prov.Name(),
+			Changes:  diffSnapshots(before, after),
+		},
+	}
+	repo.Logger().Infof("Applied config provider %q changes: %d key(s) changed", prov.Name(), len(event.Changes))
+	if len(event.Changes) > 0 {
+		repo.notify(event)
+	}
+	return after
+}
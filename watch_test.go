@@ -0,0 +1,203 @@
+package config
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type watchTestProv struct {
+	reloadTestProv
+	ch chan<- ChangeSet
+}
+
+var _ Watcher = (*watchTestProv)(nil)
+
+func (wtp *watchTestProv) Name() string { return "watch" }
+
+func (wtp *watchTestProv) SetUp(repo *Repository) error {
+	for k := range wtp.registry {
+		if err := repo.RegisterKey(NewKey(k), wtp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (wtp *watchTestProv) Watch(ch chan<- ChangeSet) { wtp.ch = ch }
+
+// set updates the registry and sends the change set.
+func (wtp *watchTestProv) set(key string, v Value) {
+	wtp.mx.Lock()
+	old := wtp.registry[key]
+	if v == nil {
+		delete(wtp.registry, key)
+	} else {
+		wtp.registry[key] = v
+	}
+	wtp.mx.Unlock()
+	wtp.ch <- ChangeSet{Changes: []Change{{Key: NewKey(key), Old: old, New: v}}}
+}
+
+func TestWatcher(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{"port": ToInt})
+	prov := &watchTestProv{}
+	prov.registry = map[string]Value{"host": "localhost"}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	if prov.ch == nil {
+		t.Fatalf("Expected Watch to be called on set up")
+	}
+	events := make(chan *ChangeEvent, 1)
+	repo.Subscribe(func(event *ChangeEvent) { events <- event })
+
+	tests := []struct {
+		name    string
+		key     string
+		value   Value
+		want    []Change
+		wantErr bool
+	}{
+		{
+			"added key",
+			"port",
+			"8080",
+			[]Change{{Key: NewKey("port"), Old: nil, New: 8080}},
+			false,
+		},
+		{
+			"updated key",
+			"host",
+			"example.com",
+			[]Change{{Key: NewKey("host"), Old: "localhost", New: "example.com"}},
+			false,
+		},
+		{
+			"deleted key",
+			"host",
+			nil,
+			[]Change{{Key: NewKey("host"), Old: "example.com", New: nil}},
+			false,
+		},
+		{
+			"invalid value",
+			"port",
+			"abc",
+			[]Change{},
+			true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			prov.set(testCase.key, testCase.value)
+			event := <-events
+			if (event.Err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected event error: got: %v, want error: %t", event.Err, testCase.wantErr)
+			}
+			if !reflect.DeepEqual(event.Changes, testCase.want) {
+				t.Fatalf("Unexpected changes: got: %#v, want: %#v", event.Changes, testCase.want)
+			}
+		})
+	}
+
+	if err := repo.TearDown(); err != nil {
+		t.Fatalf("Failed to tear down the repository: %s", err)
+	}
+	repo.mx.Lock()
+	defer repo.mx.Unlock()
+	if len(repo.watches) != 0 {
+		t.Fatalf("Expected the watches to be stopped on tear down")
+	}
+}
+
+type refreshTestProv struct {
+	reloadTestProv
+	refreshed bool
+}
+
+var _ Refresher = (*refreshTestProv)(nil)
+
+func (rtp *refreshTestProv) Refresh(context.Context) error {
+	rtp.refreshed = true
+	return nil
+}
+
+func TestRefresher(t *testing.T) {
+	repo := NewRepository()
+	prov := &refreshTestProv{}
+	repo.RegisterProvider(prov)
+	if err := repo.RefreshProvider(context.Background(), prov.Name()); err != nil {
+		t.Fatalf("Failed to refresh the provider: %s", err)
+	}
+	if !prov.refreshed {
+		t.Fatalf("Expected the provider to be refreshed")
+	}
+}
+
+// sendingTestProv keeps sending change sets until torn down.
+type sendingTestProv struct {
+	reloadTestProv
+	sent chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+var _ Watcher = (*sendingTestProv)(nil)
+
+func (stp *sendingTestProv) Name() string { return "sending" }
+
+func (stp *sendingTestProv) Watch(ch chan<- ChangeSet) {
+	stp.sent = make(chan struct{})
+	stp.done = make(chan struct{})
+	stp.wg.Add(1)
+	go func() {
+		defer stp.wg.Done()
+		for {
+			select {
+			case <-stp.done:
+				return
+			default:
+			}
+			ch <- ChangeSet{Changes: []Change{}}
+			select {
+			case <-stp.sent:
+			default:
+				close(stp.sent)
+			}
+		}
+	}()
+}
+
+func (stp *sendingTestProv) TearDown(*Repository) error {
+	// The sender might be blocked sending a change set
+	close(stp.done)
+	stp.wg.Wait()
+	return nil
+}
+
+func TestWatcherTearDownWhileSending(t *testing.T) {
+	repo := NewRepository()
+	prov := &sendingTestProv{}
+	prov.registry = map[string]Value{"host": "localhost"}
+	repo.RegisterProvider(prov)
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	<-prov.sent
+	done := make(chan error)
+	go func() { done <- repo.TearDown() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Failed to tear down the repository: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out tearing down the repository")
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+// ProviderWrapper is implemented by providers composing other providers, e.g.
+// FallbackProvider. Once a wrapper is registered in the repository, the
+// wrapped providers are no longer set up by the repository: this is the
+// wrapper's responsibility. Keys registered by the wrapped providers are
+// registered on behalf of the wrapper (see `WrapKey`), reloads applied by the
+// wrapped providers are processed on behalf of the wrapper as well.
+type ProviderWrapper interface {
+	Provider
+	// Unwrap returns the list of wrapped providers.
+	Unwrap() []Provider
+	// WrapKey translates a key registered by a wrapped provider to the key
+	// the wrapper serves it under.
+	WrapKey(Key) Key
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// FileFormat is a config file format.
+type FileFormat string
+
+const (
+	// FormatYAML stands for YAML files.
+	FormatYAML FileFormat = "yaml"
+	// FormatJSON stands for JSON files.
+	FormatJSON FileFormat = "json"
+)
+
+// WriteFileOptions is a set of WriteFile settings.
+type WriteFileOptions struct {
+	// OverridesOnly skips the keys served by default values: a
+	// DefaultProvider or the schema defaults (see DefaultValue). Only the
+	// keys overridden by other providers are written.
+	OverridesOnly bool
+}
+
+// WriteFile persists the effective configuration as a nested document, e.g. in
+// order to freeze the current runtime config. Values are written as served by
+// the providers: value references (see RegisterResolver) are not resolved and
+// the values are not mapped by the schema, which makes the file suitable for
+// a YamlProvider. The file is replaced atomically and is only readable by the
+// owner.
+func (repo *Repository) WriteFile(path string, format FileFormat) error {
+	return repo.WriteFileWithOptions(path, format, &WriteFileOptions{})
+}
+
+// WriteFileWithOptions is a version of WriteFile accepting options.
+func (repo *Repository) WriteFileWithOptions(path string, format FileFormat, options *WriteFileOptions) error {
+	var marshal func(interface{}) ([]byte, error)
+	switch format {
+	case FormatYAML:
+		marshal = yaml.Marshal
+	case FormatJSON:
+		marshal = func(v interface{}) ([]byte, error) {
+			return json.MarshalIndent(v, "", "  ")
+		}
+	default:
+		return fmt.Errorf("Unsupported config file format %q", format)
+	}
+	data, err := marshal(repo.effectiveTree(options.OverridesOnly))
+	if err != nil {
+		return fmt.Errorf("Failed to marshal the config: %s", err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic replaces the file with the data atomically. The file is only
+// readable by the owner.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("Failed to write config file %q: %s", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write config file %q: %s", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Failed to write config file %q: %s", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("Failed to write config file %q: %s", path, err)
+	}
+	return nil
+}
+
+// effectiveTree returns the nested map of the effective raw values.
+func (repo *Repository) effectiveTree(overridesOnly bool) map[string]interface{} {
+	repo.mx.Lock()
+	keys := repo.root.keys(nil)
+	repo.mx.Unlock()
+	// Parents go first: these take precedence over the children
+	sort.Slice(keys, func(a, b int) bool {
+		return keys[a].String() < keys[b].String()
+	})
+	repo.viewMx.RLock()
+	defer repo.viewMx.RUnlock()
+	res := make(map[string]interface{})
+keys:
+	for _, key := range keys {
+		v, prov, ok := repo.root.find(key).rawValue(repo, key, key)
+		if !ok || (overridesOnly && isDefaults(prov)) {
+			continue
+		}
+		ptr := res
+		for _, k := range key[:len(key)-1] {
+			next, ok := ptr[k]
+			if !ok {
+				next = make(map[string]interface{})
+				ptr[k] = next
+			}
+			m, ok := next.(map[string]interface{})
+			if !ok {
+				continue keys
+			}
+			ptr = m
+		}
+		ptr[key[len(key)-1]] = v
+	}
+	return res
+}
+
+// isDefaults returns true if the provider serves default values.
+func isDefaults(prov Provider) bool {
+	switch prov.(type) {
+	case *DefaultProvider, *schemaDefaults:
+		return true
+	}
+	return false
+}
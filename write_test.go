@@ -0,0 +1,85 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	repo := NewRepository()
+	repo.DefineSchema(map[string]Schema{
+		"server": map[string]Schema{
+			"port":    ToInt,
+			"timeout": DefaultValue(ToStr, "30s"),
+		},
+	})
+	NewDefaultProviderWithDefaults(repo, 10, map[string]Value{
+		"server.host": "localhost",
+		"server.port": 80,
+	})
+	NewMapProvider(repo, 20, "map", map[string]Value{
+		"server.port": "8080",
+		"secret":      "base64:Zm9v",
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+
+	tests := []struct {
+		name    string
+		format  FileFormat
+		options *WriteFileOptions
+		want    string
+	}{
+		{
+			"yaml",
+			FormatYAML,
+			&WriteFileOptions{},
+			"secret: base64:Zm9v\nserver:\n    host: localhost\n    port: \"8080\"\n    timeout: 30s\n",
+		},
+		{
+			"yaml overrides only",
+			FormatYAML,
+			&WriteFileOptions{OverridesOnly: true},
+			"secret: base64:Zm9v\nserver:\n    port: \"8080\"\n",
+		},
+		{
+			"json",
+			FormatJSON,
+			&WriteFileOptions{OverridesOnly: true},
+			"{\n  \"secret\": \"base64:Zm9v\",\n  \"server\": {\n    \"port\": \"8080\"\n  }\n}",
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("Failed to create a temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			path := filepath.Join(dir, "config."+string(testCase.format))
+			if err := repo.WriteFileWithOptions(path, testCase.format, testCase.options); err != nil {
+				t.Fatalf("Failed to write the config file: %s", err)
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read the config file: %s", err)
+			}
+			if string(data) != testCase.want {
+				t.Fatalf("Unexpected file contents: got: %q, want: %q", data, testCase.want)
+			}
+		})
+	}
+
+	if err := repo.WriteFile(filepath.Join(dir, "config.toml"), "toml"); err == nil {
+		t.Fatalf("Expected an error writing an unsupported format")
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 2 {
+		t.Fatalf("Unexpected files in the directory: got: %d, want: 2", len(files))
+	}
+}
@@ -1,44 +1,353 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	yaml "gopkg.in/yaml.v2"
+	yaml "gopkg.in/yaml.v3"
 )
 
 const (
 	// CfgPathKey is a string constant used globally to reach up the config
 	// file path setting.
 	CfgPathKey = "config.path"
+
+	// YamlProviderName is the default YamlProvider name.
+	YamlProviderName = "yaml"
+
+	// readerSource is the source name of the providers reading from an
+	// io.Reader.
+	readerSource = "<reader>"
+
+	// DefaultWatchInterval is the default config file check interval used by
+	// YamlProvider in watch mode.
+	DefaultWatchInterval = time.Second
 )
 
-// Redefined in tests
-var readRaw = func(source string) (map[interface{}]interface{}, error) {
-	out := make(map[interface{}]interface{})
-	data, err := ioutil.ReadFile(source)
+// OSFS is the filesystem file-based providers read from by default. Unlike
+// os.DirFS, it accepts absolute paths and paths relative to the working
+// directory.
+var OSFS fs.FS = osFS{}
+
+type osFS struct{}
+
+var _ fs.ReadFileFS = osFS{}
+
+func (osFS) Open(name string) (fs.File, error)    { return os.Open(name) }
+func (osFS) ReadFile(name string) ([]byte, error) { return ioutil.ReadFile(name) }
+
+func readFS(fsys fs.FS, source string) ([]byte, error) {
+	data, err := fs.ReadFile(fsys, source)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read yaml config file %q: %s", source, err)
 	}
-	if err := yaml.Unmarshal(data, &out); err != nil {
-		return nil, err
+	return data, nil
+}
+
+// YamlError is a yaml document error pointing at the position in the file.
+// Line and Column are zero if unknown.
+type YamlError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+var _ error = (*YamlError)(nil)
+
+// Error satisfies error interface.
+func (e *YamlError) Error() string {
+	if loc := formatLocation(e.File, e.Line, e.Column); len(loc) > 0 {
+		return loc + ": " + e.Msg
+	}
+	if e.Line == 0 {
+		return e.Msg
+	}
+	if e.Column == 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+var yamlLineErrRe = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// toYamlError converts a yaml.v3 error into a YamlError. yaml.v3 syntax
+// errors only report the line.
+func toYamlError(err error) *YamlError {
+	if m := yamlLineErrRe.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return &YamlError{Line: line, Msg: m[2]}
+	}
+	return &YamlError{Msg: strings.TrimPrefix(err.Error(), "yaml: ")}
+}
+
+// nodeError returns a YamlError pointing at the node.
+func nodeError(n *yaml.Node, format string, args ...interface{}) *YamlError {
+	return &YamlError{Line: n.Line, Column: n.Column, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseYaml decodes the raw yaml document using yaml.v3 node API. Unlike a
+// plain unmarshal into an interface{}, the node-based decoding guarantees
+// mapping keys are always strings (numeric keys like `8080:` are taken
+// verbatim), resolves anchors and aliases and honors `<<:` merge keys.
+func parseYaml(data []byte) (map[string]interface{}, error) {
+	out, _, err := parseYamlWithPositions(data)
+	return out, err
+}
+
+// yamlPos is a value position in a yaml document.
+type yamlPos struct {
+	line   int
+	column int
+}
+
+// parseYamlWithPositions is parseYaml returning the positions of the leaf
+// values as well. The positions are keyed the same way flatten keys the
+// values.
+func parseYamlWithPositions(data []byte) (map[string]interface{}, map[string]yamlPos, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, toYamlError(err)
+	}
+	positions := make(map[string]yamlPos)
+	// An empty document
+	if len(doc.Content) == 0 {
+		return make(map[string]interface{}), positions, nil
+	}
+	v, err := decodeYamlNode(&doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if v == nil {
+		return make(map[string]interface{}), positions, nil
+	}
+	out, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, nil, nodeError(doc.Content[0], "yaml config root is expected to be a mapping, got: %T", v)
+	}
+	collectYamlPositions(doc.Content[0], nil, positions)
+	return out, positions, nil
+}
+
+// collectYamlPositions records the positions of the mapping leaf values.
+// Explicitly defined keys take precedence over the merged ones, the same way
+// decodeYamlMapping resolves them.
+func collectYamlPositions(n *yaml.Node, prefix Key, out map[string]yamlPos) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	var merged []*yaml.Node
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		kn, vn := n.Content[i], n.Content[i+1]
+		if kn.Kind == yaml.AliasNode {
+			kn = kn.Alias
+		}
+		if kn.Tag == "!!merge" {
+			merged = append(merged, vn)
+			continue
+		}
+		key := prefix.Child(kn.Value)
+		target := vn
+		if target.Kind == yaml.AliasNode {
+			target = target.Alias
+		}
+		if target.Kind == yaml.MappingNode {
+			collectYamlPositions(target, key, out)
+			continue
+		}
+		out[key.String()] = yamlPos{line: vn.Line, column: vn.Column}
+	}
+	for _, mn := range merged {
+		if mn.Kind == yaml.AliasNode {
+			mn = mn.Alias
+		}
+		nodes := []*yaml.Node{mn}
+		if mn.Kind == yaml.SequenceNode {
+			nodes = mn.Content
+		}
+		for _, node := range nodes {
+			sub := make(map[string]yamlPos)
+			collectYamlPositions(node, prefix, sub)
+			for k, pos := range sub {
+				if _, ok := out[k]; !ok {
+					out[k] = pos
+				}
+			}
+		}
+	}
+}
+
+func decodeYamlNode(n *yaml.Node) (interface{}, error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return decodeYamlNode(n.Content[0])
+	case yaml.AliasNode:
+		return decodeYamlNode(n.Alias)
+	case yaml.ScalarNode:
+		// Scalar decoding respects explicit tags, e.g. `!!str 42`.
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, nodeError(n, "%s", strings.TrimPrefix(err.Error(), "yaml: "))
+		}
+		return v, nil
+	case yaml.SequenceNode:
+		out := make([]interface{}, 0, len(n.Content))
+		for _, item := range n.Content {
+			v, err := decodeYamlNode(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case yaml.MappingNode:
+		return decodeYamlMapping(n)
+	}
+	return nil, nodeError(n, "unexpected yaml node kind %d", n.Kind)
+}
+
+func decodeYamlMapping(n *yaml.Node) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+	merged := make([]map[string]interface{}, 0)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		kn, vn := n.Content[i], n.Content[i+1]
+		if kn.Kind == yaml.AliasNode {
+			kn = kn.Alias
+		}
+		if kn.Kind != yaml.ScalarNode {
+			return nil, nodeError(kn, "unsupported non-scalar yaml key")
+		}
+		if kn.Tag == "!!merge" {
+			mm, err := decodeYamlMerge(vn)
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, mm...)
+			continue
+		}
+		v, err := decodeYamlNode(vn)
+		if err != nil {
+			return nil, err
+		}
+		out[kn.Value] = v
+	}
+	// Explicitly defined keys take precedence over the merged ones. If
+	// multiple maps are merged, the first one in the list wins.
+	for _, mm := range merged {
+		for k, v := range mm {
+			if _, ok := out[k]; !ok {
+				out[k] = v
+			}
+		}
 	}
 	return out, nil
 }
 
+func decodeYamlMerge(n *yaml.Node) ([]map[string]interface{}, error) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		m, err := decodeYamlMapping(n)
+		if err != nil {
+			return nil, err
+		}
+		return []map[string]interface{}{m}, nil
+	case yaml.SequenceNode:
+		res := make([]map[string]interface{}, 0, len(n.Content))
+		for _, item := range n.Content {
+			mm, err := decodeYamlMerge(item)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, mm...)
+		}
+		return res, nil
+	}
+	return nil, nodeError(n, "merge key value is expected to be a mapping or a sequence of mappings")
+}
+
+// YamlProvider serves values from a yaml config file. In watch mode, the
+// provider periodically checks the file for changes and reloads it: the new
+// key set is applied atomically (see `Repository.ApplyReload`).
 type YamlProvider struct {
 	weight   int
 	source   string
+	data     []byte
 	options  *YamlProviderOptions
 	registry map[string]Value
+	origin   *yamlOrigin
+	repo     *Repository
+	lastData []byte
 	ready    chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+	mx       sync.RWMutex
+
+	// migrationReport is the result of the last applied migrations
+	migrationReport *MigrationReport
 }
 
+// YamlProviderOptions is a set of YamlProvider settings.
 type YamlProviderOptions struct {
+	// Watch enables periodic config file reloads.
 	Watch bool
+	// WatchInterval is the config file check interval. DefaultWatchInterval
+	// is used if not set.
+	WatchInterval time.Duration
+	// Template makes the provider render the config file as a text/template
+	// before parsing it. Templates have access to a restricted function set:
+	// `env`, `file`, `default`, `secret` (see RegisterResolver) and `quote`,
+	// e.g.:
+	//
+	//	port: {{ env "PORT" | default "8080" }}
+	//	password: {{ secret "file:///run/secrets/db_password" | quote }}
+	Template bool
+	// Name is the provider name. YamlProviderName is used if not set. Every
+	// YamlProvider registered in a repository must have a distinct name.
+	Name string
+	// PathKey is the key the config file path is looked up under if the
+	// provider has no explicit source. CfgPathKey is used if not set.
+	PathKey string
+	// Depends is the list of the providers set up before this one, e.g. the
+	// ones serving the config file path. The cli, env and default providers
+	// are used if not set.
+	Depends []string
+	// FS is the filesystem the config file is read from, e.g. an embed.FS or
+	// a fstest.MapFS. OSFS is used if not set.
+	FS fs.FS
+	// Verifier checks the config file integrity before it is parsed, e.g.
+	// its checksum (see NewChecksumVerifier). A file failing the check is
+	// rejected: the set up fails, a reload keeps the previous values.
+	Verifier Verifier
+	// Migrations upgrade the config file layout to the current version
+	// declared under ConfigVersionKey before the values are served.
+	Migrations *Migrations
 }
 
 var _ Provider = (*YamlProvider)(nil)
+var _ Refresher = (*YamlProvider)(nil)
 
 func NewYamlProvider(repo *Repository, weight int) (*YamlProvider, error) {
 	return NewYamlProviderWithOptions(repo, weight, &YamlProviderOptions{})
@@ -49,38 +358,100 @@ func NewYamlProviderWithOptions(repo *Repository, weight int, options *YamlProvi
 }
 
 func NewYamlProviderFromSource(repo *Repository, weight int, options *YamlProviderOptions, source string) (*YamlProvider, error) {
-	prov := &YamlProvider{
+	prov := newYamlProvider(weight, options, source)
+	repo.RegisterProvider(prov)
+	return prov, nil
+}
+
+func newYamlProvider(weight int, options *YamlProviderOptions, source string) *YamlProvider {
+	if options == nil {
+		options = &YamlProviderOptions{}
+	}
+	return &YamlProvider{
 		source:   source,
 		weight:   weight,
 		options:  options,
 		registry: make(map[string]Value),
 		ready:    make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// NewYamlProviderFromReader returns a new YamlProvider serving the document
+// read from r, e.g. an embedded asset, stdin or an archive entry. The reader is
+// consumed at once. YAML being a superset of JSON, the provider serves JSON
+// documents too.
+func NewYamlProviderFromReader(repo *Repository, weight int, r io.Reader) (*YamlProvider, error) {
+	return NewYamlProviderFromReaderWithOptions(repo, weight, &YamlProviderOptions{}, r)
+}
+
+// NewYamlProviderFromReaderWithOptions is NewYamlProviderFromReader accepting
+// the provider options. Watch mode is a no-op for reader-based providers.
+func NewYamlProviderFromReaderWithOptions(repo *Repository, weight int, options *YamlProviderOptions, r io.Reader) (*YamlProvider, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read yaml config: %s", err)
 	}
+	prov := newYamlProvider(weight, options, readerSource)
+	// A non-nil document makes the provider skip the file reads
+	prov.data = append([]byte{}, data...)
 	repo.RegisterProvider(prov)
 	return prov, nil
 }
 
-func (yp *YamlProvider) Name() string      { return "yaml" }
-func (yp *YamlProvider) Depends() []string { return []string{"cli", "env"} }
-func (yp *YamlProvider) Weight() int       { return yp.weight }
+func (yp *YamlProvider) Depends() []string {
+	if yp.options.Depends != nil {
+		return yp.options.Depends
+	}
+	return []string{"cli", "env", "default"}
+}
+
+func (yp *YamlProvider) Weight() int { return yp.weight }
+
+func (yp *YamlProvider) Name() string {
+	if len(yp.options.Name) > 0 {
+		return yp.options.Name
+	}
+	return YamlProviderName
+}
+
+func (yp *YamlProvider) pathKey() string {
+	if len(yp.options.PathKey) > 0 {
+		return yp.options.PathKey
+	}
+	return CfgPathKey
+}
 
 func (yp *YamlProvider) SetUp(repo *Repository) error {
 	defer close(yp.ready)
 
 	if len(yp.source) == 0 {
-		source, ok := repo.Get(NewKey(CfgPathKey))
+		source, ok, err := LookupStr(repo, yp.pathKey())
+		if err != nil {
+			return err
+		}
 		if !ok {
-			return fmt.Errorf("Failed to get yaml config path from repo")
+			return fmt.Errorf("Failed to get yaml config path %q from repo", yp.pathKey())
 		}
-		yp.source = source.(string)
+		yp.source = source
 	}
 
-	rawData, err := readRaw(yp.source)
+	yp.repo = repo
+
+	data, err := yp.read()
 	if err != nil {
 		return err
 	}
-	for k, v := range flatten(rawData) {
-		yp.registry[k] = v
+	registry, origin, err := yp.parse(data)
+	if err != nil {
+		return err
+	}
+	yp.mx.Lock()
+	yp.registry = registry
+	yp.origin = origin
+	yp.lastData = data
+	yp.mx.Unlock()
+	for k := range registry {
 		if repo != nil {
 			if err := repo.RegisterKey(NewKey(k), yp); err != nil {
 				return err
@@ -88,31 +459,256 @@ func (yp *YamlProvider) SetUp(repo *Repository) error {
 		}
 	}
 
+	if yp.options.Watch && repo != nil {
+		yp.wg.Add(1)
+		go yp.watch()
+	}
+
 	return nil
 }
 
-func flatten(in map[interface{}]interface{}) map[string]Value {
+// fsys returns the filesystem the config file is read from.
+func (yp *YamlProvider) fsys() fs.FS {
+	if yp.options.FS != nil {
+		return yp.options.FS
+	}
+	return OSFS
+}
+
+// read returns the raw config document. The document is verified if the
+// provider has a verifier.
+func (yp *YamlProvider) read() ([]byte, error) {
+	var data []byte
+	var err error
+	if yp.data != nil {
+		data = yp.data
+	} else {
+		data, err = readFS(yp.fsys(), yp.source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if yp.options.Verifier != nil {
+		if err := yp.options.Verifier.Verify(data); err != nil {
+			return nil, fmt.Errorf("Failed to verify yaml config file %q: %w", yp.source, err)
+		}
+	}
+	return data, nil
+}
+
+// yamlOrigin is the provenance of the loaded document values: the positions
+// are keyed by the migrated keys.
+type yamlOrigin struct {
+	loadedAt  time.Time
+	positions map[string]yamlPos
+}
+
+// parse returns the flattened document values along with their provenance.
+func (yp *YamlProvider) parse(data []byte) (map[string]Value, *yamlOrigin, error) {
+	if yp.options.Template {
+		rendered, err := renderTemplate(yp.repo, yp.fsys(), yp.source, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = rendered
+	}
+	rawData, positions, err := parseYamlWithPositions(data)
+	if err != nil {
+		var yerr *YamlError
+		if errors.As(err, &yerr) && yp.source != readerSource {
+			yerr.File = yp.source
+			return nil, nil, fmt.Errorf("failed to parse yaml config file %w", yerr)
+		}
+		return nil, nil, fmt.Errorf("failed to parse yaml config %q: %w", yp.source, err)
+	}
+	registry := flatten(rawData)
+	origin := &yamlOrigin{loadedAt: timeNow(), positions: positions}
+	if yp.options.Migrations != nil {
+		orig := make(map[string]Value, len(registry))
+		for k, v := range registry {
+			orig[k] = v
+		}
+		report, err := yp.options.Migrations.Apply(registry)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate yaml config file %q: %s", yp.source, err)
+		}
+		if len(report.Applied) > 0 && yp.repo != nil {
+			yp.repo.Logger().Infof("Yaml config file %q: %s", yp.source, report)
+		}
+		yp.mx.Lock()
+		yp.migrationReport = report
+		yp.mx.Unlock()
+		origin.positions = migratePositions(orig, registry, positions)
+	}
+	return registry, origin, nil
+}
+
+// migratePositions re-keys the value positions after the migrations. A value
+// moved to another key (e.g. by RenameKeys) keeps the position of the removed
+// key holding the same value, the one sharing the longest key suffix wins.
+func migratePositions(orig, migrated map[string]Value, positions map[string]yamlPos) map[string]yamlPos {
+	removed := make([]string, 0)
+	for k := range orig {
+		if _, ok := migrated[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(removed)
+	res := make(map[string]yamlPos, len(migrated))
+	for k, v := range migrated {
+		if _, ok := orig[k]; ok {
+			if pos, ok := positions[k]; ok {
+				res[k] = pos
+			}
+			continue
+		}
+		from, suffix := "", -1
+		for _, r := range removed {
+			if l := commonSuffixLen(r, k); l > suffix && reflect.DeepEqual(orig[r], v) {
+				from, suffix = r, l
+			}
+		}
+		if pos, ok := positions[from]; ok && suffix >= 0 {
+			res[k] = pos
+		}
+	}
+	return res
+}
+
+func commonSuffixLen(a, b string) int {
+	l := 0
+	for l < len(a) && l < len(b) && a[len(a)-1-l] == b[len(b)-1-l] {
+		l++
+	}
+	return l
+}
+
+// MigrationReport returns the migrations applied to the config file on the
+// last load. Returns nil if the provider has no migrations.
+func (yp *YamlProvider) MigrationReport() *MigrationReport {
+	yp.mx.RLock()
+	defer yp.mx.RUnlock()
+	return yp.migrationReport
+}
+
+// Reload re-reads the config file and applies the new key set atomically.
+// Is a no-op if the file contents did not change. If the new values fail the
+// schema validation, the previous values are kept.
+func (yp *YamlProvider) Reload() error {
+	data, err := yp.read()
+	if err != nil {
+		return err
+	}
+	yp.mx.RLock()
+	same := bytes.Equal(data, yp.lastData)
+	yp.mx.RUnlock()
+	if same {
+		return nil
+	}
+	registry, origin, err := yp.parse(data)
+	if err != nil {
+		return err
+	}
+	yp.mx.RLock()
+	prevRegistry, prevOrigin := yp.registry, yp.origin
+	yp.mx.RUnlock()
+	return yp.repo.ApplyReload(yp, func() error {
+		yp.mx.Lock()
+		yp.registry = registry
+		yp.origin = origin
+		// A rejected file version is not re-applied until the file changes
+		yp.lastData = data
+		yp.mx.Unlock()
+		for k := range registry {
+			if err := yp.repo.RegisterKey(NewKey(k), yp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func() {
+		yp.mx.Lock()
+		yp.registry = prevRegistry
+		yp.origin = prevOrigin
+		yp.mx.Unlock()
+	})
+}
+
+// Refresh re-reads the config file (see Reload), e.g. on SIGHUP (see
+// Repository.ReloadOnSignal).
+func (yp *YamlProvider) Refresh(context.Context) error {
+	return yp.Reload()
+}
+
+func (yp *YamlProvider) watch() {
+	defer yp.wg.Done()
+	interval := yp.options.WatchInterval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-yp.done:
+			return
+		case <-ticker.C:
+			if err := yp.Reload(); err != nil {
+				yp.repo.Logger().Errorf("Failed to reload yaml config file %q: %s", yp.source, err)
+			}
+		}
+	}
+}
+
+func flatten(in map[string]interface{}) map[string]Value {
 	out := make(map[string]Value)
+	flattenInto(out, nil, in)
+	return out
+}
+
+// flattenInto stores the nested map leaf values in the flat map keyed by the
+// leaf keys under the prefix.
+func flattenInto(out map[string]Value, pref Key, in map[string]interface{}) {
 	for k, v := range in {
-		if vmap, ok := v.(map[interface{}]interface{}); ok {
-			for sk, sv := range flatten(vmap) {
-				out[k.(string)+KeySepCh+sk] = Value(sv)
-			}
+		key := pref.Child(k)
+		if vmap, ok := v.(map[string]interface{}); ok {
+			flattenInto(out, key, vmap)
 		} else {
-			out[k.(string)] = Value(v)
+			out[key.String()] = Value(v)
 		}
 	}
-	return out
 }
 
+// TearDown stops the config file watcher (if any).
 func (yp *YamlProvider) TearDown(repo *Repository) error {
+	yp.stopOnce.Do(func() { close(yp.done) })
+	yp.wg.Wait()
 	return nil
 }
 
 func (yp *YamlProvider) Get(key Key) (*KeyValue, bool) {
 	<-yp.ready
+	yp.mx.RLock()
+	defer yp.mx.RUnlock()
 	if v, ok := yp.registry[key.String()]; ok {
 		return &KeyValue{Key: key, Value: v}, ok
 	}
 	return nil, false
 }
+
+// Provenance satisfies ProvenanceReporter interface: the value position is
+// reported for the (migrated) key unless the provider reads from a reader.
+func (yp *YamlProvider) Provenance(key Key) (*ValueMeta, bool) {
+	yp.mx.RLock()
+	defer yp.mx.RUnlock()
+	if _, ok := yp.registry[key.String()]; !ok {
+		return nil, false
+	}
+	meta := &ValueMeta{Provider: yp.Name(), LoadedAt: yp.origin.loadedAt}
+	if yp.source != readerSource {
+		meta.File = yp.source
+		if pos, ok := yp.origin.positions[key.String()]; ok {
+			meta.Line, meta.Column = pos.line, pos.column
+		}
+	}
+	return meta, true
+}
@@ -1,12 +1,17 @@
 package config
 
 import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
-
-	"gopkg.in/yaml.v2"
+	"testing/fstest"
+	"time"
 )
 
 const (
@@ -72,25 +77,25 @@ func TestYamlProviderSetUp(t *testing.T) {
 				"pipeline.fanout.links",
 			},
 		},
+		{
+			"Dotted keys",
+			[]byte("limits:\n  api.example.com:\n    rps: 10\n"),
+			&YamlProviderOptions{},
+			[]string{
+				`limits."api.example.com".rps`,
+			},
+		},
 	}
 
 	t.Parallel()
 
 	for _, testCase := range tests {
 		t.Run(testCase.name, func(t *testing.T) {
-
-			// Redefining the original value
-			oldReadRaw := readRaw
-			readRaw = func(source string) (map[interface{}]interface{}, error) {
-				out := make(map[interface{}]interface{})
-				if err := yaml.Unmarshal(testCase.src, &out); err != nil {
-					return nil, err
-				}
-				return out, nil
-			}
+			options := *testCase.options
+			options.FS = fstest.MapFS{"dummy.dummy": &fstest.MapFile{Data: testCase.src}}
 
 			repo := NewRepository()
-			prov, err := NewYamlProviderFromSource(repo, 0, testCase.options, "dummy.dummy")
+			prov, err := NewYamlProviderFromSource(repo, 0, &options, "dummy.dummy")
 			if err != nil {
 				t.Fatalf("Failed to initialize a new yaml provider: %s", err)
 			}
@@ -116,8 +121,352 @@ func TestYamlProviderSetUp(t *testing.T) {
 				sort.Strings(extraKeys)
 				t.Fatalf("Unexpected registration keys: %s", strings.Join(extraKeys, ", "))
 			}
+		})
+	}
+}
+
+func TestParseYaml(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			"empty document",
+			"",
+			map[string]interface{}{},
+			false,
+		},
+		{
+			"numeric keys",
+			"ports:\n  8080: http\n  8443: https\n",
+			map[string]interface{}{
+				"ports": map[string]interface{}{
+					"8080": "http",
+					"8443": "https",
+				},
+			},
+			false,
+		},
+		{
+			"anchors and aliases",
+			"base: &base\n  host: localhost\ncopy: *base\n",
+			map[string]interface{}{
+				"base": map[string]interface{}{"host": "localhost"},
+				"copy": map[string]interface{}{"host": "localhost"},
+			},
+			false,
+		},
+		{
+			"merge keys",
+			"base: &base\n  host: localhost\n  port: 80\nprod:\n  <<: *base\n  port: 443\n",
+			map[string]interface{}{
+				"base": map[string]interface{}{"host": "localhost", "port": 80},
+				"prod": map[string]interface{}{"host": "localhost", "port": 443},
+			},
+			false,
+		},
+		{
+			"merge key sequence",
+			"a: &a\n  x: 1\nb: &b\n  x: 2\n  y: 2\nc:\n  <<: [*a, *b]\n",
+			map[string]interface{}{
+				"a": map[string]interface{}{"x": 1},
+				"b": map[string]interface{}{"x": 2, "y": 2},
+				"c": map[string]interface{}{"x": 1, "y": 2},
+			},
+			false,
+		},
+		{
+			"explicit tags",
+			"port: !!str 8080\nratio: !!float 1\n",
+			map[string]interface{}{
+				"port":  "8080",
+				"ratio": float64(1),
+			},
+			false,
+		},
+		{
+			"non-mapping root",
+			"- foo\n- bar\n",
+			nil,
+			true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := parseYaml([]byte(testCase.src))
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Unexpected error: %v, want error: %t", err, testCase.wantErr)
+			}
+			if testCase.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Fatalf("Unexpected parse result: want: %#v, got: %#v", testCase.want, got)
+			}
+		})
+	}
+}
+
+// funcFS is a filesystem serving the file contents returned by the func.
+type funcFS func(name string) []byte
+
+func (f funcFS) Open(name string) (fs.File, error) {
+	return fstest.MapFS{name: &fstest.MapFile{Data: f(name)}}.Open(name)
+}
+
+func TestYamlProviderWatch(t *testing.T) {
+	var mx sync.Mutex
+	src := []byte("foo:\n  bar: 1\n")
+
+	repo := NewRepository()
+	prov, err := NewYamlProviderFromSource(repo, 0, &YamlProviderOptions{
+		Watch:         true,
+		WatchInterval: 5 * time.Millisecond,
+		FS: funcFS(func(string) []byte {
+			mx.Lock()
+			defer mx.Unlock()
+			return src
+		}),
+	}, "dummy.dummy")
+	if err != nil {
+		t.Fatalf("Failed to initialize a new yaml provider: %s", err)
+	}
+	events := make(chan *ChangeEvent, 1)
+	repo.Subscribe(func(event *ChangeEvent) { events <- event })
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
 
-			readRaw = oldReadRaw
+	if v, _ := repo.Get(NewKey("foo.bar")); v != 1 {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", v, 1)
+	}
+
+	mx.Lock()
+	src = []byte("foo:\n  bar: 2\n  baz: 3\n")
+	mx.Unlock()
+
+	select {
+	case event := <-events:
+		want := &ChangeEvent{
+			ChangeSet: ChangeSet{
+				Provider: prov.Name(),
+				Changes: []Change{
+					{Key: NewKey("foo.bar"), Old: 1, New: 2},
+					{Key: NewKey("foo.baz"), Old: nil, New: 3},
+				},
+			},
+		}
+		if !reflect.DeepEqual(event, want) {
+			t.Fatalf("Unexpected change event: got: %#v, want: %#v", event, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for a change event")
+	}
+
+	if v, _ := repo.Get(NewKey("foo.baz")); v != 3 {
+		t.Fatalf("Unexpected value: got: %#v, want: %#v", v, 3)
+	}
+}
+
+func TestMultipleYamlProviders(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base.yaml":    &fstest.MapFile{Data: []byte("db:\n  host: localhost\n  password: changeme\nsecrets:\n  path: secrets.yaml\n")},
+		"secrets.yaml": &fstest.MapFile{Data: []byte("db:\n  password: s3cr3t\n")},
+	}
+
+	repo := NewRepository()
+	NewDefaultProviderWithDefaults(repo, 0, map[string]Value{
+		CfgPathKey: "base.yaml",
+	})
+	base, _ := NewYamlProviderWithOptions(repo, 20, &YamlProviderOptions{FS: fsys})
+	// The secrets file path is served by the base file
+	secrets, _ := NewYamlProviderWithOptions(repo, 30, &YamlProviderOptions{
+		Name:    "secrets",
+		PathKey: "secrets.path",
+		Depends: []string{YamlProviderName},
+		FS:      fsys,
+	})
+	if err := repo.SetUp(); err != nil {
+		t.Fatalf("Failed to set up the repository: %s", err)
+	}
+	defer repo.TearDown()
+
+	if base.Name() != YamlProviderName || secrets.Name() != "secrets" {
+		t.Fatalf("Unexpected provider names: got: %q, %q", base.Name(), secrets.Name())
+	}
+	for key, want := range map[string]Value{
+		"db.host":     "localhost",
+		"db.password": "s3cr3t",
+	} {
+		if got, ok := repo.Get(NewKey(key)); !ok || got != want {
+			t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", key, got, want)
+		}
+	}
+
+	repo = NewRepository()
+	NewYamlProviderWithOptions(repo, 0, &YamlProviderOptions{PathKey: "secrets.path"})
+	wantErr := `Failed to get yaml config path "secrets.path" from repo`
+	if err := repo.SetUp(); err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, wantErr)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, ErrKeyNotFound }
+
+func TestYamlProviderFromReader(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]Value
+	}{
+		{
+			name: "yaml",
+			src:  "server:\n  port: 8080\n",
+			want: map[string]Value{"server.port": 8080},
+		},
+		{
+			name: "json",
+			src:  `{"server": {"host": "localhost", "tags": ["a"]}}`,
+			want: map[string]Value{"server.host": "localhost", "server.tags": []interface{}{"a"}},
+		},
+		{
+			name: "empty",
+			src:  "",
+			want: map[string]Value{},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			prov, err := NewYamlProviderFromReader(repo, 0, strings.NewReader(testCase.src))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if err := repo.SetUp(); err != nil {
+				t.Fatalf("Failed to set up the repository: %s", err)
+			}
+			defer repo.TearDown()
+			for key, want := range testCase.want {
+				if got, ok := repo.Get(NewKey(key)); !ok || !reflect.DeepEqual(got, want) {
+					t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", key, got, want)
+				}
+			}
+			if err := prov.Reload(); err != nil {
+				t.Fatalf("Unexpected reload error: %s", err)
+			}
+		})
+	}
+
+	if _, err := NewYamlProviderFromReader(NewRepository(), 0, failingReader{}); err == nil {
+		t.Fatalf("Expected an error reading from a failing reader, got nil")
+	}
+}
+
+func TestYamlProviderFS(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  port: 8082\n"), 0600); err != nil {
+		t.Fatalf("Failed to write the config file: %s", err)
+	}
+	mapFS := fstest.MapFS{
+		"etc/config.yaml": &fstest.MapFile{Data: []byte("server:\n  port: 8081\n")},
+	}
+
+	tests := []struct {
+		name    string
+		fsys    fs.FS
+		source  string
+		want    Value
+		wantErr string
+	}{
+		{name: "map fs", fsys: mapFS, source: "etc/config.yaml", want: 8081},
+		{name: "os fs", fsys: OSFS, source: path, want: 8082},
+		{name: "default fs", source: path, want: 8082},
+		{name: "missing file", fsys: mapFS, source: "config.yaml", wantErr: `failed to read yaml config file "config.yaml"`},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			NewYamlProviderFromSource(repo, 0, &YamlProviderOptions{FS: testCase.fsys}, testCase.source)
+			err := repo.SetUp()
+			defer repo.TearDown()
+			if len(testCase.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+					t.Fatalf("Unexpected error: got: %v, want it to contain: %q", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if got, ok := repo.Get(NewKey("server.port")); !ok || got != testCase.want {
+				t.Fatalf("Unexpected value for key %q: got: %#v, want: %#v", "server.port", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestYamlProviderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+		want    *YamlError
+	}{
+		{
+			name:    "syntax error",
+			src:     "a: 1\n b: 2\n",
+			wantErr: "failed to parse yaml config file config.yaml:2: mapping values are not allowed in this context",
+			want:    &YamlError{File: "config.yaml", Line: 2, Msg: "mapping values are not allowed in this context"},
+		},
+		{
+			name:    "malformed scalar",
+			src:     "server:\n  port: !!int http\n",
+			wantErr: "failed to parse yaml config file config.yaml:2:9: cannot decode !!str `http` as a !!int",
+			want:    &YamlError{File: "config.yaml", Line: 2, Column: 9, Msg: "cannot decode !!str `http` as a !!int"},
+		},
+		{
+			name:    "non-mapping root",
+			src:     "- foo\n",
+			wantErr: "failed to parse yaml config file config.yaml:1:1: yaml config root is expected to be a mapping, got: []interface {}",
+			want:    &YamlError{File: "config.yaml", Line: 1, Column: 1, Msg: "yaml config root is expected to be a mapping, got: []interface {}"},
+		},
+		{
+			name:    "non-scalar key",
+			src:     "? [a]\n: b\n",
+			wantErr: "failed to parse yaml config file config.yaml:1:3: unsupported non-scalar yaml key",
+			want:    &YamlError{File: "config.yaml", Line: 1, Column: 3, Msg: "unsupported non-scalar yaml key"},
+		},
+		{
+			name:    "malformed merge",
+			src:     "a:\n  <<: 1\n",
+			wantErr: "failed to parse yaml config file config.yaml:2:7: merge key value is expected to be a mapping or a sequence of mappings",
+			want:    &YamlError{File: "config.yaml", Line: 2, Column: 7, Msg: "merge key value is expected to be a mapping or a sequence of mappings"},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := NewRepository()
+			NewYamlProviderFromSource(repo, 0, &YamlProviderOptions{
+				FS: fstest.MapFS{"config.yaml": &fstest.MapFile{Data: []byte(testCase.src)}},
+			}, "config.yaml")
+			err := repo.SetUp()
+			if err == nil || err.Error() != testCase.wantErr {
+				t.Fatalf("Unexpected error: got: %v, want: %q", err, testCase.wantErr)
+			}
+			var yerr *YamlError
+			if !errors.As(err, &yerr) || !reflect.DeepEqual(yerr, testCase.want) {
+				t.Fatalf("Unexpected yaml error: got: %#v, want: %#v", yerr, testCase.want)
+			}
 		})
 	}
 }